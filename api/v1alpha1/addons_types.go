@@ -16,6 +16,9 @@ limitations under the License.
 package v1alpha1
 
 import (
+	helmctlv2 "github.com/fluxcd/helm-controller/api/v2beta1"
+	corev1 "k8s.io/api/core/v1"
+	apiextensionsv1 "k8s.io/apiextensions-apiserver/pkg/apis/apiextensions/v1"
 	metav1 "k8s.io/apimachinery/pkg/apis/meta/v1"
 )
 
@@ -32,16 +35,117 @@ type PreReqs struct {
 	// +optional
 	DependsOn []string `json:"dependsOn,omitempty"`
 
+	// The names of other addons the addons depend on if they are applicable to the cluster. A dependency whose
+	// clusterSelector does not match the cluster is treated as satisfied.
+	// +optional
+	OptionalDependsOn []string `json:"optionalDependsOn,omitempty"`
+
+	// APIResources are the kinds that must be served by the cluster, for example kinds defined by CRDs installed by
+	// other systems.
+	// +optional
+	APIResources []APIResourceReference `json:"apiResources,omitempty"`
+
+	// CRDs are the names of the CustomResourceDefinitions that must be established on the cluster, for example
+	// 'servicemonitors.monitoring.coreos.com'.
+	// +optional
+	CRDs []string `json:"crds,omitempty"`
+
+	// ReadinessGates are objects on the cluster, not managed by the layer, that must be ready before the layer is
+	// applied, for example a Flux Kustomization or a Deployment installed by another system.
+	// +optional
+	ReadinessGates []ReadinessGate `json:"readinessGates,omitempty"`
+
 	// Add more prerequisites in the future.
 }
 
+// ReadinessGate identifies an object that must be ready before the layer is applied.
+type ReadinessGate struct {
+	// APIVersion of the object, defaults to v1.
+	// +optional
+	APIVersion string `json:"apiVersion,omitempty"`
+
+	// Kind of the object.
+	// +required
+	Kind string `json:"kind"`
+
+	// Name of the object.
+	// +required
+	Name string `json:"name"`
+
+	// Namespace of the object, not set for cluster scoped objects.
+	// +optional
+	Namespace string `json:"namespace,omitempty"`
+
+	// ConditionType is the type of a condition that must have status True, by default the object's readiness is
+	// computed from its status and well known conditions.
+	// +optional
+	ConditionType string `json:"conditionType,omitempty"`
+}
+
+// APIResourceReference identifies a kind that must be served by the cluster.
+type APIResourceReference struct {
+	// Group of the kind, empty for the core group.
+	// +optional
+	Group string `json:"group,omitempty"`
+
+	// Version of the kind.
+	// +required
+	Version string `json:"version"`
+
+	// Kind that must be served.
+	// +required
+	Kind string `json:"kind"`
+}
+
+// GitRef defines the git reference to use, if more than one is specified the order of precedence is
+// commit, semver, tag and then branch.
+type GitRef struct {
+	// The git branch to checkout, defaults to master.
+	// +optional
+	Branch string `json:"branch,omitempty"`
+
+	// The git tag to checkout.
+	// +optional
+	Tag string `json:"tag,omitempty"`
+
+	// The git tag semver expression.
+	// +optional
+	SemVer string `json:"semver,omitempty"`
+
+	// The git commit SHA to checkout.
+	// +optional
+	Commit string `json:"commit,omitempty"`
+}
+
+// GitSourceSpec defines a git repository from which the Kraan controller creates and manages a GitRepository.
+// Layers using the same URL and ref share a single GitRepository.
+type GitSourceSpec struct {
+	// The URL of the git repository.
+	// +kubebuilder:validation:Pattern="^(http|https|ssh)://"
+	// +required
+	URL string `json:"url"`
+
+	// The git reference to checkout.
+	// +optional
+	Ref *GitRef `json:"ref,omitempty"`
+
+	// The secret containing the credentials to access the git repository, in the source namespace.
+	// +optional
+	SecretRef *corev1.LocalObjectReference `json:"secretRef,omitempty"`
+
+	// The interval at which to check the git repository for updates, defaults to 1m.
+	// +optional
+	Interval *metav1.Duration `json:"interval,omitempty"`
+}
+
 // SourceSpec defines a source location using the source types supported by the GitOps Toolkit source controller.
 type SourceSpec struct {
-	// The kind of the resource to use, currently only supports gitrepositories.source.toolkit.fluxcd.io
+	// The kind of the resource to use, currently supports gitrepositories.source.toolkit.fluxcd.io
+	// and Directory, a directory or gzip compressed tarball on the controller's filesystem.
 	// +optional
 	Kind string `json:"kind"`
-	// The name of the resource to use
-	// +required
+	// The name of the resource to use, required unless git is specified.
+	// +optional
 	Name string `json:"name"`
 
 	// The namespace of the resource to use
@@ -53,6 +157,219 @@ type SourceSpec struct {
 	// +kubebuilder:validation:Pattern="^\\./"
 	// +required
 	Path string `json:"path"`
+
+	// Include is a list of glob patterns of files within the path to apply, defaults to all files.
+	// +optional
+	Include []string `json:"include,omitempty"`
+
+	// Exclude is a list of glob patterns of files and directories within the path not to apply. Patterns in a
+	// .kraanignore file in the path are also excluded.
+	// +optional
+	Exclude []string `json:"exclude,omitempty"`
+
+	// URL of the directory or gzip compressed tarball on the controller's filesystem, file:///path.
+	// Required when kind is Directory, the name and namespace are then only used to identify the source.
+	// +kubebuilder:validation:Pattern="^file://"
+	// +optional
+	URL string `json:"url,omitempty"`
+
+	// RequireVerified prevents the layer being applied unless the source reports a successful verification of the
+	// signature of the current revision. Defaults to the controller's default.
+	// +optional
+	RequireVerified *bool `json:"requireVerified,omitempty"`
+
+	// Git defines a git repository for the Kraan controller to create a GitRepository for, in the source namespace.
+	// The name is then not used.
+	// +optional
+	Git *GitSourceSpec `json:"git,omitempty"`
+}
+
+// KubeConfigSpec defines the cluster an AddonsLayer is applied to.
+type KubeConfigSpec struct {
+	// SecretRef references the secret containing the kubeconfig of the cluster.
+	// +required
+	SecretRef KubeConfigSecretRef `json:"secretRef"`
+}
+
+// KubeConfigSecretRef references a key in a secret containing a kubeconfig.
+type KubeConfigSecretRef struct {
+	// Name of the secret.
+	// +required
+	Name string `json:"name"`
+
+	// Namespace of the secret, defaults to the Kraan controller's namespace.
+	// +optional
+	Namespace string `json:"namespace,omitempty"`
+
+	// Key of the kubeconfig in the secret, defaults to value.
+	// +optional
+	Key string `json:"key,omitempty"`
+}
+
+// AllowedNamespacesSpec defines the namespaces an AddonsLayer may deploy addons to.
+type AllowedNamespacesSpec struct {
+	// Names of namespaces the addons may be deployed to.
+	// +optional
+	Names []string `json:"names,omitempty"`
+
+	// Selectors select namespaces the addons may be deployed to by their labels, a namespace matching any of the
+	// selectors is allowed.
+	// +optional
+	Selectors []metav1.LabelSelector `json:"selectors,omitempty"`
+}
+
+// DecryptionSpec defines how the SOPS encrypted manifests in an AddonsLayer's sources are decrypted.
+type DecryptionSpec struct {
+	// Provider used to decrypt manifests.
+	// +kubebuilder:validation:Enum=sops
+	// +kubebuilder:default:=sops
+	// +optional
+	Provider string `json:"provider,omitempty"`
+
+	// SecretRef references a secret containing age identities, in keys ending in .agekey, and armored PGP private
+	// keys, in keys ending in .asc, used in addition to the Kraan controller's decryption keys.
+	// +optional
+	SecretRef *DecryptionSecretRef `json:"secretRef,omitempty"`
+}
+
+// DecryptionSecretRef references a secret containing decryption keys.
+type DecryptionSecretRef struct {
+	// Name of the secret.
+	// +required
+	Name string `json:"name"`
+
+	// Namespace of the secret, defaults to the Kraan controller's namespace.
+	// +optional
+	Namespace string `json:"namespace,omitempty"`
+}
+
+// ValuesReference references a ConfigMap, Secret or the outputs of another AddonsLayer containing values for the
+// layer's HelmReleases.
+type ValuesReference struct {
+	// Kind of the values referent, ConfigMap, Secret or AddonsLayer.
+	// +kubebuilder:validation:Enum=ConfigMap;Secret;AddonsLayer
+	// +required
+	Kind string `json:"kind"`
+
+	// Name of the values referent.
+	// +required
+	Name string `json:"name"`
+
+	// Namespace of the values referent, defaults to the Kraan controller's namespace. A Secret is read by the
+	// helm-controller from the namespace of each HelmRelease, so it is only used by the HelmReleases in its namespace.
+	// Secrets cannot be used by layers applied to a remote cluster.
+	// +optional
+	Namespace string `json:"namespace,omitempty"`
+
+	// ValuesKey is the data key the values are read from, defaults to 'values.yaml'. For an AddonsLayer it is the name
+	// of the output to read, all outputs are read if it is not set.
+	// +optional
+	ValuesKey string `json:"valuesKey,omitempty"`
+
+	// TargetPath is the dot separated path in the values the value read is set at, for example 'ingress.className'. If
+	// set the value is used as a string rather than parsed as YAML. Defaults to the output name for an AddonsLayer.
+	// +optional
+	TargetPath string `json:"targetPath,omitempty"`
+
+	// Optional marks the reference as optional, a missing referent or key is ignored.
+	// +optional
+	Optional bool `json:"optional,omitempty"`
+}
+
+// SubstituteReference references a ConfigMap, Secret or the outputs of another AddonsLayer containing variables
+// substituted in the layer's resources.
+type SubstituteReference struct {
+	// Kind of the variables referent, ConfigMap, Secret or AddonsLayer.
+	// +kubebuilder:validation:Enum=ConfigMap;Secret;AddonsLayer
+	// +required
+	Kind string `json:"kind"`
+
+	// Name of the variables referent.
+	// +required
+	Name string `json:"name"`
+
+	// Namespace of the variables referent, defaults to the Kraan controller's namespace.
+	// +optional
+	Namespace string `json:"namespace,omitempty"`
+
+	// Optional marks the reference as optional, a missing referent is ignored.
+	// +optional
+	Optional bool `json:"optional,omitempty"`
+}
+
+// LayerOutput defines a value published by the layer once it is deployed, read from a field of one of the objects on
+// the cluster the layer is applied to.
+type LayerOutput struct {
+	// Name of the output.
+	// +kubebuilder:validation:Pattern="^[-._a-zA-Z0-9]+$"
+	// +required
+	Name string `json:"name"`
+
+	// ObjectRef references the object the output is read from, which must have been deployed by the layer.
+	// +required
+	ObjectRef OutputObjectReference `json:"objectRef"`
+
+	// JSONPath is the kubectl style JSONPath expression selecting the field, for example '{.spec.ingressClassName}'.
+	// +required
+	JSONPath string `json:"jsonPath"`
+
+	// Optional marks the output as optional, it is omitted if the object or field is not found.
+	// +optional
+	Optional bool `json:"optional,omitempty"`
+}
+
+// OutputObjectReference references an object a layer output is read from.
+type OutputObjectReference struct {
+	// APIVersion of the object, defaults to v1.
+	// +optional
+	APIVersion string `json:"apiVersion,omitempty"`
+
+	// Kind of the object.
+	// +required
+	Kind string `json:"kind"`
+
+	// Name of the object.
+	// +required
+	Name string `json:"name"`
+
+	// Namespace of the object, not set for cluster scoped objects.
+	// +optional
+	Namespace string `json:"namespace,omitempty"`
+}
+
+// CommonMetadata defines the labels and annotations added to all of the layer's resources.
+type CommonMetadata struct {
+	// Labels added to the resources, replacing any labels with the same keys.
+	// +optional
+	Labels map[string]string `json:"labels,omitempty"`
+
+	// Annotations added to the resources, replacing any annotations with the same keys.
+	// +optional
+	Annotations map[string]string `json:"annotations,omitempty"`
+}
+
+// GetSources returns the source followed by any additional sources, in the order they are merged.
+func (in AddonsLayerSpec) GetSources() []SourceSpec {
+	return append([]SourceSpec{in.Source}, in.Sources...)
+}
+
+// SourceRevision records the revision of one of the sources of an AddonsLayer.
+type SourceRevision struct {
+	// Namespace of the source.
+	// +required
+	Namespace string `json:"namespace"`
+
+	// Name of the source.
+	// +required
+	Name string `json:"name"`
+
+	// Path within the source.
+	// +required
+	Path string `json:"path"`
+
+	// Revision of the source.
+	// +required
+	Revision string `json:"revision"`
 }
 
 // AddonsLayerSpec defines the desired state of AddonsLayer.
@@ -61,6 +378,76 @@ type AddonsLayerSpec struct {
 	// +required
 	Source SourceSpec `json:"source"`
 
+	// Additional sources to obtain addons definitions from, merged in order after the source. A resource with the
+	// same kind, namespace and name as one from the source or an earlier entry replaces it.
+	// +optional
+	Sources []SourceSpec `json:"sources,omitempty"`
+
+	// KubeConfig references the kubeconfig of the cluster to apply the addons to.
+	// Defaults to the cluster the Kraan controller runs in.
+	// +optional
+	KubeConfig *KubeConfigSpec `json:"kubeConfig,omitempty"`
+
+	// AllowedNamespaces restricts the namespaces the addons may be deployed to.
+	// Defaults to allowing all namespaces.
+	// +optional
+	AllowedNamespaces *AllowedNamespacesSpec `json:"allowedNamespaces,omitempty"`
+
+	// ClusterSelector restricts the layer to clusters whose labels, read from the cluster info ConfigMap, match the
+	// selector. The layer is not applied to other clusters. Defaults to all clusters.
+	// +optional
+	ClusterSelector *metav1.LabelSelector `json:"clusterSelector,omitempty"`
+
+	// ServiceAccountName is the name of the ServiceAccount impersonated when creating, updating and deleting the
+	// addons. Defaults to the Kraan controller's ServiceAccount.
+	// +optional
+	ServiceAccountName string `json:"serviceAccountName,omitempty"`
+
+	// ServiceAccountNamespace is the namespace of the ServiceAccount impersonated when creating, updating and deleting
+	// the addons. Defaults to the Kraan controller's namespace.
+	// +optional
+	ServiceAccountNamespace string `json:"serviceAccountNamespace,omitempty"`
+
+	// Decryption defines how SOPS encrypted manifests in the sources are decrypted.
+	// Defaults to using the Kraan controller's decryption keys.
+	// +optional
+	Decryption *DecryptionSpec `json:"decryption,omitempty"`
+
+	// Values are deep merged into the values of each of the layer's HelmReleases, the HelmRelease's own values take
+	// precedence. HelmReleases with the 'kraan.layerValues' annotation set to 'false' are not changed.
+	// +optional
+	Values *apiextensionsv1.JSON `json:"values,omitempty"`
+
+	// ValuesFrom references ConfigMaps and AddonsLayer outputs containing values that are merged in order, followed by
+	// Values, into the values of each of the layer's HelmReleases. Secrets are added to the start of each
+	// HelmRelease's valuesFrom instead, so their values are not copied into the HelmReleases.
+	// +optional
+	ValuesFrom []ValuesReference `json:"valuesFrom,omitempty"`
+
+	// HelmReleaseDefaults contains HelmRelease spec fields that are set in each of the layer's HelmReleases that do not
+	// set them. Fields set by a HelmRelease always take precedence.
+	// +optional
+	HelmReleaseDefaults *apiextensionsv1.JSON `json:"helmReleaseDefaults,omitempty"`
+
+	// CommonMetadata defines labels and annotations added to all of the layer's resources.
+	// +optional
+	CommonMetadata *CommonMetadata `json:"commonMetadata,omitempty"`
+
+	// PostRenderers are appended to the post renderers of each of the layer's HelmReleases.
+	// +optional
+	PostRenderers []helmctlv2.PostRenderer `json:"postRenderers,omitempty"`
+
+	// SubstituteFrom references ConfigMaps and Secrets containing variables that are substituted, in addition to the
+	// built in variables, for ${VAR} references in the layer's resources. Later entries take precedence. Setting it
+	// enables variable substitution for the layer, as does the 'kraan.substitute' annotation set to 'true'.
+	// +optional
+	SubstituteFrom []SubstituteReference `json:"substituteFrom,omitempty"`
+
+	// Outputs are values read from the layer's deployed objects, published in a ConfigMap in the Kraan controller's
+	// namespace once the layer is deployed, for use by other layers.
+	// +optional
+	Outputs []LayerOutput `json:"outputs,omitempty"`
+
 	// The prerequisites information, if not present not prerequisites
 	// +optional
 	PreReqs PreReqs `json:"prereqs,omitempty"`
@@ -111,6 +498,27 @@ const (
 	// HoldCondition represents the fact that addons are on hold.
 	HoldCondition string = "Hold"
 
+	// SourceUnverifiedCondition represents the fact that the addons are not applied because the source revision
+	// has not been verified.
+	SourceUnverifiedCondition string = "SourceUnverified"
+
+	// ForbiddenCondition represents the fact that the addons could not be applied because the AddonsLayer is not
+	// permitted to use its sources or deploy the addons, or the ServiceAccount impersonated is not permitted to create,
+	// update or delete them.
+	ForbiddenCondition string = "Forbidden"
+
+	// PolicyViolationCondition represents the fact that the addons are not applied because they do not satisfy the
+	// rules of an enforced LayerPolicy.
+	PolicyViolationCondition string = "PolicyViolation"
+
+	// PrerequisitesNotMetCondition represents the fact that the addons are not applied because API resources or CRDs
+	// required by the AddonsLayer are not available on the cluster.
+	PrerequisitesNotMetCondition string = "PrerequisitesNotMet"
+
+	// NotApplicableCondition represents the fact that the addons are not applied because the AddonsLayer's cluster
+	// selector does not match the cluster.
+	NotApplicableCondition string = "NotApplicable"
+
 	// DeletedCondition represents the fact that the addons layer has been deleted.
 	DeletedCondition string = "Deleted"
 
@@ -125,6 +533,9 @@ const (
 
 	// AddonsLayerKind is the string representation of a AddonsLayer.
 	AddonsLayerKind = "AddonsLayer"
+
+	// DirectorySourceKind is the source kind used for a directory or tarball on the controller's filesystem.
+	DirectorySourceKind = "Directory"
 )
 
 type Resource struct {
@@ -183,9 +594,27 @@ type AddonsLayerStatus struct {
 	// +required
 	DeployedRevision string `json:"revision"`
 
+	// SourceRevisions are the revisions of each of the sources that have been deployed, in the order they are merged.
+	// +optional
+	SourceRevisions []SourceRevision `json:"sourceRevisions,omitempty"`
+
 	// Resources is a list of resources managed by this layer.
 	// +optional
 	Resources []Resource `json:"resources"`
+
+	// PolicyViolations is a list of the resources in the layer that do not satisfy the rules of the LayerPolicies that
+	// apply to it.
+	// +optional
+	PolicyViolations []PolicyViolation `json:"policyViolations,omitempty"`
+
+	// UnresolvedVariables is a list of the variables referenced by the layer's resources that are not defined, the
+	// references are left unchanged.
+	// +optional
+	UnresolvedVariables []string `json:"unresolvedVariables,omitempty"`
+
+	// Outputs are the values of the layer's outputs when it was last deployed.
+	// +optional
+	Outputs map[string]string `json:"outputs,omitempty"`
 }
 
 const (
@@ -204,6 +633,19 @@ const (
 	// AddonsLayerFailedMsg represents the fact that the deployment of the addons failed.
 	AddonsLayerFailedMsg string = "AddonsLayer failed"
 
+	// AddonsLayerForbiddenMsg represents the fact that the AddonsLayer is not permitted to deploy the addons.
+	AddonsLayerForbiddenMsg string = "AddonsLayer is not permitted to deploy addons"
+
+	// AddonsLayerPolicyViolationMsg represents the fact that the addons do not satisfy the rules of an enforced LayerPolicy.
+	AddonsLayerPolicyViolationMsg string = "AddonsLayer violates LayerPolicy rules"
+
+	// AddonsLayerPrerequisitesNotMetMsg represents the fact that API resources or CRDs required by the AddonsLayer are
+	// not available.
+	AddonsLayerPrerequisitesNotMetMsg string = "AddonsLayer is waiting for prerequisites"
+
+	// AddonsLayerNotApplicableMsg represents the fact that the AddonsLayer's cluster selector does not match the cluster.
+	AddonsLayerNotApplicableMsg string = "AddonsLayer cluster selector does not match the cluster labels"
+
 	// AddonsLayerHoldMsg represents the fact that addons are on hold.
 	AddonsLayerHoldMsg string = "AddonsLayer is on hold, preventing execution"
 
@@ -0,0 +1,123 @@
+/*
+
+Licensed under the Apache License, Version 2.0 (the "License");
+you may not use this file except in compliance with the License.
+You may obtain a copy of the License at
+
+    http://www.apache.org/licenses/LICENSE-2.0
+
+Unless required by applicable law or agreed to in writing, software
+distributed under the License is distributed on an "AS IS" BASIS,
+WITHOUT WARRANTIES OR CONDITIONS OF ANY KIND, either express or implied.
+See the License for the specific language governing permissions and
+limitations under the License.
+*/
+
+package v1alpha1
+
+import (
+	metav1 "k8s.io/apimachinery/pkg/apis/meta/v1"
+)
+
+const (
+	// PolicyModeEnforce prevents AddonsLayers that violate a policy being applied.
+	PolicyModeEnforce string = "Enforce"
+
+	// PolicyModeAudit reports violations of a policy without preventing AddonsLayers being applied.
+	PolicyModeAudit string = "Audit"
+)
+
+// PolicyRule defines a rule that each resource in an AddonsLayer must satisfy.
+type PolicyRule struct {
+	// Name of the rule.
+	// +required
+	Name string `json:"name"`
+
+	// Kinds of resources the rule applies to, defaults to all kinds.
+	// +optional
+	Kinds []string `json:"kinds,omitempty"`
+
+	// Expression is a CEL expression that must evaluate to true for each resource the rule applies to. The resource is
+	// available as the variable object and the name, version and labels of the AddonsLayer as the variable layer.
+	// +required
+	Expression string `json:"expression"`
+
+	// Message reported when a resource does not satisfy the rule, defaults to the expression.
+	// +optional
+	Message string `json:"message,omitempty"`
+}
+
+// LayerPolicySpec defines the desired state of LayerPolicy.
+type LayerPolicySpec struct {
+	// Rules that each resource in the AddonsLayers selected must satisfy.
+	// +required
+	Rules []PolicyRule `json:"rules"`
+
+	// Mode is Enforce to prevent AddonsLayers that violate the rules being applied or Audit to report violations only.
+	// +kubebuilder:validation:Enum=Enforce;Audit
+	// +kubebuilder:default:=Enforce
+	// +optional
+	Mode string `json:"mode,omitempty"`
+
+	// LayerSelector selects the AddonsLayers the policy applies to by their labels, defaults to all AddonsLayers.
+	// +optional
+	LayerSelector *metav1.LabelSelector `json:"layerSelector,omitempty"`
+}
+
+// IsAudit returns true if violations of the policy are reported without preventing AddonsLayers being applied.
+func (in LayerPolicySpec) IsAudit() bool {
+	return in.Mode == PolicyModeAudit
+}
+
+// PolicyViolation describes a resource in an AddonsLayer that does not satisfy a policy rule.
+type PolicyViolation struct {
+	// Policy is the name of the LayerPolicy.
+	Policy string `json:"policy"`
+
+	// Rule is the name of the rule.
+	Rule string `json:"rule"`
+
+	// Mode of the LayerPolicy when the violation was detected.
+	Mode string `json:"mode"`
+
+	// Kind of the resource.
+	Kind string `json:"kind"`
+
+	// Namespace of the resource.
+	// +optional
+	Namespace string `json:"namespace,omitempty"`
+
+	// Name of the resource.
+	Name string `json:"name"`
+
+	// Message describing the violation.
+	Message string `json:"message"`
+}
+
+// LayerPolicy is the Schema for the layerpolicies API.
+// +genclient:nonNamespaced
+// +k8s:deepcopy-gen:interfaces=k8s.io/apimachinery/pkg/runtime.Object
+// +kubebuilder:object:root=true
+// +kubebuilder:resource:scope=Cluster,shortName=lp
+// +kubebuilder:printcolumn:name="Mode",type=string,JSONPath=`.spec.mode`
+// +kubebuilder:printcolumn:name="Age",type=date,JSONPath=`.metadata.creationTimestamp`
+type LayerPolicy struct {
+	metav1.TypeMeta   `json:",inline"`
+	metav1.ObjectMeta `json:"metadata,omitempty"`
+
+	Spec LayerPolicySpec `json:"spec,omitempty"`
+}
+
+// LayerPolicyList contains a list of LayerPolicy.
+// +genclient:nonNamespaced
+// +kubebuilder:object:root=true
+// +kubebuilder:resource:scope=Cluster
+type LayerPolicyList struct {
+	metav1.TypeMeta `json:",inline"`
+	metav1.ListMeta `json:"metadata,omitempty"`
+	Items           []LayerPolicy `json:"items"`
+}
+
+func init() {
+	SchemeBuilder.Register(&LayerPolicy{}, &LayerPolicyList{})
+}
@@ -19,8 +19,11 @@ package controllers
 import (
 	"context"
 	"fmt"
+	"reflect"
 	"regexp"
 	"sort"
+	"sync"
+	"sync/atomic"
 	"time"
 
 	helmctlv2 "github.com/fluxcd/helm-controller/api/v2beta1"
@@ -30,15 +33,20 @@ import (
 	"github.com/pkg/errors"
 	corev1 "k8s.io/api/core/v1"
 	apierrors "k8s.io/apimachinery/pkg/api/errors"
+	"k8s.io/apimachinery/pkg/api/meta"
 	metav1 "k8s.io/apimachinery/pkg/apis/meta/v1"
+	"k8s.io/apimachinery/pkg/apis/meta/v1/unstructured"
 	"k8s.io/apimachinery/pkg/runtime"
+	"k8s.io/apimachinery/pkg/runtime/schema"
 	"k8s.io/apimachinery/pkg/types"
 	"k8s.io/client-go/kubernetes"
 	kscheme "k8s.io/client-go/kubernetes/scheme"
 	typedcorev1 "k8s.io/client-go/kubernetes/typed/core/v1"
 	"k8s.io/client-go/rest"
+	"k8s.io/client-go/tools/cache"
 	"k8s.io/client-go/tools/record"
 	ctrl "sigs.k8s.io/controller-runtime"
+	ctrlcache "sigs.k8s.io/controller-runtime/pkg/cache"
 	"sigs.k8s.io/controller-runtime/pkg/client"
 	"sigs.k8s.io/controller-runtime/pkg/controller"
 	"sigs.k8s.io/controller-runtime/pkg/event"
@@ -49,25 +57,33 @@ import (
 
 	kraanv1alpha1 "github.com/fidelity/kraan/api/v1alpha1"
 	"github.com/fidelity/kraan/pkg/apply"
+	"github.com/fidelity/kraan/pkg/clusters"
 	"github.com/fidelity/kraan/pkg/common"
 	"github.com/fidelity/kraan/pkg/layers"
 	"github.com/fidelity/kraan/pkg/logging"
 	"github.com/fidelity/kraan/pkg/metrics"
+	"github.com/fidelity/kraan/pkg/policy"
 	"github.com/fidelity/kraan/pkg/repos"
+	"github.com/fidelity/kraan/pkg/storage"
 )
 
 var (
 	hrOwnerKey = ".owner"
 	reconciler *AddonsLayerReconciler
+
+	rehydrateRetryDelay = 5 * time.Second // Delay before retrying a failed list of the AddonsLayers to rehydrate
 )
 
 const (
 	reasonRegex = "^[A-Za-z]([A-Za-z0-9_,:]*[A-Za-z0-9_])?$" // Regex for k8s 1.19 conditions reason field
+
+	rehydrateRequeueDelay = time.Second // Requeue delay for layers reconciled before startup rehydration completes
 )
 
 // AddonsLayerReconcilerOptions are the reconciller options
 type AddonsLayerReconcilerOptions struct {
 	MaxConcurrentReconciles int
+	SyncWorkers             int
 }
 
 // SetupWithManagerAndOptions setup manager with supplied options
@@ -87,6 +103,15 @@ func (r *AddonsLayerReconciler) SetupWithManagerAndOptions(mgr ctrl.Manager, opt
 		return errors.Wrap(err, "failed setting up FieldIndexer for HelmRepository owner")
 	}
 
+	if err := mgr.Add(rehydrator{reconciler: r, concurrency: opts.MaxConcurrentReconciles}); err != nil {
+		return errors.Wrap(err, "failed adding source rehydration to manager")
+	}
+
+	r.Syncer = repos.NewSyncer(r.Log.WithName("syncer"), r.Repos, r.Metrics, opts.SyncWorkers)
+	if err := mgr.Add(r.Syncer); err != nil {
+		return errors.Wrap(err, "failed adding source syncer to manager")
+	}
+
 	ctl, err := ctrl.NewControllerManagedBy(mgr).
 		For(addonsLayer).
 		Owns(hr).
@@ -97,6 +122,9 @@ func (r *AddonsLayerReconciler) SetupWithManagerAndOptions(mgr ctrl.Manager, opt
 	if err != nil {
 		return errors.Wrap(err, "error creating controller")
 	}
+	r.ctl = ctl
+	r.cache = mgr.GetCache()
+	r.gateWatches = map[schema.GroupVersionKind]bool{}
 	err = ctl.Watch(
 		&source.Kind{Type: &sourcev1.GitRepository{}},
 		handler.EnqueueRequestsFromMapFunc(r.repoMapperFunc),
@@ -138,7 +166,7 @@ func (r *AddonsLayerReconciler) SetupWithManagerAndOptions(mgr ctrl.Manager, opt
 				}
 
 				if oldRepo.GetArtifact() != nil && newRepo.GetArtifact() != nil &&
-					oldRepo.GetArtifact().Revision != newRepo.GetArtifact().Revision {
+					!repos.RevisionsEqual(oldRepo.GetArtifact().Revision, newRepo.GetArtifact().Revision) {
 					r.Log.V(1).Info("changed revision to process", logging.GetGitRepoInfo(newRepo)...)
 					r.Log.V(1).Info("old revision", logging.GetGitRepoInfo(oldRepo)...)
 					return true
@@ -175,6 +203,13 @@ func (r *AddonsLayerReconciler) SetupWithManagerAndOptions(mgr ctrl.Manager, opt
 	if err != nil {
 		return errors.Wrap(err, "error creating controller")
 	}
+	err = ctl.Watch(
+		&source.Channel{Source: r.syncEvents(r.Repos.Subscribe())},
+		handler.EnqueueRequestsFromMapFunc(r.syncedMapperFunc),
+	)
+	if err != nil {
+		return errors.Wrap(err, "error creating repository sync status watch")
+	}
 	err = ctl.Watch(
 		&source.Kind{Type: &kraanv1alpha1.AddonsLayer{}},
 		handler.EnqueueRequestsFromMapFunc(r.layerMapperFunc),
@@ -209,10 +244,9 @@ func (r *AddonsLayerReconciler) SetupWithManagerAndOptions(mgr ctrl.Manager, opt
 					return false
 				}
 
-				if common.GetSourceNamespace(old.Spec.Source.NameSpace) != common.GetSourceNamespace(new.Spec.Source.NameSpace) || old.Spec.Source.Name != new.Spec.Source.Name {
+				for _, repoName := range removedSources(old.Spec, new.Spec) {
 					r.Log.V(1).Info("layer source changed, remove from users list for previous source",
-						append(logging.GetFunctionAndSource(logging.MyCaller), logging.GetLayerInfo(new)...)...)
-					repoName := fmt.Sprintf("%s/%s", common.GetSourceNamespace(old.Spec.Source.NameSpace), old.Spec.Source.Name)
+						append(logging.GetFunctionAndSource(logging.MyCaller), append(logging.GetLayerInfo(new), "source", repoName)...)...)
 					repo := r.Repos.Get(repoName)
 					if repo != nil {
 						if repo.RemoveUser(old.Name) {
@@ -222,8 +256,8 @@ func (r *AddonsLayerReconciler) SetupWithManagerAndOptions(mgr ctrl.Manager, opt
 					}
 				}
 
-				if new.Status.State == kraanv1alpha1.DeployedCondition {
-					r.Log.V(1).Info("layer deployed, process dependent layers",
+				if new.Status.State == kraanv1alpha1.DeployedCondition || new.Status.State == kraanv1alpha1.NotApplicableCondition {
+					r.Log.V(1).Info("layer deployed or not applicable, process dependent layers",
 						append(logging.GetFunctionAndSource(logging.MyCaller), logging.GetLayerInfo(new)...)...)
 					return true
 				}
@@ -243,6 +277,35 @@ func (r *AddonsLayerReconciler) SetupWithManagerAndOptions(mgr ctrl.Manager, opt
 			},
 		},
 	)
+	if err != nil {
+		return errors.Wrap(err, "error creating AddonsLayer watch")
+	}
+	err = ctl.Watch(
+		&source.Kind{Type: &helmctlv2.HelmRelease{}},
+		handler.EnqueueRequestsFromMapFunc(r.releaseMapperFunc),
+		predicate.Funcs{
+			UpdateFunc: func(e event.UpdateEvent) bool {
+				oldHr, okOld := e.ObjectOld.(*helmctlv2.HelmRelease)
+				newHr, okNew := e.ObjectNew.(*helmctlv2.HelmRelease)
+				return !okOld || !okNew || !reflect.DeepEqual(oldHr.Status.Conditions, newHr.Status.Conditions) ||
+					!reflect.DeepEqual(oldHr.GetAnnotations(), newHr.GetAnnotations())
+			},
+		},
+	)
+	if err != nil {
+		return errors.Wrap(err, "error creating HelmRelease watch")
+	}
+	err = ctl.Watch(
+		&source.Kind{Type: &kraanv1alpha1.LayerPolicy{}},
+		handler.EnqueueRequestsFromMapFunc(r.policyMapperFunc),
+		predicate.Funcs{
+			UpdateFunc: func(e event.UpdateEvent) bool {
+				r.Log.V(1).Info("update event for LayerPolicy", append(logging.GetFunctionAndSource(logging.MyCaller), logging.GetObjKindNamespaceName(e.ObjectNew)...)...)
+				return e.ObjectOld.GetGeneration() != e.ObjectNew.GetGeneration() ||
+					!reflect.DeepEqual(e.ObjectOld.GetLabels(), e.ObjectNew.GetLabels())
+			},
+		},
+	)
 	return err
 }
 
@@ -283,10 +346,19 @@ type AddonsLayerReconciler struct {
 	Scheme   *runtime.Scheme
 	Context  context.Context
 	Applier  apply.LayerApplier
+	Clusters clusters.Clusters
 	Repos    repos.Repos
+	Syncer   repos.Syncer
 	Metrics  metrics.Metrics
 	Recorder record.EventRecorder
 	regex    *regexp.Regexp
+
+	rehydrated atomic.Bool
+
+	ctl         controller.Controller
+	cache       ctrlcache.Cache
+	gateMutex   sync.Mutex
+	gateWatches map[schema.GroupVersionKind]bool
 }
 
 // EventRecorder returns an EventRecorder type that can be
@@ -306,7 +378,7 @@ func eventRecorder(
 
 // NewReconciler returns an AddonsLayerReconciler instance
 func NewReconciler(config *rest.Config, client client.Client, logger logr.Logger,
-	scheme *runtime.Scheme) (*AddonsLayerReconciler, error) {
+	scheme *runtime.Scheme, store storage.Storage) (*AddonsLayerReconciler, error) {
 	reconciler = &AddonsLayerReconciler{
 		Config: config,
 		Client: client,
@@ -320,11 +392,13 @@ func NewReconciler(config *rest.Config, client client.Client, logger logr.Logger
 	}
 	reconciler.Recorder = eventRecorder(reconciler.k8client)
 	reconciler.Context = context.Background()
-	reconciler.Applier, err = apply.NewApplier(client, logger.WithName("applier"), scheme)
+	reconciler.Applier, err = apply.NewApplier(client, logger.WithName("applier"), scheme, store)
 	if err != nil {
 		return nil, errors.WithMessagef(err, "%s - failed to create applier", logging.CallerStr(logging.Me))
 	}
+	reconciler.Clusters = clusters.NewClusters(config, reconciler.k8client, scheme, logger.WithName("clusters"))
 	reconciler.Repos = repos.NewRepos(reconciler.Context, reconciler.Log)
+	reconciler.Repos.SetStorage(store)
 
 	reconciler.Metrics = metrics.NewMetrics()
 
@@ -473,56 +547,86 @@ func (r *AddonsLayerReconciler) checkSuccess(l layers.Layer) (string, error) {
 		return "", nil
 	}
 	l.SetStatusDeployed()
+	if len(l.GetSpec().Outputs) > 0 || len(l.GetFullStatus().Outputs) > 0 {
+		if err := applier.PublishOutputs(ctx, l); err != nil {
+			return "", errors.WithMessagef(err, "%s - failed to publish outputs", logging.CallerStr(logging.Me))
+		}
+	}
 	revision, err := r.getRevision(l)
 	if err != nil {
 		return "", errors.WithMessagef(err, "%s - failed to get revision", logging.CallerStr(logging.Me))
 	}
+	if err := r.updateSourceRevisions(l); err != nil {
+		return "", errors.WithMessagef(err, "%s - failed to get source revisions", logging.CallerStr(logging.Me))
+	}
 	return revision, nil
 }
 
-func (r *AddonsLayerReconciler) waitForData(l layers.Layer, repo repos.Repo) (err error) {
+// checkData checks that the source data for the current revision of each of the layer's sources has been synced and
+// links the layer directories to it. If the data is not yet available the layer is set to pending, it will be
+// reprocessed when the repository sync status changes.
+func (r *AddonsLayerReconciler) checkData(l layers.Layer) (bool, error) {
 	logging.TraceCall(r.Log)
 	defer logging.TraceExit(r.Log)
 
-	MaxTries := 15
-	for try := 1; try < MaxTries; try++ {
-		err = repo.LinkData(l.GetSourcePath(), l.GetSpec().Source.Path)
-		if err == nil {
-			r.Log.V(1).Info("linked to layer data",
-				append(logging.GetFunctionAndSource(logging.MyCaller), "requestName", l.GetName(), "kind", logging.GitRepoSourceKind(),
-					"namespace", common.GetSourceNamespace(l.GetSpec().Source.NameSpace), "name", l.GetSpec().Source.Name, "layer", l.GetName())...)
-			return nil
+	synced := []repos.Repo{}
+	for _, source := range l.GetSources() {
+		repo, err := r.checkSourceData(l, source)
+		if err != nil || repo == nil {
+			return false, err
 		}
-		r.Log.V(1).Info("waiting for layer data to be synced",
-			append(logging.GetFunctionAndSource(logging.MyCaller), "layer", l.GetName(), "kind", logging.GitRepoSourceKind(),
-				"namespace", common.GetSourceNamespace(l.GetSpec().Source.NameSpace), "name", l.GetSpec().Source.Name, "path", l.GetSpec().Source.Path)...)
-		time.Sleep(time.Second)
+		synced = append(synced, repo)
+	}
+
+	for _, repo := range synced {
+		if err := linkLayerData(repo, l); err != nil {
+			return false, errors.WithMessagef(err, "%s - failed to link to layer data", logging.CallerStr(logging.Me))
+		}
+		repo.AddUser(l.GetName())
+	}
+	if artifact := synced[0].GetGitRepo().Status.Artifact; artifact != nil {
+		l.SetSourceRevision(artifact.Revision)
 	}
-	l.StatusUpdate(kraanv1alpha1.FailedCondition, fmt.Sprintf("%s, %s", kraanv1alpha1.AddonsLayerFailedMsg, errors.Cause(err).Error()))
-	return errors.WithMessagef(err, "%s - failed to link to layer data", logging.CallerStr(logging.Me))
+	r.Log.V(1).Info("linked to layer data",
+		append(logging.GetFunctionAndSource(logging.MyCaller), "requestName", l.GetName(), "kind", logging.GitRepoSourceKind(),
+			"namespace", common.GetSourceNamespace(l.GetSpec().Source.NameSpace), "name", common.GetSourceName(l.GetSpec().Source), "layer", l.GetName())...)
+	return true, nil
 }
 
-func (r *AddonsLayerReconciler) checkData(l layers.Layer) (bool, error) {
+// checkSourceData returns the repository for one of a layer's sources if the data for its current revision has been
+// synced, or nil if the layer must wait for it.
+func (r *AddonsLayerReconciler) checkSourceData(l layers.Layer, source kraanv1alpha1.SourceSpec) (repos.Repo, error) {
 	logging.TraceCall(r.Log)
 	defer logging.TraceExit(r.Log)
 
-	sourceRepoName := l.GetSourceKey()
-	MaxTries := 5
-	for try := 1; try < MaxTries; try++ {
-		repo := r.Repos.Get(sourceRepoName)
-		if repo != nil {
-			if err := r.waitForData(l, repo); err != nil {
-				return false, errors.WithMessagef(err, "%s - failed to find layer data", logging.CallerStr(logging.Me))
-			}
-			return true, nil
-		}
+	sourceRepoName := common.GetSourceKey(source)
+	repo := r.Repos.Get(sourceRepoName)
+	if repo == nil {
 		r.Log.Info("waiting for layer data",
-			append(logging.GetFunctionAndSource(logging.MyCaller), "requestName", l.GetName(), "kind", logging.GitRepoSourceKind(), "source", l.GetSpec().Source)...)
-		time.Sleep(time.Duration(time.Second * time.Duration(try))) //nolint: unconvert // ignore
+			append(logging.GetFunctionAndSource(logging.MyCaller), "requestName", l.GetName(), "kind", logging.GitRepoSourceKind(), "source", source)...)
+		l.SetDelayedRequeue()
+		l.SetStatusPending()
+		return nil, nil
 	}
-	l.SetDelayedRequeue()
-	l.SetStatusPending()
-	return false, nil
+
+	revision := ""
+	if repo.GetGitRepo().Status.Artifact != nil {
+		revision = repo.GetGitRepo().Status.Artifact.Revision
+	}
+	status := r.Repos.GetSyncStatus(sourceRepoName)
+	switch {
+	case status.State == repos.SyncStateFailed && status.Revision == revision:
+		return nil, errors.WithMessagef(status.Err, "%s - failed to sync layer data", logging.CallerStr(logging.Me))
+	case status.State != repos.SyncStateSynced || status.Revision != revision:
+		r.Log.V(1).Info("waiting for layer data to be synced",
+			append(logging.GetFunctionAndSource(logging.MyCaller), "layer", l.GetName(), "kind", logging.GitRepoSourceKind(),
+				"namespace", common.GetSourceNamespace(source.NameSpace), "name", common.GetSourceName(source),
+				"revision", revision, "state", status.State, "syncedRevision", status.Revision)...)
+		l.SetDelayedRequeue()
+		l.SetStatusPending()
+		return nil, nil
+	}
+	return repo, nil
 }
 
 func (r *AddonsLayerReconciler) getRevision(l layers.Layer) (string, error) {
@@ -537,6 +641,31 @@ func (r *AddonsLayerReconciler) getRevision(l layers.Layer) (string, error) {
 	return repo.GetGitRepo().Status.Artifact.Revision, nil
 }
 
+// updateSourceRevisions records the revisions of each of the layer's sources that have been deployed.
+func (r *AddonsLayerReconciler) updateSourceRevisions(l layers.Layer) error {
+	logging.TraceCall(r.Log)
+	defer logging.TraceExit(r.Log)
+
+	revisions := []kraanv1alpha1.SourceRevision{}
+	for _, source := range l.GetSources() {
+		repo := r.Repos.Get(common.GetSourceKey(source))
+		if repo == nil || repo.GetGitRepo().Status.Artifact == nil {
+			return fmt.Errorf("unable to find revision of source: %s", common.GetSourceKey(source))
+		}
+		revisions = append(revisions, kraanv1alpha1.SourceRevision{
+			Namespace: common.GetSourceNamespace(source.NameSpace),
+			Name:      common.GetSourceName(source),
+			Path:      source.Path,
+			Revision:  repo.GetGitRepo().Status.Artifact.Revision,
+		})
+	}
+	if !cmp.Equal(revisions, l.GetFullStatus().SourceRevisions) {
+		l.GetFullStatus().SourceRevisions = revisions
+		l.SetUpdated()
+	}
+	return nil
+}
+
 func (r *AddonsLayerReconciler) compareResources(current, new []kraanv1alpha1.Resource) bool {
 	logging.TraceCall(r.Log)
 	defer logging.TraceExit(r.Log)
@@ -575,27 +704,124 @@ func (r *AddonsLayerReconciler) updateResources(l layers.Layer) {
 	}
 }
 
+// refreshLocalSource queues a sync of a layer's directory sources if their content has changed. There are no source
+// events for directory sources, the revision is recomputed from the content each time the layer is reconciled.
+func (r *AddonsLayerReconciler) refreshLocalSource(l layers.Layer) error {
+	logging.TraceCall(r.Log)
+	defer logging.TraceExit(r.Log)
+
+	for _, source := range l.GetSources() {
+		if source.Kind != kraanv1alpha1.DirectorySourceKind {
+			continue
+		}
+		srcRepo, err := repos.NewLocalSource(source.Name, common.GetSourceNamespace(source.NameSpace), source.URL)
+		if err != nil {
+			return errors.WithMessagef(err, "%s - failed to read directory source", logging.CallerStr(logging.Me))
+		}
+		if repo := r.Repos.Get(common.GetSourceKey(source)); repo != nil && repo.GetGitRepo().Status.Artifact != nil &&
+			repo.GetGitRepo().Status.Artifact.Revision == srcRepo.Status.Artifact.Revision {
+			continue
+		}
+		r.Log.V(1).Info("directory source changed", append(logging.GetGitRepoInfo(srcRepo), append(logging.GetFunctionAndSource(logging.MyCaller), "layer", l.GetName())...)...)
+		r.Syncer.Enqueue(srcRepo)
+	}
+	return nil
+}
+
 func (r *AddonsLayerReconciler) isReady(l layers.Layer) (bool, error) {
 	logging.TraceCall(r.Log)
 	defer logging.TraceExit(r.Log)
 
-	sourceRepoName := l.GetSourceKey()
-	repo := r.Repos.Get(sourceRepoName)
-	if repo == nil {
-		return false, fmt.Errorf("unable to find git repository object")
+	for _, source := range l.GetSources() {
+		repo := r.Repos.Get(common.GetSourceKey(source))
+		if repo == nil {
+			// The repository is added when it is synced, the layer is reconciled again once it has been synced.
+			l.SetDelayedRequeue()
+			l.StatusUpdate(kraanv1alpha1.PendingCondition, fmt.Sprintf("layer source: %s not yet synced.", common.GetSourceKey(source)))
+			return false, nil
+		}
+		ready, srcMsg := l.SourceReady(repo.GetGitRepo())
+		if !ready {
+			l.SetDelayedRequeue()
+			message := fmt.Sprintf("layer source: %s not ready, source state: %s.", repo.GetGitRepo().Name, srcMsg)
+			l.StatusUpdate(kraanv1alpha1.PendingCondition, message)
+			return false, nil
+		}
+		if l.IsVerificationRequired(source) {
+			verified, verifyMsg := repos.SourceVerified(repo.GetGitRepo())
+			if !verified {
+				l.SetDelayedRequeue()
+				l.SetStatusSourceUnverified(common.GetSourceKey(source), verifyMsg)
+				return false, nil
+			}
+		}
 	}
-	revision := "not set"
-	if repo.GetGitRepo().Status.Artifact != nil {
-		revision = repo.GetGitRepo().Status.Artifact.Revision
+	return true, nil
+}
+
+// checkReadinessGates returns descriptions of the layer's readiness gates that are not ready. Gates on the cluster the
+// controller is running in are watched so the layer is reconciled when they change, gates on other clusters are checked
+// again after the layer's interval.
+func (r *AddonsLayerReconciler) checkReadinessGates(l layers.Layer) ([]string, error) {
+	logging.TraceCall(r.Log)
+	defer logging.TraceExit(r.Log)
+
+	if len(l.GetSpec().PreReqs.ReadinessGates) == 0 {
+		return nil, nil
 	}
-	ready, srcMsg := l.RevisionReady(repo.GetGitRepo().Status.Conditions, revision)
-	if !ready {
+	watched := false
+	if !l.GetCluster().IsRemote() {
+		var err error
+		watched, err = r.watchReadinessGates(l)
+		if err != nil {
+			return nil, errors.WithMessagef(err, "%s - failed to watch readiness gates", logging.CallerStr(logging.Me))
+		}
+	}
+	notReady, err := l.CheckReadinessGates()
+	if err != nil {
+		return nil, errors.WithMessagef(err, "%s - failed to check readiness gates", logging.CallerStr(logging.Me))
+	}
+	if len(notReady) > 0 && !watched {
 		l.SetDelayedRequeue()
-		message := fmt.Sprintf("layer source: %s not ready, source state: %s.", repo.GetGitRepo().Name, srcMsg)
-		l.StatusUpdate(kraanv1alpha1.PendingCondition, message)
+	}
+	return notReady, nil
+}
+
+// watchReadinessGates starts a watch for each kind referenced by the layer's readiness gates that is not already
+// watched. It returns false if a kind cannot be watched because it is not served by the cluster. Each watch starts an
+// informer that caches every object of the kind, in all namespaces, for the lifetime of the controller, watches are not
+// stopped when no layer has a gate of the kind.
+func (r *AddonsLayerReconciler) watchReadinessGates(l layers.Layer) (bool, error) {
+	logging.TraceCall(r.Log)
+	defer logging.TraceExit(r.Log)
+
+	if r.ctl == nil {
 		return false, nil
 	}
-	return true, nil
+	r.gateMutex.Lock()
+	defer r.gateMutex.Unlock()
+	watched := true
+	for _, gate := range l.GetSpec().PreReqs.ReadinessGates {
+		gvk := layers.ReadinessGateGVK(gate)
+		if r.gateWatches[gvk] {
+			continue
+		}
+		if _, err := r.RESTMapper().RESTMapping(gvk.GroupKind(), gvk.Version); err != nil {
+			if meta.IsNoMatchError(err) {
+				watched = false
+				continue
+			}
+			return false, errors.Wrapf(err, "%s - failed to get rest mapping for: %s", logging.CallerStr(logging.Me), gvk)
+		}
+		obj := &unstructured.Unstructured{}
+		obj.SetGroupVersionKind(gvk)
+		if err := r.ctl.Watch(source.NewKindWithCache(obj, r.cache), handler.EnqueueRequestsFromMapFunc(r.readinessGateMapperFunc)); err != nil {
+			return false, errors.Wrapf(err, "%s - failed to watch: %s", logging.CallerStr(logging.Me), gvk)
+		}
+		r.gateWatches[gvk] = true
+		r.Log.Info("watching readiness gate kind", append(logging.GetFunctionAndSource(logging.MyCaller), "kind", gvk.String(), "layer", l.GetName())...)
+	}
+	return watched, nil
 }
 
 func (r *AddonsLayerReconciler) adopt(l layers.Layer) error {
@@ -631,6 +857,29 @@ func (r *AddonsLayerReconciler) processAddonLayer(l layers.Layer) (string, error
 		return "", nil
 	}
 
+	applicable, err := l.IsApplicable()
+	if err != nil {
+		return "", errors.WithMessagef(err, "%s - failed to check cluster selector", logging.CallerStr(logging.Me))
+	}
+	if !applicable {
+		// The layer's resources are left in place, the layer is no longer applied or pruned.
+		l.SetStatusNotApplicable()
+		l.SetDelayedRequeue()
+		return "", nil
+	}
+
+	if err := l.CheckSourceNamespaces(); err != nil {
+		return "", errors.WithMessagef(err, "%s - source not allowed", logging.CallerStr(logging.Me))
+	}
+
+	if err := r.ensureInlineSource(l); err != nil {
+		return "", errors.WithMessagef(err, "%s - failed to process inline git source", logging.CallerStr(logging.Me))
+	}
+
+	if err := r.refreshLocalSource(l); err != nil {
+		return "", errors.WithMessagef(err, "%s - failed to process directory source", logging.CallerStr(logging.Me))
+	}
+
 	ready, err := r.isReady(l)
 	if err != nil {
 		return "", errors.WithMessagef(err, "%s - failed to check source is ready", logging.CallerStr(logging.Me))
@@ -660,6 +909,25 @@ func (r *AddonsLayerReconciler) processAddonLayer(l layers.Layer) (string, error
 		return "", nil
 	}
 
+	missing, err := l.CheckPrerequisites()
+	if err != nil {
+		return "", errors.WithMessagef(err, "%s - failed to check prerequisites", logging.CallerStr(logging.Me))
+	}
+	if len(missing) > 0 {
+		l.SetStatusPrerequisitesNotMet(missing)
+		l.SetDelayedRequeue()
+		return "", nil
+	}
+
+	notReady, err := r.checkReadinessGates(l)
+	if err != nil {
+		return "", errors.WithMessagef(err, "%s - failed to check readiness gates", logging.CallerStr(logging.Me))
+	}
+	if len(notReady) > 0 {
+		l.SetStatusPrerequisitesNotMet(notReady)
+		return "", nil
+	}
+
 	layerStatusUpdated, err := r.processPrune(l)
 	if err != nil {
 		return "", errors.WithMessagef(err, "%s - failed to perform prune processing", logging.CallerStr(logging.Me))
@@ -706,12 +974,18 @@ func (r *AddonsLayerReconciler) updateRequeue(l layers.Layer) (res ctrl.Result,
 // Reconcile process AddonsLayers custom resources.
 // +kubebuilder:rbac:groups=kraan.io,resources=addons,verbs=get;list;watch;create;update;patch;delete
 // +kubebuilder:rbac:groups=kraan.io,resources=addons/status,verbs=get;update;patch
+// +kubebuilder:rbac:groups=kraan.io,resources=layerpolicies,verbs=get;list;watch
 func (r *AddonsLayerReconciler) Reconcile(ctx context.Context, req ctrl.Request) (ctrl.Result, error) { //nolint:funlen,gocyclo // ok
 	logging.TraceCall(r.Log)
 	defer logging.TraceExit(r.Log)
 
 	reconcileStart := time.Now()
 
+	if !r.rehydrated.Load() {
+		r.Log.V(1).Info("waiting for source data rehydration", append(logging.GetFunctionAndSource(logging.MyCaller), "layer", req.NamespacedName.Name)...)
+		return ctrl.Result{RequeueAfter: rehydrateRequeueDelay}, nil
+	}
+
 	addonsLayer := &kraanv1alpha1.AddonsLayer{}
 	if err := r.Get(ctx, req.NamespacedName, addonsLayer); err != nil {
 		if apierrors.IsNotFound(err) {
@@ -747,6 +1021,38 @@ func (r *AddonsLayerReconciler) Reconcile(ctx context.Context, req ctrl.Request)
 		return res, err
 	}
 
+	if addonsLayer.Spec.KubeConfig != nil {
+		cluster, e := r.Clusters.Get(ctx, addonsLayer.Spec.KubeConfig)
+		if e != nil {
+			log.Error(e, "failed to access target cluster", logging.GetFunctionAndSource(logging.MyCaller)...)
+			l.StatusUpdate(kraanv1alpha1.FailedCondition, fmt.Sprintf("%s, %s", kraanv1alpha1.AddonsLayerFailedMsg, errors.Cause(e).Error()))
+			l.SetDelayedRequeue()
+			return r.updateRequeue(l)
+		}
+		l.SetCluster(cluster)
+	}
+
+	if addonsLayer.Spec.ServiceAccountName != "" {
+		if e := l.CheckServiceAccountNamespace(); e != nil {
+			log.Error(e, "service account not allowed", logging.GetFunctionAndSource(logging.MyCaller)...)
+			l.StatusUpdate(kraanv1alpha1.ForbiddenCondition, fmt.Sprintf("%s, %s", kraanv1alpha1.AddonsLayerForbiddenMsg, errors.Cause(e).Error()))
+			l.SetDelayedRequeue()
+			return r.updateRequeue(l)
+		}
+		serviceAccount := types.NamespacedName{
+			Namespace: common.GetSourceNamespace(addonsLayer.Spec.ServiceAccountNamespace),
+			Name:      addonsLayer.Spec.ServiceAccountName,
+		}
+		cluster, e := r.Clusters.Impersonate(l.GetCluster(), serviceAccount)
+		if e != nil {
+			log.Error(e, "failed to impersonate service account", logging.GetFunctionAndSource(logging.MyCaller)...)
+			l.StatusUpdate(kraanv1alpha1.FailedCondition, fmt.Sprintf("%s, %s", kraanv1alpha1.AddonsLayerFailedMsg, errors.Cause(e).Error()))
+			l.SetDelayedRequeue()
+			return r.updateRequeue(l)
+		}
+		l.SetCluster(cluster)
+	}
+
 	if addonsLayer.ObjectMeta.DeletionTimestamp.IsZero() { //nolint: nestif // ok
 		if !common.ContainsString(addonsLayer.ObjectMeta.Finalizers, kraanv1alpha1.AddonsFinalizer) {
 			r.Log.V(1).Info("adding finalizer to addonsLayer", append(logging.GetFunctionAndSource(logging.MyCaller), "layer", req.NamespacedName.Name)...)
@@ -783,6 +1089,14 @@ func (r *AddonsLayerReconciler) Reconcile(ctx context.Context, req ctrl.Request)
 				l.SetDelayedRequeue()     // Schedule requeue
 				return r.updateRequeue(l) // don't proceed with delete
 			}
+			if addonsLayer.Spec.KubeConfig != nil {
+				if err = r.Applier.DeleteAll(ctx, l); err != nil {
+					return ctrl.Result{}, errors.WithMessagef(err, "%s - failed to delete resources from target cluster", logging.CallerStr(logging.Me))
+				}
+			}
+			if err = r.pruneInlineSources(ctx, addonsLayer, nil); err != nil {
+				return ctrl.Result{}, errors.WithMessagef(err, "%s - failed to release inline git source", logging.CallerStr(logging.Me))
+			}
 			// Remove our finalizer from the list and update it
 			addonsLayer.ObjectMeta.Finalizers = common.RemoveString(l.GetAddonsLayer().ObjectMeta.Finalizers, kraanv1alpha1.AddonsFinalizer)
 			r.recordReadiness(addonsLayer, true)
@@ -798,7 +1112,16 @@ func (r *AddonsLayerReconciler) Reconcile(ctx context.Context, req ctrl.Request)
 
 	deployedRevision, err := r.processAddonLayer(l)
 	if err != nil {
-		l.StatusUpdate(kraanv1alpha1.FailedCondition, fmt.Sprintf("%s, %s", kraanv1alpha1.AddonsLayerFailedMsg, errors.Cause(err).Error()))
+		violationErr := &policy.ViolationError{}
+		if errors.As(err, &violationErr) {
+			l.StatusUpdate(kraanv1alpha1.PolicyViolationCondition, fmt.Sprintf("%s, %s", kraanv1alpha1.AddonsLayerPolicyViolationMsg, violationErr.Error()))
+			l.SetDelayedRequeue()
+		} else if apierrors.IsForbidden(err) {
+			l.StatusUpdate(kraanv1alpha1.ForbiddenCondition, fmt.Sprintf("%s, %s", kraanv1alpha1.AddonsLayerForbiddenMsg, errors.Cause(err).Error()))
+			l.SetDelayedRequeue()
+		} else {
+			l.StatusUpdate(kraanv1alpha1.FailedCondition, fmt.Sprintf("%s, %s", kraanv1alpha1.AddonsLayerFailedMsg, errors.Cause(err).Error()))
+		}
 		log.Error(err, "failed to process addons layer", logging.GetFunctionAndSource(logging.MyCaller)...)
 	}
 
@@ -886,7 +1209,7 @@ func (r *AddonsLayerReconciler) update(ctx context.Context, a *kraanv1alpha1.Add
 	return nil
 }
 
-func (r *AddonsLayerReconciler) repoMapperFunc(o client.Object) []reconcile.Request { //nolint:gocyclo //ok
+func (r *AddonsLayerReconciler) repoMapperFunc(o client.Object) []reconcile.Request {
 	logging.TraceCall(r.Log)
 	defer logging.TraceExit(r.Log)
 
@@ -902,41 +1225,141 @@ func (r *AddonsLayerReconciler) repoMapperFunc(o client.Object) []reconcile.Requ
 		r.Log.Error(err, "unable to list AddonsLayers", append(logging.GetGitRepoInfo(srcRepo), logging.GetFunctionAndSource(logging.MyCaller)...)...)
 		return []reconcile.Request{}
 	}
-	layerList := []layers.Layer{}
 	addons := []reconcile.Request{}
 	for _, addon := range addonsList.Items {
-		layer := layers.CreateLayer(r.Context, r.Client, r.k8client, r.Log, r.Recorder, r.Scheme, &addon) //nolint:scopelint // ok
-		if layer.GetSpec().Source.Name == srcRepo.Name && common.GetSourceNamespace(layer.GetSpec().Source.NameSpace) == srcRepo.Namespace {
+		if usesGitRepository(addon.Spec, srcRepo.Namespace, srcRepo.Name) {
 			r.Log.V(1).Info("layer is using this source", append(logging.GetGitRepoInfo(srcRepo), append(logging.GetFunctionAndSource(logging.MyCaller), "layer", addon.Name)...)...)
-			layerList = append(layerList, layer)
-			addons = append(addons, reconcile.Request{NamespacedName: types.NamespacedName{Name: layer.GetName(), Namespace: ""}})
+			addons = append(addons, reconcile.Request{NamespacedName: types.NamespacedName{Name: addon.Name, Namespace: ""}})
 		}
 	}
 	if len(addons) == 0 {
 		return []reconcile.Request{}
 	}
+	r.Syncer.Enqueue(srcRepo)
+	r.Log.V(1).Info("queued source sync", append(logging.GetGitRepoInfo(srcRepo), append(logging.GetFunctionAndSource(logging.MyCaller), "layers", addons)...)...)
+	return addons
+}
+
+// syncAndLink syncs the repository data for a source and links the directories of the layers using it.
+func (r *AddonsLayerReconciler) syncAndLink(srcRepo *sourcev1.GitRepository, layerList []layers.Layer) error {
+	logging.TraceCall(r.Log)
+	defer logging.TraceExit(r.Log)
+
 	repo := r.Repos.Add(srcRepo)
 	r.Log.V(1).Info("created repo object", logging.GetGitRepoInfo(srcRepo)...)
 	if err := repo.SyncRepo(); err != nil {
-		r.Log.Error(err, "unable to sync repo, not requeuing", append(logging.GetGitRepoInfo(srcRepo), logging.GetFunctionAndSource(logging.MyCaller)...)...)
-		return []reconcile.Request{}
+		return errors.WithMessagef(err, "%s - failed to sync repo", logging.CallerStr(logging.Me))
 	}
 
+	layerNames := []string{}
 	for _, layer := range layerList {
-		if err := repo.LinkData(layer.GetSourcePath(), layer.GetSpec().Source.Path); err != nil {
+		if err := linkLayerData(repo, layer); err != nil {
 			r.Log.Error(err, "unable to link referencing AddonsLayer directory to repository data",
 				append(logging.GetGitRepoInfo(srcRepo), append(logging.GetFunctionAndSource(logging.MyCaller), "layers", layer.GetName())...)...)
 			continue
 		}
 		repo.AddUser(layer.GetName())
+		layerNames = append(layerNames, layer.GetName())
 	}
-	r.Log.V(1).Info("synced source", append(logging.GetGitRepoInfo(srcRepo), append(logging.GetFunctionAndSource(logging.MyCaller), "layers", addons)...)...)
+	r.Log.V(1).Info("synced source", append(logging.GetGitRepoInfo(srcRepo), append(logging.GetFunctionAndSource(logging.MyCaller), "layers", layerNames)...)...)
 	if err := repo.TidyRepo(); err != nil {
 		r.Log.Error(err, "unable to garbage collect repo revisions", append(logging.GetGitRepoInfo(srcRepo), logging.GetFunctionAndSource(logging.MyCaller)...)...)
 	}
+	return nil
+}
+
+// syncEvents converts repository sync status notifications into generic events for the GitRepository concerned.
+func (r *AddonsLayerReconciler) syncEvents(statuses <-chan repos.SyncStatus) <-chan event.GenericEvent {
+	events := make(chan event.GenericEvent)
+	go func() {
+		defer close(events)
+		for status := range statuses {
+			if status.State != repos.SyncStateSynced && status.State != repos.SyncStateFailed {
+				continue
+			}
+			namespace, name, err := cache.SplitMetaNamespaceKey(status.Key)
+			if err != nil {
+				r.Log.Error(err, "unable to parse repository key", append(logging.GetFunctionAndSource(logging.MyCaller), "repo", status.Key)...)
+				continue
+			}
+			srcRepo := &sourcev1.GitRepository{}
+			srcRepo.SetNamespace(namespace)
+			srcRepo.SetName(name)
+			events <- event.GenericEvent{Object: srcRepo}
+		}
+	}()
+	return events
+}
+
+// syncedMapperFunc returns requests for the layers using a repository whose sync status has changed.
+func (r *AddonsLayerReconciler) syncedMapperFunc(o client.Object) []reconcile.Request {
+	logging.TraceCall(r.Log)
+	defer logging.TraceExit(r.Log)
+
+	addonsList := &kraanv1alpha1.AddonsLayerList{}
+	if err := r.List(r.Context, addonsList); err != nil {
+		r.Log.Error(err, "unable to list AddonsLayers", append(logging.GetObjNamespaceName(o), logging.GetFunctionAndSource(logging.MyCaller)...)...)
+		return []reconcile.Request{}
+	}
+	addons := []reconcile.Request{}
+	for _, addon := range addonsList.Items {
+		if usesSource(addon.Spec, o.GetNamespace(), o.GetName()) {
+			r.Log.V(1).Info("layer source data synced", append(logging.GetObjNamespaceName(o), append(logging.GetFunctionAndSource(logging.MyCaller), "layer", addon.Name)...)...)
+			addons = append(addons, reconcile.Request{NamespacedName: types.NamespacedName{Name: addon.Name, Namespace: ""}})
+		}
+	}
 	return addons
 }
 
+// usesSource returns true if any of a layer's sources uses the named GitRepository.
+func usesSource(spec kraanv1alpha1.AddonsLayerSpec, namespace, name string) bool {
+	for _, source := range spec.GetSources() {
+		if common.GetSourceName(source) == name && common.GetSourceNamespace(source.NameSpace) == namespace {
+			return true
+		}
+	}
+	return false
+}
+
+// usesGitRepository returns true if any of a layer's sources, other than directory sources, uses the named GitRepository.
+func usesGitRepository(spec kraanv1alpha1.AddonsLayerSpec, namespace, name string) bool {
+	for _, source := range spec.GetSources() {
+		if source.Kind != kraanv1alpha1.DirectorySourceKind &&
+			common.GetSourceName(source) == name && common.GetSourceNamespace(source.NameSpace) == namespace {
+			return true
+		}
+	}
+	return false
+}
+
+// removedSources returns the keys of the sources used by the old layer spec that are not used by the new spec.
+func removedSources(old, new kraanv1alpha1.AddonsLayerSpec) []string {
+	removed := []string{}
+	for _, source := range old.GetSources() {
+		key := common.GetSourceKey(source)
+		if !usesSource(new, common.GetSourceNamespace(source.NameSpace), common.GetSourceName(source)) &&
+			!common.ContainsString(removed, key) {
+			removed = append(removed, key)
+		}
+	}
+	return removed
+}
+
+// linkLayerData links the directories of each of a layer's sources using a repository to the repository data.
+func linkLayerData(repo repos.Repo, layer layers.Layer) error {
+	srcRepo := repo.GetGitRepo()
+	sourcePaths := layer.GetSourcePaths()
+	for index, source := range layer.GetSources() {
+		if common.GetSourceName(source) != srcRepo.Name || common.GetSourceNamespace(source.NameSpace) != srcRepo.Namespace {
+			continue
+		}
+		if err := repo.LinkData(sourcePaths[index], source.Path); err != nil {
+			return errors.WithMessagef(err, "%s - failed to link layer source path: %s", logging.CallerStr(logging.Me), source.Path)
+		}
+	}
+	return nil
+}
+
 func (r *AddonsLayerReconciler) layerMapperFunc(o client.Object) []reconcile.Request {
 	logging.TraceCall(r.Log)
 	defer logging.TraceExit(r.Log)
@@ -956,7 +1379,7 @@ func (r *AddonsLayerReconciler) layerMapperFunc(o client.Object) []reconcile.Req
 	addons := []reconcile.Request{}
 	for _, addon := range addonsList.Items {
 		layer := layers.CreateLayer(r.Context, r.Client, r.k8client, r.Log, r.Recorder, r.Scheme, &addon) //nolint:scopelint // ok
-		if common.ContainsString(layer.GetSpec().PreReqs.DependsOn, fmt.Sprintf("%s@%s", src.Name, src.Status.Version)) {
+		if dependsOnLayer(layer, src.Name, src.Status.Version) || layer.ReferencesOutputs(src.Name) {
 			r.Log.V(1).Info("layer dependent on updated layer", append(logging.GetLayerInfo(src), append(logging.GetFunctionAndSource(logging.MyCaller), "layer", addon.Name)...)...)
 			addons = append(addons, reconcile.Request{NamespacedName: types.NamespacedName{Name: layer.GetName(), Namespace: ""}})
 		}
@@ -967,6 +1390,90 @@ func (r *AddonsLayerReconciler) layerMapperFunc(o client.Object) []reconcile.Req
 	return addons
 }
 
+// dependsOnLayer returns true if a layer depends on the version of another layer, or on a HelmRelease of the other
+// layer.
+func dependsOnLayer(layer layers.Layer, name, version string) bool {
+	prereqs := layer.GetSpec().PreReqs
+	for _, dependency := range append(append([]string{}, prereqs.DependsOn...), prereqs.OptionalDependsOn...) {
+		otherName, release, otherVersion := layers.ParseDependency(dependency)
+		if otherName == name && (len(release) > 0 || otherVersion == version) {
+			return true
+		}
+	}
+	return false
+}
+
+// releaseMapperFunc requeues the AddonsLayers that depend on a HelmRelease that changed.
+func (r *AddonsLayerReconciler) releaseMapperFunc(o client.Object) []reconcile.Request {
+	logging.TraceCall(r.Log)
+	defer logging.TraceExit(r.Log)
+
+	owner := o.GetLabels()[common.OwnerLabel]
+	if len(owner) == 0 {
+		return []reconcile.Request{}
+	}
+	addonsList := &kraanv1alpha1.AddonsLayerList{}
+	if err := r.List(r.Context, addonsList); err != nil {
+		r.Log.Error(err, "unable to list AddonsLayers", append(logging.GetFunctionAndSource(logging.MyCaller), logging.GetObjKindNamespaceName(o)...)...)
+		return []reconcile.Request{}
+	}
+	addons := []reconcile.Request{}
+	for _, addon := range addonsList.Items {
+		prereqs := addon.Spec.PreReqs
+		for _, dependency := range append(append([]string{}, prereqs.DependsOn...), prereqs.OptionalDependsOn...) {
+			if name, release, _ := layers.ParseDependency(dependency); name == owner && len(release) > 0 && layers.ReleaseMatches(release, o) {
+				r.Log.V(1).Info("layer dependent on updated release", append(logging.GetFunctionAndSource(logging.MyCaller), append(logging.GetObjKindNamespaceName(o), "layer", addon.Name)...)...)
+				addons = append(addons, reconcile.Request{NamespacedName: types.NamespacedName{Name: addon.Name, Namespace: ""}})
+				break
+			}
+		}
+	}
+	return addons
+}
+
+// readinessGateMapperFunc requeues the AddonsLayers that have a readiness gate on an object that changed, whatever their
+// status, so a layer that is already deployed notices a gate that is no longer ready.
+func (r *AddonsLayerReconciler) readinessGateMapperFunc(o client.Object) []reconcile.Request {
+	logging.TraceCall(r.Log)
+	defer logging.TraceExit(r.Log)
+
+	addonsList := &kraanv1alpha1.AddonsLayerList{}
+	if err := r.List(r.Context, addonsList); err != nil {
+		r.Log.Error(err, "unable to list AddonsLayers", append(logging.GetFunctionAndSource(logging.MyCaller), logging.GetObjKindNamespaceName(o)...)...)
+		return []reconcile.Request{}
+	}
+	groupKind := o.GetObjectKind().GroupVersionKind().GroupKind()
+	addons := []reconcile.Request{}
+	for _, addon := range addonsList.Items {
+		for _, gate := range addon.Spec.PreReqs.ReadinessGates {
+			if layers.ReadinessGateGVK(gate).GroupKind() == groupKind && gate.Name == o.GetName() && gate.Namespace == o.GetNamespace() {
+				r.Log.V(1).Info("readiness gate changed", append(logging.GetFunctionAndSource(logging.MyCaller), append(logging.GetObjKindNamespaceName(o), "layer", addon.Name)...)...)
+				addons = append(addons, reconcile.Request{NamespacedName: types.NamespacedName{Name: addon.Name, Namespace: ""}})
+				break
+			}
+		}
+	}
+	return addons
+}
+
+// policyMapperFunc requeues all AddonsLayers when a LayerPolicy changes so they are checked against its rules.
+func (r *AddonsLayerReconciler) policyMapperFunc(o client.Object) []reconcile.Request {
+	logging.TraceCall(r.Log)
+	defer logging.TraceExit(r.Log)
+
+	r.Log.V(1).Info("layer policy changed", append(logging.GetFunctionAndSource(logging.MyCaller), logging.GetObjKindNamespaceName(o)...)...)
+	addonsList := &kraanv1alpha1.AddonsLayerList{}
+	if err := r.List(r.Context, addonsList); err != nil {
+		r.Log.Error(err, "unable to list AddonsLayers", append(logging.GetFunctionAndSource(logging.MyCaller), logging.GetObjKindNamespaceName(o)...)...)
+		return []reconcile.Request{}
+	}
+	addons := []reconcile.Request{}
+	for _, addon := range addonsList.Items {
+		addons = append(addons, reconcile.Request{NamespacedName: types.NamespacedName{Name: addon.Name, Namespace: ""}})
+	}
+	return addons
+}
+
 func (r *AddonsLayerReconciler) indexHelmReleaseByOwner(o client.Object) []string {
 	logging.TraceCall(r.Log)
 	defer logging.TraceExit(r.Log)
@@ -0,0 +1,51 @@
+package controllers_test
+
+import (
+	"context"
+	"testing"
+
+	"github.com/go-logr/logr"
+	"github.com/google/go-cmp/cmp"
+	appsv1 "k8s.io/api/apps/v1"
+	metav1 "k8s.io/apimachinery/pkg/apis/meta/v1"
+	"k8s.io/apimachinery/pkg/runtime"
+	"k8s.io/apimachinery/pkg/types"
+	"sigs.k8s.io/controller-runtime/pkg/client/fake"
+	"sigs.k8s.io/controller-runtime/pkg/reconcile"
+
+	kraanv1alpha1 "github.com/fidelity/kraan/api/v1alpha1"
+	"github.com/fidelity/kraan/controllers"
+)
+
+func TestReadinessGateMapperFunc(t *testing.T) {
+	scheme := runtime.NewScheme()
+	_ = kraanv1alpha1.AddToScheme(scheme) //nolint:errcheck // ok
+	_ = appsv1.AddToScheme(scheme)        //nolint:errcheck // ok
+
+	coredns := kraanv1alpha1.ReadinessGate{APIVersion: "apps/v1", Kind: "Deployment", Name: "coredns", Namespace: "kube-system"}
+	newLayer := func(name, state string, gates ...kraanv1alpha1.ReadinessGate) *kraanv1alpha1.AddonsLayer {
+		return &kraanv1alpha1.AddonsLayer{
+			ObjectMeta: metav1.ObjectMeta{Name: name},
+			Spec:       kraanv1alpha1.AddonsLayerSpec{PreReqs: kraanv1alpha1.PreReqs{ReadinessGates: gates}},
+			Status:     kraanv1alpha1.AddonsLayerStatus{State: state},
+		}
+	}
+	client := fake.NewClientBuilder().WithScheme(scheme).WithObjects(
+		newLayer("waiting", kraanv1alpha1.PrerequisitesNotMetCondition, coredns),
+		newLayer("deployed", kraanv1alpha1.DeployedCondition, coredns),
+		newLayer("other-gate", kraanv1alpha1.PrerequisitesNotMetCondition,
+			kraanv1alpha1.ReadinessGate{APIVersion: "apps/v1", Kind: "Deployment", Name: "metrics-server", Namespace: "kube-system"}),
+		newLayer("no-gates", kraanv1alpha1.DeployedCondition),
+	).Build()
+	r := &controllers.AddonsLayerReconciler{Client: client, Log: logr.Discard(), Scheme: scheme, Context: context.Background()}
+
+	deployment := &appsv1.Deployment{ObjectMeta: metav1.ObjectMeta{Name: "coredns", Namespace: "kube-system"}}
+	deployment.SetGroupVersionKind(appsv1.SchemeGroupVersion.WithKind("Deployment"))
+	expected := []reconcile.Request{
+		{NamespacedName: types.NamespacedName{Name: "deployed"}},
+		{NamespacedName: types.NamespacedName{Name: "waiting"}},
+	}
+	if diff := cmp.Diff(expected, r.ReadinessGateMapperFunc(deployment)); diff != "" {
+		t.Fatalf("unexpected requests (-want +got):\n%s", diff)
+	}
+}
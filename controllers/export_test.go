@@ -0,0 +1,29 @@
+package controllers
+
+import (
+	"time"
+
+	"sigs.k8s.io/controller-runtime/pkg/client"
+	"sigs.k8s.io/controller-runtime/pkg/manager"
+	"sigs.k8s.io/controller-runtime/pkg/reconcile"
+)
+
+const RehydrateRequeueDelay = rehydrateRequeueDelay
+
+var (
+	InlineSourceSpec   = inlineSourceSpec
+	InlineSourceOwners = inlineSourceOwners
+	RemoveOwner        = removeOwner
+)
+
+func (r *AddonsLayerReconciler) ReadinessGateMapperFunc(o client.Object) []reconcile.Request {
+	return r.readinessGateMapperFunc(o)
+}
+
+func SetRehydrateRetryDelay(delay time.Duration) {
+	rehydrateRetryDelay = delay
+}
+
+func NewRehydrator(r *AddonsLayerReconciler, concurrency int) manager.LeaderElectionRunnable {
+	return rehydrator{reconciler: r, concurrency: concurrency}
+}
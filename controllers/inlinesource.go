@@ -0,0 +1,229 @@
+package controllers
+
+import (
+	"context"
+	"fmt"
+	"sort"
+	"time"
+
+	"github.com/fluxcd/pkg/apis/meta"
+	sourcev1 "github.com/fluxcd/source-controller/api/v1beta2"
+	"github.com/pkg/errors"
+	apierrors "k8s.io/apimachinery/pkg/api/errors"
+	metav1 "k8s.io/apimachinery/pkg/apis/meta/v1"
+	"k8s.io/apimachinery/pkg/types"
+	"sigs.k8s.io/controller-runtime/pkg/client"
+
+	kraanv1alpha1 "github.com/fidelity/kraan/api/v1alpha1"
+	"github.com/fidelity/kraan/pkg/common"
+	"github.com/fidelity/kraan/pkg/layers"
+	"github.com/fidelity/kraan/pkg/logging"
+)
+
+const (
+	// managedByLabel identifies GitRepositories created by the Kraan controller for inline git sources.
+	managedByLabel = "kraan/managed-by"
+	managedByValue = "kraan"
+)
+
+// defaultInlineSourceInterval is the interval used for inline git sources that do not specify one.
+var defaultInlineSourceInterval = metav1.Duration{Duration: time.Minute}
+
+// ensureInlineSource creates or updates the GitRepositories for a layer's inline git sources. A GitRepository is shared
+// by all layers using the same URL and ref, each of which is recorded as an owner so that it is retained until the last
+// of them is deleted. Any other GitRepository previously created for the layer is released.
+func (r *AddonsLayerReconciler) ensureInlineSource(l layers.Layer) error {
+	logging.TraceCall(r.Log)
+	defer logging.TraceExit(r.Log)
+
+	keep := []string{}
+	for _, source := range l.GetSources() {
+		if source.Git == nil {
+			continue
+		}
+		if err := r.ensureGitRepository(l, source); err != nil {
+			return err
+		}
+		keep = append(keep, common.GetSourceKey(source))
+	}
+	return r.pruneInlineSources(r.Context, l.GetAddonsLayer(), keep)
+}
+
+// ensureGitRepository creates or updates the GitRepository for one of a layer's inline git sources.
+func (r *AddonsLayerReconciler) ensureGitRepository(l layers.Layer, source kraanv1alpha1.SourceSpec) error {
+	logging.TraceCall(r.Log)
+	defer logging.TraceExit(r.Log)
+
+	name := common.GetSourceName(source)
+	namespace := common.GetSourceNamespace(source.NameSpace)
+
+	users, err := r.inlineSourceUsers(r.Context, name, namespace)
+	if err != nil {
+		return errors.WithMessagef(err, "%s - failed to list layers using inline source", logging.CallerStr(logging.Me))
+	}
+	if !containsLayer(users, l.GetAddonsLayer()) {
+		users = append(users, l.GetAddonsLayer())
+	}
+
+	srcRepo := &sourcev1.GitRepository{}
+	err = r.Get(r.Context, client.ObjectKey{Namespace: namespace, Name: name}, srcRepo)
+	if err != nil && !apierrors.IsNotFound(err) {
+		return errors.Wrapf(err, "%s - failed to get GitRepository: %s/%s", logging.CallerStr(logging.Me), namespace, name)
+	}
+	exists := err == nil
+	if exists && srcRepo.Labels[managedByLabel] != managedByValue {
+		return fmt.Errorf("GitRepository: %s/%s, already exists and is not managed by kraan", namespace, name)
+	}
+
+	srcRepo.Name = name
+	srcRepo.Namespace = namespace
+	if srcRepo.Labels == nil {
+		srcRepo.Labels = map[string]string{}
+	}
+	srcRepo.Labels[managedByLabel] = managedByValue
+	srcRepo.OwnerReferences = inlineSourceOwners(users)
+	srcRepo.Spec = inlineSourceSpec(source.Git, namespace, users, srcRepo.Spec)
+
+	if exists {
+		if err := r.Update(r.Context, srcRepo); err != nil {
+			return errors.Wrapf(err, "%s - failed to update GitRepository: %s/%s", logging.CallerStr(logging.Me), namespace, name)
+		}
+		return nil
+	}
+	r.Log.Info("creating GitRepository for inline source", append(logging.GetFunctionAndSource(logging.MyCaller), "namespace", namespace, "name", name, "layer", l.GetName())...)
+	if err := r.Create(r.Context, srcRepo); err != nil {
+		return errors.Wrapf(err, "%s - failed to create GitRepository: %s/%s", logging.CallerStr(logging.Me), namespace, name)
+	}
+	return nil
+}
+
+// inlineSourceUsers returns the layers, sorted by name, whose inline git source uses the named GitRepository.
+func (r *AddonsLayerReconciler) inlineSourceUsers(ctx context.Context, name, namespace string) ([]*kraanv1alpha1.AddonsLayer, error) {
+	addonsList := &kraanv1alpha1.AddonsLayerList{}
+	if err := r.List(ctx, addonsList); err != nil {
+		return nil, errors.Wrapf(err, "%s - failed to list AddonsLayers", logging.CallerStr(logging.Me))
+	}
+	users := []*kraanv1alpha1.AddonsLayer{}
+	for i := range addonsList.Items {
+		addon := &addonsList.Items[i]
+		if !addon.DeletionTimestamp.IsZero() {
+			continue
+		}
+		if inlineGitSource(addon.Spec, name, namespace) != nil {
+			users = append(users, addon)
+		}
+	}
+	sort.Slice(users, func(i, j int) bool { return users[i].Name < users[j].Name })
+	return users, nil
+}
+
+// pruneInlineSources removes a layer from the owners of the GitRepositories created for inline git sources, other than
+// those identified by keep, deleting those that are no longer used by any layer.
+func (r *AddonsLayerReconciler) pruneInlineSources(ctx context.Context, addonsLayer *kraanv1alpha1.AddonsLayer, keep []string) error {
+	logging.TraceCall(r.Log)
+	defer logging.TraceExit(r.Log)
+
+	srcList := &sourcev1.GitRepositoryList{}
+	if err := r.List(ctx, srcList, client.MatchingLabels{managedByLabel: managedByValue}); err != nil {
+		return errors.Wrapf(err, "%s - failed to list GitRepositories", logging.CallerStr(logging.Me))
+	}
+	for i := range srcList.Items {
+		srcRepo := &srcList.Items[i]
+		if common.ContainsString(keep, fmt.Sprintf("%s/%s", srcRepo.Namespace, srcRepo.Name)) {
+			continue
+		}
+		owners := removeOwner(srcRepo.OwnerReferences, addonsLayer.UID)
+		if len(owners) == len(srcRepo.OwnerReferences) {
+			continue
+		}
+		if len(owners) == 0 {
+			r.Log.Info("deleting unused GitRepository for inline source", append(logging.GetGitRepoInfo(srcRepo), append(logging.GetFunctionAndSource(logging.MyCaller), "layer", addonsLayer.Name)...)...)
+			if err := r.Delete(ctx, srcRepo); client.IgnoreNotFound(err) != nil {
+				return errors.Wrapf(err, "%s - failed to delete GitRepository: %s/%s", logging.CallerStr(logging.Me), srcRepo.Namespace, srcRepo.Name)
+			}
+			continue
+		}
+		srcRepo.OwnerReferences = owners
+		if err := r.Update(ctx, srcRepo); err != nil {
+			return errors.Wrapf(err, "%s - failed to update GitRepository: %s/%s", logging.CallerStr(logging.Me), srcRepo.Namespace, srcRepo.Name)
+		}
+	}
+	return nil
+}
+
+// inlineSourceSpec returns the GitRepository spec for an inline git source. The interval may differ between the layers
+// sharing the GitRepository, the shortest interval is used.
+func inlineSourceSpec(git *kraanv1alpha1.GitSourceSpec, namespace string, users []*kraanv1alpha1.AddonsLayer, spec sourcev1.GitRepositorySpec) sourcev1.GitRepositorySpec {
+	name := common.GetInlineSourceName(git)
+	spec.URL = git.URL
+	spec.Reference = nil
+	if git.Ref != nil {
+		spec.Reference = &sourcev1.GitRepositoryRef{
+			Branch: git.Ref.Branch,
+			Tag:    git.Ref.Tag,
+			SemVer: git.Ref.SemVer,
+			Commit: git.Ref.Commit,
+		}
+	}
+	spec.SecretRef = nil
+	if git.SecretRef != nil {
+		spec.SecretRef = &meta.LocalObjectReference{Name: git.SecretRef.Name}
+	}
+	spec.Interval = metav1.Duration{}
+	for _, user := range users {
+		userGit := inlineGitSource(user.Spec, name, namespace)
+		if userGit == nil {
+			continue
+		}
+		interval := defaultInlineSourceInterval
+		if userGit.Interval != nil {
+			interval = *userGit.Interval
+		}
+		if spec.Interval.Duration == 0 || interval.Duration < spec.Interval.Duration {
+			spec.Interval = interval
+		}
+	}
+	return spec
+}
+
+// inlineGitSource returns a layer's inline git source that uses the named GitRepository, or nil if it has none.
+func inlineGitSource(spec kraanv1alpha1.AddonsLayerSpec, name, namespace string) *kraanv1alpha1.GitSourceSpec {
+	for _, source := range spec.GetSources() {
+		if source.Git != nil && common.GetSourceName(source) == name && common.GetSourceNamespace(source.NameSpace) == namespace {
+			return source.Git
+		}
+	}
+	return nil
+}
+
+func inlineSourceOwners(users []*kraanv1alpha1.AddonsLayer) []metav1.OwnerReference {
+	owners := make([]metav1.OwnerReference, 0, len(users))
+	for _, user := range users {
+		owners = append(owners, metav1.OwnerReference{
+			APIVersion: kraanv1alpha1.GroupVersion.String(),
+			Kind:       "AddonsLayer",
+			Name:       user.Name,
+			UID:        user.UID,
+		})
+	}
+	return owners
+}
+
+func containsLayer(users []*kraanv1alpha1.AddonsLayer, addonsLayer *kraanv1alpha1.AddonsLayer) bool {
+	for _, user := range users {
+		if user.UID == addonsLayer.UID {
+			return true
+		}
+	}
+	return false
+}
+
+func removeOwner(owners []metav1.OwnerReference, uid types.UID) []metav1.OwnerReference {
+	remaining := []metav1.OwnerReference{}
+	for _, owner := range owners {
+		if owner.UID != uid {
+			remaining = append(remaining, owner)
+		}
+	}
+	return remaining
+}
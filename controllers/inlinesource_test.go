@@ -0,0 +1,69 @@
+package controllers_test
+
+import (
+	"testing"
+	"time"
+
+	sourcev1 "github.com/fluxcd/source-controller/api/v1beta2"
+	corev1 "k8s.io/api/core/v1"
+	metav1 "k8s.io/apimachinery/pkg/apis/meta/v1"
+	"k8s.io/apimachinery/pkg/types"
+
+	kraanv1alpha1 "github.com/fidelity/kraan/api/v1alpha1"
+	"github.com/fidelity/kraan/controllers"
+	"github.com/fidelity/kraan/pkg/common"
+)
+
+func TestInlineSourceSpec(t *testing.T) {
+	newLayer := func(name, secret string, interval time.Duration) *kraanv1alpha1.AddonsLayer {
+		git := &kraanv1alpha1.GitSourceSpec{URL: "https://github.com/fidelity/kraan.git", Ref: &kraanv1alpha1.GitRef{Branch: "main"}}
+		if secret != "" {
+			git.SecretRef = &corev1.LocalObjectReference{Name: secret}
+		}
+		if interval != 0 {
+			git.Interval = &metav1.Duration{Duration: interval}
+		}
+		return &kraanv1alpha1.AddonsLayer{
+			ObjectMeta: metav1.ObjectMeta{Name: name, UID: types.UID(name)},
+			Spec:       kraanv1alpha1.AddonsLayerSpec{Source: kraanv1alpha1.SourceSpec{Git: git}},
+		}
+	}
+
+	first := newLayer("first", "creds", 5*time.Minute)
+	second := newLayer("second", "creds", 0)
+	third := newLayer("third", "creds", 30*time.Second)
+	users := []*kraanv1alpha1.AddonsLayer{first, second, third}
+
+	spec := controllers.InlineSourceSpec(first.Spec.Source.Git, common.GetSourceNamespace(""), users, sourcev1.GitRepositorySpec{})
+	if spec.URL != first.Spec.Source.Git.URL || spec.Reference == nil || spec.Reference.Branch != "main" {
+		t.Fatalf("controllers.InlineSourceSpec did not set url and ref: %#v", spec)
+	}
+	if spec.SecretRef == nil || spec.SecretRef.Name != "creds" {
+		t.Fatalf("controllers.InlineSourceSpec did not use the secret: %#v", spec.SecretRef)
+	}
+	if spec.Interval.Duration != 30*time.Second {
+		t.Fatalf("controllers.InlineSourceSpec did not use the shortest interval: %s", spec.Interval.Duration)
+	}
+	if spec := controllers.InlineSourceSpec(first.Spec.Source.Git, common.GetSourceNamespace(""), users[:2], spec); spec.Interval.Duration != time.Minute {
+		t.Fatalf("controllers.InlineSourceSpec did not use the default interval: %s", spec.Interval.Duration)
+	}
+
+	if common.GetSourceName(first.Spec.Source) != common.GetSourceName(third.Spec.Source) {
+		t.Fatalf("layers with the same url and ref do not share a GitRepository")
+	}
+	if other := newLayer("other", "other", 0); common.GetSourceName(first.Spec.Source) == common.GetSourceName(other.Spec.Source) {
+		t.Fatalf("layers with different secrets share a GitRepository")
+	}
+	if public := newLayer("public", "", 0); common.GetSourceName(first.Spec.Source) == common.GetSourceName(public.Spec.Source) {
+		t.Fatalf("layers with and without a secret share a GitRepository")
+	}
+	third.Spec.Source.Git.Ref.Branch = "dev"
+	if common.GetSourceName(first.Spec.Source) == common.GetSourceName(third.Spec.Source) {
+		t.Fatalf("layers with different refs share a GitRepository")
+	}
+
+	owners := controllers.RemoveOwner(controllers.InlineSourceOwners(users), second.UID)
+	if len(owners) != 2 || owners[0].Name != "first" || owners[1].Name != "third" {
+		t.Fatalf("controllers.RemoveOwner did not remove the layer: %#v", owners)
+	}
+}
@@ -0,0 +1,149 @@
+/*
+
+
+Licensed under the Apache License, Version 2.0 (the "License");
+you may not use this file except in compliance with the License.
+You may obtain a copy of the License at
+
+    http://www.apache.org/licenses/LICENSE-2.0
+
+Unless required by applicable law or agreed to in writing, software
+distributed under the License is distributed on an "AS IS" BASIS,
+WITHOUT WARRANTIES OR CONDITIONS OF ANY KIND, either express or implied.
+See the License for the specific language governing permissions and
+limitations under the License.
+*/
+
+package controllers
+
+import (
+	"context"
+	"fmt"
+	"net/http"
+	"sync"
+	"time"
+
+	sourcev1 "github.com/fluxcd/source-controller/api/v1beta2"
+	"github.com/pkg/errors"
+	"k8s.io/apimachinery/pkg/types"
+
+	kraanv1alpha1 "github.com/fidelity/kraan/api/v1alpha1"
+	"github.com/fidelity/kraan/pkg/common"
+	"github.com/fidelity/kraan/pkg/layers"
+	"github.com/fidelity/kraan/pkg/logging"
+	"github.com/fidelity/kraan/pkg/repos"
+)
+
+// rehydrator is a manager runnable that restores repository data and layer links when the controller starts.
+type rehydrator struct {
+	reconciler  *AddonsLayerReconciler
+	concurrency int
+}
+
+// Start runs the rehydration, it returns once all sources have been processed.
+func (h rehydrator) Start(ctx context.Context) error {
+	return h.reconciler.rehydrate(ctx, h.concurrency)
+}
+
+// NeedLeaderElection returns false so that every replica rehydrates its local data.
+func (h rehydrator) NeedLeaderElection() bool {
+	return false
+}
+
+// RehydratedCheck is a readiness check that fails until the startup rehydration has completed.
+func (r *AddonsLayerReconciler) RehydratedCheck(req *http.Request) error {
+	if !r.rehydrated.Load() {
+		return fmt.Errorf("source data not yet rehydrated")
+	}
+	return nil
+}
+
+// rehydrate lists all AddonsLayers and the GitRepositories they use, syncing the repository data and linking
+// the layer directories before reconciliation starts, so that the first reconciles do not wait for source events.
+// The controller is only marked as rehydrated once the AddonsLayers have been listed and their sources processed,
+// listing is retried until it succeeds or the context is cancelled.
+func (r *AddonsLayerReconciler) rehydrate(ctx context.Context, concurrency int) error {
+	logging.TraceCall(r.Log)
+	defer logging.TraceExit(r.Log)
+
+	addonsList, err := r.listLayersToRehydrate(ctx)
+	if err != nil {
+		return err
+	}
+
+	sources := map[types.NamespacedName][]layers.Layer{}
+	sourceSpecs := map[types.NamespacedName]kraanv1alpha1.SourceSpec{}
+	for index := range addonsList.Items {
+		layer := layers.CreateLayer(r.Context, r.Client, r.k8client, r.Log, r.Recorder, r.Scheme, &addonsList.Items[index])
+		for _, source := range layer.GetSources() {
+			key := types.NamespacedName{Namespace: common.GetSourceNamespace(source.NameSpace), Name: common.GetSourceName(source)}
+			if _, ok := sourceSpecs[key]; !ok {
+				sourceSpecs[key] = source
+			}
+			if layerList := sources[key]; len(layerList) == 0 || layerList[len(layerList)-1] != layer {
+				sources[key] = append(layerList, layer)
+			}
+		}
+	}
+
+	if concurrency < 1 {
+		concurrency = 1
+	}
+	sem := make(chan struct{}, concurrency)
+	var wg sync.WaitGroup
+	for key, layerList := range sources {
+		srcRepo, err := r.getLayerSource(ctx, key, sourceSpecs[key])
+		if err != nil {
+			r.Log.Error(err, "unable to get GitRepository used by layers", append(logging.GetFunctionAndSource(logging.MyCaller), "source", key.String())...)
+			continue
+		}
+		if srcRepo.GetArtifact() == nil {
+			r.Log.V(1).Info("GitRepository has no artifact, not rehydrating", logging.GetGitRepoInfo(srcRepo)...)
+			continue
+		}
+		wg.Add(1)
+		sem <- struct{}{}
+		go func(srcRepo *sourcev1.GitRepository, layerList []layers.Layer) {
+			defer wg.Done()
+			defer func() { <-sem }()
+			if err := r.syncAndLink(srcRepo, layerList); err != nil {
+				r.Log.Error(err, "unable to rehydrate source", append(logging.GetGitRepoInfo(srcRepo), logging.GetFunctionAndSource(logging.MyCaller)...)...)
+			}
+		}(srcRepo, layerList)
+	}
+	wg.Wait()
+
+	r.rehydrated.Store(true)
+	r.Log.Info("rehydrated source data", append(logging.GetFunctionAndSource(logging.MyCaller), "sources", len(sources), "layers", len(addonsList.Items))...)
+	return nil
+}
+
+// listLayersToRehydrate lists all AddonsLayers, retrying until the list succeeds. It only returns an error if the
+// context is cancelled.
+func (r *AddonsLayerReconciler) listLayersToRehydrate(ctx context.Context) (*kraanv1alpha1.AddonsLayerList, error) {
+	for {
+		addonsList := &kraanv1alpha1.AddonsLayerList{}
+		err := r.List(ctx, addonsList)
+		if err == nil {
+			return addonsList, nil
+		}
+		r.Log.Error(err, "unable to list AddonsLayers, retrying rehydration", append(logging.GetFunctionAndSource(logging.MyCaller), "delay", rehydrateRetryDelay)...)
+		select {
+		case <-ctx.Done():
+			return nil, errors.Wrapf(ctx.Err(), "%s - rehydration cancelled", logging.CallerStr(logging.Me))
+		case <-time.After(rehydrateRetryDelay):
+		}
+	}
+}
+
+// getLayerSource returns the GitRepository used by a layer, building it from the local content for directory sources.
+func (r *AddonsLayerReconciler) getLayerSource(ctx context.Context, key types.NamespacedName, source kraanv1alpha1.SourceSpec) (*sourcev1.GitRepository, error) {
+	if source.Kind == kraanv1alpha1.DirectorySourceKind {
+		return repos.NewLocalSource(key.Name, key.Namespace, source.URL)
+	}
+	srcRepo := &sourcev1.GitRepository{}
+	if err := r.Get(ctx, key, srcRepo); err != nil {
+		return nil, err
+	}
+	return srcRepo, nil
+}
@@ -0,0 +1,158 @@
+package controllers_test
+
+import (
+	"context"
+	"fmt"
+	"sync/atomic"
+	"testing"
+	"time"
+
+	sourcev1 "github.com/fluxcd/source-controller/api/v1beta2"
+	"github.com/go-logr/logr"
+	"github.com/golang/mock/gomock"
+	metav1 "k8s.io/apimachinery/pkg/apis/meta/v1"
+	"k8s.io/apimachinery/pkg/runtime"
+	"k8s.io/apimachinery/pkg/types"
+	"k8s.io/client-go/tools/record"
+	ctrl "sigs.k8s.io/controller-runtime"
+	"sigs.k8s.io/controller-runtime/pkg/client"
+	"sigs.k8s.io/controller-runtime/pkg/client/fake"
+	"sigs.k8s.io/controller-runtime/pkg/manager"
+
+	kraanv1alpha1 "github.com/fidelity/kraan/api/v1alpha1"
+	"github.com/fidelity/kraan/controllers"
+	mocks "github.com/fidelity/kraan/pkg/mocks/repos"
+	"github.com/fidelity/kraan/pkg/repos"
+)
+
+// fakeRepo is a repository whose data is always synced, it calls link for each layer linked to it.
+type fakeRepo struct {
+	repos.Repo
+	gitRepo *sourcev1.GitRepository
+	link    func()
+}
+
+func (f *fakeRepo) SyncRepo() error                             { return nil }
+func (f *fakeRepo) GetGitRepo() *sourcev1.GitRepository         { return f.gitRepo }
+func (f *fakeRepo) AddUser(name string)                         {}
+func (f *fakeRepo) TidyRepo() error                             { return nil }
+func (f *fakeRepo) LinkData(layerPath, sourcePath string) error { f.link(); return nil }
+
+func TestRehydrate(t *testing.T) {
+	scheme := runtime.NewScheme()
+	_ = kraanv1alpha1.AddToScheme(scheme) //nolint:errcheck // ok
+	_ = sourcev1.AddToScheme(scheme)      //nolint:errcheck // ok
+
+	newRepo := func(name string) *sourcev1.GitRepository {
+		return &sourcev1.GitRepository{
+			ObjectMeta: metav1.ObjectMeta{Name: name, Namespace: "flux-system"},
+			Status:     sourcev1.GitRepositoryStatus{Artifact: &sourcev1.Artifact{Revision: "main@sha1:0123456789abcdef"}},
+		}
+	}
+	newLayer := func(name, repo string) *kraanv1alpha1.AddonsLayer {
+		return &kraanv1alpha1.AddonsLayer{
+			ObjectMeta: metav1.ObjectMeta{Name: name},
+			Spec: kraanv1alpha1.AddonsLayerSpec{
+				Source: kraanv1alpha1.SourceSpec{Name: repo, NameSpace: "flux-system", Path: "./" + name},
+			},
+		}
+	}
+	gitRepos := map[string]*sourcev1.GitRepository{"base": newRepo("base"), "apps": newRepo("apps")}
+	client := fake.NewClientBuilder().WithScheme(scheme).WithObjects(
+		gitRepos["base"], gitRepos["apps"],
+		newLayer("bootstrap", "base"), newLayer("common", "base"), newLayer("apps", "apps"),
+	).Build()
+
+	mockCtl := gomock.NewController(t)
+	defer mockCtl.Finish()
+	mockRepos := mocks.NewMockRepos(mockCtl)
+	r := &controllers.AddonsLayerReconciler{
+		Client:   client,
+		Log:      logr.Discard(),
+		Scheme:   scheme,
+		Context:  context.Background(),
+		Repos:    mockRepos,
+		Recorder: record.NewFakeRecorder(10),
+	}
+
+	var linked int32
+	repoFakes := map[string]repos.Repo{}
+	for name, gitRepo := range gitRepos {
+		repoFakes[name] = &fakeRepo{gitRepo: gitRepo, link: func() {
+			if r.RehydratedCheck(nil) == nil {
+				t.Errorf("ready before all sources were linked")
+			}
+			atomic.AddInt32(&linked, 1)
+		}}
+	}
+	mockRepos.EXPECT().Add(gomock.Any()).DoAndReturn(func(srcRepo *sourcev1.GitRepository) repos.Repo {
+		return repoFakes[srcRepo.Name]
+	}).Times(len(gitRepos))
+
+	if r.RehydratedCheck(nil) == nil {
+		t.Fatalf("ready before rehydration started")
+	}
+	result, err := r.Reconcile(context.Background(), ctrl.Request{NamespacedName: types.NamespacedName{Name: "apps"}})
+	if err != nil || result.RequeueAfter != controllers.RehydrateRequeueDelay {
+		t.Fatalf("reconcile before rehydration returned: %#v, %v", result, err)
+	}
+
+	var runnable manager.LeaderElectionRunnable = controllers.NewRehydrator(r, 2)
+	if runnable.NeedLeaderElection() {
+		t.Fatalf("rehydration requires leader election")
+	}
+	if err := runnable.(manager.Runnable).Start(context.Background()); err != nil {
+		t.Fatalf("rehydration returned an error: %s", err)
+	}
+	if atomic.LoadInt32(&linked) != 3 {
+		t.Fatalf("linked %d layers, expected 3", linked)
+	}
+	if err := r.RehydratedCheck(nil); err != nil {
+		t.Fatalf("not ready after rehydration: %s", err)
+	}
+}
+
+// failingListClient fails the first lists it is asked to perform.
+type failingListClient struct {
+	client.Client
+	failures int32
+}
+
+func (c *failingListClient) List(ctx context.Context, list client.ObjectList, opts ...client.ListOption) error {
+	if atomic.AddInt32(&c.failures, -1) >= 0 {
+		return fmt.Errorf("list failed")
+	}
+	return c.Client.List(ctx, list, opts...)
+}
+
+func TestRehydrateListFailure(t *testing.T) {
+	scheme := runtime.NewScheme()
+	_ = kraanv1alpha1.AddToScheme(scheme) //nolint:errcheck // ok
+	controllers.SetRehydrateRetryDelay(10 * time.Millisecond)
+	defer controllers.SetRehydrateRetryDelay(5 * time.Second)
+
+	listClient := &failingListClient{Client: fake.NewClientBuilder().WithScheme(scheme).Build(), failures: 2}
+	r := &controllers.AddonsLayerReconciler{Client: listClient, Log: logr.Discard(), Scheme: scheme, Context: context.Background()}
+	runnable := controllers.NewRehydrator(r, 1).(manager.Runnable)
+
+	if err := runnable.Start(context.Background()); err != nil {
+		t.Fatalf("rehydration returned an error: %s", err)
+	}
+	if atomic.LoadInt32(&listClient.failures) >= 0 {
+		t.Fatalf("rehydration did not retry the failed lists")
+	}
+	if err := r.RehydratedCheck(nil); err != nil {
+		t.Fatalf("not ready after rehydration: %s", err)
+	}
+
+	listClient = &failingListClient{Client: fake.NewClientBuilder().WithScheme(scheme).Build(), failures: 1000}
+	r = &controllers.AddonsLayerReconciler{Client: listClient, Log: logr.Discard(), Scheme: scheme, Context: context.Background()}
+	ctx, cancel := context.WithTimeout(context.Background(), 50*time.Millisecond)
+	defer cancel()
+	if err := controllers.NewRehydrator(r, 1).(manager.Runnable).Start(ctx); err == nil {
+		t.Fatalf("rehydration did not return an error when cancelled")
+	}
+	if r.RehydratedCheck(nil) == nil {
+		t.Fatalf("ready although the AddonsLayers could not be listed")
+	}
+}
@@ -34,6 +34,7 @@ import (
 	extv1b1 "k8s.io/apiextensions-apiserver/pkg/apis/apiextensions/v1beta1"
 	"k8s.io/apimachinery/pkg/runtime"
 	ctrl "sigs.k8s.io/controller-runtime"
+	"sigs.k8s.io/controller-runtime/pkg/client"
 	_ "sigs.k8s.io/controller-runtime/pkg/healthz"
 	"sigs.k8s.io/controller-runtime/pkg/log"
 	"sigs.k8s.io/controller-runtime/pkg/log/zap"
@@ -42,7 +43,9 @@ import (
 	kraanv1alpha1 "github.com/fidelity/kraan/api/v1alpha1"
 	"github.com/fidelity/kraan/controllers"
 	"github.com/fidelity/kraan/pkg/common"
+	"github.com/fidelity/kraan/pkg/layers"
 	"github.com/fidelity/kraan/pkg/repos"
+	"github.com/fidelity/kraan/pkg/storage"
 )
 
 var (
@@ -115,6 +118,13 @@ func main() { //nolint:funlen // ok
 		leaderElectionNamespace string
 		logLevel                string
 		concurrent              int
+		syncWorkers             int
+		storageBackend          string
+		requireVerified         bool
+		noCrossNamespaceSources bool
+		decryptionSecret        string
+		clusterInfoConfigMap    string
+		localSourceRoot         string
 		syncPeriod              time.Duration
 	)
 
@@ -130,6 +140,19 @@ func main() { //nolint:funlen // ok
 		"Enable leader election for controller manager. "+
 			"Enabling this will ensure there is only one active controller manager.")
 	flag.IntVar(&concurrent, "concurrent", 4, "The number of concurrent reconciles per controller.")
+	flag.IntVar(&syncWorkers, "sync-workers", repos.DefaultSyncWorkers, "The number of workers syncing source artifacts.")
+	flag.StringVar(&storageBackend, "storage-backend", storage.DefaultBackend,
+		"The storage backend used for source data. Can be disk or memory.")
+	flag.BoolVar(&requireVerified, "require-verified-sources", false,
+		"Require source revisions to be verified before applying layers that do not set spec.source.requireVerified.")
+	flag.BoolVar(&noCrossNamespaceSources, "no-cross-namespace-sources", false,
+		"Restrict layers to sources in the controller's namespace or a namespace in their spec.allowedNamespaces.")
+	flag.StringVar(&decryptionSecret, "decryption-secret", "",
+		"The name of a Secret in the controller's namespace containing age or PGP keys used to decrypt SOPS encrypted manifests in all layers.")
+	flag.StringVar(&clusterInfoConfigMap, "cluster-info-configmap", layers.ClusterInfoConfigMap,
+		"The namespace and name of the ConfigMap, on each target cluster, whose labels and data are the cluster's labels.")
+	flag.StringVar(&localSourceRoot, "local-source-root", "",
+		"The directory containing the directories and tarballs used by Directory sources. Directory sources are disabled if not set.")
 	flag.StringVar(&logLevel, "log-level", "info", "Set logging level. Can be debug, info or error.")
 	flag.StringVar(&healthAddr,
 		"health-addr",
@@ -148,6 +171,11 @@ func main() { //nolint:funlen // ok
 	logOpts.BindFlags(flag.CommandLine)
 
 	flag.Parse()
+	layers.DefaultRequireVerified = requireVerified
+	layers.NoCrossNamespaceSources = noCrossNamespaceSources
+	layers.DecryptionSecret = decryptionSecret
+	layers.ClusterInfoConfigMap = clusterInfoConfigMap
+	repos.LocalSourceRoot = localSourceRoot
 
 	setupLog.Info("command-line flags", "osArgs", os.Args[:1])
 	logger := NewLogger(&logOpts)
@@ -167,11 +195,18 @@ func main() { //nolint:funlen // ok
 		os.Exit(1)
 	}
 
+	store, err := storage.New(storageBackend)
+	if err != nil {
+		setupLog.Error(err, "unable to create storage backend")
+		os.Exit(1)
+	}
+
 	r, err := controllers.NewReconciler(
 		mgr.GetConfig(),
 		mgr.GetClient(),
 		logger.WithName("controller"),
-		mgr.GetScheme())
+		mgr.GetScheme(),
+		store)
 	if err != nil {
 		setupLog.Error(err, "unable to create Reconciler")
 		os.Exit(1)
@@ -179,6 +214,7 @@ func main() { //nolint:funlen // ok
 
 	err = r.SetupWithManagerAndOptions(mgr, controllers.AddonsLayerReconcilerOptions{
 		MaxConcurrentReconciles: concurrent,
+		SyncWorkers:             syncWorkers,
 	})
 	// +kubebuilder:scaffold:builder
 	if err != nil {
@@ -186,6 +222,11 @@ func main() { //nolint:funlen // ok
 		os.Exit(1)
 	}
 
+	if err := mgr.AddReadyzCheck("rehydrated", r.RehydratedCheck); err != nil {
+		setupLog.Error(err, "unable to create rehydration ready check")
+		os.Exit(1)
+	}
+
 	// +kubebuilder:scaffold:builder
 
 	setupLog.Info("starting manager")
@@ -207,6 +248,8 @@ func createManager(metricsAddr string, healthAddr string, enableLeaderElection b
 		LeaderElectionID:        "925331a6.kraan.io",
 		Namespace:               "",
 		SyncPeriod:              &syncPeriod,
+		// Secrets and ConfigMaps are read directly rather than caching every Secret and ConfigMap in the cluster.
+		ClientDisableCacheFor: []client.Object{&corev1.Secret{}, &corev1.ConfigMap{}},
 	})
 	if err != nil {
 		return nil, errors.Wrap(err, "unable to start manager")
@@ -5,16 +5,22 @@ import (
 	"encoding/json"
 	"fmt"
 	"os"
+	"path/filepath"
 	"strings"
 	"testing"
 	"time"
 
 	helmctlv2 "github.com/fluxcd/helm-controller/api/v2beta1"
+	"github.com/fluxcd/pkg/apis/kustomize"
 	"github.com/go-logr/logr"
 	testlogr "github.com/go-logr/logr/testing"
 	gomock "github.com/golang/mock/gomock"
+	"github.com/google/go-cmp/cmp"
 	"github.com/paulcarlton-ww/goutils/pkg/testutils"
 	corev1 "k8s.io/api/core/v1"
+	apiextensionsv1 "k8s.io/apiextensions-apiserver/pkg/apis/apiextensions/v1"
+	k8serrors "k8s.io/apimachinery/pkg/api/errors"
+	apimeta "k8s.io/apimachinery/pkg/api/meta"
 	metav1 "k8s.io/apimachinery/pkg/apis/meta/v1"
 	"k8s.io/apimachinery/pkg/runtime"
 	types "k8s.io/apimachinery/pkg/types"
@@ -25,10 +31,12 @@ import (
 
 	kraanv1alpha1 "github.com/fidelity/kraan/api/v1alpha1"
 	"github.com/fidelity/kraan/pkg/apply"
+	"github.com/fidelity/kraan/pkg/clusters"
 	"github.com/fidelity/kraan/pkg/common"
 	"github.com/fidelity/kraan/pkg/internal/kubectl"
 	kubectlmocks "github.com/fidelity/kraan/pkg/internal/mocks/kubectl"
 	"github.com/fidelity/kraan/pkg/layers"
+	"github.com/fidelity/kraan/pkg/storage"
 )
 
 const (
@@ -203,7 +211,8 @@ func createApplier(t *testing.T, params []interface{}) apply.LayerApplier {
 	applier, err := apply.NewApplier(
 		params[0].(client.Client),
 		params[1].(logr.Logger),
-		params[2].(*runtime.Scheme))
+		params[2].(*runtime.Scheme),
+		storage.NewMemory())
 	if err != nil {
 		t.Fatalf("failed to create applier, %s", err)
 	}
@@ -264,7 +273,7 @@ func fakeAddonsLayer(sourcePath, layerName string, layerUID types.UID) *kraanv1a
 func TestNewApplier(t *testing.T) {
 	logger := testlogr.NewTestLogger(t)
 	client := fake.NewClientBuilder().WithScheme(testScheme).Build()
-	applier, err := apply.NewApplier(client, logger, testScheme)
+	applier, err := apply.NewApplier(client, logger, testScheme, storage.NewMemory())
 	if err != nil {
 		t.Fatalf("The NewApplier constructor returned an error: %s", err)
 	}
@@ -283,7 +292,7 @@ func TestNewApplierWithMockKubectl(t *testing.T) {
 
 	logger := testlogr.NewTestLogger(t)
 	client := fake.NewClientBuilder().WithScheme(testScheme).Build()
-	applier, err := apply.NewApplier(client, logger, testScheme)
+	applier, err := apply.NewApplier(client, logger, testScheme, storage.NewMemory())
 	if err != nil {
 		t.Fatalf("The NewApplier constructor returned an error: %s", err)
 	}
@@ -439,6 +448,16 @@ func checkOwnerAndLabels(u testutils.TestUtil, name string, results, exepcted in
 	}
 	owningLayer := apply.LayerOwner(obj)
 
+	layer, ok := testData.Inputs[0].(layers.Layer)
+	if !ok {
+		t.Fatalf("failed to cast input data to layers.Layer")
+		return false
+	}
+	if ownerLabel == layer.GetName() && obj.GetAnnotations()[common.LayerVersionAnnotation] != layer.GetSpec().Version {
+		t.Errorf("layer version annotation: %s, expected: %s", obj.GetAnnotations()[common.LayerVersionAnnotation], layer.GetSpec().Version)
+		return false
+	}
+
 	e := c.Get(context.Background(), key, hr)
 	if e != nil {
 		t.Fatalf("failed to get an HelmRelease, %s", e)
@@ -556,6 +575,35 @@ func TestAddOwner(t *testing.T) { //nolint: funlen // ok
 	}
 }
 
+func TestAddOwnerRemoteCluster(t *testing.T) {
+	a := castToApplier(t, createApplier(t, getApplierParams(t,
+		[]string{addonsFileName},
+		[]string{noOwner1HelmReleasesFileName},
+		nil, testScheme)))
+	layer := getLayer(t, appsLayer, addonsFileName)
+	layer.SetCluster(&clusters.Cluster{Key: "clusters/remote/value"})
+
+	objs := getHelmReleasesAsRuntimeObjsList(getHelmReleasesFromFiles(t, noOwner1HelmReleasesFileName))
+	if err := apply.AddOwnerRefs(a, layer, objs); err != nil {
+		t.Fatalf("AddOwnerRefs returned an error: %s", err)
+	}
+	hr, ok := objs[0].(*helmctlv2.HelmRelease)
+	if !ok {
+		t.Fatalf("failed to cast object to HelmRelease")
+	}
+	if len(hr.OwnerReferences) != 0 {
+		t.Fatalf("owner reference added to HelmRelease in remote cluster: %v", hr.OwnerReferences)
+	}
+	if hr.Labels["kraan/layer"] != appsLayer {
+		t.Fatalf("owner label not set on HelmRelease in remote cluster: %v", hr.Labels)
+	}
+
+	hr.Labels["kraan/layer"] = bootstrapLayer
+	if err := apply.AddOwnerRefs(a, layer, objs); err == nil {
+		t.Fatalf("AddOwnerRefs did not return an error for a HelmRelease owned by another layer")
+	}
+}
+
 func TestLayerOwner(t *testing.T) {
 	tests := []*testutils.DefTest{
 		{
@@ -794,7 +842,7 @@ func checkOrphanLabel(u testutils.TestUtil, name string, actual, expected interf
 		return false
 	}
 
-	hr, ok := testData.Inputs[1].(*helmctlv2.HelmRelease)
+	hr, ok := testData.Inputs[2].(*helmctlv2.HelmRelease)
 	if !ok {
 		t.Fatalf("failed to cast input to *helmctlv2.HelmRelease")
 
@@ -863,6 +911,7 @@ func TestOrphanLabel(t *testing.T) { //nolint: funlen //ok
 			Description: "no orphan label",
 			Inputs: []interface{}{
 				context.Background(),
+				getLayer(t, appsLayer, addonsFileName),
 				getHelmReleaseFromList(t, bootstrapMicroService1, getHelmReleasesFromFiles(t, helmReleasesFileName)),
 			},
 			Expected:           []interface{}{&now, nil},
@@ -878,6 +927,7 @@ func TestOrphanLabel(t *testing.T) { //nolint: funlen //ok
 			Description: "existing orphan label",
 			Inputs: []interface{}{
 				context.Background(),
+				getLayer(t, appsLayer, addonsFileName),
 				getHelmReleaseFromList(t, bootstrapOrphaned, getHelmReleasesFromFiles(t, orphan1HelmReleasesFileName)),
 			},
 			Expected:           []interface{}{&orphanedTS, nil},
@@ -893,6 +943,7 @@ func TestOrphanLabel(t *testing.T) { //nolint: funlen //ok
 			Description: "existing orphan label, invalid timestamp",
 			Inputs: []interface{}{
 				context.Background(),
+				getLayer(t, appsLayer, addonsFileName),
 				getHelmReleaseFromList(t, bootstrapOrphaned, getHelmReleasesFromFiles(t, orphanBadTSHelmReleasesFileName)),
 			},
 			Expected:  []interface{}{nilTS, []string{"failed to parse orphaned label value as timestamp"}},
@@ -909,7 +960,8 @@ func TestOrphanLabel(t *testing.T) { //nolint: funlen //ok
 
 		ts, err := apply.OrphanLabel(a,
 			testData.Inputs[0].(context.Context),
-			testData.Inputs[1].(*helmctlv2.HelmRelease))
+			testData.Inputs[1].(layers.Layer),
+			testData.Inputs[2].(*helmctlv2.HelmRelease))
 
 		testData.Results = []interface{}{ts, err}
 
@@ -1234,3 +1286,446 @@ func TestOrphan(t *testing.T) { //nolint: funlen // ok
 		}
 	}
 }
+
+func TestMergeSources(t *testing.T) {
+	newHr := func(namespace, name, version string) *helmctlv2.HelmRelease {
+		hr := &helmctlv2.HelmRelease{
+			TypeMeta:   metav1.TypeMeta{Kind: helmctlv2.HelmReleaseKind, APIVersion: helmctlv2.GroupVersion.String()},
+			ObjectMeta: metav1.ObjectMeta{Namespace: namespace, Name: name},
+		}
+		hr.Spec.Chart.Spec.Version = version
+		return hr
+	}
+	newCm := func(namespace, name string) *corev1.ConfigMap {
+		return &corev1.ConfigMap{
+			TypeMeta:   metav1.TypeMeta{Kind: "ConfigMap", APIVersion: "v1"},
+			ObjectMeta: metav1.ObjectMeta{Namespace: namespace, Name: name},
+		}
+	}
+
+	base := []runtime.Object{newHr("apps", "microservice-1", "1.0.0"), newHr("apps", "microservice-2", "1.0.0"), newCm("apps", "microservice-1")}
+	overlay := []runtime.Object{newHr("apps", "microservice-2", "2.0.0"), newHr("apps", "microservice-3", "1.0.0")}
+
+	objs := apply.MergeSources([][]runtime.Object{base, overlay})
+	expected := []string{"apps/microservice-1:1.0.0", "apps/microservice-2:2.0.0", "apps/microservice-1", "apps/microservice-3:1.0.0"}
+	if len(objs) != len(expected) {
+		t.Fatalf("apply.MergeSources returned %d objects, expected %d", len(objs), len(expected))
+	}
+	for index, obj := range objs {
+		label := apply.GetObjLabel(obj)
+		if hr, ok := obj.(*helmctlv2.HelmRelease); ok {
+			label = fmt.Sprintf("%s:%s", label, hr.Spec.Chart.Spec.Version)
+		}
+		if label != expected[index] {
+			t.Fatalf("apply.MergeSources returned %s at position %d, expected %s", label, index, expected[index])
+		}
+	}
+}
+
+func TestSetHelmReleaseDefaults(t *testing.T) { //nolint:funlen // ok
+	defaults := map[string]interface{}{}
+	if err := json.Unmarshal([]byte(`{
+		"interval": "5m",
+		"timeout": "10m",
+		"serviceAccountName": "deployer",
+		"install": {"remediation": {"retries": 3}},
+		"upgrade": {"cleanupOnFail": true, "remediation": {"retries": 2}}
+	}`), &defaults); err != nil {
+		t.Fatalf("failed to parse defaults: %s", err)
+	}
+
+	manifest := []byte(`apiVersion: helm.toolkit.fluxcd.io/v2beta1
+kind: HelmRelease
+metadata:
+  name: podinfo
+  namespace: apps
+spec:
+  chart:
+    spec:
+      chart: podinfo
+  timeout: 1m
+  install:
+    remediation:
+      retries: 0
+  upgrade:
+    cleanupOnFail: false
+    remediation:
+      retries: 5
+`)
+	data, changed, err := apply.SetHelmReleaseDefaults(manifest, defaults)
+	if err != nil {
+		t.Fatalf("apply.SetHelmReleaseDefaults returned an error: %s", err)
+	}
+	if !changed {
+		t.Fatalf("apply.SetHelmReleaseDefaults did not change the manifest")
+	}
+	hr := &helmctlv2.HelmRelease{}
+	if err := json.Unmarshal(data, hr); err != nil {
+		t.Fatalf("failed to decode HelmRelease: %s", err)
+	}
+	expected := helmctlv2.HelmReleaseSpec{
+		Interval:           metav1.Duration{Duration: 5 * time.Minute},
+		Timeout:            &metav1.Duration{Duration: time.Minute},
+		ServiceAccountName: "deployer",
+		Install:            &helmctlv2.Install{Remediation: &helmctlv2.InstallRemediation{Retries: 0}},
+		Upgrade:            &helmctlv2.Upgrade{CleanupOnFail: false, Remediation: &helmctlv2.UpgradeRemediation{Retries: 5}},
+	}
+	expected.Chart.Spec.Chart = "podinfo"
+	if diff := cmp.Diff(expected, hr.Spec); diff != "" {
+		t.Fatalf("unexpected spec, explicit false and 0 should not be replaced (-want +got):\n%s", diff)
+	}
+
+	if _, changed, _ := apply.SetHelmReleaseDefaults(data, defaults); changed {
+		t.Fatalf("apply.SetHelmReleaseDefaults changed a HelmRelease with all the defaults set")
+	}
+	secret := []byte("apiVersion: v1\nkind: Secret\nmetadata:\n  name: registry\n")
+	if result, changed, err := apply.SetHelmReleaseDefaults(secret, defaults); err != nil || changed || string(result) != string(secret) {
+		t.Fatalf("apply.SetHelmReleaseDefaults changed a Secret, error: %v", err)
+	}
+}
+
+func TestAddLayerMetadata(t *testing.T) {
+	layer := getLayer(t, appsLayer, addonsFileName)
+	layer.GetSpec().CommonMetadata = &kraanv1alpha1.CommonMetadata{
+		Labels:      map[string]string{"cost-center": "1234", "team": "platform"},
+		Annotations: map[string]string{"owner": "platform@example.com"},
+	}
+	postRenderer := helmctlv2.PostRenderer{Kustomize: &helmctlv2.Kustomize{Images: []kustomize.Image{{Name: "nginx", NewName: "mirror/nginx"}}}}
+	layer.GetSpec().PostRenderers = []helmctlv2.PostRenderer{postRenderer}
+
+	hr := &helmctlv2.HelmRelease{ObjectMeta: metav1.ObjectMeta{Namespace: "apps", Name: "podinfo", Labels: map[string]string{"team": "apps", "tier": "web"}}}
+	hr.Spec.PostRenderers = []helmctlv2.PostRenderer{{}}
+	secret := &corev1.Secret{ObjectMeta: metav1.ObjectMeta{Namespace: "apps", Name: "registry"}}
+
+	if err := apply.AddLayerMetadata(apply.KubectlLayerApplier{}, layer, [][]runtime.Object{{hr}, {secret}}); err != nil {
+		t.Fatalf("apply.AddLayerMetadata returned an error: %s", err)
+	}
+	if diff := cmp.Diff(map[string]string{"cost-center": "1234", "team": "platform", "tier": "web"}, hr.Labels); diff != "" {
+		t.Fatalf("unexpected HelmRelease labels (-want +got):\n%s", diff)
+	}
+	if diff := cmp.Diff(map[string]string{"owner": "platform@example.com"}, secret.Annotations); diff != "" {
+		t.Fatalf("unexpected Secret annotations (-want +got):\n%s", diff)
+	}
+	if diff := cmp.Diff([]helmctlv2.PostRenderer{{}, postRenderer}, hr.Spec.PostRenderers); diff != "" {
+		t.Fatalf("unexpected HelmRelease post renderers (-want +got):\n%s", diff)
+	}
+}
+
+func TestMergeLayerValues(t *testing.T) { //nolint:funlen // ok
+	layer := getLayer(t, appsLayer, addonsFileName)
+	layer.GetSpec().ValuesFrom = []kraanv1alpha1.ValuesReference{
+		{Kind: "ConfigMap", Name: "cluster-values"},
+		{Kind: "Secret", Namespace: "apps", Name: "registry-values", ValuesKey: "registry.yaml", Optional: true},
+	}
+	layerValues := map[string]interface{}{
+		"cluster": map[string]interface{}{"name": "dev", "region": "eu-west-1"},
+		"mirror":  "registry.example.com",
+	}
+	hrValuesFrom := helmctlv2.ValuesReference{Kind: "ConfigMap", Name: "podinfo-values"}
+	newHelmRelease := func(annotations map[string]string) *helmctlv2.HelmRelease {
+		return newValuesHelmRelease("apps", annotations, hrValuesFrom)
+	}
+
+	hr := newHelmRelease(nil)
+	if err := apply.MergeLayerValues(apply.KubectlLayerApplier{}, layer, hr, layerValues); err != nil {
+		t.Fatalf("apply.MergeLayerValues returned an error: %s", err)
+	}
+	values := map[string]interface{}{}
+	if err := json.Unmarshal(hr.Spec.Values.Raw, &values); err != nil {
+		t.Fatalf("failed to parse merged values: %s", err)
+	}
+	expected := map[string]interface{}{
+		"cluster":  map[string]interface{}{"name": "podinfo", "region": "eu-west-1"},
+		"mirror":   "registry.example.com",
+		"replicas": float64(2),
+	}
+	if diff := cmp.Diff(expected, values); diff != "" {
+		t.Fatalf("unexpected values, the HelmRelease's values should take precedence (-want +got):\n%s", diff)
+	}
+	expectedValuesFrom := []helmctlv2.ValuesReference{
+		{Kind: "Secret", Name: "registry-values", ValuesKey: "registry.yaml", Optional: true},
+		hrValuesFrom,
+	}
+	if diff := cmp.Diff(expectedValuesFrom, hr.Spec.ValuesFrom); diff != "" {
+		t.Fatalf("unexpected valuesFrom (-want +got):\n%s", diff)
+	}
+
+	excluded := newHelmRelease(map[string]string{"kraan.layerValues": "false"})
+	if err := apply.MergeLayerValues(apply.KubectlLayerApplier{}, layer, excluded, layerValues); err != nil {
+		t.Fatalf("apply.MergeLayerValues returned an error: %s", err)
+	}
+	if diff := cmp.Diff(newHelmRelease(map[string]string{"kraan.layerValues": "false"}), excluded); diff != "" {
+		t.Fatalf("HelmRelease excluded from layer values was changed (-want +got):\n%s", diff)
+	}
+
+}
+
+func newValuesHelmRelease(namespace string, annotations map[string]string, valuesFrom ...helmctlv2.ValuesReference) *helmctlv2.HelmRelease {
+	hr := &helmctlv2.HelmRelease{ObjectMeta: metav1.ObjectMeta{Namespace: namespace, Name: "podinfo", Annotations: annotations}}
+	hr.Spec.Values = &apiextensionsv1.JSON{Raw: []byte(`{"cluster":{"name":"podinfo"},"replicas":2}`)}
+	hr.Spec.ValuesFrom = valuesFrom
+	return hr
+}
+
+func TestMergeLayerValuesSecretNamespaces(t *testing.T) {
+	layer := getLayer(t, appsLayer, addonsFileName)
+	layer.GetSpec().ValuesFrom = []kraanv1alpha1.ValuesReference{
+		{Kind: "Secret", Namespace: "apps", Name: "apps-values"},
+		{Kind: "Secret", Namespace: "ingress", Name: "ingress-values"},
+		{Kind: "Secret", Name: "controller-values"},
+	}
+	tests := []struct {
+		namespace string
+		expected  []helmctlv2.ValuesReference
+	}{
+		{namespace: "apps", expected: []helmctlv2.ValuesReference{{Kind: "Secret", Name: "apps-values"}}},
+		{namespace: "ingress", expected: []helmctlv2.ValuesReference{{Kind: "Secret", Name: "ingress-values"}}},
+		{namespace: common.GetSourceNamespace(""), expected: []helmctlv2.ValuesReference{{Kind: "Secret", Name: "controller-values"}}},
+		{namespace: "monitoring"},
+	}
+	for _, test := range tests {
+		hr := newValuesHelmRelease(test.namespace, nil)
+		if err := apply.MergeLayerValues(apply.KubectlLayerApplier{}, layer, hr, nil); err != nil {
+			t.Errorf("namespace: %s, apply.MergeLayerValues returned an error: %s", test.namespace, err)
+			continue
+		}
+		if diff := cmp.Diff(test.expected, hr.Spec.ValuesFrom); diff != "" {
+			t.Errorf("namespace: %s, unexpected valuesFrom (-want +got):\n%s", test.namespace, diff)
+		}
+	}
+
+	layer.SetCluster(&clusters.Cluster{Key: "remote"})
+	if err := apply.MergeLayerValues(apply.KubectlLayerApplier{}, layer, newValuesHelmRelease("apps", nil), nil); err == nil {
+		t.Fatalf("apply.MergeLayerValues did not return an error for a values Secret in a remote cluster layer")
+	}
+	if err := apply.MergeLayerValues(apply.KubectlLayerApplier{}, layer, newValuesHelmRelease("apps", map[string]string{"kraan.layerValues": "false"}), nil); err != nil {
+		t.Fatalf("apply.MergeLayerValues returned an error for a HelmRelease excluded from layer values: %s", err)
+	}
+}
+
+func TestSubstitutionEnabled(t *testing.T) {
+	newSecret := func(annotation string) *corev1.Secret {
+		secret := &corev1.Secret{ObjectMeta: metav1.ObjectMeta{Namespace: "apps", Name: "registry"}}
+		if annotation != "" {
+			secret.Annotations = map[string]string{"kraan.substitute": annotation}
+		}
+		return secret
+	}
+	tests := []struct {
+		annotation   string
+		layerEnabled bool
+		expected     bool
+	}{
+		{annotation: "", layerEnabled: false, expected: false},
+		{annotation: "", layerEnabled: true, expected: true},
+		{annotation: "true", layerEnabled: false, expected: true},
+		{annotation: "false", layerEnabled: true, expected: false},
+	}
+	for _, test := range tests {
+		if enabled := apply.SubstitutionEnabled(newSecret(test.annotation), test.layerEnabled); enabled != test.expected {
+			t.Errorf("annotation: %q, layer enabled: %t, substitution enabled: %t, expected: %t", test.annotation,
+				test.layerEnabled, enabled, test.expected)
+		}
+	}
+}
+
+func TestGetOutputValue(t *testing.T) { //nolint:funlen // ok
+	layerRef := metav1.OwnerReference{APIVersion: "kraan.io/v1alpha1", Kind: "AddonsLayer", Name: appsLayer}
+	hr := &helmctlv2.HelmRelease{ObjectMeta: metav1.ObjectMeta{Namespace: "apps", Name: "ingress", OwnerReferences: []metav1.OwnerReference{layerRef}}}
+	otherHr := &helmctlv2.HelmRelease{ObjectMeta: metav1.ObjectMeta{Namespace: "apps", Name: "other"}}
+	helmLabels := func(name string) map[string]string {
+		return map[string]string{"helm.toolkit.fluxcd.io/name": name, "helm.toolkit.fluxcd.io/namespace": "apps"}
+	}
+	newConfigMap := func(namespace, name string, labels map[string]string) *corev1.ConfigMap {
+		return &corev1.ConfigMap{
+			ObjectMeta: metav1.ObjectMeta{Namespace: namespace, Name: name, Labels: labels},
+			Data:       map[string]string{"class": "nginx"},
+		}
+	}
+	objs := []client.Object{hr, otherHr,
+		newConfigMap("apps", "owned", map[string]string{"kraan/layer": appsLayer}),
+		newConfigMap("apps", "deployed", helmLabels("ingress")),
+		newConfigMap("apps", "other-release", helmLabels("other")),
+		newConfigMap("apps", "not-owned", nil),
+		newConfigMap("kube-system", "not-allowed", map[string]string{"kraan/layer": appsLayer}),
+	}
+	layer := getLayer(t, appsLayer, addonsFileName)
+	layer.GetSpec().AllowedNamespaces = &kraanv1alpha1.AllowedNamespacesSpec{Names: []string{"apps"}}
+	layer.SetCluster(&clusters.Cluster{Client: fake.NewClientBuilder().WithScheme(testScheme).WithObjects(objs...).Build()})
+
+	tests := []struct {
+		namespace string
+		name      string
+		found     bool
+		forbidden bool
+	}{
+		{namespace: "apps", name: "owned", found: true},
+		{namespace: "apps", name: "deployed", found: true},
+		{namespace: "apps", name: "missing"},
+		{namespace: "apps", name: "other-release", forbidden: true},
+		{namespace: "apps", name: "not-owned", forbidden: true},
+		{namespace: "kube-system", name: "not-allowed", forbidden: true},
+	}
+	for _, test := range tests {
+		output := kraanv1alpha1.LayerOutput{
+			Name:      "ingressClass",
+			ObjectRef: kraanv1alpha1.OutputObjectReference{Kind: "ConfigMap", Namespace: test.namespace, Name: test.name},
+			JSONPath:  "{.data.class}",
+		}
+		value, found, err := apply.GetOutputValue(apply.KubectlLayerApplier{}, context.Background(), layer, output)
+		if test.forbidden {
+			if !k8serrors.IsForbidden(err) {
+				t.Errorf("object: %s/%s, did not return a forbidden error: %v", test.namespace, test.name, err)
+			}
+			continue
+		}
+		if err != nil {
+			t.Errorf("object: %s/%s, returned an error: %s", test.namespace, test.name, err)
+			continue
+		}
+		if found != test.found || (found && value != "nginx") {
+			t.Errorf("object: %s/%s, returned value: %q, found: %t, expected found: %t", test.namespace, test.name, value, found, test.found)
+		}
+	}
+}
+
+func TestJSONPathValue(t *testing.T) {
+	obj := map[string]interface{}{
+		"metadata": map[string]interface{}{"name": "nginx"},
+		"spec":     map[string]interface{}{"controller": "k8s.io/ingress-nginx", "replicas": int64(2)},
+		"status": map[string]interface{}{
+			"loadBalancer": map[string]interface{}{"ingress": []interface{}{
+				map[string]interface{}{"ip": "10.0.0.1"}, map[string]interface{}{"ip": "10.0.0.2"},
+			}},
+		},
+	}
+	tests := []struct {
+		expression string
+		expected   string
+		found      bool
+	}{
+		{"{.metadata.name}", "nginx", true},
+		{".spec.controller", "k8s.io/ingress-nginx", true},
+		{"{.spec.replicas}", "2", true},
+		{"{.status.loadBalancer.ingress[*].ip}", "10.0.0.1 10.0.0.2", true},
+		{"{.status.loadBalancer.ingress[0]}", `{"ip":"10.0.0.1"}`, true},
+		{"{.spec.missing}", "", false},
+	}
+	for _, test := range tests {
+		value, found, err := apply.JSONPathValue(obj, test.expression)
+		if err != nil {
+			t.Fatalf("apply.JSONPathValue(%q) returned an error: %s", test.expression, err)
+		}
+		if value != test.expected || found != test.found {
+			t.Errorf("apply.JSONPathValue(%q) returned %q, %t, expected %q, %t", test.expression, value, found, test.expected, test.found)
+		}
+	}
+	if _, _, err := apply.JSONPathValue(obj, "{.spec[}"); err == nil {
+		t.Fatalf("apply.JSONPathValue did not return an error for an invalid expression")
+	}
+}
+
+func TestRenderTemplateFilesDefaultNamespace(t *testing.T) {
+	kubeConfig := filepath.Join(t.TempDir(), "kubeconfig")
+	if err := os.WriteFile(kubeConfig, []byte(`apiVersion: v1
+kind: Config
+clusters:
+- name: remote
+  cluster:
+    server: https://remote.example.com
+contexts:
+- name: remote
+  context:
+    cluster: remote
+    namespace: apps
+current-context: remote
+`), 0o600); err != nil {
+		t.Fatalf("failed to write kubeconfig: %s", err)
+	}
+	store := storage.NewMemory()
+	if err := store.MkdirAll("/source"); err != nil {
+		t.Fatalf("failed to create source directory: %s", err)
+	}
+	manifest := `apiVersion: helm.toolkit.fluxcd.io/v2beta1
+kind: HelmRelease
+metadata:
+  name: {{ .Layer.Name }}-podinfo
+---
+apiVersion: helm.toolkit.fluxcd.io/v2beta1
+kind: HelmRelease
+metadata:
+  name: ingress
+  namespace: ingress
+---
+apiVersion: v1
+kind: Namespace
+metadata:
+  name: {{ .Layer.Name }}
+`
+	if err := store.WriteFile("/source/podinfo.yaml.tmpl", []byte(manifest), 0o600); err != nil {
+		t.Fatalf("failed to write template: %s", err)
+	}
+
+	mapper := apimeta.NewDefaultRESTMapper(nil)
+	mapper.Add(helmctlv2.GroupVersion.WithKind("HelmRelease"), apimeta.RESTScopeNamespace)
+	mapper.Add(corev1.SchemeGroupVersion.WithKind("Namespace"), apimeta.RESTScopeRoot)
+	layer := getLayer(t, appsLayer, addonsFileName)
+	layer.SetCluster(&clusters.Cluster{
+		Key:            "remote",
+		Client:         fake.NewClientBuilder().WithScheme(testScheme).WithRESTMapper(mapper).Build(),
+		K8sClient:      fakeK8s.NewSimpleClientset(),
+		KubeConfigPath: kubeConfig,
+	})
+
+	objs, err := apply.RenderTemplateFiles(apply.NewKubectlLayerApplier(testScheme, store), layer, "/source", []string{"/podinfo.yaml.tmpl"})
+	if err != nil {
+		t.Fatalf("apply.RenderTemplateFiles returned an error: %s", err)
+	}
+	namespaces := []string{}
+	for _, obj := range objs {
+		namespaces = append(namespaces, fmt.Sprintf("%s %s/%s", obj.GetObjectKind().GroupVersionKind().Kind,
+			obj.(metav1.Object).GetNamespace(), obj.(metav1.Object).GetName()))
+	}
+	expected := []string{"HelmRelease apps/apps-podinfo", "HelmRelease ingress/ingress", "Namespace /apps"}
+	if diff := cmp.Diff(expected, namespaces); diff != "" {
+		t.Fatalf("unexpected rendered objects (-want +got):\n%s", diff)
+	}
+}
+
+func TestPruneSecrets(t *testing.T) {
+	newSecret := func(name, owner string) *corev1.Secret {
+		return &corev1.Secret{ObjectMeta: metav1.ObjectMeta{Namespace: "apps", Name: name, Labels: map[string]string{"kraan/layer": owner}}}
+	}
+	ctx := context.Background()
+	remoteClient := fake.NewClientBuilder().WithScheme(testScheme).WithObjects(
+		newSecret("kept", appsLayer), newSecret("removed", appsLayer), newSecret("other-layer", bootstrapLayer)).Build()
+	layer := getLayer(t, appsLayer, addonsFileName)
+	layer.SetCluster(&clusters.Cluster{Key: "remote", Client: remoteClient})
+	layer.SetSourceResources([]runtime.Object{newSecret("kept", appsLayer)})
+	applier := apply.NewKubectlLayerApplier(testScheme, storage.NewMemory())
+
+	pruneRequired, pruneHrs, err := applier.PruneIsRequired(ctx, layer)
+	if err != nil {
+		t.Fatalf("PruneIsRequired returned an error: %s", err)
+	}
+	if !pruneRequired || len(pruneHrs) != 0 {
+		t.Fatalf("PruneIsRequired returned: %t, %d HelmReleases, expected a Secret to prune", pruneRequired, len(pruneHrs))
+	}
+	if err := applier.Prune(ctx, layer, pruneHrs); err != nil {
+		t.Fatalf("Prune returned an error: %s", err)
+	}
+	secrets := &corev1.SecretList{}
+	if err := remoteClient.List(ctx, secrets); err != nil {
+		t.Fatalf("failed to list secrets: %s", err)
+	}
+	names := []string{}
+	for _, secret := range secrets.Items {
+		names = append(names, secret.Name)
+	}
+	if diff := cmp.Diff([]string{"kept", "other-layer"}, names); diff != "" {
+		t.Fatalf("unexpected secrets after prune (-want +got):\n%s", diff)
+	}
+	if pruneRequired, _, err := applier.PruneIsRequired(ctx, layer); err != nil || pruneRequired {
+		t.Fatalf("PruneIsRequired returned: %t, %v, after prune", pruneRequired, err)
+	}
+}
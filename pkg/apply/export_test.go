@@ -6,8 +6,10 @@ import (
 	"unsafe"
 
 	"github.com/go-logr/logr"
+	"k8s.io/apimachinery/pkg/runtime"
 
 	"github.com/fidelity/kraan/pkg/internal/kubectl"
+	"github.com/fidelity/kraan/pkg/storage"
 )
 
 func SetNewKubectlFunc(kubectlFunc func(logger logr.Logger) (kubectl.Kubectl, error)) {
@@ -24,8 +26,21 @@ var (
 	GetTimestamp  = getTimestamp
 	LabelValue    = labelValue
 	GetObjLabel   = getObjLabel
+	MergeSources  = mergeSourceResources
+
+	SetHelmReleaseDefaults = setHelmReleaseDefaults
+	AddLayerMetadata       = KubectlLayerApplier.addLayerMetadata
+	MergeLayerValues       = KubectlLayerApplier.mergeLayerValues
+	JSONPathValue          = jsonPathValue
+	SubstitutionEnabled    = substitutionEnabled
+	GetOutputValue         = KubectlLayerApplier.getOutputValue
+	RenderTemplateFiles    = KubectlLayerApplier.renderTemplateFiles
 )
 
+func NewKubectlLayerApplier(scheme *runtime.Scheme, store storage.Storage) KubectlLayerApplier {
+	return KubectlLayerApplier{scheme: scheme, store: store}
+}
+
 func GetField(t *testing.T, obj interface{}, fieldName string) interface{} {
 	o, ok := obj.(KubectlLayerApplier)
 	if !ok {
@@ -7,12 +7,16 @@ package apply
 To generate mock code for the LayerApplier run 'go generate ./...' from the project root directory.
 */
 import (
+	"bufio"
 	"bytes"
 	"context"
 	"encoding/json"
 	"fmt"
+	"io"
 	"os"
+	"path/filepath"
 	"reflect"
+	"sort"
 	"strings"
 	"time"
 
@@ -27,23 +31,47 @@ import (
 	k8serrors "k8s.io/apimachinery/pkg/api/errors"
 	apimeta "k8s.io/apimachinery/pkg/api/meta"
 	metav1 "k8s.io/apimachinery/pkg/apis/meta/v1"
+	"k8s.io/apimachinery/pkg/apis/meta/v1/unstructured"
 	"k8s.io/apimachinery/pkg/runtime"
 	"k8s.io/apimachinery/pkg/runtime/serializer"
+	"k8s.io/apimachinery/pkg/types"
+	utilyaml "k8s.io/apimachinery/pkg/util/yaml"
+	"k8s.io/client-go/tools/clientcmd"
+	"k8s.io/client-go/util/jsonpath"
 	"sigs.k8s.io/controller-runtime/pkg/client"
 	"sigs.k8s.io/controller-runtime/pkg/controller/controllerutil"
 
 	kraanv1alpha1 "github.com/fidelity/kraan/api/v1alpha1"
+	"github.com/fidelity/kraan/pkg/common"
+	"github.com/fidelity/kraan/pkg/internal/filter"
 	"github.com/fidelity/kraan/pkg/internal/kubectl"
 	"github.com/fidelity/kraan/pkg/layers"
 	"github.com/fidelity/kraan/pkg/logging"
+	"github.com/fidelity/kraan/pkg/policy"
+	"github.com/fidelity/kraan/pkg/sops"
+	"github.com/fidelity/kraan/pkg/storage"
+	"github.com/fidelity/kraan/pkg/substitute"
+	"github.com/fidelity/kraan/pkg/templates"
 )
 
 const (
 	orphanedLabel = "orphaned"
+	// layerValuesAnnotation set to false on a HelmRelease prevents the layer's values being merged into its values.
+	layerValuesAnnotation = "kraan.layerValues"
+	// substituteAnnotation set to true on an AddonsLayer or a resource enables variable substitution in the layer's
+	// resources or the resource, set to false on a resource it prevents variables being substituted in it.
+	substituteAnnotation = "kraan.substitute"
+	// templateAnnotation set to true on a resource renders the templates in its string values.
+	templateAnnotation = "kraan.template"
+	// templateSuffix is the suffix of template files, which are rendered before their resources are decoded.
+	templateSuffix = ".tmpl"
+	// helmReleaseNameLabel and helmReleaseNamespaceLabel are set by the helm-controller on the objects it deploys.
+	helmReleaseNameLabel      = "helm.toolkit.fluxcd.io/name"
+	helmReleaseNamespaceLabel = "helm.toolkit.fluxcd.io/namespace"
 )
 
 var (
-	ownerLabel     string                                            = "kraan/layer"
+	ownerLabel     string                                            = common.OwnerLabel
 	newKubectlFunc func(logger logr.Logger) (kubectl.Kubectl, error) = kubectl.NewKubectl
 )
 
@@ -51,6 +79,7 @@ var (
 type LayerApplier interface {
 	Apply(ctx context.Context, layer layers.Layer) (err error)
 	Prune(ctx context.Context, layer layers.Layer, pruneHrs []*helmctlv2.HelmRelease) (err error)
+	DeleteAll(ctx context.Context, layer layers.Layer) (err error)
 	PruneIsRequired(ctx context.Context, layer layers.Layer) (pruneRequired bool, pruneHrs []*helmctlv2.HelmRelease, err error)
 	ApplyIsRequired(ctx context.Context, layer layers.Layer) (applyIsRequired bool, err error)
 	ApplyWasSuccessful(ctx context.Context, layer layers.Layer) (applyIsRequired bool, hrName string, err error)
@@ -59,8 +88,9 @@ type LayerApplier interface {
 	Orphan(ctx context.Context, layer layers.Layer, hr *helmctlv2.HelmRelease) (bool, error)
 	GetOrphanedHelmReleases(ctx context.Context, layer layers.Layer) (foundHrs map[string]*helmctlv2.HelmRelease, err error)
 	Adopt(ctx context.Context, layer layers.Layer, hr *helmctlv2.HelmRelease) error
+	PublishOutputs(ctx context.Context, layer layers.Layer) error
 	addOwnerRefs(layer layers.Layer, objs []runtime.Object) error
-	orphanLabel(ctx context.Context, hr *helmctlv2.HelmRelease) (*metav1.Time, error)
+	orphanLabel(ctx context.Context, layer layers.Layer, hr *helmctlv2.HelmRelease) (*metav1.Time, error)
 	GetHelmReleases(ctx context.Context, layer layers.Layer) (foundHrs map[string]*helmctlv2.HelmRelease, err error)
 }
 
@@ -70,10 +100,11 @@ type KubectlLayerApplier struct {
 	kubectl kubectl.Kubectl
 	scheme  *runtime.Scheme
 	logger  logr.Logger
+	store   storage.Storage
 }
 
-// NewApplier returns a LayerApplier instance.
-func NewApplier(client client.Client, logger logr.Logger, scheme *runtime.Scheme) (applier LayerApplier, err error) {
+// NewApplier returns a LayerApplier instance, layer source directories are read from the storage backend specified.
+func NewApplier(client client.Client, logger logr.Logger, scheme *runtime.Scheme, store storage.Storage) (applier LayerApplier, err error) {
 	kubectl, err := newKubectlFunc(logger)
 	if err != nil {
 		return nil, errors.WithMessagef(err, "%s - failed to create a Kubectl provider for KubectlLayerApplier", logging.CallerStr(logging.Me))
@@ -83,6 +114,7 @@ func NewApplier(client client.Client, logger logr.Logger, scheme *runtime.Scheme
 		kubectl: kubectl,
 		scheme:  scheme,
 		logger:  logger,
+		store:   store,
 	}
 	return applier, nil
 }
@@ -92,6 +124,23 @@ func (a KubectlLayerApplier) getLog(layer layers.Layer) (logger logr.Logger) {
 	return logger
 }
 
+// getClient returns the client for the cluster a layer is applied to.
+func (a KubectlLayerApplier) getClient(layer layers.Layer) client.Client {
+	if cluster := layer.GetCluster(); cluster != nil {
+		return cluster.Client
+	}
+	return a.client
+}
+
+// listOwned lists the objects owned by a layer. The owner index is only available for the cluster the controller is
+// running in, the owner label is used for other clusters.
+func (a KubectlLayerApplier) listOwned(ctx context.Context, layer layers.Layer, list client.ObjectList) error {
+	if cluster := layer.GetCluster(); cluster.IsRemote() {
+		return cluster.Client.List(ctx, list, client.MatchingLabels{ownerLabel: layer.GetName()})
+	}
+	return a.client.List(ctx, list, client.MatchingFields{".owner": layer.GetName()})
+}
+
 func (a KubectlLayerApplier) log(level int, msg string, layer layers.Layer, keysAndValues ...interface{}) {
 	a.getLog(layer).V(level).Info(msg, append(keysAndValues, append(logging.GetFunctionAndSource(logging.MyCaller+2), "sourcePath", layer.GetSourcePath())...)...)
 }
@@ -140,6 +189,14 @@ func getObjLabel(obj runtime.Object) string {
 	return fmt.Sprintf("%s/%s", mobj.GetNamespace(), mobj.GetName())
 }
 
+func getObjKindLabel(obj runtime.Object) string {
+	kind := obj.GetObjectKind().GroupVersionKind().GroupKind().String()
+	if kind == "" {
+		kind = fmt.Sprintf("%T", obj)
+	}
+	return fmt.Sprintf("%s/%s", kind, getObjLabel(obj))
+}
+
 // GetOrphanedHelmReleases returns a map of HelmReleases that are labeled as orphaned and not owned by layer
 func (a KubectlLayerApplier) GetOrphanedHelmReleases(ctx context.Context, layer layers.Layer) (foundHrs map[string]*helmctlv2.HelmRelease, err error) {
 	logging.TraceCall(a.getLog(layer))
@@ -150,14 +207,14 @@ func (a KubectlLayerApplier) GetOrphanedHelmReleases(ctx context.Context, layer
 	listOptions := &client.ListOptions{}
 	labels.ApplyToList(listOptions)
 
-	err = a.client.List(ctx, hrList, listOptions)
+	err = a.getClient(layer).List(ctx, hrList, listOptions)
 	if err != nil {
 		return nil, errors.Wrapf(err, "%s - failed to list orphaned HelmRelease resources '%s'", logging.CallerStr(logging.Me), layer.GetName())
 	}
 
 	foundHrs = map[string]*helmctlv2.HelmRelease{}
 	for _, hr := range hrList.Items {
-		if layer.GetName() != layerOwner(&hr) { //nolint: scopelint // ok
+		if layer.GetName() != clusterLayerOwner(layer, &hr) { //nolint: scopelint // ok
 			foundHrs[getLabel(hr.ObjectMeta)] = hr.DeepCopy()
 		}
 	}
@@ -189,7 +246,7 @@ func (a KubectlLayerApplier) decodeHelmRepos(layer layers.Layer, objs []runtime.
 	logging.TraceCall(a.getLog(layer))
 	defer logging.TraceExit(a.getLog(layer))
 	for _, obj := range objs {
-		a.logTrace("checking for HelmRepository type", layer, "object", obj)
+		a.logTrace("checking for HelmRepository type", layer, logging.GetObjKindNamespaceName(obj)...)
 		switch obj.(type) {
 		case *sourcev1.HelmRepository:
 			hr, ok := obj.(*sourcev1.HelmRepository)
@@ -207,6 +264,19 @@ func (a KubectlLayerApplier) decodeHelmRepos(layer layers.Layer, objs []runtime.
 	return hrs, err
 }
 
+func (a KubectlLayerApplier) decodeSecrets(layer layers.Layer, objs []runtime.Object) (secrets []*corev1.Secret) {
+	logging.TraceCall(a.getLog(layer))
+	defer logging.TraceExit(a.getLog(layer))
+	for _, obj := range objs {
+		if secret, ok := obj.(*corev1.Secret); ok {
+			// Never log the secret itself, it may contain decrypted data.
+			a.logDebug("found Secret in Object list", layer, logging.GetObjKindNamespaceName(obj)...)
+			secrets = append(secrets, secret)
+		}
+	}
+	return secrets
+}
+
 func (a KubectlLayerApplier) decodeAddons(layer layers.Layer,
 	json []byte) (objs []runtime.Object, err error) {
 	logging.TraceCall(a.getLog(layer))
@@ -217,6 +287,15 @@ func (a KubectlLayerApplier) decodeAddons(layer layers.Layer,
 	// dez := a.scheme.Codecs.UniversalDeserializer()
 	dez := serializer.NewCodecFactory(a.scheme).UniversalDeserializer()
 
+	defaults, err := getHelmReleaseDefaults(layer)
+	if err != nil {
+		return nil, errors.WithMessagef(err, "%s - failed to get helm release defaults", logging.CallerStr(logging.Me))
+	}
+	json, err = a.withHelmReleaseDefaults(layer, json, defaults)
+	if err != nil {
+		return nil, err
+	}
+
 	obj, gvk, err := dez.Decode(json, nil, nil)
 	if err != nil {
 		a.logError(err, "unable to parse JSON output from kubectl", layer, "output", string(json))
@@ -228,7 +307,7 @@ func (a KubectlLayerApplier) decodeAddons(layer layers.Layer,
 	switch obj.(type) {
 	case *corev1.List:
 		a.logTrace("decoded raw object List from kubectl output", layer, "list", logging.LogJSON(obj))
-		return a.decodeList(layer, obj.(*corev1.List), &dez)
+		return a.decodeList(layer, obj.(*corev1.List), &dez, defaults)
 	default:
 		/*msg := "decoded kubectl output was not a HelmRelease or List"
 		err = fmt.Errorf(msg)
@@ -237,6 +316,23 @@ func (a KubectlLayerApplier) decodeAddons(layer layers.Layer,
 	}
 }
 
+// checkNamespace returns a Forbidden error if an object is in a namespace the layer is not allowed to deploy addons to.
+func (a KubectlLayerApplier) checkNamespace(layer layers.Layer, robj runtime.Object, obj metav1.Object) error {
+	if obj.GetNamespace() == "" {
+		return nil
+	}
+	allowed, err := layer.IsNamespaceAllowed(obj.GetNamespace())
+	if err != nil {
+		return errors.WithMessagef(err, "%s - failed to check namespace of: %s", logging.CallerStr(logging.Me), getObjLabel(robj))
+	}
+	if !allowed {
+		resource, _ := apimeta.UnsafeGuessKindToResource(robj.GetObjectKind().GroupVersionKind())
+		return k8serrors.NewForbidden(resource.GroupResource(), obj.GetName(),
+			fmt.Errorf("layer: %s, is not allowed to deploy to namespace: %s", layer.GetName(), obj.GetNamespace()))
+	}
+	return nil
+}
+
 func (a KubectlLayerApplier) addOwnerRefs(layer layers.Layer, objs []runtime.Object) error {
 	logging.TraceCall(a.getLog(layer))
 	defer logging.TraceExit(a.getLog(layer))
@@ -248,7 +344,11 @@ func (a KubectlLayerApplier) addOwnerRefs(layer layers.Layer, objs []runtime.Obj
 			return err
 		}
 
-		owningLayer := layerOwner(obj)
+		if err := a.checkNamespace(layer, robj, obj); err != nil {
+			return err
+		}
+
+		owningLayer := clusterLayerOwner(layer, obj)
 		if len(owningLayer) > 0 && owningLayer != layer.GetName() {
 			a.logDebug("resource already owned by another AddonsLayer", layer, logging.GetObjKindNamespaceName(robj)...)
 			if len(labelValue(orphanedLabel, &obj)) > 0 {
@@ -257,11 +357,15 @@ func (a KubectlLayerApplier) addOwnerRefs(layer layers.Layer, objs []runtime.Obj
 			return fmt.Errorf("%s - HelmRelease: %s, also included in layer: %s", logging.CallerStr(logging.Me), getObjLabel(robj), labelValue(ownerLabel, &obj))
 		}
 
-		a.logDebug("Adding owner ref to resource for AddonsLayer", layer, logging.GetObjKindNamespaceName(robj)...)
-		err := controllerutil.SetControllerReference(layer.GetAddonsLayer(), obj, a.scheme)
-		if err != nil {
-			// could not apply owner ref for object
-			return errors.Wrapf(err, "%s - failed to apply owner reference to: %s", logging.CallerStr(logging.Me), getObjLabel(robj))
+		// The AddonsLayer is not in a remote cluster, an owner reference to it would cause the remote cluster's garbage
+		// collector to delete the resource, the owner label identifies the owning layer instead.
+		if !layer.GetCluster().IsRemote() {
+			a.logDebug("Adding owner ref to resource for AddonsLayer", layer, logging.GetObjKindNamespaceName(robj)...)
+			err := controllerutil.SetControllerReference(layer.GetAddonsLayer(), obj, a.scheme)
+			if err != nil {
+				// could not apply owner ref for object
+				return errors.Wrapf(err, "%s - failed to apply owner reference to: %s", logging.CallerStr(logging.Me), getObjLabel(robj))
+			}
 		}
 		labels := obj.GetLabels()
 		if labels == nil {
@@ -269,6 +373,9 @@ func (a KubectlLayerApplier) addOwnerRefs(layer layers.Layer, objs []runtime.Obj
 		}
 		labels[ownerLabel] = layer.GetName()
 		obj.SetLabels(labels)
+		if _, ok := robj.(*helmctlv2.HelmRelease); ok {
+			obj.SetAnnotations(mergeStringMaps(obj.GetAnnotations(), map[string]string{common.LayerVersionAnnotation: layer.GetSpec().Version}))
+		}
 	}
 	return nil
 }
@@ -277,7 +384,7 @@ func (a KubectlLayerApplier) GetHelmReleases(ctx context.Context, layer layers.L
 	logging.TraceCall(a.getLog(layer))
 	defer logging.TraceExit(a.getLog(layer))
 	hrList := &helmctlv2.HelmReleaseList{}
-	err = a.client.List(ctx, hrList, client.MatchingFields{".owner": layer.GetName()})
+	err = a.listOwned(ctx, layer, hrList)
 	if err != nil {
 		return nil, errors.Wrapf(err, "%s - failed to list HelmRelease resources owned by '%s'", logging.CallerStr(logging.Me), layer.GetName())
 	}
@@ -318,6 +425,19 @@ func (a KubectlLayerApplier) applyHelmRepoObjects(ctx context.Context, layer lay
 	return nil
 }
 
+func (a KubectlLayerApplier) applySecretObjects(ctx context.Context, layer layers.Layer, objs []*corev1.Secret) error {
+	logging.TraceCall(a.getLog(layer))
+	defer logging.TraceExit(a.getLog(layer))
+	for _, obj := range objs {
+		err := a.applyObject(ctx, layer, obj)
+		if err != nil {
+			return errors.Wrapf(err, "%s - failed to apply layer secret resource", logging.CallerStr(logging.Me))
+		}
+		a.logDebug("secret resource successfully applied", layer, logging.GetObjKindNamespaceName(obj)...)
+	}
+	return nil
+}
+
 /*
 func (a KubectlLayerApplier) applyObjects(ctx context.Context, layer layers.Layer, objs []runtime.Object) error {
 	logging.TraceCall(a.getLog(layer))
@@ -339,7 +459,7 @@ func (a KubectlLayerApplier) getHelmRepos(ctx context.Context, layer layers.Laye
 	logging.TraceCall(a.getLog(layer))
 	defer logging.TraceExit(a.getLog(layer))
 	hrList := &sourcev1.HelmRepositoryList{}
-	err = a.client.List(ctx, hrList, client.MatchingFields{".owner": layer.GetName()})
+	err = a.listOwned(ctx, layer, hrList)
 	if err != nil {
 		return nil, errors.Wrapf(err, "%s - failed to list HelmRepos resources owned by '%s'", logging.CallerStr(logging.Me), layer.GetName())
 	}
@@ -349,6 +469,27 @@ func (a KubectlLayerApplier) getHelmRepos(ctx context.Context, layer layers.Laye
 	return foundHrs, nil
 }
 
+// getSecrets returns the Secrets owned by a layer. Secrets are not cached by the controller so they are listed using the
+// owner label rather than the owner index, Secrets in the cluster the controller is running in must also have an owner
+// reference to the layer.
+func (a KubectlLayerApplier) getSecrets(ctx context.Context, layer layers.Layer) (secrets []*corev1.Secret, err error) {
+	logging.TraceCall(a.getLog(layer))
+	defer logging.TraceExit(a.getLog(layer))
+	secretList := &corev1.SecretList{}
+	err = a.getClient(layer).List(ctx, secretList, client.MatchingLabels{ownerLabel: layer.GetName()})
+	if err != nil {
+		return nil, errors.Wrapf(err, "%s - failed to list Secrets owned by '%s'", logging.CallerStr(logging.Me), layer.GetName())
+	}
+	for i := range secretList.Items {
+		secret := &secretList.Items[i]
+		if clusterLayerOwner(layer, secret) != layer.GetName() {
+			continue
+		}
+		secrets = append(secrets, secret.DeepCopy())
+	}
+	return secrets, nil
+}
+
 func (a KubectlLayerApplier) isObjectPresent(ctx context.Context, layer layers.Layer, obj client.Object) (bool, error) {
 	logging.TraceCall(a.getLog(layer))
 	defer logging.TraceExit(a.getLog(layer))
@@ -359,7 +500,7 @@ func (a KubectlLayerApplier) isObjectPresent(ctx context.Context, layer layers.L
 		return false, fmt.Errorf("failed to convert runtime.Object to client.Object")
 	}
 
-	err := a.client.Get(ctx, key, existing)
+	err := a.getClient(layer).Get(ctx, key, existing)
 	if err != nil {
 		if k8serrors.IsNotFound(err) {
 			removeResourceVersion(obj)
@@ -376,19 +517,22 @@ func (a KubectlLayerApplier) applyObject(ctx context.Context, layer layers.Layer
 	logging.TraceCall(a.getLog(layer))
 	defer logging.TraceExit(a.getLog(layer))
 	a.logDebug("applying object", layer, logging.GetObjKindNamespaceName(obj)...)
+	if err := a.checkNamespace(layer, obj, obj); err != nil {
+		return err
+	}
 	present, err := a.isObjectPresent(ctx, layer, obj)
 	if err != nil {
 		return errors.WithMessagef(err, "%s - failed to determine if object '%s' is present on the target cluster", logging.CallerStr(logging.Me), getObjLabel(obj))
 	}
 	if !present {
 		// object does not exist, create resource
-		err = a.client.Create(ctx, obj, &client.CreateOptions{})
+		err = a.getClient(layer).Create(ctx, obj, &client.CreateOptions{})
 		if err != nil {
 			return errors.Wrapf(err, "%s - failed to Create Object '%s' on the target cluster", logging.CallerStr(logging.Me), getObjLabel(obj))
 		}
 	} else {
 		// Object exists, update resource
-		err = a.client.Update(ctx, obj, &client.UpdateOptions{})
+		err = a.getClient(layer).Update(ctx, obj, &client.UpdateOptions{})
 		if err != nil {
 			return errors.Wrapf(err, "%s - failed to Update object '%s' on the target cluster", logging.CallerStr(logging.Me), getObjLabel(obj))
 		}
@@ -398,7 +542,7 @@ func (a KubectlLayerApplier) applyObject(ctx context.Context, layer layers.Layer
 }
 
 func (a KubectlLayerApplier) decodeList(layer layers.Layer,
-	raws *corev1.List, dez *runtime.Decoder) (objs []runtime.Object, err error) {
+	raws *corev1.List, dez *runtime.Decoder, defaults map[string]interface{}) (objs []runtime.Object, err error) {
 	logging.TraceCall(a.getLog(layer))
 	defer logging.TraceExit(a.getLog(layer))
 	dec := *dez
@@ -406,7 +550,11 @@ func (a KubectlLayerApplier) decodeList(layer layers.Layer,
 	a.logTrace("decoding list of raw JSON items", layer, "length", len(raws.Items))
 
 	for _, raw := range raws.Items {
-		obj, _, decodeErr := dec.Decode(raw.Raw, nil, nil)
+		data, defaultsErr := a.withHelmReleaseDefaults(layer, raw.Raw, defaults)
+		if defaultsErr != nil {
+			return nil, defaultsErr
+		}
+		obj, _, decodeErr := dec.Decode(data, nil, nil)
 		if decodeErr != nil {
 			err = fmt.Errorf("could not decode JSON to a runtime.Object: %w", decodeErr)
 			a.logError(err, err.Error(), layer, "rawJSON", string(raw.Raw))
@@ -418,12 +566,12 @@ func (a KubectlLayerApplier) decodeList(layer layers.Layer,
 	return objs, err
 }
 
-func (a KubectlLayerApplier) checkSourcePath(layer layers.Layer) (sourceDir string, err error) {
+func (a KubectlLayerApplier) checkSourcePath(layer layers.Layer, sourcePath string) (sourceDir string, err error) {
 	logging.TraceCall(a.getLog(layer))
 	defer logging.TraceExit(a.getLog(layer))
-	a.logDebug("Checking layer source directory", layer)
-	sourceDir = layer.GetSourcePath()
-	info, err := os.Stat(sourceDir)
+	a.logDebug("Checking layer source directory", layer, "path", sourcePath)
+	sourceDir = sourcePath
+	info, err := a.store.Stat(sourceDir)
 	if os.IsNotExist(err) {
 		a.logDebug("source directory not found", layer)
 		return sourceDir, errors.Wrapf(err, "source directory (%s) not found for AddonsLayer %s",
@@ -448,12 +596,122 @@ func (a KubectlLayerApplier) checkSourcePath(layer layers.Layer) (sourceDir stri
 	return sourceDir, nil
 }
 
-func (a KubectlLayerApplier) doApply(layer layers.Layer, sourceDir string) (output []byte, err error) {
+// getSourceFilter returns the filter selecting the files in a source directory using the source's include and exclude
+// patterns and .kraanignore file.
+func (a KubectlLayerApplier) getSourceFilter(layer layers.Layer, sourceDir string, source kraanv1alpha1.SourceSpec) (*filter.Filter, error) {
+	logging.TraceCall(a.getLog(layer))
+	defer logging.TraceExit(a.getLog(layer))
+	ignore, err := a.store.ReadFile(sourceDir + filter.IgnoreFile)
+	if err != nil && !os.IsNotExist(err) {
+		return nil, errors.WithMessagef(err, "%s - failed to read %s", logging.CallerStr(logging.Me), filter.IgnoreFile)
+	}
+	sourceFilter, err := filter.New(source.Include, source.Exclude, ignore)
+	if err != nil {
+		return nil, errors.WithMessagef(err, "%s - invalid source file filter", logging.CallerStr(logging.Me))
+	}
+	if !sourceFilter.IsEmpty() {
+		a.logDebug("filtering source directory", layer, "include", source.Include, "exclude", source.Exclude, "ignoreFile", len(ignore) > 0)
+	}
+	return sourceFilter, nil
+}
+
+// isTemplate returns true if a file is a manifest template, rendered before its resources are decoded.
+func isTemplate(name string) bool {
+	return strings.HasSuffix(strings.ToLower(name), templateSuffix)
+}
+
+// isManifest returns true if a file is read by kubectl when applying a directory.
+func isManifest(name string) bool {
+	ext := strings.ToLower(filepath.Ext(name))
+	return ext == ".yaml" || ext == ".yml" || ext == ".json"
+}
+
+// findSourceFiles returns the slash separated paths, relative to a source directory, of the SOPS encrypted manifests
+// and template files selected by the source filter and the number of other manifests selected.
+func (a KubectlLayerApplier) findSourceFiles(layer layers.Layer, sourceDir, relDir string, sourceFilter *filter.Filter) (encrypted, templates []string, manifests int, err error) {
+	dir := strings.TrimSuffix(sourceDir, string(os.PathSeparator))
+	if relDir != "" {
+		dir = fmt.Sprintf("%s/%s", dir, relDir)
+	}
+	entries, err := a.store.ReadDir(dir)
+	if err != nil {
+		return nil, nil, 0, errors.WithMessagef(err, "%s - failed to read directory: %s", logging.CallerStr(logging.Me), dir)
+	}
+	for _, entry := range entries {
+		rel := entry.Name()
+		if relDir != "" {
+			rel = fmt.Sprintf("%s/%s", relDir, entry.Name())
+		}
+		info, err := a.store.Stat(fmt.Sprintf("%s/%s", dir, entry.Name()))
+		if err != nil {
+			return nil, nil, 0, errors.WithMessagef(err, "%s - failed to stat: %s", logging.CallerStr(logging.Me), rel)
+		}
+		if !sourceFilter.Match(rel, info.IsDir()) {
+			continue
+		}
+		if info.IsDir() {
+			dirEncrypted, dirTemplates, dirManifests, err := a.findSourceFiles(layer, sourceDir, rel, sourceFilter)
+			if err != nil {
+				return nil, nil, 0, err
+			}
+			encrypted = append(encrypted, dirEncrypted...)
+			templates = append(templates, dirTemplates...)
+			manifests += dirManifests
+			continue
+		}
+		if isTemplate(entry.Name()) {
+			a.logDebug("found template file", layer, "file", rel)
+			templates = append(templates, rel)
+			continue
+		}
+		if !isManifest(entry.Name()) {
+			continue
+		}
+		data, err := a.store.ReadFile(fmt.Sprintf("%s/%s", dir, entry.Name()))
+		if err != nil {
+			return nil, nil, 0, errors.WithMessagef(err, "%s - failed to read file: %s", logging.CallerStr(logging.Me), rel)
+		}
+		if sops.IsEncrypted(data) {
+			a.logDebug("found encrypted manifest", layer, "file", rel)
+			encrypted = append(encrypted, rel)
+			continue
+		}
+		manifests++
+	}
+	return encrypted, templates, manifests, nil
+}
+
+// localSourceDir makes the files in a source directory that are selected by the source filter available on the local
+// filesystem, excluding the encrypted manifests, which are decrypted in memory.
+func (a KubectlLayerApplier) localSourceDir(layer layers.Layer, sourceDir string, sourceFilter *filter.Filter, encrypted []string) (string, func(), error) {
+	logging.TraceCall(a.getLog(layer))
+	defer logging.TraceExit(a.getLog(layer))
+	if sourceFilter.IsEmpty() && len(encrypted) == 0 {
+		return a.store.LocalPath(sourceDir)
+	}
+	return storage.LocalCopy(a.store, strings.TrimSuffix(sourceDir, string(os.PathSeparator)), func(relPath string, isDir bool) bool {
+		return sourceFilter.Match(relPath, isDir) && (isDir || !common.ContainsString(encrypted, relPath))
+	})
+}
+
+func (a KubectlLayerApplier) doApply(layer layers.Layer, sourceDir string, sourceFilter *filter.Filter, encrypted []string) (output []byte, err error) {
 	logging.TraceCall(a.getLog(layer))
 	defer logging.TraceExit(a.getLog(layer))
+	localDir, cleanup, err := a.localSourceDir(layer, sourceDir, sourceFilter, encrypted)
+	if err != nil {
+		return nil, errors.WithMessagef(err, "%s - failed to make source directory available to kubectl", logging.CallerStr(logging.Me))
+	}
+	defer cleanup()
+	if localDir != sourceDir {
+		localDir += string(os.PathSeparator)
+	}
 	MaxTries := 5
 	for try := 1; try < MaxTries; try++ {
-		output, err = a.kubectl.Apply(sourceDir).WithLogger(layer.GetLogger()).DryRun()
+		cmd := a.kubectl.Apply(localDir).WithLogger(layer.GetLogger())
+		if cluster := layer.GetCluster(); cluster != nil {
+			cmd = cmd.WithKubeConfig(cluster.KubeConfigPath)
+		}
+		output, err = cmd.DryRun()
 		if err == nil {
 			return output, nil
 		}
@@ -462,23 +720,59 @@ func (a KubectlLayerApplier) doApply(layer layers.Layer, sourceDir string) (outp
 	return output, errors.WithMessagef(err, "%s - failed to run dry run apply", logging.CallerStr(logging.Me))
 }
 
+// getSourceResources returns the layer's resources, which are only rendered once for each reconcile. Copies are returned
+// so applying them does not change the resources later checks in the reconcile compare with the cluster.
 func (a KubectlLayerApplier) getSourceResources(layer layers.Layer) (objs []runtime.Object, err error) {
 	logging.TraceCall(a.getLog(layer))
 	defer logging.TraceExit(a.getLog(layer))
-	sourceDir, err := a.checkSourcePath(layer)
+	rendered := layer.GetSourceResources()
+	if rendered == nil {
+		rendered, err = a.renderSourceResources(layer)
+		if err != nil {
+			return nil, err
+		}
+		layer.SetSourceResources(rendered)
+	}
+	objs = make([]runtime.Object, 0, len(rendered))
+	for _, obj := range rendered {
+		objs = append(objs, obj.DeepCopyObject())
+	}
+	return objs, nil
+}
+
+// renderSourceResources reads the resources in the layer's sources, renders their templates, substitutes variables and
+// sets their values, owners and metadata, then checks them against the layer's policies.
+func (a KubectlLayerApplier) renderSourceResources(layer layers.Layer) (objs []runtime.Object, err error) {
+	logging.TraceCall(a.getLog(layer))
+	defer logging.TraceExit(a.getLog(layer))
+	sourceObjs := [][]runtime.Object{}
+	sources := layer.GetSources()
+	for index, sourcePath := range layer.GetSourcePaths() {
+		pathObjs, err := a.getSourcePathResources(layer, sourcePath, sources[index])
+		if err != nil {
+			return nil, err
+		}
+		sourceObjs = append(sourceObjs, pathObjs)
+	}
+	err = a.addLayerMetadata(layer, sourceObjs)
 	if err != nil {
-		return nil, errors.WithMessagef(err, "failed to check source path")
+		return nil, errors.WithMessagef(err, "%s - failed to add layer metadata", logging.CallerStr(logging.Me))
 	}
 
-	output, err := a.doApply(layer, sourceDir)
+	err = a.renderTemplates(layer, sourceObjs)
 	if err != nil {
-		return nil, errors.WithMessagef(err, "%s - failed to execute kubectl while parsing source directory (%s) for AddonsLayer %s",
-			logging.CallerStr(logging.Me), sourceDir, layer.GetName())
+		return nil, errors.WithMessagef(err, "%s - failed to render templates", logging.CallerStr(logging.Me))
+	}
+
+	err = a.substituteVariables(layer, sourceObjs)
+	if err != nil {
+		return nil, errors.WithMessagef(err, "%s - failed to substitute variables", logging.CallerStr(logging.Me))
 	}
+	objs = mergeSourceResources(sourceObjs)
 
-	objs, err = a.decodeAddons(layer, output)
+	err = a.setHelmReleaseValues(layer, objs)
 	if err != nil {
-		return nil, errors.WithMessagef(err, "%s - failed to decode apply dry run output", logging.CallerStr(logging.Me))
+		return nil, errors.WithMessagef(err, "%s - failed to set helm release values", logging.CallerStr(logging.Me))
 	}
 
 	err = a.addOwnerRefs(layer, objs)
@@ -486,9 +780,441 @@ func (a KubectlLayerApplier) getSourceResources(layer layers.Layer) (objs []runt
 		return nil, errors.WithMessagef(err, "%s - failed to add owner reference", logging.CallerStr(logging.Me))
 	}
 
+	err = a.checkPolicies(layer, objs)
+	if err != nil {
+		return nil, errors.WithMessagef(err, "%s - failed to check layer policies", logging.CallerStr(logging.Me))
+	}
+
+	if objs == nil {
+		objs = []runtime.Object{}
+	}
+	return objs, nil
+}
+
+// addLayerMetadata adds the layer's common labels and annotations to its resources and appends its post renderers to
+// its HelmReleases. This is done before variables are substituted so they can contain variable references.
+func (a KubectlLayerApplier) addLayerMetadata(layer layers.Layer, sourceObjs [][]runtime.Object) error {
+	logging.TraceCall(a.getLog(layer))
+	defer logging.TraceExit(a.getLog(layer))
+	spec := layer.GetSpec()
+	if spec.CommonMetadata == nil && len(spec.PostRenderers) == 0 {
+		return nil
+	}
+	for _, objs := range sourceObjs {
+		for _, obj := range objs {
+			metaObj, ok := obj.(metav1.Object)
+			if !ok {
+				return fmt.Errorf("failed to convert runtime.Object to meta.Object")
+			}
+			if spec.CommonMetadata != nil {
+				metaObj.SetLabels(mergeStringMaps(metaObj.GetLabels(), spec.CommonMetadata.Labels))
+				metaObj.SetAnnotations(mergeStringMaps(metaObj.GetAnnotations(), spec.CommonMetadata.Annotations))
+			}
+			if hr, ok := obj.(*helmctlv2.HelmRelease); ok {
+				for _, postRenderer := range spec.PostRenderers {
+					hr.Spec.PostRenderers = append(hr.Spec.PostRenderers, *postRenderer.DeepCopy())
+				}
+			}
+			a.logTrace("added layer metadata", layer, logging.GetObjKindNamespaceName(obj)...)
+		}
+	}
+	return nil
+}
+
+// mergeStringMaps returns base with the entries in overlay added, replacing entries with the same keys.
+func mergeStringMaps(base, overlay map[string]string) map[string]string {
+	if len(overlay) == 0 {
+		return base
+	}
+	if base == nil {
+		base = make(map[string]string, len(overlay))
+	}
+	for key, value := range overlay {
+		base[key] = value
+	}
+	return base
+}
+
+// substituteVariables replaces the variable references in the string values of the layer's resources, recording the
+// names of any variables that are not defined in the layer's status. Substitution is enabled for all of the layer's
+// resources if the layer has a substituteFrom or the kraan.substitute annotation set to true, otherwise only for
+// resources with that annotation. Resources with the kraan.substitute annotation set to false are not changed.
+func (a KubectlLayerApplier) substituteVariables(layer layers.Layer, sourceObjs [][]runtime.Object) error {
+	logging.TraceCall(a.getLog(layer))
+	defer logging.TraceExit(a.getLog(layer))
+	layerEnabled := len(layer.GetSpec().SubstituteFrom) > 0 || layer.GetAddonsLayer().GetAnnotations()[substituteAnnotation] == "true"
+	var variables map[string]string
+	unresolved := map[string]bool{}
+	for _, objs := range sourceObjs {
+		for index, obj := range objs {
+			if !substitutionEnabled(obj, layerEnabled) {
+				continue
+			}
+			content, err := runtime.DefaultUnstructuredConverter.ToUnstructured(obj)
+			if err != nil {
+				return errors.Wrapf(err, "%s - failed to convert %s to unstructured", logging.CallerStr(logging.Me), getObjKindLabel(obj))
+			}
+			if !substitute.HasReferences(content) {
+				continue
+			}
+			if variables == nil {
+				variables, err = layer.GetVariables()
+				if err != nil {
+					return errors.WithMessagef(err, "%s - failed to get variables", logging.CallerStr(logging.Me))
+				}
+			}
+			changed, names := substitute.Object(content, variables)
+			for _, name := range names {
+				unresolved[name] = true
+			}
+			if !changed {
+				continue
+			}
+			substituted, ok := reflect.New(reflect.TypeOf(obj).Elem()).Interface().(runtime.Object)
+			if !ok {
+				return fmt.Errorf("failed to create object of type %T", obj)
+			}
+			if err := runtime.DefaultUnstructuredConverter.FromUnstructured(content, substituted); err != nil {
+				return errors.Wrapf(err, "%s - failed to convert %s from unstructured", logging.CallerStr(logging.Me), getObjKindLabel(obj))
+			}
+			a.logDebug("substituted variables", layer, logging.GetObjKindNamespaceName(substituted)...)
+			objs[index] = substituted
+		}
+	}
+	names := make([]string, 0, len(unresolved))
+	for name := range unresolved {
+		names = append(names, name)
+	}
+	sort.Strings(names)
+	if len(names) > 0 {
+		a.logInfo("resources reference undefined variables", layer, "variables", names)
+	}
+	layer.SetUnresolvedVariables(names)
+	return nil
+}
+
+// substitutionEnabled returns true if variables should be substituted in a resource, the resource's kraan.substitute
+// annotation takes precedence over the layer.
+func substitutionEnabled(obj runtime.Object, layerEnabled bool) bool {
+	metaObj, ok := obj.(metav1.Object)
+	if !ok {
+		return layerEnabled
+	}
+	switch metaObj.GetAnnotations()[substituteAnnotation] {
+	case "true":
+		return true
+	case "false":
+		return false
+	}
+	return layerEnabled
+}
+
+// checkPolicies evaluates the LayerPolicies that apply to a layer against its resources, recording any violations in
+// the layer's status. A policy.ViolationError is returned if a resource violates an enforced policy.
+func (a KubectlLayerApplier) checkPolicies(layer layers.Layer, objs []runtime.Object) error {
+	logging.TraceCall(a.getLog(layer))
+	defer logging.TraceExit(a.getLog(layer))
+	policies := &kraanv1alpha1.LayerPolicyList{}
+	if err := a.client.List(layer.GetContext(), policies); err != nil {
+		return errors.Wrapf(err, "%s - failed to list layer policies", logging.CallerStr(logging.Me))
+	}
+	violations, err := policy.Evaluate(policies.Items, layer.GetAddonsLayer(), objs)
+	if err != nil {
+		return errors.WithMessagef(err, "%s - failed to evaluate layer policies", logging.CallerStr(logging.Me))
+	}
+	layer.SetPolicyViolations(violations)
+	for _, violation := range violations {
+		a.logInfo("policy violation", layer, "policy", violation.Policy, "rule", violation.Rule, "mode", violation.Mode,
+			"kind", violation.Kind, "namespace", violation.Namespace, "name", violation.Name, "message", violation.Message)
+	}
+	if enforced := policy.Enforced(violations); len(enforced) > 0 {
+		return &policy.ViolationError{Violations: enforced}
+	}
+	return nil
+}
+
+func (a KubectlLayerApplier) getSourcePathResources(layer layers.Layer, sourcePath string, source kraanv1alpha1.SourceSpec) (objs []runtime.Object, err error) {
+	logging.TraceCall(a.getLog(layer))
+	defer logging.TraceExit(a.getLog(layer))
+	sourceDir, err := a.checkSourcePath(layer, sourcePath)
+	if err != nil {
+		return nil, errors.WithMessagef(err, "failed to check source path")
+	}
+
+	sourceFilter, err := a.getSourceFilter(layer, sourceDir, source)
+	if err != nil {
+		return nil, err
+	}
+
+	encrypted, templates, manifests, err := a.findSourceFiles(layer, sourceDir, "", sourceFilter)
+	if err != nil {
+		return nil, errors.WithMessagef(err, "%s - failed to check for encrypted manifests and templates", logging.CallerStr(logging.Me))
+	}
+
+	if (len(encrypted) == 0 && len(templates) == 0) || manifests > 0 {
+		output, err := a.doApply(layer, sourceDir, sourceFilter, encrypted)
+		if err != nil {
+			return nil, errors.WithMessagef(err, "%s - failed to execute kubectl while parsing source directory (%s) for AddonsLayer %s",
+				logging.CallerStr(logging.Me), sourceDir, layer.GetName())
+		}
+
+		objs, err = a.decodeAddons(layer, output)
+		if err != nil {
+			return nil, errors.WithMessagef(err, "%s - failed to decode apply dry run output", logging.CallerStr(logging.Me))
+		}
+	}
+
+	if len(encrypted) > 0 {
+		decrypted, err := a.decryptManifests(layer, sourceDir, encrypted)
+		if err != nil {
+			return nil, errors.WithMessagef(err, "%s - failed to decrypt manifests in source directory (%s) for AddonsLayer %s",
+				logging.CallerStr(logging.Me), sourceDir, layer.GetName())
+		}
+		objs = append(objs, decrypted...)
+	}
+
+	if len(templates) > 0 {
+		rendered, err := a.renderTemplateFiles(layer, sourceDir, templates)
+		if err != nil {
+			return nil, errors.WithMessagef(err, "%s - failed to render templates in source directory (%s) for AddonsLayer %s",
+				logging.CallerStr(logging.Me), sourceDir, layer.GetName())
+		}
+		objs = append(objs, rendered...)
+	}
+	return objs, nil
+}
+
+// decryptManifests decrypts SOPS encrypted manifests in memory and decodes the resources they contain, using the
+// layer's decryption keys. The plaintext is never written to storage.
+func (a KubectlLayerApplier) decryptManifests(layer layers.Layer, sourceDir string, encrypted []string) (objs []runtime.Object, err error) {
+	logging.TraceCall(a.getLog(layer))
+	defer logging.TraceExit(a.getLog(layer))
+	secrets, err := layer.GetDecryptionKeys()
+	if err != nil {
+		return nil, errors.WithMessagef(err, "%s - failed to get decryption keys", logging.CallerStr(logging.Me))
+	}
+	keys, err := sops.ParseKeys(secrets...)
+	if err != nil {
+		return nil, errors.WithMessagef(err, "%s - invalid decryption keys", logging.CallerStr(logging.Me))
+	}
+	if keys.IsEmpty() {
+		return nil, fmt.Errorf("source contains encrypted manifests: %s, but no decryption keys are available", strings.Join(encrypted, ", "))
+	}
+	for _, rel := range encrypted {
+		data, err := a.store.ReadFile(sourceDir + rel)
+		if err != nil {
+			return nil, errors.WithMessagef(err, "%s - failed to read file: %s", logging.CallerStr(logging.Me), rel)
+		}
+		plaintext, err := sops.Decrypt(data, keys)
+		if err != nil {
+			return nil, errors.WithMessagef(err, "%s - failed to decrypt file: %s", logging.CallerStr(logging.Me), rel)
+		}
+		decoded, err := a.decodeManifest(layer, plaintext)
+		if err != nil {
+			return nil, errors.WithMessagef(err, "%s - failed to decode decrypted file: %s", logging.CallerStr(logging.Me), rel)
+		}
+		objs = append(objs, decoded...)
+	}
+	return objs, nil
+}
+
+// renderTemplateFiles renders manifest templates in memory and decodes the resources they contain.
+func (a KubectlLayerApplier) renderTemplateFiles(layer layers.Layer, sourceDir string, files []string) (objs []runtime.Object, err error) {
+	logging.TraceCall(a.getLog(layer))
+	defer logging.TraceExit(a.getLog(layer))
+	data, err := a.getTemplateData(layer)
+	if err != nil {
+		return nil, errors.WithMessagef(err, "%s - failed to get template data", logging.CallerStr(logging.Me))
+	}
+	for _, rel := range files {
+		text, err := a.store.ReadFile(sourceDir + rel)
+		if err != nil {
+			return nil, errors.WithMessagef(err, "%s - failed to read file: %s", logging.CallerStr(logging.Me), rel)
+		}
+		rendered, err := templates.Render(rel, string(text), data)
+		if err != nil {
+			return nil, errors.Wrapf(err, "%s - failed to render template: %s", logging.CallerStr(logging.Me), rel)
+		}
+		decoded, err := a.decodeManifest(layer, []byte(rendered))
+		if err != nil {
+			return nil, errors.WithMessagef(err, "%s - failed to decode rendered template: %s", logging.CallerStr(logging.Me), rel)
+		}
+		objs = append(objs, decoded...)
+	}
+	return objs, nil
+}
+
+// decodeManifest decodes the resources in a multi-document yaml manifest. Namespaced resources that do not specify a
+// namespace are placed in the namespace the kubectl dry run would use, as they are not passed to kubectl.
+func (a KubectlLayerApplier) decodeManifest(layer layers.Layer, data []byte) (objs []runtime.Object, err error) {
+	dez := serializer.NewCodecFactory(a.scheme).UniversalDeserializer()
+	defaults, err := getHelmReleaseDefaults(layer)
+	if err != nil {
+		return nil, errors.WithMessagef(err, "%s - failed to get helm release defaults", logging.CallerStr(logging.Me))
+	}
+	namespace := ""
+	reader := utilyaml.NewYAMLReader(bufio.NewReader(bytes.NewReader(data)))
+	for {
+		doc, err := reader.Read()
+		if err == io.EOF {
+			break
+		}
+		if err != nil {
+			return nil, errors.Wrapf(err, "%s - failed to read manifest", logging.CallerStr(logging.Me))
+		}
+		if len(bytes.TrimSpace(doc)) == 0 {
+			continue
+		}
+		doc, err = a.withHelmReleaseDefaults(layer, doc, defaults)
+		if err != nil {
+			return nil, err
+		}
+		obj, gvk, err := dez.Decode(doc, nil, nil)
+		if err != nil {
+			return nil, errors.Wrapf(err, "%s - failed to decode manifest", logging.CallerStr(logging.Me))
+		}
+		mobj, err := apimeta.Accessor(obj)
+		if err != nil {
+			return nil, errors.Wrapf(err, "%s - failed to access object metadata", logging.CallerStr(logging.Me))
+		}
+		if mobj.GetNamespace() == "" {
+			mapping, err := a.getClient(layer).RESTMapper().RESTMapping(gvk.GroupKind(), gvk.Version)
+			if err != nil {
+				return nil, errors.Wrapf(err, "%s - failed to get resource mapping for: %s", logging.CallerStr(logging.Me), gvk)
+			}
+			if mapping.Scope.Name() == apimeta.RESTScopeNameNamespace {
+				if namespace == "" {
+					if namespace, err = a.defaultNamespace(layer); err != nil {
+						return nil, err
+					}
+				}
+				mobj.SetNamespace(namespace)
+			}
+		}
+		a.logDebug("decoded Kubernetes object", layer, logging.GetObjKindNamespaceName(obj)...)
+		objs = append(objs, obj)
+	}
 	return objs, nil
 }
 
+// defaultNamespace returns the namespace kubectl uses for resources that do not specify one, the namespace of the
+// kubeconfig's current context or, when running in a pod without a kubeconfig, the pod's namespace.
+func (a KubectlLayerApplier) defaultNamespace(layer layers.Layer) (string, error) {
+	rules := clientcmd.NewDefaultClientConfigLoadingRules()
+	if cluster := layer.GetCluster(); cluster != nil && cluster.KubeConfigPath != "" {
+		rules.ExplicitPath = cluster.KubeConfigPath
+	}
+	namespace, _, err := clientcmd.NewNonInteractiveDeferredLoadingClientConfig(rules, &clientcmd.ConfigOverrides{}).Namespace()
+	if err != nil {
+		return "", errors.Wrapf(err, "%s - failed to get default namespace", logging.CallerStr(logging.Me))
+	}
+	return namespace, nil
+}
+
+// templateData is the context a layer's templates are rendered with.
+type templateData struct {
+	// Layer contains the layer's name, version, spec and revisions.
+	Layer templateLayer
+	// Dependencies are the versions of the layers the layer depends on, by name.
+	Dependencies map[string]string
+	// Cluster contains facts about the cluster the layer is applied to.
+	Cluster *layers.ClusterInfo
+	// Variables are the variables substituted in the layer's resources.
+	Variables map[string]string
+}
+
+type templateLayer struct {
+	Name             string
+	Version          string
+	SourceRevision   string
+	DeployedRevision string
+	Spec             *kraanv1alpha1.AddonsLayerSpec
+}
+
+func (a KubectlLayerApplier) getTemplateData(layer layers.Layer) (*templateData, error) {
+	cluster, err := layer.GetClusterInfo()
+	if err != nil {
+		return nil, errors.WithMessagef(err, "%s - failed to get cluster info", logging.CallerStr(logging.Me))
+	}
+	variables, err := layer.GetVariables()
+	if err != nil {
+		return nil, errors.WithMessagef(err, "%s - failed to get variables", logging.CallerStr(logging.Me))
+	}
+	return &templateData{
+		Layer: templateLayer{
+			Name:             layer.GetName(),
+			Version:          layer.GetSpec().Version,
+			SourceRevision:   layer.GetSourceRevision(),
+			DeployedRevision: layer.GetFullStatus().DeployedRevision,
+			Spec:             layer.GetSpec(),
+		},
+		Dependencies: layer.GetDependencyVersions(),
+		Cluster:      cluster,
+		Variables:    variables,
+	}, nil
+}
+
+// renderTemplates renders the template actions in the string values of resources with the template annotation set to
+// true. This is done before variables are substituted.
+func (a KubectlLayerApplier) renderTemplates(layer layers.Layer, sourceObjs [][]runtime.Object) error {
+	logging.TraceCall(a.getLog(layer))
+	defer logging.TraceExit(a.getLog(layer))
+	var data *templateData
+	for _, objs := range sourceObjs {
+		for index, robj := range objs {
+			obj, ok := robj.(metav1.Object)
+			if !ok || obj.GetAnnotations()[templateAnnotation] != "true" {
+				continue
+			}
+			content, err := runtime.DefaultUnstructuredConverter.ToUnstructured(robj)
+			if err != nil {
+				return errors.Wrapf(err, "%s - failed to convert %s to unstructured", logging.CallerStr(logging.Me), getObjKindLabel(robj))
+			}
+			if data == nil {
+				if data, err = a.getTemplateData(layer); err != nil {
+					return errors.WithMessagef(err, "%s - failed to get template data", logging.CallerStr(logging.Me))
+				}
+			}
+			changed, err := templates.Object(content, getObjKindLabel(robj), data)
+			if err != nil {
+				return errors.Wrapf(err, "%s - failed to render templates in %s", logging.CallerStr(logging.Me), getObjKindLabel(robj))
+			}
+			if !changed {
+				continue
+			}
+			rendered, ok := reflect.New(reflect.TypeOf(robj).Elem()).Interface().(runtime.Object)
+			if !ok {
+				return fmt.Errorf("failed to create object of type %T", robj)
+			}
+			if err := runtime.DefaultUnstructuredConverter.FromUnstructured(content, rendered); err != nil {
+				return errors.Wrapf(err, "%s - failed to convert %s from unstructured", logging.CallerStr(logging.Me), getObjKindLabel(robj))
+			}
+			a.logDebug("rendered templates", layer, logging.GetObjKindNamespaceName(rendered)...)
+			objs[index] = rendered
+		}
+	}
+	return nil
+}
+
+// mergeSourceResources merges the resources from each of a layer's sources, in order. A resource with the same kind,
+// namespace and name as one from an earlier source replaces it, in the position of the earlier resource.
+func mergeSourceResources(sourceObjs [][]runtime.Object) []runtime.Object {
+	objs := []runtime.Object{}
+	index := map[string]int{}
+	for _, pathObjs := range sourceObjs {
+		for _, obj := range pathObjs {
+			key := getObjKindLabel(obj)
+			if i, ok := index[key]; ok {
+				objs[i] = obj
+				continue
+			}
+			index[key] = len(objs)
+			objs = append(objs, obj)
+		}
+	}
+	return objs
+}
+
 func (a KubectlLayerApplier) getSourceHelmReleases(layer layers.Layer) (hrs map[string]*helmctlv2.HelmRelease, err error) {
 	logging.TraceCall(a.getLog(layer))
 	defer logging.TraceExit(a.getLog(layer))
@@ -502,13 +1228,6 @@ func (a KubectlLayerApplier) getSourceHelmReleases(layer layers.Layer) (hrs map[
 		return nil, errors.WithMessagef(err, "%s - failed to decode helm releases", logging.CallerStr(logging.Me))
 	}
 
-	// Check for kraan.updateVersion annotation
-	for _, source := range sourceHrs {
-		if err := a.processUpdateVersionAnnotation(layer, source); err != nil {
-			return nil, errors.WithMessagef(err, "%s - failed to process updateVersion annotation", logging.CallerStr(logging.Me))
-		}
-	}
-
 	hrs = map[string]*helmctlv2.HelmRelease{}
 	for _, hr := range sourceHrs {
 		hrs[getLabel(hr.ObjectMeta)] = hr
@@ -533,6 +1252,16 @@ func (a KubectlLayerApplier) getSourceHelmRepos(layer layers.Layer) (hrs []*sour
 	return hrs, nil
 }
 
+func (a KubectlLayerApplier) getSourceSecrets(layer layers.Layer) (secrets []*corev1.Secret, err error) {
+	logging.TraceCall(a.getLog(layer))
+	defer logging.TraceExit(a.getLog(layer))
+	objs, err := a.getSourceResources(layer)
+	if err != nil {
+		return nil, errors.WithMessagef(err, "%s - failed to get source secrets", logging.CallerStr(logging.Me))
+	}
+	return a.decodeSecrets(layer, objs), nil
+}
+
 // GetSourceAndClusterHelmReleases returns source and cluster helmreleases
 func (a KubectlLayerApplier) GetSourceAndClusterHelmReleases(ctx context.Context, layer layers.Layer) (sourceHrs, clusterHrs map[string]*helmctlv2.HelmRelease, err error) {
 	logging.TraceCall(a.getLog(layer))
@@ -556,6 +1285,17 @@ func (a KubectlLayerApplier) Apply(ctx context.Context, layer layers.Layer) (err
 	defer logging.TraceExit(a.getLog(layer))
 	a.logDebug("applying", layer)
 
+	// Secrets are applied first because HelmReleases may reference them in their valuesFrom.
+	secrets, err := a.getSourceSecrets(layer)
+	if err != nil {
+		return errors.WithMessagef(err, "%s - failed to get source secrets", logging.CallerStr(logging.Me))
+	}
+
+	err = a.applySecretObjects(ctx, layer, secrets)
+	if err != nil {
+		return errors.WithMessagef(err, "%s - failed to apply secret objects", logging.CallerStr(logging.Me))
+	}
+
 	sourceHrs, err := a.getSourceHelmReleases(layer)
 	if err != nil {
 		return errors.WithMessagef(err, "%s - failed to get source helm releases", logging.CallerStr(logging.Me))
@@ -587,6 +1327,15 @@ func layerOwner(obj metav1.Object) string {
 	return ""
 }
 
+// clusterLayerOwner returns the name of the AddonsLayer owning an object in the cluster a layer is applied to. Objects in
+// remote clusters have no owner reference, their owner label is used instead.
+func clusterLayerOwner(layer layers.Layer, obj metav1.Object) string {
+	if layer.GetCluster().IsRemote() {
+		return obj.GetLabels()[ownerLabel]
+	}
+	return layerOwner(obj)
+}
+
 func changeOwner(layer layers.Layer, hr *helmctlv2.HelmRelease) {
 	for index, owner := range hr.OwnerReferences {
 		if owner.Kind == "AddonsLayer" && owner.APIVersion == "kraan.io/v1alpha1" && owner.Name != layer.GetName() {
@@ -620,7 +1369,7 @@ func labelValue(labelName string, obj *metav1.Object) string {
 	return ""
 }
 
-func (a KubectlLayerApplier) orphanLabel(ctx context.Context, hr *helmctlv2.HelmRelease) (*metav1.Time, error) {
+func (a KubectlLayerApplier) orphanLabel(ctx context.Context, layer layers.Layer, hr *helmctlv2.HelmRelease) (*metav1.Time, error) {
 	labels := hr.GetLabels()
 	for label, value := range labels {
 		if label == orphanedLabel {
@@ -635,7 +1384,7 @@ func (a KubectlLayerApplier) orphanLabel(ctx context.Context, hr *helmctlv2.Helm
 	now := metav1.Now()
 	labels[orphanedLabel] = strings.ReplaceAll(now.UTC().Format(time.RFC3339), ":", ".")
 	hr.SetLabels(labels)
-	err := a.client.Update(ctx, hr, &client.UpdateOptions{})
+	err := a.getClient(layer).Update(ctx, hr, &client.UpdateOptions{})
 	if err != nil {
 		return nil, errors.Wrapf(err, "%s - failed to Update helmRelease '%s'", logging.CallerStr(logging.Me), getObjLabel(hr))
 	}
@@ -658,7 +1407,7 @@ func (a KubectlLayerApplier) Adopt(ctx context.Context, layer layers.Layer, hr *
 
 	changeOwner(layer, hr)
 
-	err := a.client.Update(ctx, hr, &client.UpdateOptions{})
+	err := a.getClient(layer).Update(ctx, hr, &client.UpdateOptions{})
 	if err != nil {
 		return errors.Wrapf(err, "%s - failed to Update helmRelease '%s'", logging.CallerStr(logging.Me), getObjLabel(hr))
 	}
@@ -675,7 +1424,7 @@ func (a KubectlLayerApplier) Orphan(ctx context.Context, layer layers.Layer, hr
 	// label HR to as orphan if not already labelled, set label value to time now.
 	key := client.ObjectKeyFromObject(hr)
 	existing := hr.DeepCopyObject()
-	err = a.client.Get(ctx, key, existing.(client.Object))
+	err = a.getClient(layer).Get(ctx, key, existing.(client.Object))
 	if err != nil {
 		if k8serrors.IsNotFound(err) {
 			removeResourceVersion(hr)
@@ -689,12 +1438,12 @@ func (a KubectlLayerApplier) Orphan(ctx context.Context, layer layers.Layer, hr
 		return false, fmt.Errorf("failed to convert runtime.Object to HelmRelease")
 	}
 
-	if layer.GetName() != layerOwner(theHr) {
+	if layer.GetName() != clusterLayerOwner(layer, theHr) {
 		a.logDebug("Layer no longer owns HelmRelease", layer, logging.GetObjKindNamespaceName(hr)...)
 		return false, nil
 	}
 
-	orphanedTime, err := a.orphanLabel(ctx, theHr)
+	orphanedTime, err := a.orphanLabel(ctx, layer, theHr)
 	if err != nil {
 		return false, errors.WithMessagef(err, "%s - failed to get/set orphan label '%s'", logging.CallerStr(logging.Me), getObjLabel(hr))
 	}
@@ -702,20 +1451,207 @@ func (a KubectlLayerApplier) Orphan(ctx context.Context, layer layers.Layer, hr
 	return orphanedTime.Add(layer.GetDelay()).After(time.Now()), nil
 }
 
-// Prune the AddonsLayer by removing the Addons found in the cluster that have since been removed from the Layer.
+// Prune the AddonsLayer by removing the Addons found in the cluster that have since been removed from the Layer. The
+// Secrets owned by the layer that have been removed from its source are also removed.
 func (a KubectlLayerApplier) Prune(ctx context.Context, layer layers.Layer, pruneHrs []*helmctlv2.HelmRelease) (err error) {
 	logging.TraceCall(a.getLog(layer))
 	defer logging.TraceExit(a.getLog(layer))
 	for _, hr := range pruneHrs {
-		err := a.client.Delete(ctx, hr, client.PropagationPolicy(metav1.DeletePropagationBackground))
+		err := a.getClient(layer).Delete(ctx, hr, client.PropagationPolicy(metav1.DeletePropagationBackground))
 		if err != nil {
 			return errors.Wrapf(err, "%s - unable to delete HelmRelease '%s' for AddonsLayer '%s'",
 				logging.CallerStr(logging.Me), getLabel(hr.ObjectMeta), layer.GetName())
 		}
 	}
+	pruneSecrets, err := a.getPruneSecrets(ctx, layer)
+	if err != nil {
+		return errors.WithMessagef(err, "%s - failed to get secrets to prune", logging.CallerStr(logging.Me))
+	}
+	for _, secret := range pruneSecrets {
+		if err := a.getClient(layer).Delete(ctx, secret); client.IgnoreNotFound(err) != nil {
+			return errors.Wrapf(err, "%s - unable to delete Secret '%s' for AddonsLayer '%s'",
+				logging.CallerStr(logging.Me), getLabel(secret.ObjectMeta), layer.GetName())
+		}
+	}
+	return nil
+}
+
+// DeleteAll removes the HelmReleases, HelmRepositories and Secrets owned by a layer from the cluster it is applied to. This is only
+// required for layers applied to other clusters, where the garbage collector cannot resolve the owner references.
+func (a KubectlLayerApplier) DeleteAll(ctx context.Context, layer layers.Layer) (err error) {
+	logging.TraceCall(a.getLog(layer))
+	defer logging.TraceExit(a.getLog(layer))
+	hrs, err := a.GetHelmReleases(ctx, layer)
+	if err != nil {
+		return errors.WithMessagef(err, "%s - failed to get helm releases", logging.CallerStr(logging.Me))
+	}
+	for _, hr := range hrs {
+		if err := a.getClient(layer).Delete(ctx, hr, client.PropagationPolicy(metav1.DeletePropagationBackground)); client.IgnoreNotFound(err) != nil {
+			return errors.Wrapf(err, "%s - unable to delete HelmRelease '%s' for AddonsLayer '%s'",
+				logging.CallerStr(logging.Me), getLabel(hr.ObjectMeta), layer.GetName())
+		}
+	}
+	repos, err := a.getHelmRepos(ctx, layer)
+	if err != nil {
+		return errors.WithMessagef(err, "%s - failed to get helm repositories", logging.CallerStr(logging.Me))
+	}
+	for _, repo := range repos {
+		if err := a.getClient(layer).Delete(ctx, repo, client.PropagationPolicy(metav1.DeletePropagationBackground)); client.IgnoreNotFound(err) != nil {
+			return errors.Wrapf(err, "%s - unable to delete HelmRepository '%s' for AddonsLayer '%s'",
+				logging.CallerStr(logging.Me), getLabel(repo.ObjectMeta), layer.GetName())
+		}
+	}
+	secrets, err := a.getSecrets(ctx, layer)
+	if err != nil {
+		return errors.WithMessagef(err, "%s - failed to get secrets", logging.CallerStr(logging.Me))
+	}
+	for _, secret := range secrets {
+		if err := a.getClient(layer).Delete(ctx, secret); client.IgnoreNotFound(err) != nil {
+			return errors.Wrapf(err, "%s - unable to delete Secret '%s' for AddonsLayer '%s'",
+				logging.CallerStr(logging.Me), getLabel(secret.ObjectMeta), layer.GetName())
+		}
+	}
 	return nil
 }
 
+// PublishOutputs reads the values of the layer's outputs from the cluster it is applied to and publishes them in a
+// ConfigMap, owned by the layer, in the controller's namespace. The ConfigMap is deleted if the layer has no outputs.
+func (a KubectlLayerApplier) PublishOutputs(ctx context.Context, layer layers.Layer) error {
+	logging.TraceCall(a.getLog(layer))
+	defer logging.TraceExit(a.getLog(layer))
+	configMap := &corev1.ConfigMap{ObjectMeta: metav1.ObjectMeta{
+		Name:      common.GetOutputsConfigMapName(layer.GetName()),
+		Namespace: common.GetRuntimeNamespace(),
+	}}
+	if len(layer.GetSpec().Outputs) == 0 {
+		if err := a.client.Delete(ctx, configMap); client.IgnoreNotFound(err) != nil {
+			return errors.Wrapf(err, "%s - unable to delete outputs ConfigMap for AddonsLayer '%s'", logging.CallerStr(logging.Me), layer.GetName())
+		}
+		layer.SetOutputs(nil)
+		return nil
+	}
+	outputs := map[string]string{}
+	for _, output := range layer.GetSpec().Outputs {
+		value, found, err := a.getOutputValue(ctx, layer, output)
+		if err != nil {
+			return errors.WithMessagef(err, "%s - failed to get output: %s", logging.CallerStr(logging.Me), output.Name)
+		}
+		if !found {
+			if output.Optional {
+				continue
+			}
+			return fmt.Errorf("%s - output: %s, not found in %s: %s", logging.CallerStr(logging.Me), output.Name,
+				output.ObjectRef.Kind, types.NamespacedName{Namespace: output.ObjectRef.Namespace, Name: output.ObjectRef.Name})
+		}
+		outputs[output.Name] = value
+	}
+	result, err := controllerutil.CreateOrUpdate(ctx, a.client, configMap, func() error {
+		configMap.Labels = mergeStringMaps(configMap.Labels, map[string]string{ownerLabel: layer.GetName()})
+		configMap.Data = outputs
+		return controllerutil.SetControllerReference(layer.GetAddonsLayer(), configMap, a.scheme)
+	})
+	if err != nil {
+		return errors.Wrapf(err, "%s - unable to publish outputs ConfigMap for AddonsLayer '%s'", logging.CallerStr(logging.Me), layer.GetName())
+	}
+	if result != controllerutil.OperationResultNone {
+		a.logInfo("published AddonsLayer outputs", layer, "configMap", getLabel(configMap.ObjectMeta), "operation", result)
+	}
+	layer.SetOutputs(outputs)
+	return nil
+}
+
+// getOutputValue returns the value of a layer output, false if the object or field is not found. The object must be in
+// a namespace the layer is allowed to deploy addons to and have been deployed by the layer, a Forbidden error is
+// returned otherwise.
+func (a KubectlLayerApplier) getOutputValue(ctx context.Context, layer layers.Layer, output kraanv1alpha1.LayerOutput) (string, bool, error) {
+	apiVersion := output.ObjectRef.APIVersion
+	if apiVersion == "" {
+		apiVersion = "v1"
+	}
+	obj := &unstructured.Unstructured{}
+	obj.SetAPIVersion(apiVersion)
+	obj.SetKind(output.ObjectRef.Kind)
+	obj.SetNamespace(output.ObjectRef.Namespace)
+	obj.SetName(output.ObjectRef.Name)
+	if err := a.checkNamespace(layer, obj, obj); err != nil {
+		return "", false, err
+	}
+	key := types.NamespacedName{Namespace: output.ObjectRef.Namespace, Name: output.ObjectRef.Name}
+	if err := a.getClient(layer).Get(ctx, key, obj); err != nil {
+		if k8serrors.IsNotFound(err) {
+			return "", false, nil
+		}
+		return "", false, errors.Wrapf(err, "%s - failed to get %s: %s", logging.CallerStr(logging.Me), output.ObjectRef.Kind, key)
+	}
+	deployed, err := a.isLayerObject(ctx, layer, obj)
+	if err != nil {
+		return "", false, err
+	}
+	if !deployed {
+		resource, _ := apimeta.UnsafeGuessKindToResource(obj.GroupVersionKind())
+		return "", false, k8serrors.NewForbidden(resource.GroupResource(), key.String(),
+			fmt.Errorf("layer: %s, can only read outputs from objects it deployed", layer.GetName()))
+	}
+	return jsonPathValue(obj.Object, output.JSONPath)
+}
+
+// isLayerObject returns true if an object was deployed by the layer, it is owned by the layer or was deployed by one of
+// the layer's HelmReleases, identified by the labels the helm-controller adds to the objects it deploys.
+func (a KubectlLayerApplier) isLayerObject(ctx context.Context, layer layers.Layer, obj metav1.Object) (bool, error) {
+	if clusterLayerOwner(layer, obj) == layer.GetName() || obj.GetLabels()[ownerLabel] == layer.GetName() {
+		return true, nil
+	}
+	name, namespace := obj.GetLabels()[helmReleaseNameLabel], obj.GetLabels()[helmReleaseNamespaceLabel]
+	if name == "" || namespace == "" {
+		return false, nil
+	}
+	hr := &helmctlv2.HelmRelease{}
+	if err := a.getClient(layer).Get(ctx, types.NamespacedName{Namespace: namespace, Name: name}, hr); err != nil {
+		if k8serrors.IsNotFound(err) {
+			return false, nil
+		}
+		return false, errors.Wrapf(err, "%s - failed to get HelmRelease: %s/%s", logging.CallerStr(logging.Me), namespace, name)
+	}
+	return clusterLayerOwner(layer, hr) == layer.GetName(), nil
+}
+
+// jsonPathValue returns the value of the fields of an unstructured object selected by a JSONPath expression, false if
+// no field is selected. Strings are returned as is, other values as JSON, multiple values are separated by spaces.
+func jsonPathValue(obj map[string]interface{}, expression string) (string, bool, error) {
+	if !strings.HasPrefix(strings.TrimSpace(expression), "{") {
+		expression = fmt.Sprintf("{%s}", expression)
+	}
+	parser := jsonpath.New("output").AllowMissingKeys(true)
+	if err := parser.Parse(expression); err != nil {
+		return "", false, errors.Wrapf(err, "%s - invalid JSONPath: %s", logging.CallerStr(logging.Me), expression)
+	}
+	results, err := parser.FindResults(obj)
+	if err != nil {
+		return "", false, errors.Wrapf(err, "%s - failed to evaluate JSONPath: %s", logging.CallerStr(logging.Me), expression)
+	}
+	values := []string{}
+	for _, result := range results {
+		for _, value := range result {
+			if !value.IsValid() || !value.CanInterface() {
+				continue
+			}
+			if text, ok := value.Interface().(string); ok {
+				values = append(values, text)
+				continue
+			}
+			data, err := json.Marshal(value.Interface())
+			if err != nil {
+				return "", false, errors.Wrapf(err, "%s - failed to format value of JSONPath: %s", logging.CallerStr(logging.Me), expression)
+			}
+			values = append(values, string(data))
+		}
+	}
+	if len(values) == 0 {
+		return "", false, nil
+	}
+	return strings.Join(values, " "), true, nil
+}
+
 // getResourceInfo updates a resource object with details from object on cluster
 func (a KubectlLayerApplier) getResourceInfo(layer layers.Layer, resource kraanv1alpha1.Resource, conditions []metav1.Condition) kraanv1alpha1.Resource {
 	logging.TraceCall(a.getLog(layer))
@@ -818,9 +1754,229 @@ func (a KubectlLayerApplier) PruneIsRequired(ctx context.Context, layer layers.L
 		}
 	}
 
+	pruneSecrets, err := a.getPruneSecrets(ctx, layer)
+	if err != nil {
+		return false, nil, errors.WithMessagef(err, "%s - failed to get secrets to prune", logging.CallerStr(logging.Me))
+	}
+	if len(pruneSecrets) > 0 {
+		pruneRequired = true
+	}
+
 	return pruneRequired, pruneHrs, nil
 }
 
+// getPruneSecrets returns the Secrets owned by the layer on the cluster that are not in the layer's source.
+func (a KubectlLayerApplier) getPruneSecrets(ctx context.Context, layer layers.Layer) (pruneSecrets []*corev1.Secret, err error) {
+	logging.TraceCall(a.getLog(layer))
+	defer logging.TraceExit(a.getLog(layer))
+	sourceSecrets, err := a.getSourceSecrets(layer)
+	if err != nil {
+		return nil, errors.WithMessagef(err, "%s - failed to get source secrets", logging.CallerStr(logging.Me))
+	}
+	inSource := map[string]bool{}
+	for _, secret := range sourceSecrets {
+		inSource[getLabel(secret.ObjectMeta)] = true
+	}
+	clusterSecrets, err := a.getSecrets(ctx, layer)
+	if err != nil {
+		return nil, errors.WithMessagef(err, "%s - failed to get secrets", logging.CallerStr(logging.Me))
+	}
+	for _, secret := range clusterSecrets {
+		if !inSource[getLabel(secret.ObjectMeta)] {
+			a.logDebug("pruned Secret for AddonsLayer in KubeAPI but not in source directory", layer, logging.GetObjKindNamespaceName(secret)...)
+			pruneSecrets = append(pruneSecrets, secret)
+		}
+	}
+	return pruneSecrets, nil
+}
+
+// getHelmReleaseDefaults returns the layer's HelmRelease spec defaults as an unstructured map, nil if it has none.
+func getHelmReleaseDefaults(layer layers.Layer) (map[string]interface{}, error) {
+	spec := layer.GetSpec().HelmReleaseDefaults
+	if spec == nil || len(spec.Raw) == 0 {
+		return nil, nil
+	}
+	defaults := map[string]interface{}{}
+	if err := json.Unmarshal(spec.Raw, &defaults); err != nil {
+		return nil, errors.Wrapf(err, "%s - failed to parse helmReleaseDefaults", logging.CallerStr(logging.Me))
+	}
+	return defaults, nil
+}
+
+// withHelmReleaseDefaults returns the manifest of a resource with the layer's helmReleaseDefaults applied if it is a
+// HelmRelease. The defaults are applied before the resource is decoded, so fields explicitly set to their zero value in
+// the source, such as false or 0, are not replaced.
+func (a KubectlLayerApplier) withHelmReleaseDefaults(layer layers.Layer, data []byte, defaults map[string]interface{}) ([]byte, error) {
+	if len(defaults) == 0 {
+		return data, nil
+	}
+	result, changed, err := setHelmReleaseDefaults(data, defaults)
+	if err != nil {
+		return nil, errors.WithMessagef(err, "%s - failed to apply helm release defaults", logging.CallerStr(logging.Me))
+	}
+	if changed {
+		a.logDebug("applied helm release defaults", layer)
+	}
+	return result, nil
+}
+
+// setHelmReleaseDefaults sets the fields of a HelmRelease's spec that are not set in its yaml or json manifest to the
+// defaults, returning the updated manifest as json and true if it was changed. Other resources are returned unchanged.
+func setHelmReleaseDefaults(data []byte, defaults map[string]interface{}) ([]byte, bool, error) {
+	jsonData, err := utilyaml.ToJSON(data)
+	if err != nil {
+		return nil, false, errors.Wrapf(err, "%s - failed to convert manifest to json", logging.CallerStr(logging.Me))
+	}
+	decoder := json.NewDecoder(bytes.NewReader(jsonData))
+	decoder.UseNumber()
+	content := map[string]interface{}{}
+	if err := decoder.Decode(&content); err != nil {
+		return nil, false, errors.Wrapf(err, "%s - failed to parse manifest", logging.CallerStr(logging.Me))
+	}
+	apiVersion, _ := content["apiVersion"].(string) //nolint:errcheck // ok
+	if content["kind"] != helmctlv2.HelmReleaseKind || !strings.HasPrefix(apiVersion, helmctlv2.GroupVersion.Group+"/") {
+		return data, false, nil
+	}
+	spec, ok := content["spec"].(map[string]interface{})
+	if !ok {
+		spec = map[string]interface{}{}
+		content["spec"] = spec
+	}
+	if !setUnsetFields(spec, defaults) {
+		return data, false, nil
+	}
+	result, err := json.Marshal(content)
+	if err != nil {
+		return nil, false, errors.Wrapf(err, "%s - failed to convert HelmRelease to json", logging.CallerStr(logging.Me))
+	}
+	return result, true, nil
+}
+
+// setUnsetFields copies the values in defaults to the fields of target that are not set, recursing into maps. Fields
+// that are set, even to false, 0 or an empty string, are not changed. It returns true if target was changed.
+func setUnsetFields(target, defaults map[string]interface{}) (changed bool) {
+	for key, value := range defaults {
+		current, found := target[key]
+		if found && current != nil {
+			defaultMap, defaultIsMap := value.(map[string]interface{})
+			currentMap, currentIsMap := current.(map[string]interface{})
+			if defaultIsMap && currentIsMap {
+				changed = setUnsetFields(currentMap, defaultMap) || changed
+			}
+			continue
+		}
+		target[key] = runtime.DeepCopyJSONValue(value)
+		changed = true
+	}
+	return changed
+}
+
+// setHelmReleaseValues merges the layer's values into its HelmReleases and processes their kraan.updateVersion
+// annotation. This is done before the layer's policies are checked so they are checked against the values applied.
+func (a KubectlLayerApplier) setHelmReleaseValues(layer layers.Layer, objs []runtime.Object) error {
+	var values map[string]interface{}
+	valuesRead := false
+	for _, obj := range objs {
+		source, ok := obj.(*helmctlv2.HelmRelease)
+		if !ok {
+			continue
+		}
+		if !valuesRead {
+			var err error
+			values, err = layer.GetValues()
+			if err != nil {
+				return errors.WithMessagef(err, "%s - failed to get layer values", logging.CallerStr(logging.Me))
+			}
+			valuesRead = true
+		}
+		if err := a.mergeLayerValues(layer, source, values); err != nil {
+			return errors.WithMessagef(err, "%s - failed to merge layer values", logging.CallerStr(logging.Me))
+		}
+		// Check for kraan.updateVersion annotation
+		if err := a.processUpdateVersionAnnotation(layer, source); err != nil {
+			return errors.WithMessagef(err, "%s - failed to process updateVersion annotation", logging.CallerStr(logging.Me))
+		}
+	}
+	return nil
+}
+
+// mergeLayerValues deep merges the HelmRelease's values into the layer's values, so values set by the HelmRelease take
+// precedence, and adds the Secrets referenced by the layer's valuesFrom to the start of the HelmRelease's valuesFrom so
+// the helm-controller reads them rather than their values being copied into the HelmRelease. HelmReleases with the
+// kraan.layerValues annotation set to false are not changed.
+func (a KubectlLayerApplier) mergeLayerValues(layer layers.Layer, source *helmctlv2.HelmRelease, layerValues map[string]interface{}) error {
+	if source.GetAnnotations()[layerValuesAnnotation] == "false" {
+		a.logDebug("HelmRelease excluded from layer values", layer, logging.GetObjKindNamespaceName(source)...)
+		return nil
+	}
+	secretRefs, err := a.getValuesSecretRefs(layer, source)
+	if err != nil {
+		return err
+	}
+	if len(layerValues) == 0 && len(secretRefs) == 0 {
+		return nil
+	}
+	if len(secretRefs) > 0 {
+		source.Spec.ValuesFrom = append(secretRefs, source.Spec.ValuesFrom...)
+	}
+	if len(layerValues) == 0 {
+		return nil
+	}
+	// Round trip the layer values through json so each HelmRelease gets its own copy.
+	layerJSON, err := json.Marshal(layerValues)
+	if err != nil {
+		return errors.WithMessagef(err, "%s - failed convert layer values to json string", logging.CallerStr(logging.Me))
+	}
+	values := make(map[string]interface{})
+	if err := json.Unmarshal(layerJSON, &values); err != nil {
+		return errors.WithMessagef(err, "%s - failed convert layer values from json string", logging.CallerStr(logging.Me))
+	}
+	if source.Spec.Values != nil {
+		hrValues := make(map[string]interface{})
+		if err := json.Unmarshal(source.Spec.Values.Raw, &hrValues); err != nil {
+			return errors.WithMessagef(err, "%s - failed convert values to json string", logging.CallerStr(logging.Me))
+		}
+		values = common.MergeValues(values, hrValues)
+	}
+	newJSON, err := json.Marshal(values)
+	if err != nil {
+		return errors.WithMessagef(err, "%s - failed convert merged values to json string", logging.CallerStr(logging.Me))
+	}
+	source.Spec.Values = &apiextensionsv1.JSON{Raw: newJSON}
+	a.logDebug("merged layer values", layer, logging.GetObjKindNamespaceName(source)...)
+	return nil
+}
+
+// getValuesSecretRefs returns the Secrets referenced by the layer's valuesFrom as HelmRelease values references. The
+// helm-controller reads them from the HelmRelease's namespace, so Secrets in other namespaces are skipped. The
+// helm-controller of a remote cluster cannot read Secrets in the cluster the layer is in, so they are an error.
+func (a KubectlLayerApplier) getValuesSecretRefs(layer layers.Layer, source *helmctlv2.HelmRelease) ([]helmctlv2.ValuesReference, error) {
+	refs := []helmctlv2.ValuesReference{}
+	for _, ref := range layer.GetSpec().ValuesFrom {
+		if ref.Kind != "Secret" {
+			continue
+		}
+		if layer.GetCluster().IsRemote() {
+			return nil, fmt.Errorf("%s - values Secret: %s, cannot be read by the helm-controller of remote cluster: %s", logging.CallerStr(logging.Me),
+				ref.Name, layer.GetCluster().Key)
+		}
+		namespace := common.GetSourceNamespace(ref.Namespace)
+		if namespace != source.GetNamespace() {
+			a.logDebug("values Secret not in HelmRelease namespace", layer, append(logging.GetObjKindNamespaceName(source),
+				"secretNamespace", namespace, "secretName", ref.Name)...)
+			continue
+		}
+		refs = append(refs, helmctlv2.ValuesReference{
+			Kind:       ref.Kind,
+			Name:       ref.Name,
+			ValuesKey:  ref.ValuesKey,
+			TargetPath: ref.TargetPath,
+			Optional:   ref.Optional,
+		})
+	}
+	return refs, nil
+}
+
 // processUpdateVersionAnnotation checks for kraan.updateVersion annotation set to true and updates values to include current version.
 func (a KubectlLayerApplier) processUpdateVersionAnnotation(layer layers.Layer, source *helmctlv2.HelmRelease) error {
 	annotations := source.GetAnnotations()
@@ -873,7 +2029,74 @@ func (a KubectlLayerApplier) ApplyIsRequired(ctx context.Context, layer layers.L
 			return true, nil
 		}
 	}
-	return a.helmReposApplyRequired(ctx, layer)
+	applyIsRequired, err = a.helmReposApplyRequired(ctx, layer)
+	if err != nil || applyIsRequired {
+		return applyIsRequired, err
+	}
+	return a.secretsApplyRequired(ctx, layer)
+}
+
+// secretsApplyRequired returns true if any Secret in the layer's source is missing from the cluster or differs from the
+// Secret on the cluster.
+func (a KubectlLayerApplier) secretsApplyRequired(ctx context.Context, layer layers.Layer) (applyIsRequired bool, err error) {
+	logging.TraceCall(a.getLog(layer))
+	defer logging.TraceExit(a.getLog(layer))
+	sourceSecrets, err := a.getSourceSecrets(layer)
+	if err != nil {
+		return false, errors.WithMessagef(err, "%s - failed to get source secrets", logging.CallerStr(logging.Me))
+	}
+
+	for _, source := range sourceSecrets {
+		found := &corev1.Secret{}
+		err := a.getClient(layer).Get(ctx, client.ObjectKeyFromObject(source), found)
+		if err != nil {
+			if k8serrors.IsNotFound(err) {
+				a.logDebug("found new Secret in AddonsLayer source directory", layer, logging.GetObjKindNamespaceName(source)...)
+				return true, nil
+			}
+			return false, errors.Wrapf(err, "%s - failed to get Secret '%s'", logging.CallerStr(logging.Me), getLabel(source.ObjectMeta))
+		}
+		if sourceHasSecretChanged(source, found) {
+			// Never log the secret data, only the fact that it changed.
+			a.logDebug("found change for Secret in AddonsLayer source directory", layer, logging.GetObjKindNamespaceName(source)...)
+			return true, nil
+		}
+	}
+	return false, nil
+}
+
+// sourceHasSecretChanged compares a source Secret with the Secret on the cluster. The API server merges stringData into
+// data so the source is compared in the same form.
+func sourceHasSecretChanged(source, found *corev1.Secret) bool {
+	data := map[string][]byte{}
+	for key, value := range source.Data {
+		data[key] = value
+	}
+	for key, value := range source.StringData {
+		data[key] = []byte(value)
+	}
+	foundData := found.Data
+	if foundData == nil {
+		foundData = map[string][]byte{}
+	}
+	sourceType := source.Type
+	if sourceType == "" {
+		sourceType = corev1.SecretTypeOpaque
+	}
+	return sourceType != found.Type || !reflect.DeepEqual(data, foundData) ||
+		!reflect.DeepEqual(source.ObjectMeta.Labels, found.ObjectMeta.Labels) ||
+		annotationsChanged(source.ObjectMeta.Annotations, found.ObjectMeta.Annotations)
+}
+
+// annotationsChanged returns true if any of the source annotations is missing or different on the cluster. Annotations
+// added on the cluster, for example by the Flux CLI, are ignored.
+func annotationsChanged(source, found map[string]string) bool {
+	for key, value := range source {
+		if foundValue, ok := found[key]; !ok || foundValue != value {
+			return true
+		}
+	}
+	return false
 }
 
 func (a KubectlLayerApplier) helmReposApplyRequired(ctx context.Context, layer layers.Layer) (applyIsRequired bool, err error) {
@@ -929,6 +2152,11 @@ func (a KubectlLayerApplier) sourceHasReleaseChanged(layer layers.Layer, source,
 			append(logging.GetObjKindNamespaceName(source), "label source", source.ObjectMeta.Labels, "label found", found.ObjectMeta.Labels)...)
 		return true
 	}
+	if annotationsChanged(source.ObjectMeta.Annotations, found.ObjectMeta.Annotations) {
+		a.logDebug("found annotation change for HelmRelease in AddonsLayer source directory", layer,
+			append(logging.GetObjKindNamespaceName(source), "annotation source", source.ObjectMeta.Annotations, "annotation found", found.ObjectMeta.Annotations)...)
+		return true
+	}
 	a.logTrace("found no changes for HelmRelease in AddonsLayer source directory", layer)
 	return false
 }
@@ -947,6 +2175,11 @@ func (a KubectlLayerApplier) sourceHasRepoChanged(layer layers.Layer, source, fo
 			append(logging.GetObjKindNamespaceName(source), "label source", source.ObjectMeta.Labels, "label found", found.ObjectMeta.Labels)...)
 		return true
 	}
+	if annotationsChanged(source.ObjectMeta.Annotations, found.ObjectMeta.Annotations) {
+		a.logDebug("found annotation change for HelmRepository in AddonsLayer source directory", layer,
+			append(logging.GetObjKindNamespaceName(source), "annotation source", source.ObjectMeta.Annotations, "annotation found", found.ObjectMeta.Annotations)...)
+		return true
+	}
 	a.logDebug("found no change for HelmRepositories in AddonsLayer source directory", layer)
 	return false
 }
@@ -0,0 +1,334 @@
+// Package clusters provides cached clients for the clusters AddonsLayers are applied to.
+//
+//go:generate mockgen -destination=../mocks/clusters/mockClusters.go -package=mocks -source=clusters.go . Clusters
+package clusters
+
+import (
+	"context"
+	"crypto/sha256"
+	"encoding/hex"
+	"fmt"
+	"os"
+	"sync"
+	"time"
+
+	"github.com/go-logr/logr"
+	"github.com/pkg/errors"
+	metav1 "k8s.io/apimachinery/pkg/apis/meta/v1"
+	"k8s.io/apimachinery/pkg/runtime"
+	"k8s.io/apimachinery/pkg/types"
+	"k8s.io/client-go/kubernetes"
+	"k8s.io/client-go/rest"
+	"k8s.io/client-go/tools/clientcmd"
+	"sigs.k8s.io/controller-runtime/pkg/client"
+	"sigs.k8s.io/controller-runtime/pkg/client/apiutil"
+
+	kraanv1alpha1 "github.com/fidelity/kraan/api/v1alpha1"
+	"github.com/fidelity/kraan/pkg/common"
+	"github.com/fidelity/kraan/pkg/logging"
+)
+
+// DefaultKubeConfigKey is the key of the kubeconfig in a secret if none is specified.
+const DefaultKubeConfigKey = "value"
+
+var (
+	// HealthCheckInterval is the minimum interval between checks that a cached cluster client can reach its API server.
+	HealthCheckInterval = time.Minute
+	// Timeout is the timeout of requests to a cluster's API server, used if the cluster's kubeconfig does not set one.
+	Timeout = 30 * time.Second
+)
+
+// Cluster holds the clients used to access a cluster AddonsLayers are applied to.
+type Cluster struct {
+	// Key is the namespace, name and key of the secret containing the cluster's kubeconfig.
+	Key string
+	// Client is a client for the cluster.
+	Client client.Client
+	// K8sClient is a clientset for the cluster.
+	K8sClient kubernetes.Interface
+	// KubeConfigPath is the path of a file containing the cluster's kubeconfig, for use by kubectl.
+	KubeConfigPath string
+	// ServiceAccount is the ServiceAccount impersonated by Client, if any.
+	ServiceAccount string
+	config         *rest.Config
+	base           *Cluster
+	checksum       string
+	checked        time.Time
+}
+
+// IsRemote returns true if the cluster is not the cluster the controller is running in. A nil cluster is the cluster
+// the controller is running in.
+func (c *Cluster) IsRemote() bool {
+	return c != nil && c.Key != ""
+}
+
+// Clusters provides cached clients for the clusters AddonsLayers are applied to.
+type Clusters interface {
+	Get(ctx context.Context, kubeConfig *kraanv1alpha1.KubeConfigSpec) (*Cluster, error)
+	Impersonate(cluster *Cluster, serviceAccount types.NamespacedName) (*Cluster, error)
+	Delete(key string)
+	Close()
+}
+
+// clustersData is the Clusters implementation.
+type clustersData struct {
+	Clusters `json:"-"`
+	sync.Mutex
+	config       *rest.Config
+	k8client     kubernetes.Interface
+	scheme       *runtime.Scheme
+	log          logr.Logger
+	dir          string
+	clusters     map[string]*Cluster
+	impersonated map[string]*Cluster
+}
+
+// NewClusters returns a Clusters object that reads kubeconfig secrets using the clientset provided and creates clients
+// using the scheme provided. The config and clientset provided are those of the cluster the controller is running in.
+func NewClusters(config *rest.Config, k8client kubernetes.Interface, scheme *runtime.Scheme, log logr.Logger) Clusters {
+	return &clustersData{
+		config:       config,
+		k8client:     k8client,
+		scheme:       scheme,
+		log:          log,
+		clusters:     map[string]*Cluster{},
+		impersonated: map[string]*Cluster{},
+	}
+}
+
+// GetServiceAccountUser returns the user name used to impersonate a ServiceAccount.
+func GetServiceAccountUser(serviceAccount types.NamespacedName) string {
+	return fmt.Sprintf("system:serviceaccount:%s:%s", serviceAccount.Namespace, serviceAccount.Name)
+}
+
+// GetKey returns the namespace, name and key of the secret containing a kubeconfig.
+func GetKey(kubeConfig *kraanv1alpha1.KubeConfigSpec) string {
+	key := kubeConfig.SecretRef.Key
+	if key == "" {
+		key = DefaultKubeConfigKey
+	}
+	return fmt.Sprintf("%s/%s/%s", common.GetSourceNamespace(kubeConfig.SecretRef.Namespace), kubeConfig.SecretRef.Name, key)
+}
+
+// Get returns the cluster described by a kubeconfig secret. The clients are cached and recreated if the kubeconfig
+// changes or the cluster's API server cannot be reached. The API server is checked without holding the cache lock, so
+// an unreachable cluster does not delay access to other clusters.
+func (c *clustersData) Get(ctx context.Context, kubeConfig *kraanv1alpha1.KubeConfigSpec) (*Cluster, error) {
+	logging.TraceCall(c.log)
+	defer logging.TraceExit(c.log)
+
+	key := GetKey(kubeConfig)
+	data, err := c.readKubeConfig(ctx, kubeConfig)
+	if err != nil {
+		return nil, err
+	}
+	hash := sha256.Sum256(data)
+	checksum := hex.EncodeToString(hash[:])
+
+	c.Lock()
+	cached, ok := c.clusters[key]
+	c.Unlock()
+	if ok && cached.checksum == checksum {
+		if time.Since(cached.checked) < HealthCheckInterval {
+			return cached, nil
+		}
+		err := healthCheck(cached)
+		if err == nil {
+			c.Lock()
+			cached.checked = time.Now()
+			c.Unlock()
+			return cached, nil
+		}
+		c.log.Info("cluster health check failed, recreating clients", append(logging.GetFunctionAndSource(logging.MyCaller), "cluster", key, "error", err.Error())...)
+	}
+
+	c.Lock()
+	cluster, err := c.newCluster(key, checksum, data)
+	c.Unlock()
+	if err != nil {
+		return nil, err
+	}
+	if err := healthCheck(cluster); err != nil {
+		c.Lock()
+		c.removeKubeConfig(cluster)
+		if current, ok := c.clusters[key]; ok && current == cached {
+			c.deleteCluster(key)
+		}
+		c.Unlock()
+		return nil, errors.WithMessagef(err, "%s - cluster: %s, is not reachable", logging.CallerStr(logging.Me), key)
+	}
+
+	c.Lock()
+	defer c.Unlock()
+	if current, ok := c.clusters[key]; ok && current != cached && current.checksum == checksum {
+		// Another reconcile recreated the clients while the health check was in progress.
+		c.removeKubeConfig(cluster)
+		return current, nil
+	}
+	c.deleteCluster(key)
+	c.log.V(1).Info("created cluster clients", append(logging.GetFunctionAndSource(logging.MyCaller), "cluster", key)...)
+	c.clusters[key] = cluster
+	return cluster, nil
+}
+
+// Impersonate returns a copy of a cluster, or of the cluster the controller is running in if passed nil, whose client
+// and clientset impersonate a ServiceAccount. The clients are cached until the cluster's clients are recreated.
+func (c *clustersData) Impersonate(cluster *Cluster, serviceAccount types.NamespacedName) (*Cluster, error) {
+	logging.TraceCall(c.log)
+	defer logging.TraceExit(c.log)
+
+	user := GetServiceAccountUser(serviceAccount)
+	config := c.config
+	key := user
+	if cluster != nil {
+		config = cluster.config
+		key = fmt.Sprintf("%s#%s", cluster.Key, user)
+	}
+
+	c.Lock()
+	defer c.Unlock()
+	if impersonated, ok := c.impersonated[key]; ok && impersonated.base == cluster {
+		return impersonated, nil
+	}
+
+	impersonatedConfig := rest.CopyConfig(config)
+	impersonatedConfig.Impersonate = rest.ImpersonationConfig{UserName: user}
+	mapper, err := apiutil.NewDynamicRESTMapper(impersonatedConfig, apiutil.WithLazyDiscovery)
+	if err != nil {
+		return nil, errors.Wrapf(err, "%s - failed to create rest mapper for service account: %s", logging.CallerStr(logging.Me), user)
+	}
+	k8sClient, err := client.New(impersonatedConfig, client.Options{Scheme: c.scheme, Mapper: mapper})
+	if err != nil {
+		return nil, errors.Wrapf(err, "%s - failed to create client for service account: %s", logging.CallerStr(logging.Me), user)
+	}
+	k8sClientset, err := kubernetes.NewForConfig(impersonatedConfig)
+	if err != nil {
+		return nil, errors.Wrapf(err, "%s - failed to create clientset for service account: %s", logging.CallerStr(logging.Me), user)
+	}
+	impersonated := &Cluster{
+		Client:         k8sClient,
+		K8sClient:      k8sClientset,
+		ServiceAccount: user,
+		config:         impersonatedConfig,
+		base:           cluster,
+	}
+	if cluster != nil {
+		impersonated.Key = cluster.Key
+		impersonated.KubeConfigPath = cluster.KubeConfigPath
+	}
+	c.log.V(1).Info("created impersonating client", append(logging.GetFunctionAndSource(logging.MyCaller), "cluster", impersonated.Key, "serviceAccount", user)...)
+	c.impersonated[key] = impersonated
+	return impersonated, nil
+}
+
+// Delete removes a cluster from the cache.
+func (c *clustersData) Delete(key string) {
+	c.Lock()
+	defer c.Unlock()
+	c.deleteCluster(key)
+}
+
+// Close removes all clusters, and the clients impersonating ServiceAccounts, from the cache, deleting the clusters'
+// kubeconfig files.
+func (c *clustersData) Close() {
+	c.Lock()
+	defer c.Unlock()
+	for key := range c.clusters {
+		c.deleteCluster(key)
+	}
+	c.impersonated = map[string]*Cluster{}
+	if c.dir != "" {
+		os.RemoveAll(c.dir) //nolint:errcheck // ok
+		c.dir = ""
+	}
+}
+
+func (c *clustersData) deleteCluster(key string) {
+	if cluster, ok := c.clusters[key]; ok {
+		c.removeKubeConfig(cluster)
+		delete(c.clusters, key)
+		for impersonatedKey, impersonated := range c.impersonated {
+			if impersonated.base == cluster {
+				delete(c.impersonated, impersonatedKey)
+			}
+		}
+	}
+}
+
+func (c *clustersData) removeKubeConfig(cluster *Cluster) {
+	if err := os.Remove(cluster.KubeConfigPath); err != nil && !os.IsNotExist(err) {
+		c.log.Error(err, "unable to remove kubeconfig file", append(logging.GetFunctionAndSource(logging.MyCaller), "cluster", cluster.Key)...)
+	}
+}
+
+func (c *clustersData) readKubeConfig(ctx context.Context, kubeConfig *kraanv1alpha1.KubeConfigSpec) ([]byte, error) {
+	namespace := common.GetSourceNamespace(kubeConfig.SecretRef.Namespace)
+	secret, err := c.k8client.CoreV1().Secrets(namespace).Get(ctx, kubeConfig.SecretRef.Name, metav1.GetOptions{})
+	if err != nil {
+		return nil, errors.Wrapf(err, "%s - failed to get kubeconfig secret: %s/%s", logging.CallerStr(logging.Me), namespace, kubeConfig.SecretRef.Name)
+	}
+	key := kubeConfig.SecretRef.Key
+	if key == "" {
+		key = DefaultKubeConfigKey
+	}
+	data, ok := secret.Data[key]
+	if !ok || len(data) == 0 {
+		return nil, fmt.Errorf("kubeconfig secret: %s/%s, does not contain key: %s", namespace, kubeConfig.SecretRef.Name, key)
+	}
+	return data, nil
+}
+
+func (c *clustersData) newCluster(key, checksum string, data []byte) (*Cluster, error) {
+	config, err := clientcmd.RESTConfigFromKubeConfig(data)
+	if err != nil {
+		return nil, errors.Wrapf(err, "%s - failed to parse kubeconfig for cluster: %s", logging.CallerStr(logging.Me), key)
+	}
+	if config.Timeout == 0 {
+		config.Timeout = Timeout
+	}
+	mapper, err := apiutil.NewDynamicRESTMapper(config, apiutil.WithLazyDiscovery)
+	if err != nil {
+		return nil, errors.Wrapf(err, "%s - failed to create rest mapper for cluster: %s", logging.CallerStr(logging.Me), key)
+	}
+	k8sClient, err := client.New(config, client.Options{Scheme: c.scheme, Mapper: mapper})
+	if err != nil {
+		return nil, errors.Wrapf(err, "%s - failed to create client for cluster: %s", logging.CallerStr(logging.Me), key)
+	}
+	clientset, err := kubernetes.NewForConfig(config)
+	if err != nil {
+		return nil, errors.Wrapf(err, "%s - failed to create clientset for cluster: %s", logging.CallerStr(logging.Me), key)
+	}
+	if c.dir == "" {
+		c.dir, err = os.MkdirTemp("", "kraan-kubeconfig-*")
+		if err != nil {
+			return nil, errors.Wrapf(err, "%s - failed to create kubeconfig directory", logging.CallerStr(logging.Me))
+		}
+	}
+	file, err := os.CreateTemp(c.dir, "kubeconfig-*")
+	if err != nil {
+		return nil, errors.Wrapf(err, "%s - failed to create kubeconfig file for cluster: %s", logging.CallerStr(logging.Me), key)
+	}
+	defer file.Close()
+	if _, err := file.Write(data); err != nil {
+		os.Remove(file.Name()) //nolint:errcheck // ok
+		return nil, errors.Wrapf(err, "%s - failed to write kubeconfig for cluster: %s", logging.CallerStr(logging.Me), key)
+	}
+	kubeConfigPath := file.Name()
+	return &Cluster{
+		Key:            key,
+		Client:         k8sClient,
+		K8sClient:      clientset,
+		KubeConfigPath: kubeConfigPath,
+		config:         config,
+		checksum:       checksum,
+		checked:        time.Now(),
+	}, nil
+}
+
+// healthCheck checks that the cluster's API server can be reached.
+func healthCheck(cluster *Cluster) error {
+	if _, err := cluster.K8sClient.Discovery().ServerVersion(); err != nil {
+		return errors.Wrapf(err, "%s - failed to get server version of cluster: %s", logging.CallerStr(logging.Me), cluster.Key)
+	}
+	return nil
+}
@@ -0,0 +1,226 @@
+package clusters_test
+
+import (
+	"context"
+	"fmt"
+	"net/http"
+	"net/http/httptest"
+	"os"
+	"testing"
+	"time"
+
+	"github.com/go-logr/logr"
+	corev1 "k8s.io/api/core/v1"
+	metav1 "k8s.io/apimachinery/pkg/apis/meta/v1"
+	"k8s.io/apimachinery/pkg/runtime"
+	"k8s.io/apimachinery/pkg/types"
+	fakeK8s "k8s.io/client-go/kubernetes/fake"
+	"k8s.io/client-go/rest"
+
+	kraanv1alpha1 "github.com/fidelity/kraan/api/v1alpha1"
+	"github.com/fidelity/kraan/pkg/clusters"
+)
+
+const kubeConfigTemplate = `apiVersion: v1
+kind: Config
+clusters:
+- name: %[1]s
+  cluster:
+    server: %[2]s
+contexts:
+- name: %[1]s
+  context:
+    cluster: %[1]s
+    user: %[1]s
+current-context: %[1]s
+users:
+- name: %[1]s
+  user:
+    token: test
+`
+
+func newServer() *httptest.Server {
+	return httptest.NewServer(http.HandlerFunc(func(w http.ResponseWriter, r *http.Request) {
+		if r.URL.Path != "/version" {
+			http.NotFound(w, r)
+			return
+		}
+		w.Header().Set("Content-Type", "application/json")
+		fmt.Fprint(w, `{"major":"1","minor":"26","gitVersion":"v1.26.3"}`)
+	}))
+}
+
+func newSecret(name, server string) *corev1.Secret {
+	return &corev1.Secret{
+		ObjectMeta: metav1.ObjectMeta{Namespace: "clusters", Name: "remote"},
+		Data:       map[string][]byte{clusters.DefaultKubeConfigKey: []byte(fmt.Sprintf(kubeConfigTemplate, name, server))},
+	}
+}
+
+func TestGet(t *testing.T) {
+	server := newServer()
+	defer server.Close()
+
+	ctx := context.Background()
+	k8sClient := fakeK8s.NewSimpleClientset(newSecret("remote", server.URL))
+	c := clusters.NewClusters(&rest.Config{}, k8sClient, runtime.NewScheme(), logr.Discard())
+	defer c.Close()
+
+	kubeConfig := &kraanv1alpha1.KubeConfigSpec{SecretRef: kraanv1alpha1.KubeConfigSecretRef{Namespace: "clusters", Name: "remote"}}
+	if key := clusters.GetKey(kubeConfig); key != "clusters/remote/value" {
+		t.Fatalf("clusters.GetKey returned unexpected key: %s", key)
+	}
+
+	if _, err := c.Get(ctx, &kraanv1alpha1.KubeConfigSpec{SecretRef: kraanv1alpha1.KubeConfigSecretRef{Namespace: "clusters", Name: "missing"}}); err == nil {
+		t.Fatalf("Get did not return an error for a missing secret")
+	}
+	if _, err := c.Get(ctx, &kraanv1alpha1.KubeConfigSpec{SecretRef: kraanv1alpha1.KubeConfigSecretRef{Namespace: "clusters", Name: "remote", Key: "other"}}); err == nil {
+		t.Fatalf("Get did not return an error for a missing key")
+	}
+
+	first, err := c.Get(ctx, kubeConfig)
+	if err != nil {
+		t.Fatalf("Get returned an error: %s", err)
+	}
+	if _, err := os.Stat(first.KubeConfigPath); err != nil {
+		t.Fatalf("kubeconfig file not written: %s", err)
+	}
+	second, err := c.Get(ctx, kubeConfig)
+	if err != nil {
+		t.Fatalf("Get returned an error: %s", err)
+	}
+	if first != second {
+		t.Fatalf("Get did not return the cached cluster")
+	}
+
+	if _, err := k8sClient.CoreV1().Secrets("clusters").Update(ctx, newSecret("renamed", server.URL), metav1.UpdateOptions{}); err != nil {
+		t.Fatalf("failed to update secret: %s", err)
+	}
+	third, err := c.Get(ctx, kubeConfig)
+	if err != nil {
+		t.Fatalf("Get returned an error: %s", err)
+	}
+	if third == first {
+		t.Fatalf("Get did not recreate the cluster when the kubeconfig changed")
+	}
+	if _, err := os.Stat(first.KubeConfigPath); !os.IsNotExist(err) {
+		t.Fatalf("previous kubeconfig file not removed")
+	}
+
+	interval := clusters.HealthCheckInterval
+	clusters.HealthCheckInterval = 0
+	defer func() { clusters.HealthCheckInterval = interval }()
+	server.Close()
+	if _, err := c.Get(ctx, kubeConfig); err == nil {
+		t.Fatalf("Get did not return an error for an unreachable cluster")
+	}
+	if _, err := os.Stat(third.KubeConfigPath); !os.IsNotExist(err) {
+		t.Fatalf("kubeconfig file of unreachable cluster not removed")
+	}
+}
+
+func TestGetUnreachableCluster(t *testing.T) {
+	healthy := newServer()
+	defer healthy.Close()
+	release := make(chan struct{})
+	hanging := httptest.NewServer(http.HandlerFunc(func(w http.ResponseWriter, r *http.Request) {
+		<-release
+	}))
+	defer hanging.Close()
+	defer close(release)
+
+	timeout := clusters.Timeout
+	clusters.Timeout = 2 * time.Second
+	defer func() { clusters.Timeout = timeout }()
+
+	ctx := context.Background()
+	hangingSecret := newSecret("hanging", hanging.URL)
+	hangingSecret.Name = "hanging"
+	k8sClient := fakeK8s.NewSimpleClientset(newSecret("remote", healthy.URL), hangingSecret)
+	c := clusters.NewClusters(&rest.Config{}, k8sClient, runtime.NewScheme(), logr.Discard())
+	defer c.Close()
+
+	hangingErr := make(chan error)
+	go func() {
+		_, err := c.Get(ctx, &kraanv1alpha1.KubeConfigSpec{SecretRef: kraanv1alpha1.KubeConfigSecretRef{Namespace: "clusters", Name: "hanging"}})
+		hangingErr <- err
+	}()
+	time.Sleep(100 * time.Millisecond)
+
+	start := time.Now()
+	if _, err := c.Get(ctx, &kraanv1alpha1.KubeConfigSpec{SecretRef: kraanv1alpha1.KubeConfigSecretRef{Namespace: "clusters", Name: "remote"}}); err != nil {
+		t.Fatalf("Get returned an error: %s", err)
+	}
+	if elapsed := time.Since(start); elapsed >= clusters.Timeout {
+		t.Fatalf("Get of reachable cluster was delayed by unreachable cluster: %s", elapsed)
+	}
+
+	select {
+	case err := <-hangingErr:
+		if err == nil {
+			t.Fatalf("Get did not return an error for an unresponsive cluster")
+		}
+	case <-time.After(2 * clusters.Timeout):
+		t.Fatalf("Get of unresponsive cluster did not time out")
+	}
+}
+
+func TestImpersonate(t *testing.T) {
+	impersonatedUser := make(chan string, 1)
+	server := httptest.NewServer(http.HandlerFunc(func(w http.ResponseWriter, r *http.Request) {
+		select {
+		case impersonatedUser <- r.Header.Get("Impersonate-User"):
+		default:
+		}
+		w.Header().Set("Content-Type", "application/json")
+		fmt.Fprint(w, `{"major":"1","minor":"26","gitVersion":"v1.26.3"}`)
+	}))
+	defer server.Close()
+
+	ctx := context.Background()
+	k8sClient := fakeK8s.NewSimpleClientset(newSecret("remote", server.URL))
+	c := clusters.NewClusters(&rest.Config{Host: server.URL}, k8sClient, runtime.NewScheme(), logr.Discard())
+	defer c.Close()
+
+	serviceAccount := types.NamespacedName{Namespace: "tenant", Name: "deployer"}
+	local, err := c.Impersonate(nil, serviceAccount)
+	if err != nil {
+		t.Fatalf("Impersonate returned an error: %s", err)
+	}
+	if local.ServiceAccount != "system:serviceaccount:tenant:deployer" || local.KubeConfigPath != "" || local.K8sClient == k8sClient {
+		t.Fatalf("Impersonate returned unexpected cluster: %#v", local)
+	}
+	if _, err := local.K8sClient.Discovery().ServerVersion(); err != nil {
+		t.Fatalf("failed to get server version: %s", err)
+	}
+	if user := <-impersonatedUser; user != local.ServiceAccount {
+		t.Fatalf("clientset impersonated %q, expected %q", user, local.ServiceAccount)
+	}
+	if again, err := c.Impersonate(nil, serviceAccount); err != nil || again != local {
+		t.Fatalf("Impersonate did not return the cached cluster")
+	}
+
+	kubeConfig := &kraanv1alpha1.KubeConfigSpec{SecretRef: kraanv1alpha1.KubeConfigSecretRef{Namespace: "clusters", Name: "remote"}}
+	cluster, err := c.Get(ctx, kubeConfig)
+	if err != nil {
+		t.Fatalf("Get returned an error: %s", err)
+	}
+	remote, err := c.Impersonate(cluster, serviceAccount)
+	if err != nil {
+		t.Fatalf("Impersonate returned an error: %s", err)
+	}
+	if remote == local || remote.KubeConfigPath != cluster.KubeConfigPath || remote.K8sClient == cluster.K8sClient || remote.Key != cluster.Key {
+		t.Fatalf("Impersonate did not return a copy of the remote cluster: %#v", remote)
+	}
+
+	if _, err := k8sClient.CoreV1().Secrets("clusters").Update(ctx, newSecret("renamed", server.URL), metav1.UpdateOptions{}); err != nil {
+		t.Fatalf("failed to update secret: %s", err)
+	}
+	cluster, err = c.Get(ctx, kubeConfig)
+	if err != nil {
+		t.Fatalf("Get returned an error: %s", err)
+	}
+	if recreated, err := c.Impersonate(cluster, serviceAccount); err != nil || recreated == remote {
+		t.Fatalf("Impersonate did not recreate the client when the cluster changed")
+	}
+}
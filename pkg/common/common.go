@@ -2,7 +2,23 @@
 package common
 
 import (
+	"crypto/sha256"
+	"encoding/hex"
+	"fmt"
 	"os"
+	"strings"
+
+	kraanv1alpha1 "github.com/fidelity/kraan/api/v1alpha1"
+)
+
+// InlineSourcePrefix is the prefix of the names of GitRepositories created for inline git sources.
+const InlineSourcePrefix = "kraan-"
+
+const (
+	// OwnerLabel is set on the resources applied by a layer to the name of the layer.
+	OwnerLabel = "kraan/layer"
+	// LayerVersionAnnotation is set on the HelmReleases applied by a layer to the version of the layer.
+	LayerVersionAnnotation = "kraan.layerVersion"
 )
 
 // Following two functions copied from HelmController
@@ -55,3 +71,47 @@ func GetSourceNamespace(nameSpace string) string {
 	}
 	return GetRuntimeNamespace()
 }
+
+// GetSourceName returns the name of the GitRepository used by a source, for inline git sources this is the name of
+// the GitRepository created by the Kraan controller for the source's URL and ref.
+func GetSourceName(source kraanv1alpha1.SourceSpec) string {
+	if source.Git != nil {
+		return GetInlineSourceName(source.Git)
+	}
+	return source.Name
+}
+
+// GetInlineSourceName returns the name of the GitRepository for an inline git source, derived from its URL, ref and
+// credentials secret, so layers using different credentials do not share a GitRepository.
+func GetInlineSourceName(git *kraanv1alpha1.GitSourceSpec) string {
+	ref := kraanv1alpha1.GitRef{}
+	if git.Ref != nil {
+		ref = *git.Ref
+	}
+	id := fmt.Sprintf("%s\n%s\n%s\n%s\n%s", git.URL, ref.Branch, ref.Tag, ref.SemVer, ref.Commit)
+	if git.SecretRef != nil {
+		id = fmt.Sprintf("%s\n%s", id, git.SecretRef.Name)
+	}
+	hash := sha256.Sum256([]byte(id))
+	return InlineSourcePrefix + hex.EncodeToString(hash[:])[:12]
+}
+
+// GetOutputsConfigMapName returns the name of the ConfigMap in the controller's namespace a layer's outputs are
+// published in.
+func GetOutputsConfigMapName(layerName string) string {
+	return fmt.Sprintf("kraan-%s-outputs", layerName)
+}
+
+// GetSourceKey returns the namespace and name of the GitRepository used by a source.
+func GetSourceKey(source kraanv1alpha1.SourceSpec) string {
+	return fmt.Sprintf("%s/%s", GetSourceNamespace(source.NameSpace), GetSourceName(source))
+}
+
+// SplitNamespaceName splits a namespace/name string into its parts, the namespace is the runtime namespace if the string
+// does not contain a namespace.
+func SplitNamespaceName(key string) (string, string) {
+	if index := strings.Index(key, "/"); index >= 0 {
+		return key[:index], key[index+1:]
+	}
+	return GetRuntimeNamespace(), key
+}
@@ -0,0 +1,37 @@
+package common
+
+import "strings"
+
+// MergeValues deep merges Helm values from overlay into base, returning base. Maps are merged recursively, any other
+// value in overlay replaces the value in base.
+func MergeValues(base, overlay map[string]interface{}) map[string]interface{} {
+	if base == nil {
+		base = make(map[string]interface{}, len(overlay))
+	}
+	for key, value := range overlay {
+		if overlayMap, ok := value.(map[string]interface{}); ok {
+			if baseMap, ok := base[key].(map[string]interface{}); ok {
+				base[key] = MergeValues(baseMap, overlayMap)
+				continue
+			}
+			base[key] = MergeValues(nil, overlayMap)
+			continue
+		}
+		base[key] = value
+	}
+	return base
+}
+
+// SetValue sets a value at a dot separated path in Helm values, creating or replacing any intermediate maps.
+func SetValue(values map[string]interface{}, path string, value interface{}) {
+	keys := strings.Split(path, ".")
+	for _, key := range keys[:len(keys)-1] {
+		next, ok := values[key].(map[string]interface{})
+		if !ok {
+			next = map[string]interface{}{}
+			values[key] = next
+		}
+		values = next
+	}
+	values[keys[len(keys)-1]] = value
+}
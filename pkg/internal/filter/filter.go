@@ -0,0 +1,158 @@
+// Package filter selects the files within a layer source path that are applied.
+package filter
+
+import (
+	"bufio"
+	"bytes"
+	"path"
+	"regexp"
+	"strings"
+
+	"github.com/pkg/errors"
+
+	"github.com/fidelity/kraan/pkg/logging"
+)
+
+// IgnoreFile is the name of the file in a layer source path containing patterns of files to exclude.
+const IgnoreFile = ".kraanignore"
+
+// Filter selects files using include and exclude glob patterns. Patterns containing a '/' other than at the end are
+// matched against the path relative to the layer source path, other patterns are matched against the file or
+// directory name at any depth. A '*' matches any characters other than '/', '**' matches any characters including '/',
+// '?' matches any single character other than '/' and a pattern ending in '/' only matches directories.
+type Filter struct {
+	include []pattern
+	exclude []pattern
+}
+
+type pattern struct {
+	regex   *regexp.Regexp
+	negate  bool
+	dirOnly bool
+}
+
+// New returns a Filter selecting files that match any of the include patterns, or all files if there are none, and
+// do not match any of the exclude patterns. The content of an ignore file, using the same format as a .gitignore file,
+// adds exclude patterns, a pattern prefixed by '!' re-includes files excluded by an earlier pattern.
+func New(include, exclude []string, ignore []byte) (*Filter, error) {
+	f := &Filter{}
+	for _, glob := range include {
+		p, err := newPattern(glob)
+		if err != nil {
+			return nil, err
+		}
+		f.include = append(f.include, p)
+	}
+	for _, glob := range exclude {
+		p, err := newPattern(glob)
+		if err != nil {
+			return nil, err
+		}
+		f.exclude = append(f.exclude, p)
+	}
+	scanner := bufio.NewScanner(bytes.NewReader(ignore))
+	for scanner.Scan() {
+		line := strings.TrimSpace(scanner.Text())
+		if line == "" || strings.HasPrefix(line, "#") {
+			continue
+		}
+		negate := strings.HasPrefix(line, "!")
+		p, err := newPattern(strings.TrimPrefix(line, "!"))
+		if err != nil {
+			return nil, errors.WithMessagef(err, "%s - invalid pattern in %s", logging.CallerStr(logging.Me), IgnoreFile)
+		}
+		p.negate = negate
+		f.exclude = append(f.exclude, p)
+	}
+	if err := scanner.Err(); err != nil {
+		return nil, errors.Wrapf(err, "%s - failed to read %s", logging.CallerStr(logging.Me), IgnoreFile)
+	}
+	return f, nil
+}
+
+// IsEmpty returns true if the filter selects all files.
+func (f *Filter) IsEmpty() bool {
+	return len(f.include) == 0 && len(f.exclude) == 0
+}
+
+// Match returns true if the file or directory at the slash separated path, relative to the layer source path, is
+// selected. Directories are only checked against the exclude patterns, files within a directory that is not selected
+// are not selected either.
+func (f *Filter) Match(relPath string, isDir bool) bool {
+	relPath = strings.Trim(path.Clean("/"+relPath), "/")
+	if relPath == IgnoreFile {
+		return false
+	}
+	excluded := false
+	for _, p := range f.exclude {
+		if p.match(relPath, isDir) {
+			excluded = !p.negate
+		}
+	}
+	if excluded {
+		return false
+	}
+	if isDir || len(f.include) == 0 {
+		return true
+	}
+	for _, p := range f.include {
+		if p.match(relPath, isDir) {
+			return true
+		}
+	}
+	return false
+}
+
+func (p pattern) match(relPath string, isDir bool) bool {
+	if p.dirOnly && !isDir {
+		return false
+	}
+	return p.regex.MatchString(relPath)
+}
+
+func newPattern(glob string) (pattern, error) {
+	p := pattern{}
+	if strings.HasSuffix(glob, "/") {
+		p.dirOnly = true
+		glob = strings.TrimRight(glob, "/")
+	}
+	if glob == "" {
+		return p, errors.New("empty pattern")
+	}
+	anchored := strings.Contains(glob, "/")
+	glob = strings.TrimPrefix(glob, "/")
+
+	var expr strings.Builder
+	if !anchored {
+		expr.WriteString("(^|.*/)")
+	} else {
+		expr.WriteString("^")
+	}
+	for i := 0; i < len(glob); i++ {
+		switch c := glob[i]; c {
+		case '*':
+			if i+1 < len(glob) && glob[i+1] == '*' {
+				i++
+				if i+1 < len(glob) && glob[i+1] == '/' {
+					i++
+					expr.WriteString("(.*/)?")
+					continue
+				}
+				expr.WriteString(".*")
+				continue
+			}
+			expr.WriteString("[^/]*")
+		case '?':
+			expr.WriteString("[^/]")
+		default:
+			expr.WriteString(regexp.QuoteMeta(string(c)))
+		}
+	}
+	expr.WriteString("$")
+	regex, err := regexp.Compile(expr.String())
+	if err != nil {
+		return p, errors.Wrapf(err, "%s - invalid pattern: %s", logging.CallerStr(logging.Me), glob)
+	}
+	p.regex = regex
+	return p, nil
+}
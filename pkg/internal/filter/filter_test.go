@@ -0,0 +1,63 @@
+package filter_test
+
+import (
+	"testing"
+
+	"github.com/fidelity/kraan/pkg/internal/filter"
+)
+
+func TestMatch(t *testing.T) {
+	ignore := []byte(`# not manifests
+README*
+examples/
+values-*.yaml
+!values-overrides.yaml
+`)
+	f, err := filter.New([]string{"*.yaml", "*.yml"}, []string{"test/**"}, ignore)
+	if err != nil {
+		t.Fatalf("filter.New returned an error: %s", err)
+	}
+
+	tests := []struct {
+		path     string
+		isDir    bool
+		expected bool
+	}{
+		{"hr.yaml", false, true},
+		{"apps/hr.yml", false, true},
+		{"apps/notes.txt", false, false},
+		{"README.md", false, false},
+		{"apps/README.yaml", false, false},
+		{"examples", true, false},
+		{"apps/examples", true, false},
+		{"examples.yaml", false, true},
+		{"apps/values-dev.yaml", false, false},
+		{"apps/values-overrides.yaml", false, true},
+		{"test/fixture.yaml", false, false},
+		{"apps/test/fixture.yaml", false, true},
+		{"apps", true, true},
+		{filter.IgnoreFile, false, false},
+	}
+
+	for _, test := range tests {
+		if got := f.Match(test.path, test.isDir); got != test.expected {
+			t.Fatalf("%T.Match(%s, %t) returned %t, expected %t", f, test.path, test.isDir, got, test.expected)
+		}
+	}
+}
+
+func TestEmpty(t *testing.T) {
+	f, err := filter.New(nil, nil, []byte("\n# comment only\n"))
+	if err != nil {
+		t.Fatalf("filter.New returned an error: %s", err)
+	}
+	if !f.IsEmpty() {
+		t.Fatalf("filter with no patterns is not empty")
+	}
+	if !f.Match("apps/hr.yaml", false) {
+		t.Fatalf("empty filter did not select file")
+	}
+	if _, err := filter.New([]string{"/"}, nil, nil); err == nil {
+		t.Fatalf("filter.New did not return an error for an empty pattern")
+	}
+}
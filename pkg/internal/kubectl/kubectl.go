@@ -104,6 +104,7 @@ type Command interface {
 	Build() (buildDir string)
 	DryRun() (output []byte, err error)
 	WithLogger(logger logr.Logger) (self Command)
+	WithKubeConfig(kubeConfigPath string) (self Command)
 	getPath() string
 	getSubCmd() string
 	getArgs() []string
@@ -210,6 +211,15 @@ func (c *abstractCommand) WithLogger(logger logr.Logger) (self Command) {
 	return c
 }
 
+// WithKubeConfig sets the kubeconfig file the command should use to access the cluster if passed a path that is not
+// empty, by default the command accesses the cluster the controller runs in.
+func (c *abstractCommand) WithKubeConfig(kubeConfigPath string) (self Command) {
+	if kubeConfigPath != "" {
+		c.args = append(c.args, "--kubeconfig", kubeConfigPath)
+	}
+	return c
+}
+
 // ApplyCommand is a Kubectl sub-command that recursively applies all the YAML files it finds in a directory.
 type ApplyCommand struct {
 	abstractCommand
@@ -165,6 +165,20 @@ func (mr *MockCommandMockRecorder) WithLogger(arg0 interface{}) *gomock.Call {
 	return mr.mock.ctrl.RecordCallWithMethodType(mr.mock, "WithLogger", reflect.TypeOf((*MockCommand)(nil).WithLogger), arg0)
 }
 
+// WithKubeConfig mocks base method
+func (m *MockCommand) WithKubeConfig(arg0 string) kubectl.Command {
+	m.ctrl.T.Helper()
+	ret := m.ctrl.Call(m, "WithKubeConfig", arg0)
+	ret0, _ := ret[0].(kubectl.Command)
+	return ret0
+}
+
+// WithKubeConfig indicates an expected call of WithKubeConfig
+func (mr *MockCommandMockRecorder) WithKubeConfig(arg0 interface{}) *gomock.Call {
+	mr.mock.ctrl.T.Helper()
+	return mr.mock.ctrl.RecordCallWithMethodType(mr.mock, "WithKubeConfig", reflect.TypeOf((*MockCommand)(nil).WithKubeConfig), arg0)
+}
+
 // asString mocks base method
 func (m *MockCommand) asString() string {
 	m.ctrl.T.Helper()
@@ -4,14 +4,19 @@
 package tarconsumer
 
 import (
+	"archive/tar"
 	"bytes"
+	"compress/gzip"
 	"context"
 	"fmt"
 	"io"
 	"net/http"
+	"path"
+	"strings"
 
-	"github.com/fluxcd/pkg/untar"
 	"github.com/pkg/errors"
+
+	"github.com/fidelity/kraan/pkg/storage"
 )
 
 type TarConsumer interface {
@@ -63,11 +68,70 @@ func (t *tarConsumerData) GetTar(ctx context.Context) ([]byte, error) {
 	return streamToByte(resp.Body)
 }
 
-// UnpackTar unpacks tar data to specified path
-func UnpackTar(tar []byte, path string) (err error) {
-	r := bytes.NewReader(tar)
-	_, err = untar.Untar(r, path)
-	return errors.Wrap(err, "failed to unpack tar data")
+// UnpackTar unpacks gzip compressed tar data to specified path in the storage backend.
+func UnpackTar(store storage.Storage, data []byte, path string) (err error) {
+	return errors.Wrap(untar(store, bytes.NewReader(data), path), "failed to unpack tar data")
+}
+
+// untar is adapted from github.com/fluxcd/pkg/untar, writing to a storage backend rather than the local filesystem.
+func untar(store storage.Storage, r io.Reader, dir string) error {
+	zr, err := gzip.NewReader(r)
+	if err != nil {
+		return fmt.Errorf("requires gzip-compressed body: %v", err)
+	}
+	tr := tar.NewReader(zr)
+	madeDir := map[string]bool{}
+	for {
+		f, err := tr.Next()
+		if err == io.EOF {
+			break
+		}
+		if err != nil {
+			return fmt.Errorf("tar error: %v", err)
+		}
+		if !validRelPath(f.Name) {
+			return fmt.Errorf("tar contained invalid name error %q", f.Name)
+		}
+		abs := path.Join(dir, f.Name)
+
+		mode := f.FileInfo().Mode()
+		switch {
+		case mode.IsRegular():
+			parent := path.Dir(abs)
+			if !madeDir[parent] {
+				if err := store.MkdirAll(parent); err != nil {
+					return err
+				}
+				madeDir[parent] = true
+			}
+			buf := new(bytes.Buffer)
+			n, err := io.Copy(buf, tr)
+			if err != nil {
+				return fmt.Errorf("error reading %s: %v", f.Name, err)
+			}
+			if n != f.Size {
+				return fmt.Errorf("only read %d bytes for %s; expected %d", n, f.Name, f.Size)
+			}
+			if err := store.WriteFile(abs, buf.Bytes(), mode.Perm()); err != nil {
+				return fmt.Errorf("error writing to %s: %v", abs, err)
+			}
+		case mode.IsDir():
+			if err := store.MkdirAll(abs); err != nil {
+				return err
+			}
+			madeDir[abs] = true
+		default:
+			return fmt.Errorf("tar file entry %s contained unsupported file type %v", f.Name, mode)
+		}
+	}
+	return nil
+}
+
+func validRelPath(p string) bool {
+	if p == "" || strings.Contains(p, `\`) || strings.HasPrefix(p, "/") || strings.Contains(p, "../") {
+		return false
+	}
+	return true
 }
 
 func streamToByte(stream io.Reader) ([]byte, error) {
@@ -4,12 +4,12 @@ import (
 	"bytes"
 	"context"
 	"fmt"
-	"os"
 	"reflect"
 	"testing"
 
 	"github.com/fidelity/kraan/pkg/internal/tarconsumer"
 	"github.com/fidelity/kraan/pkg/internal/testutils"
+	"github.com/fidelity/kraan/pkg/storage"
 )
 
 const (
@@ -89,15 +89,15 @@ func TestTarUpack(t *testing.T) {
 			return
 		}
 
-		dir, err := os.MkdirTemp("", "test-*")
-		if err != nil {
-			t.Fatalf(err.Error())
-			return
-		}
-		err = tarconsumer.UnpackTar(data, dir)
+		store := storage.NewMemory()
+		dir := "/data"
+		err = tarconsumer.UnpackTar(store, data, dir)
 		if !reflect.DeepEqual(err, test.expected) {
 			t.Fatalf("error returned from %T.UnpackTar did not match expected error:\nWanted: %#v\nGot: %#v", tarConsumer, test.expected, err)
 		}
+		if _, err := store.Stat(fmt.Sprintf("%s/%s", dir, test.tarDataDir)); err != nil {
+			t.Fatalf("unpacked directory %s not found: %s", test.tarDataDir, err)
+		}
 		t.Logf("test: %s, successful", test.name)
 	}
 
@@ -5,27 +5,38 @@ package layers
 
 import (
 	"context"
+	"encoding/json"
 	"fmt"
 	"os"
+	"reflect"
 	"strings"
 	"time"
 
+	helmctlv2 "github.com/fluxcd/helm-controller/api/v2beta1"
 	sourcev1 "github.com/fluxcd/source-controller/api/v1beta2"
 	"github.com/go-logr/logr"
 	"github.com/pkg/errors"
 	"golang.org/x/mod/semver"
 	corev1 "k8s.io/api/core/v1"
+	apierrors "k8s.io/apimachinery/pkg/api/errors"
+	"k8s.io/apimachinery/pkg/api/meta"
 	metav1 "k8s.io/apimachinery/pkg/apis/meta/v1"
+	"k8s.io/apimachinery/pkg/apis/meta/v1/unstructured"
+	"k8s.io/apimachinery/pkg/labels"
 	"k8s.io/apimachinery/pkg/runtime"
+	"k8s.io/apimachinery/pkg/runtime/schema"
 	"k8s.io/apimachinery/pkg/types"
 	"k8s.io/client-go/kubernetes"
 	"k8s.io/client-go/tools/record"
 	"k8s.io/client-go/tools/reference"
 	"sigs.k8s.io/controller-runtime/pkg/client"
+	"sigs.k8s.io/yaml"
 
 	kraanv1alpha1 "github.com/fidelity/kraan/api/v1alpha1"
+	"github.com/fidelity/kraan/pkg/clusters"
 	"github.com/fidelity/kraan/pkg/common"
 	"github.com/fidelity/kraan/pkg/logging"
+	"github.com/fidelity/kraan/pkg/readiness"
 	"github.com/fidelity/kraan/pkg/repos"
 )
 
@@ -33,6 +44,27 @@ import (
 var (
 	MaxConditions = 10
 	RootPath      = "/data"
+	// DefaultRequireVerified is used for layers that do not specify whether their source must be verified.
+	DefaultRequireVerified = false
+	// NoCrossNamespaceSources restricts layers to sources in the controller's namespace or a namespace they are allowed
+	// to deploy addons to.
+	NoCrossNamespaceSources = false
+	// DecryptionSecret is the name of a secret in the controller's namespace containing the keys used to decrypt the
+	// SOPS encrypted manifests of all layers.
+	DecryptionSecret = ""
+	// DefaultValuesKey is the data key values are read from when a layer's values reference does not specify one.
+	DefaultValuesKey = "values.yaml"
+	// ClusterInfoConfigMap is the namespace and name of the ConfigMap, on each cluster layers are applied to, whose labels
+	// and data are used as the cluster's labels.
+	ClusterInfoConfigMap = "kube-system/kraan-cluster-info"
+)
+
+// The names of the built in variables substituted in a layer's resources.
+const (
+	LayerNameVariable      = "KRAAN_LAYER_NAME"
+	LayerVersionVariable   = "KRAAN_LAYER_VERSION"
+	SourceRevisionVariable = "KRAAN_SOURCE_REVISION"
+	K8sVersionVariable     = "KRAAN_K8S_VERSION"
 )
 
 func init() {
@@ -49,18 +81,41 @@ type Layer interface {
 	SetStatusPruning()
 	SetStatusPending()
 	SetStatusDeployed()
+	SetStatusSourceUnverified(sourceKey, reason string)
+	SetStatusNotApplicable()
+	SetStatusPrerequisitesNotMet(missing []string)
 	StatusUpdate(status, message string)
+	SetPolicyViolations(violations []kraanv1alpha1.PolicyViolation)
 
 	IsHold() bool
+	IsApplicable() (bool, error)
 	SetHold()
 	DependenciesDeployed() bool
+	GetDependencyVersions() map[string]string
 
 	GetSourceKey() string
+	CheckSourceNamespaces() error
+	IsNamespaceAllowed(namespace string) (bool, error)
+	CheckServiceAccountNamespace() error
+	GetDecryptionKeys() ([]map[string][]byte, error)
+	GetValues() (map[string]interface{}, error)
+	GetVariables() (map[string]string, error)
+	SetUnresolvedVariables(names []string)
+	SetOutputs(outputs map[string]string)
+	ReferencesOutputs(name string) bool
+	SetSourceRevision(revision string)
+	GetSourceRevision() string
+	SetSourceResources(objs []runtime.Object)
+	GetSourceResources() []runtime.Object
+	GetK8sVersion() (string, error)
+	GetClusterInfo() (*ClusterInfo, error)
 	GetStatus() string
 	GetName() string
 	GetLogger() logr.Logger
 	GetContext() context.Context
 	GetSourcePath() string
+	GetSourcePaths() []string
+	GetSources() []kraanv1alpha1.SourceSpec
 	GetTimeout() time.Duration
 	IsUpdated() bool
 	NeedsRequeue() bool
@@ -72,10 +127,25 @@ type Layer interface {
 	SetDeleted()
 	GetRequiredK8sVersion() string
 	CheckK8sVersion() bool
+	CheckPrerequisites() ([]string, error)
+	CheckReadinessGates() ([]string, error)
 	GetFullStatus() *kraanv1alpha1.AddonsLayerStatus
 	GetSpec() *kraanv1alpha1.AddonsLayerSpec
 	GetAddonsLayer() *kraanv1alpha1.AddonsLayer
-	RevisionReady(conditions []metav1.Condition, revision string) (bool, string)
+	SourceReady(srcRepo *sourcev1.GitRepository) (bool, string)
+	IsVerificationRequired(source kraanv1alpha1.SourceSpec) bool
+	SetCluster(cluster *clusters.Cluster)
+	GetCluster() *clusters.Cluster
+}
+
+// ClusterInfo contains facts about the cluster a layer is applied to.
+type ClusterInfo struct {
+	// Version is the api server version.
+	Version string
+	// NodeCount is the number of nodes in the cluster.
+	NodeCount int
+	// Labels are the labels and data of the cluster info ConfigMap, empty if it does not exist.
+	Labels map[string]string
 }
 
 // KraanLayer is the Schema for the addons API.
@@ -89,9 +159,14 @@ type KraanLayer struct {
 	ctx         context.Context
 	client      client.Client
 	k8client    kubernetes.Interface
+	cluster     *clusters.Cluster
 	log         logr.Logger
 	recorder    record.EventRecorder
 	ref         *corev1.ObjectReference
+	revision    string
+	resources   []runtime.Object
+	clusterInfo *ClusterInfo
+	namespaces  map[string]labels.Set
 	Layer       `json:"-"`
 	addonsLayer *kraanv1alpha1.AddonsLayer
 }
@@ -141,6 +216,21 @@ func (l *KraanLayer) GetSourcePath() string {
 		l.GetSpec().Version)
 }
 
+// GetSourcePaths gets the paths to the top directories of each of an addons layer's sources in the local filesystem,
+// in the order they are merged. The first is the layer's source path.
+func (l *KraanLayer) GetSourcePaths() []string {
+	paths := []string{l.GetSourcePath()}
+	for index := range l.GetSpec().Sources {
+		paths = append(paths, fmt.Sprintf("%s-%d", l.GetSourcePath(), index+1))
+	}
+	return paths
+}
+
+// GetSources gets the addons layer's source followed by any additional sources.
+func (l *KraanLayer) GetSources() []kraanv1alpha1.SourceSpec {
+	return l.GetSpec().GetSources()
+}
+
 // SetUpdated sets the updated flag to cause the AddonsLayer to update the custom resource.
 func (l *KraanLayer) SetUpdated() {
 	l.updated = true
@@ -173,14 +263,199 @@ func (l *KraanLayer) GetRequiredK8sVersion() string {
 }
 
 func (l *KraanLayer) getK8sClient() kubernetes.Interface {
+	if l.cluster != nil {
+		return l.cluster.K8sClient
+	}
 	return l.k8client
 }
 
+func (l *KraanLayer) getClient() client.Client {
+	if l.cluster != nil {
+		return l.cluster.Client
+	}
+	return l.client
+}
+
+// SetCluster sets the cluster the layer is applied to, nil for the cluster the controller is running in.
+func (l *KraanLayer) SetCluster(cluster *clusters.Cluster) {
+	l.cluster = cluster
+	l.resources = nil
+}
+
+// GetCluster returns the cluster the layer is applied to, nil for the cluster the controller is running in.
+func (l *KraanLayer) GetCluster() *clusters.Cluster {
+	return l.cluster
+}
+
+// SetSourceRevision sets the revision of the layer's source that is being processed.
+func (l *KraanLayer) SetSourceRevision(revision string) {
+	l.revision = revision
+	l.resources = nil
+}
+
+// GetSourceRevision returns the revision of the layer's source that is being processed.
+func (l *KraanLayer) GetSourceRevision() string {
+	return l.revision
+}
+
+// SetSourceResources records the resources rendered from the layer's source, so they are only rendered once for each
+// reconcile. They are cleared when the layer's cluster or source revision is set.
+func (l *KraanLayer) SetSourceResources(objs []runtime.Object) {
+	l.resources = objs
+}
+
+// GetSourceResources returns the resources rendered from the layer's source, nil if they have not been rendered.
+func (l *KraanLayer) GetSourceResources() []runtime.Object {
+	return l.resources
+}
+
+// GetK8sVersion returns the api server version of the cluster the layer is applied to.
+func (l *KraanLayer) GetK8sVersion() (string, error) {
+	versionInfo, err := l.getK8sClient().Discovery().ServerVersion()
+	if err != nil {
+		return "", errors.Wrapf(err, "%s - failed get server version", logging.CallerStr(logging.Me))
+	}
+	return versionInfo.String(), nil
+}
+
+// CheckPrerequisites returns descriptions of the API resources and CRDs required by the layer that are not available on
+// the cluster it is applied to, an empty list if all of them are available.
+func (l *KraanLayer) CheckPrerequisites() ([]string, error) {
+	logging.TraceCall(l.GetLogger())
+	defer logging.TraceExit(l.GetLogger())
+	prereqs := l.GetSpec().PreReqs
+	missing := []string{}
+	served := map[string]*metav1.APIResourceList{}
+	for _, resource := range prereqs.APIResources {
+		groupVersion := schema.GroupVersion{Group: resource.Group, Version: resource.Version}.String()
+		resources, found := served[groupVersion]
+		if !found {
+			var err error
+			resources, err = l.getK8sClient().Discovery().ServerResourcesForGroupVersion(groupVersion)
+			if err != nil && !apierrors.IsNotFound(err) {
+				return nil, errors.Wrapf(err, "%s - failed to get api resources for: %s", logging.CallerStr(logging.Me), groupVersion)
+			}
+			served[groupVersion] = resources
+		}
+		if !isKindServed(resources, resource.Kind) {
+			missing = append(missing, fmt.Sprintf("api resource: %s, kind: %s, not served", groupVersion, resource.Kind))
+		}
+	}
+	for _, name := range prereqs.CRDs {
+		crd := &unstructured.Unstructured{}
+		crd.SetGroupVersionKind(schema.GroupVersionKind{Group: "apiextensions.k8s.io", Version: "v1", Kind: "CustomResourceDefinition"})
+		if err := l.getClient().Get(l.ctx, types.NamespacedName{Name: name}, crd); err != nil {
+			if apierrors.IsNotFound(err) {
+				missing = append(missing, fmt.Sprintf("crd: %s, not found", name))
+				continue
+			}
+			return nil, errors.Wrapf(err, "%s - failed to get crd: %s", logging.CallerStr(logging.Me), name)
+		}
+		if !isEstablished(crd) {
+			missing = append(missing, fmt.Sprintf("crd: %s, not established", name))
+		}
+	}
+	return missing, nil
+}
+
+func isKindServed(resources *metav1.APIResourceList, kind string) bool {
+	if resources == nil {
+		return false
+	}
+	for _, resource := range resources.APIResources {
+		if resource.Kind == kind {
+			return true
+		}
+	}
+	return false
+}
+
+// isEstablished returns true if a CustomResourceDefinition has an Established condition with status True.
+func isEstablished(crd *unstructured.Unstructured) bool {
+	conditions, _, _ := unstructured.NestedSlice(crd.Object, "status", "conditions")
+	for _, item := range conditions {
+		condition, ok := item.(map[string]interface{})
+		if ok && condition["type"] == "Established" && condition["status"] == string(metav1.ConditionTrue) {
+			return true
+		}
+	}
+	return false
+}
+
+// ReadinessGateGVK returns the group, version and kind of a readiness gate's object.
+func ReadinessGateGVK(gate kraanv1alpha1.ReadinessGate) schema.GroupVersionKind {
+	apiVersion := gate.APIVersion
+	if apiVersion == "" {
+		apiVersion = "v1"
+	}
+	return schema.FromAPIVersionAndKind(apiVersion, gate.Kind)
+}
+
+// CheckReadinessGates returns descriptions of the layer's readiness gates that are not ready, an empty list if all of
+// them are ready.
+func (l *KraanLayer) CheckReadinessGates() ([]string, error) {
+	logging.TraceCall(l.GetLogger())
+	defer logging.TraceExit(l.GetLogger())
+	notReady := []string{}
+	for _, gate := range l.GetSpec().PreReqs.ReadinessGates {
+		key := types.NamespacedName{Namespace: gate.Namespace, Name: gate.Name}
+		obj := &unstructured.Unstructured{}
+		obj.SetGroupVersionKind(ReadinessGateGVK(gate))
+		if err := l.getClient().Get(l.ctx, key, obj); err != nil {
+			if apierrors.IsNotFound(err) || meta.IsNoMatchError(err) {
+				notReady = append(notReady, fmt.Sprintf("%s: %s, not found", gate.Kind, key))
+				continue
+			}
+			return nil, errors.Wrapf(err, "%s - failed to get readiness gate %s: %s", logging.CallerStr(logging.Me), gate.Kind, key)
+		}
+		result := readiness.Compute(obj, gate.ConditionType)
+		if !result.Ready() {
+			notReady = append(notReady, fmt.Sprintf("%s: %s, %s, %s", gate.Kind, key, result.Status, result.Message))
+		}
+	}
+	return notReady, nil
+}
+
+// GetClusterInfo returns facts about the cluster the layer is applied to. They are read once for each reconcile.
+func (l *KraanLayer) GetClusterInfo() (*ClusterInfo, error) {
+	logging.TraceCall(l.GetLogger())
+	defer logging.TraceExit(l.GetLogger())
+	if l.clusterInfo != nil {
+		return l.clusterInfo, nil
+	}
+	version, err := l.GetK8sVersion()
+	if err != nil {
+		return nil, err
+	}
+	nodes, err := l.getK8sClient().CoreV1().Nodes().List(l.ctx, metav1.ListOptions{ResourceVersion: "0"})
+	if err != nil {
+		return nil, errors.Wrapf(err, "%s - failed to list nodes", logging.CallerStr(logging.Me))
+	}
+	info := &ClusterInfo{Version: version, NodeCount: len(nodes.Items), Labels: map[string]string{}}
+	if ClusterInfoConfigMap != "" {
+		namespace, name := common.SplitNamespaceName(ClusterInfoConfigMap)
+		configMap, err := l.getK8sClient().CoreV1().ConfigMaps(namespace).Get(l.ctx, name, metav1.GetOptions{})
+		if err != nil && !apierrors.IsNotFound(err) {
+			return nil, errors.Wrapf(err, "%s - failed to get cluster info configmap: %s", logging.CallerStr(logging.Me), ClusterInfoConfigMap)
+		}
+		if err == nil {
+			for key, value := range configMap.Labels {
+				info.Labels[key] = value
+			}
+			for key, value := range configMap.Data {
+				info.Labels[key] = value
+			}
+		}
+	}
+	l.clusterInfo = info
+	return info, nil
+}
+
 // CheckK8sVersion checks if the cluster api server version is equal to or above the required version.
 func (l *KraanLayer) CheckK8sVersion() bool {
 	logging.TraceCall(l.GetLogger())
 	defer logging.TraceExit(l.GetLogger())
-	versionInfo, err := l.getK8sClient().Discovery().ServerVersion()
+	serverVersion, err := l.GetK8sVersion()
 	if err != nil {
 		l.GetLogger().Error(err, "failed get server version", logging.GetFunctionAndSource(logging.MyCaller)...)
 		l.StatusUpdate(l.GetStatus(), fmt.Sprintf("failed to obtain cluster api server version, %s",
@@ -188,7 +463,7 @@ func (l *KraanLayer) CheckK8sVersion() bool {
 		l.SetDelayedRequeue()
 		return false
 	}
-	return semver.Compare(versionInfo.String(), l.GetRequiredK8sVersion()) >= 0
+	return semver.Compare(serverVersion, l.GetRequiredK8sVersion()) >= 0
 }
 
 func (l *KraanLayer) setStatus(status, message string) {
@@ -262,9 +537,439 @@ func (l *KraanLayer) SetStatusPending() {
 	l.setStatus(kraanv1alpha1.FailedCondition, message)
 }
 
+// SetStatusSourceUnverified sets the addon layer's status to waiting for the revision of one of its sources to be verified.
+func (l *KraanLayer) SetStatusSourceUnverified(sourceKey, reason string) {
+	logging.TraceCall(l.GetLogger())
+	defer logging.TraceExit(l.GetLogger())
+	message := fmt.Sprintf("Layer source: %s, revision not verified, %s.", sourceKey, reason)
+	l.setStatus(kraanv1alpha1.SourceUnverifiedCondition, message)
+}
+
+// SetStatusPrerequisitesNotMet sets the addon layer's status to waiting for the prerequisites that are missing.
+func (l *KraanLayer) SetStatusPrerequisitesNotMet(missing []string) {
+	logging.TraceCall(l.GetLogger())
+	defer logging.TraceExit(l.GetLogger())
+	l.setStatus(kraanv1alpha1.PrerequisitesNotMetCondition,
+		fmt.Sprintf("%s, missing: %s", kraanv1alpha1.AddonsLayerPrerequisitesNotMetMsg, strings.Join(missing, "; ")))
+}
+
+// SetStatusNotApplicable sets the addon layer's status to not applicable to the cluster.
+func (l *KraanLayer) SetStatusNotApplicable() {
+	logging.TraceCall(l.GetLogger())
+	defer logging.TraceExit(l.GetLogger())
+	if l.GetStatus() != kraanv1alpha1.NotApplicableCondition {
+		l.setStatus(kraanv1alpha1.NotApplicableCondition, kraanv1alpha1.AddonsLayerNotApplicableMsg)
+	}
+}
+
+// IsApplicable returns true if the layer's cluster selector matches the labels of the cluster it is applied to, or it
+// has no cluster selector.
+func (l *KraanLayer) IsApplicable() (bool, error) {
+	logging.TraceCall(l.GetLogger())
+	defer logging.TraceExit(l.GetLogger())
+	if l.GetSpec().ClusterSelector == nil {
+		return true, nil
+	}
+	selector, err := metav1.LabelSelectorAsSelector(l.GetSpec().ClusterSelector)
+	if err != nil {
+		return false, errors.Wrapf(err, "%s - invalid cluster selector", logging.CallerStr(logging.Me))
+	}
+	info, err := l.GetClusterInfo()
+	if err != nil {
+		return false, errors.WithMessagef(err, "%s - failed to get cluster info", logging.CallerStr(logging.Me))
+	}
+	return selector.Matches(labels.Set(info.Labels)), nil
+}
+
+// IsVerificationRequired returns true if the revision of one of the layer's sources must be verified before the layer
+// is applied.
+func (l *KraanLayer) IsVerificationRequired(source kraanv1alpha1.SourceSpec) bool {
+	if source.RequireVerified != nil {
+		return *source.RequireVerified
+	}
+	return DefaultRequireVerified
+}
+
 // GetSourceKey gets the namespace and name of the source used by layer.
 func (l *KraanLayer) GetSourceKey() string {
-	return fmt.Sprintf("%s/%s", common.GetSourceNamespace(l.GetSpec().Source.NameSpace), l.GetSpec().Source.Name)
+	return common.GetSourceKey(l.GetSpec().Source)
+}
+
+// CheckSourceNamespaces returns a Forbidden error if the layer uses a source in a namespace it is not allowed to use.
+// Unless cross namespace sources are restricted a layer may use sources in any namespace, otherwise its sources must be
+// in the controller's namespace or a namespace the layer is allowed to deploy addons to.
+func (l *KraanLayer) CheckSourceNamespaces() error {
+	logging.TraceCall(l.GetLogger())
+	defer logging.TraceExit(l.GetLogger())
+	if !NoCrossNamespaceSources {
+		return nil
+	}
+	for _, source := range l.GetSources() {
+		namespace := common.GetSourceNamespace(source.NameSpace)
+		if namespace == common.GetRuntimeNamespace() {
+			continue
+		}
+		allowed, err := l.namespaceAllowed(l.k8client, "", namespace)
+		if err != nil {
+			return errors.WithMessagef(err, "%s - failed to check source namespace", logging.CallerStr(logging.Me))
+		}
+		if !allowed {
+			return apierrors.NewForbidden(sourcev1.GroupVersion.WithResource("gitrepositories").GroupResource(), common.GetSourceName(source),
+				fmt.Errorf("cross namespace source references are not allowed, layer: %s, is not allowed to use namespace: %s", l.GetName(), namespace))
+		}
+	}
+	return nil
+}
+
+// IsNamespaceAllowed returns true if the layer is allowed to deploy addons to a namespace of the cluster it is applied to.
+func (l *KraanLayer) IsNamespaceAllowed(namespace string) (bool, error) {
+	logging.TraceCall(l.GetLogger())
+	defer logging.TraceExit(l.GetLogger())
+	clusterKey := ""
+	if l.cluster.IsRemote() {
+		clusterKey = l.cluster.Key
+	}
+	return l.namespaceAllowed(l.getK8sClient(), clusterKey, namespace)
+}
+
+// CheckServiceAccountNamespace returns a Forbidden error if the ServiceAccount the layer impersonates is in a namespace
+// of the cluster it is applied to that the layer is not allowed to deploy addons to.
+func (l *KraanLayer) CheckServiceAccountNamespace() error {
+	logging.TraceCall(l.GetLogger())
+	defer logging.TraceExit(l.GetLogger())
+	if l.GetSpec().ServiceAccountName == "" {
+		return nil
+	}
+	namespace := common.GetSourceNamespace(l.GetSpec().ServiceAccountNamespace)
+	allowed, err := l.IsNamespaceAllowed(namespace)
+	if err != nil {
+		return errors.WithMessagef(err, "%s - failed to check service account namespace", logging.CallerStr(logging.Me))
+	}
+	if !allowed {
+		return apierrors.NewForbidden(corev1.Resource("serviceaccounts"), l.GetSpec().ServiceAccountName,
+			fmt.Errorf("layer: %s, is not allowed to use service accounts in namespace: %s", l.GetName(), namespace))
+	}
+	return nil
+}
+
+// GetDecryptionKeys returns the data of the secrets containing the keys used to decrypt the layer's SOPS encrypted
+// manifests, the controller's decryption secret, if any, and the secret referenced by the layer's decryption spec.
+// Secrets are read from the cluster the controller runs in. If cross namespace sources are restricted the layer's
+// secret must be in the controller's namespace or a namespace the layer is allowed to deploy addons to.
+func (l *KraanLayer) GetDecryptionKeys() ([]map[string][]byte, error) {
+	logging.TraceCall(l.GetLogger())
+	defer logging.TraceExit(l.GetLogger())
+	keys := []map[string][]byte{}
+	if DecryptionSecret != "" {
+		data, err := l.getSecretData(common.GetRuntimeNamespace(), DecryptionSecret)
+		if err != nil {
+			return nil, err
+		}
+		keys = append(keys, data)
+	}
+	decryption := l.GetSpec().Decryption
+	if decryption == nil || decryption.SecretRef == nil {
+		return keys, nil
+	}
+	namespace := common.GetSourceNamespace(decryption.SecretRef.Namespace)
+	if err := l.checkReferenceNamespace(corev1.Resource("secrets"), decryption.SecretRef.Name, namespace); err != nil {
+		return nil, err
+	}
+	data, err := l.getSecretData(namespace, decryption.SecretRef.Name)
+	if err != nil {
+		return nil, errors.WithMessagef(err, "%s - failed to get decryption secret", logging.CallerStr(logging.Me))
+	}
+	return append(keys, data), nil
+}
+
+// GetValues returns the values to merge into the values of the layer's HelmReleases, the values read from the
+// ConfigMaps and AddonsLayer outputs referenced by the layer's valuesFrom merged in order, followed by the layer's
+// values. Secret references are not read, they are added to the valuesFrom of each HelmRelease so their values are
+// not copied into the HelmRelease's spec. Returns nil if the layer has no values.
+func (l *KraanLayer) GetValues() (map[string]interface{}, error) {
+	logging.TraceCall(l.GetLogger())
+	defer logging.TraceExit(l.GetLogger())
+	spec := l.GetSpec()
+	if spec.Values == nil && len(spec.ValuesFrom) == 0 {
+		return nil, nil
+	}
+	values := map[string]interface{}{}
+	for _, ref := range spec.ValuesFrom {
+		if ref.Kind == "Secret" {
+			continue
+		}
+		refValues, err := l.getReferencedValues(ref)
+		if err != nil {
+			return nil, err
+		}
+		values = common.MergeValues(values, refValues)
+	}
+	if spec.Values != nil && len(spec.Values.Raw) > 0 {
+		specValues := map[string]interface{}{}
+		if err := json.Unmarshal(spec.Values.Raw, &specValues); err != nil {
+			return nil, errors.Wrapf(err, "%s - failed to parse layer values", logging.CallerStr(logging.Me))
+		}
+		values = common.MergeValues(values, specValues)
+	}
+	return values, nil
+}
+
+func (l *KraanLayer) getReferencedValues(ref kraanv1alpha1.ValuesReference) (map[string]interface{}, error) {
+	key := ref.ValuesKey
+	if key == "" && ref.Kind != kraanv1alpha1.AddonsLayerKind {
+		key = DefaultValuesKey
+	}
+	data, err := l.getReferencedData(ref.Kind, ref.Name, ref.Namespace, ref.Optional)
+	if err != nil {
+		return nil, errors.WithMessagef(err, "%s - failed to get values", logging.CallerStr(logging.Me))
+	}
+	if data == nil {
+		return nil, nil
+	}
+	values := map[string]interface{}{}
+	if key == "" {
+		// All of the outputs of an AddonsLayer, set at the target path if specified.
+		for name, value := range data {
+			path := name
+			if ref.TargetPath != "" {
+				path = ref.TargetPath + "." + name
+			}
+			common.SetValue(values, path, value)
+		}
+		return values, nil
+	}
+	value, found := data[key]
+	if !found {
+		if ref.Optional {
+			return nil, nil
+		}
+		return nil, fmt.Errorf("%s: %s, does not contain key: %s", ref.Kind, l.getReferenceName(ref.Kind, ref.Name, ref.Namespace), key)
+	}
+	targetPath := ref.TargetPath
+	if targetPath == "" && ref.Kind == kraanv1alpha1.AddonsLayerKind {
+		targetPath = key
+	}
+	if targetPath != "" {
+		common.SetValue(values, targetPath, value)
+		return values, nil
+	}
+	if err := yaml.Unmarshal([]byte(value), &values); err != nil {
+		return nil, errors.Wrapf(err, "%s - failed to parse values in %s: %s, key: %s", logging.CallerStr(logging.Me),
+			ref.Kind, l.getReferenceName(ref.Kind, ref.Name, ref.Namespace), key)
+	}
+	return values, nil
+}
+
+// getReferenceName returns the name of a resource referenced by the layer for use in messages.
+func (l *KraanLayer) getReferenceName(kind, name, namespace string) string {
+	if kind == kraanv1alpha1.AddonsLayerKind {
+		return name
+	}
+	return fmt.Sprintf("%s/%s", common.GetSourceNamespace(namespace), name)
+}
+
+// GetVariables returns the variables substituted in the layer's resources, the data of the ConfigMaps and Secrets
+// referenced by the layer's substituteFrom, in order, and the built in variables, which take precedence.
+func (l *KraanLayer) GetVariables() (map[string]string, error) {
+	logging.TraceCall(l.GetLogger())
+	defer logging.TraceExit(l.GetLogger())
+	variables := map[string]string{}
+	for _, ref := range l.GetSpec().SubstituteFrom {
+		data, err := l.getReferencedData(ref.Kind, ref.Name, ref.Namespace, ref.Optional)
+		if err != nil {
+			return nil, errors.WithMessagef(err, "%s - failed to get substitution variables", logging.CallerStr(logging.Me))
+		}
+		for name, value := range data {
+			variables[name] = value
+		}
+	}
+	k8sVersion, err := l.GetK8sVersion()
+	if err != nil {
+		return nil, err
+	}
+	variables[LayerNameVariable] = l.GetName()
+	variables[LayerVersionVariable] = l.GetSpec().Version
+	variables[SourceRevisionVariable] = l.GetSourceRevision()
+	variables[K8sVersionVariable] = k8sVersion
+	return variables, nil
+}
+
+// getReferencedData returns the data of a ConfigMap or Secret referenced by the layer, or the outputs published by
+// an AddonsLayer, nil if an optional referent is not found.
+func (l *KraanLayer) getReferencedData(kind, name, namespace string, optional bool) (map[string]string, error) {
+	namespace = common.GetSourceNamespace(namespace)
+	data := map[string]string{}
+	switch kind {
+	case kraanv1alpha1.AddonsLayerKind:
+		configMap, err := l.k8client.CoreV1().ConfigMaps(common.GetRuntimeNamespace()).Get(l.ctx, common.GetOutputsConfigMapName(name), metav1.GetOptions{})
+		if err != nil {
+			if optional && apierrors.IsNotFound(err) {
+				return nil, nil
+			}
+			return nil, errors.Wrapf(err, "%s - failed to get outputs of AddonsLayer: %s", logging.CallerStr(logging.Me), name)
+		}
+		for key, value := range configMap.Data {
+			data[key] = value
+		}
+	case "Secret":
+		if err := l.checkReferenceNamespace(corev1.Resource("secrets"), name, namespace); err != nil {
+			return nil, err
+		}
+		secretData, err := l.getSecretData(namespace, name)
+		if err != nil {
+			if optional && apierrors.IsNotFound(err) {
+				return nil, nil
+			}
+			return nil, err
+		}
+		for key, value := range secretData {
+			data[key] = string(value)
+		}
+	case "ConfigMap":
+		if err := l.checkReferenceNamespace(corev1.Resource("configmaps"), name, namespace); err != nil {
+			return nil, err
+		}
+		configMap, err := l.k8client.CoreV1().ConfigMaps(namespace).Get(l.ctx, name, metav1.GetOptions{})
+		if err != nil {
+			if optional && apierrors.IsNotFound(err) {
+				return nil, nil
+			}
+			return nil, errors.Wrapf(err, "%s - failed to get configmap: %s/%s", logging.CallerStr(logging.Me), namespace, name)
+		}
+		for key, value := range configMap.Data {
+			data[key] = value
+		}
+	default:
+		return nil, fmt.Errorf("unsupported reference kind: %s", kind)
+	}
+	return data, nil
+}
+
+// SetUnresolvedVariables records the names of the variables referenced by the layer's resources that are not defined
+// in its status, raising a warning event when they change.
+func (l *KraanLayer) SetUnresolvedVariables(names []string) {
+	logging.TraceCall(l.GetLogger())
+	defer logging.TraceExit(l.GetLogger())
+	if len(names) == 0 {
+		names = nil
+	}
+	if reflect.DeepEqual(l.addonsLayer.Status.UnresolvedVariables, names) {
+		return
+	}
+	if names != nil {
+		l.recorder.Event(l.ref, corev1.EventTypeWarning, "UnresolvedVariables",
+			fmt.Sprintf("AddonsLayer resources reference undefined variables: %s", strings.Join(names, ", ")))
+	}
+	l.addonsLayer.Status.UnresolvedVariables = names
+	l.updated = true
+}
+
+// SetOutputs records the values of the layer's outputs in its status.
+func (l *KraanLayer) SetOutputs(outputs map[string]string) {
+	logging.TraceCall(l.GetLogger())
+	defer logging.TraceExit(l.GetLogger())
+	if len(outputs) == 0 {
+		outputs = nil
+	}
+	if reflect.DeepEqual(l.addonsLayer.Status.Outputs, outputs) {
+		return
+	}
+	l.addonsLayer.Status.Outputs = outputs
+	l.updated = true
+}
+
+// ReferencesOutputs returns true if the layer's valuesFrom or substituteFrom references the outputs of a layer.
+func (l *KraanLayer) ReferencesOutputs(name string) bool {
+	for _, ref := range l.GetSpec().ValuesFrom {
+		if ref.Kind == kraanv1alpha1.AddonsLayerKind && ref.Name == name {
+			return true
+		}
+	}
+	for _, ref := range l.GetSpec().SubstituteFrom {
+		if ref.Kind == kraanv1alpha1.AddonsLayerKind && ref.Name == name {
+			return true
+		}
+	}
+	return false
+}
+
+// checkReferenceNamespace returns a Forbidden error if cross namespace sources are restricted and a resource referenced
+// by the layer is not in the controller's namespace or a namespace the layer is allowed to deploy addons to.
+func (l *KraanLayer) checkReferenceNamespace(resource schema.GroupResource, name, namespace string) error {
+	if !NoCrossNamespaceSources || namespace == common.GetRuntimeNamespace() {
+		return nil
+	}
+	allowed, err := l.namespaceAllowed(l.k8client, "", namespace)
+	if err != nil {
+		return errors.WithMessagef(err, "%s - failed to check %s namespace", logging.CallerStr(logging.Me), resource.Resource)
+	}
+	if !allowed {
+		return apierrors.NewForbidden(resource, name,
+			fmt.Errorf("cross namespace references are not allowed, layer: %s, is not allowed to use namespace: %s", l.GetName(), namespace))
+	}
+	return nil
+}
+
+func (l *KraanLayer) getSecretData(namespace, name string) (map[string][]byte, error) {
+	secret, err := l.k8client.CoreV1().Secrets(namespace).Get(l.ctx, name, metav1.GetOptions{})
+	if err != nil {
+		return nil, errors.Wrapf(err, "%s - failed to get secret: %s/%s", logging.CallerStr(logging.Me), namespace, name)
+	}
+	return secret.Data, nil
+}
+
+// namespaceAllowed returns true if a namespace is one of the layer's allowed namespaces or its labels match one of the
+// allowed namespace selectors. All namespaces are allowed if the layer has no allowed namespaces. The labels of each
+// namespace are read once per reconcile, clusterKey identifies the cluster the namespace is in.
+func (l *KraanLayer) namespaceAllowed(k8client kubernetes.Interface, clusterKey, namespace string) (bool, error) {
+	allowed := l.GetSpec().AllowedNamespaces
+	if allowed == nil || common.ContainsString(allowed.Names, namespace) {
+		return true, nil
+	}
+	if len(allowed.Selectors) == 0 {
+		return false, nil
+	}
+	nsLabels, err := l.getNamespaceLabels(k8client, clusterKey, namespace)
+	if err != nil {
+		return false, err
+	}
+	if nsLabels == nil {
+		return false, nil
+	}
+	for index := range allowed.Selectors {
+		selector, err := metav1.LabelSelectorAsSelector(&allowed.Selectors[index])
+		if err != nil {
+			return false, errors.Wrapf(err, "%s - invalid allowed namespaces selector", logging.CallerStr(logging.Me))
+		}
+		if selector.Matches(nsLabels) {
+			return true, nil
+		}
+	}
+	return false, nil
+}
+
+// getNamespaceLabels returns the labels of a namespace, or nil if it does not exist, caching the result for the rest of
+// the reconcile.
+func (l *KraanLayer) getNamespaceLabels(k8client kubernetes.Interface, clusterKey, namespace string) (labels.Set, error) {
+	key := fmt.Sprintf("%s#%s", clusterKey, namespace)
+	if nsLabels, ok := l.namespaces[key]; ok {
+		return nsLabels, nil
+	}
+	var nsLabels labels.Set
+	ns, err := k8client.CoreV1().Namespaces().Get(l.ctx, namespace, metav1.GetOptions{})
+	if err == nil {
+		nsLabels = labels.Set(ns.Labels)
+		if nsLabels == nil {
+			nsLabels = labels.Set{}
+		}
+	} else if !apierrors.IsNotFound(err) {
+		return nil, errors.Wrapf(err, "%s - failed to get namespace: %s", logging.CallerStr(logging.Me), namespace)
+	}
+	if l.namespaces == nil {
+		l.namespaces = map[string]labels.Set{}
+	}
+	l.namespaces[key] = nsLabels
+	return nsLabels, nil
 }
 
 // StatusUpdate sets the addon layer's status.
@@ -274,6 +979,28 @@ func (l *KraanLayer) StatusUpdate(status, message string) {
 	l.setStatus(status, message)
 }
 
+// SetPolicyViolations records the LayerPolicy violations of the layer's resources in its status, raising a warning
+// event for each new violation of an audit only policy.
+func (l *KraanLayer) SetPolicyViolations(violations []kraanv1alpha1.PolicyViolation) {
+	logging.TraceCall(l.GetLogger())
+	defer logging.TraceExit(l.GetLogger())
+	if len(violations) == 0 {
+		violations = nil
+	}
+	if reflect.DeepEqual(l.addonsLayer.Status.PolicyViolations, violations) {
+		return
+	}
+	for _, violation := range violations {
+		if violation.Mode == kraanv1alpha1.PolicyModeAudit {
+			l.recorder.Event(l.ref, corev1.EventTypeWarning, kraanv1alpha1.PolicyViolationCondition,
+				fmt.Sprintf("%s %s/%s violates audit rule: %s/%s, %s", violation.Kind, violation.Namespace, violation.Name,
+					violation.Policy, violation.Rule, violation.Message))
+		}
+	}
+	l.addonsLayer.Status.PolicyViolations = violations
+	l.updated = true
+}
+
 // IsHold returns hold status.
 func (l *KraanLayer) IsHold() bool {
 	return l.addonsLayer.Spec.Hold
@@ -287,15 +1014,42 @@ func getNameVersion(nameVersion string) (string, string) {
 	return parts[0], parts[1]
 }
 
-func (l *KraanLayer) RevisionReady(conditions []metav1.Condition, revision string) (bool, string) {
+// ParseDependency returns the layer name, HelmRelease name and version of a dependency. The HelmRelease name is only
+// set for a dependency on a single HelmRelease of the layer, written as 'layer/release@version' or
+// 'layer/namespace/release@version', it includes the namespace if specified.
+func ParseDependency(dependency string) (name, release, version string) {
+	name, version = getNameVersion(dependency)
+	if index := strings.Index(name, "/"); index >= 0 {
+		return name[:index], name[index+1:], version
+	}
+	return name, "", version
+}
+
+// ReleaseMatches returns true if a HelmRelease is the release of a release dependency, 'release' or 'namespace/release'.
+func ReleaseMatches(release string, hr metav1.Object) bool {
+	if index := strings.Index(release, "/"); index >= 0 {
+		return release[:index] == hr.GetNamespace() && release[index+1:] == hr.GetName()
+	}
+	return release == hr.GetName()
+}
+
+// GetDependencyVersions returns the versions of the layers the layer depends on, including optional dependencies, by
+// name.
+func (l *KraanLayer) GetDependencyVersions() map[string]string {
+	dependencies := append(append([]string{}, l.GetSpec().PreReqs.DependsOn...), l.GetSpec().PreReqs.OptionalDependsOn...)
+	versions := make(map[string]string, len(dependencies))
+	for _, dependency := range dependencies {
+		name, _, version := ParseDependency(dependency)
+		versions[name] = version
+	}
+	return versions
+}
+
+// SourceReady checks that a layer's source has been reconciled and has an artifact available.
+func (l *KraanLayer) SourceReady(srcRepo *sourcev1.GitRepository) (bool, string) {
 	logging.TraceCall(l.GetLogger())
 	defer logging.TraceExit(l.GetLogger())
-	for _, cond := range conditions {
-		if cond.Type == "Ready" {
-			return cond.Status == metav1.ConditionTrue && strings.Contains(cond.Message, revision), cond.Message
-		}
-	}
-	return false, "GitRepository not yet reconciled"
+	return repos.SourceReady(srcRepo)
 }
 
 func (l *KraanLayer) isOtherDeployed(otherVersion string, otherLayer *kraanv1alpha1.AddonsLayer) bool { //nolint: funlen // ok
@@ -318,7 +1072,26 @@ func (l *KraanLayer) isOtherDeployed(otherVersion string, otherLayer *kraanv1alp
 		l.setStatus(kraanv1alpha1.ApplyPendingCondition, message)
 		return false
 	}
-	otherSource, err := l.getSource(common.GetSourceNamespace(otherLayer.Spec.Source.NameSpace), otherLayer.Spec.Source.Name)
+	for index, source := range otherLayer.Spec.GetSources() {
+		if !l.isOtherSourceDeployed(otherLayer, source, deployedRevision(otherLayer, index)) {
+			return false
+		}
+	}
+	if otherLayer.Status.State != kraanv1alpha1.DeployedCondition {
+		l.GetLogger().V(2).Info("waiting for deployed", append(logging.GetFunctionAndSource(logging.MyCaller),
+			"dependson", otherLayer.Name, "state", otherLayer.Status.State, "layer", l.GetName())...)
+		message := fmt.Sprintf("Waiting for layer: %s, version: %s to be applied. Layer: %s, current state: %s.",
+			otherLayer.ObjectMeta.Name, otherVersion, otherLayer.ObjectMeta.Name, otherLayer.Status.State)
+		l.setStatus(kraanv1alpha1.ApplyPendingCondition, message)
+		return false
+	}
+	return true
+}
+
+// isOtherSourceDeployed checks that the revision of one of another layer's sources that has been deployed is the
+// source's current revision.
+func (l *KraanLayer) isOtherSourceDeployed(otherLayer *kraanv1alpha1.AddonsLayer, source kraanv1alpha1.SourceSpec, deployed string) bool {
+	otherSource, err := l.getLayerSource(source)
 	if err != nil {
 		message := fmt.Sprintf("Unable to obtain source revision for layer: %s, %s", otherLayer.ObjectMeta.Name, err.Error())
 		l.setStatus(kraanv1alpha1.FailedCondition, message)
@@ -338,52 +1111,130 @@ func (l *KraanLayer) isOtherDeployed(otherVersion string, otherLayer *kraanv1alp
 	if otherSource.Status.Artifact != nil {
 		revision = otherSource.Status.Artifact.Revision
 	}
-	ready, srcMsg := l.RevisionReady(otherSource.Status.Conditions, revision)
+	ready, srcMsg := l.SourceReady(otherSource)
 	if !ready {
 		l.GetLogger().V(2).Info("waiting for source to be ready", append(logging.GetFunctionAndSource(logging.MyCaller),
 			"dependson", otherLayer.Name, "source", otherSource.ObjectMeta.Name,
-			"deployed", otherLayer.Status.DeployedRevision, "revision", revision, "layer", l.GetName())...)
+			"deployed", deployed, "revision", revision, "layer", l.GetName())...)
 		message := fmt.Sprintf("Waiting for layer: %s, layer source: %s not ready. Layer: %s, source: %s, status: %s.",
 			otherLayer.ObjectMeta.Name, otherSource.ObjectMeta.Name, otherLayer.ObjectMeta.Name, otherSource.ObjectMeta.Name, srcMsg)
 		l.setStatus(kraanv1alpha1.ApplyPendingCondition, message)
 		return false
 	}
 
-	if otherLayer.Status.DeployedRevision != otherSource.Status.Artifact.Revision {
+	if !repos.RevisionsEqual(deployed, otherSource.Status.Artifact.Revision) {
 		l.GetLogger().V(2).Info("waiting for source revision", append(logging.GetFunctionAndSource(logging.MyCaller),
 			"dependson", otherLayer.Name, "source", otherSource.ObjectMeta.Name,
-			"deployed", otherLayer.Status.DeployedRevision, "revision", otherSource.Status.Artifact.Revision, "layer", l.GetName())...)
+			"deployed", deployed, "revision", otherSource.Status.Artifact.Revision, "layer", l.GetName())...)
 		message := fmt.Sprintf("Waiting for layer: %s, to apply source revision: %s. Layer: %s, current state: %s, deployed revision: %s.",
-			otherLayer.ObjectMeta.Name, otherSource.Status.Artifact.Revision, otherLayer.ObjectMeta.Name, otherLayer.Status.State, otherLayer.Status.DeployedRevision)
-		l.setStatus(kraanv1alpha1.ApplyPendingCondition, message)
-		return false
-	}
-	if otherLayer.Status.State != kraanv1alpha1.DeployedCondition {
-		l.GetLogger().V(2).Info("waiting for deployed", append(logging.GetFunctionAndSource(logging.MyCaller),
-			"dependson", otherLayer.Name, "state", otherLayer.Status.State, "layer", l.GetName())...)
-		message := fmt.Sprintf("Waiting for layer: %s, version: %s to be applied. Layer: %s, current state: %s.",
-			otherLayer.ObjectMeta.Name, otherVersion, otherLayer.ObjectMeta.Name, otherLayer.Status.State)
+			otherLayer.ObjectMeta.Name, otherSource.Status.Artifact.Revision, otherLayer.ObjectMeta.Name, otherLayer.Status.State, deployed)
 		l.setStatus(kraanv1alpha1.ApplyPendingCondition, message)
 		return false
 	}
 	return true
 }
 
-// DependenciesDeployed checks that all the layers this layer is dependent on are deployed.
+// deployedRevision returns the revision of a layer's source that has been deployed.
+func deployedRevision(addonsLayer *kraanv1alpha1.AddonsLayer, index int) string {
+	if index == 0 {
+		return addonsLayer.Status.DeployedRevision
+	}
+	if index < len(addonsLayer.Status.SourceRevisions) {
+		return addonsLayer.Status.SourceRevisions[index].Revision
+	}
+	return ""
+}
+
+// DependenciesDeployed checks that all the layers this layer is dependent on are deployed. An optional dependency that
+// is not applicable to the cluster is treated as deployed.
 func (l *KraanLayer) DependenciesDeployed() bool {
 	logging.TraceCall(l.GetLogger())
 	defer logging.TraceExit(l.GetLogger())
 	for _, otherNameVersion := range l.GetSpec().PreReqs.DependsOn {
-		otherName, otherVersion := getNameVersion(otherNameVersion)
-		otherLayer, err := l.getOtherAddonsLayer(otherName)
+		if !l.isDependencyDeployed(otherNameVersion, false) {
+			return false
+		}
+	}
+	for _, otherNameVersion := range l.GetSpec().PreReqs.OptionalDependsOn {
+		if !l.isDependencyDeployed(otherNameVersion, true) {
+			return false
+		}
+	}
+	return true
+}
+
+func (l *KraanLayer) isDependencyDeployed(otherNameVersion string, optional bool) bool {
+	otherName, release, otherVersion := ParseDependency(otherNameVersion)
+	otherLayer, err := l.getOtherAddonsLayer(otherName)
+	if err != nil {
+		l.StatusUpdate(kraanv1alpha1.FailedCondition, fmt.Sprintf("%s, %s", kraanv1alpha1.AddonsLayerFailedMsg, err.Error()))
+		return false
+	}
+	if optional && otherLayer.Status.State == kraanv1alpha1.NotApplicableCondition &&
+		otherLayer.Status.ObservedGeneration == otherLayer.Generation {
+		l.GetLogger().V(1).Info("optional dependency not applicable", append(logging.GetFunctionAndSource(logging.MyCaller), "dependson", otherName, "layer", l.GetName())...)
+		return true
+	}
+	if len(release) > 0 {
+		return l.isOtherReleaseReady(release, otherVersion, otherLayer)
+	}
+	return l.isOtherDeployed(otherVersion, otherLayer)
+}
+
+// isOtherReleaseReady checks that a HelmRelease applied by the required version of another layer is ready.
+func (l *KraanLayer) isOtherReleaseReady(release, otherVersion string, otherLayer *kraanv1alpha1.AddonsLayer) bool {
+	logging.TraceCall(l.GetLogger())
+	defer logging.TraceExit(l.GetLogger())
+	l.GetLogger().V(1).Info("checking release dependency", append(logging.GetFunctionAndSource(logging.MyCaller), "dependson", otherLayer.Name, "release", release, "layer", l.GetName())...)
+	if !reflect.DeepEqual(otherLayer.Spec.KubeConfig, l.GetSpec().KubeConfig) {
+		message := fmt.Sprintf("%s, release: %s, of layer: %s, is applied to a different cluster", kraanv1alpha1.AddonsLayerFailedMsg, release, otherLayer.Name)
+		l.setStatus(kraanv1alpha1.FailedCondition, message)
+		return false
+	}
+	hrList := &helmctlv2.HelmReleaseList{}
+	if err := l.getClient().List(l.ctx, hrList, client.MatchingLabels{common.OwnerLabel: otherLayer.Name}); err != nil {
+		l.setStatus(kraanv1alpha1.FailedCondition, fmt.Sprintf("%s, %s", kraanv1alpha1.AddonsLayerFailedMsg, err.Error()))
+		return false
+	}
+	matches := []*helmctlv2.HelmRelease{}
+	for index := range hrList.Items {
+		if ReleaseMatches(release, &hrList.Items[index]) {
+			matches = append(matches, &hrList.Items[index])
+		}
+	}
+	if len(matches) > 1 {
+		message := fmt.Sprintf("%s, release: %s, matches more than one HelmRelease of layer: %s, specify the dependency as: %s/namespace/%s@%s",
+			kraanv1alpha1.AddonsLayerFailedMsg, release, otherLayer.Name, otherLayer.Name, release, otherVersion)
+		l.setStatus(kraanv1alpha1.FailedCondition, message)
+		return false
+	}
+	found := false
+	for _, hr := range matches {
+		found = true
+		if version := hr.Annotations[common.LayerVersionAnnotation]; version != otherVersion {
+			message := fmt.Sprintf("Waiting for layer: %s, version: %s, release: %s to be applied. Release: %s, applied by version: %s.",
+				otherLayer.Name, otherVersion, release, client.ObjectKeyFromObject(hr), version)
+			l.setStatus(kraanv1alpha1.ApplyPendingCondition, message)
+			return false
+		}
+		content, err := runtime.DefaultUnstructuredConverter.ToUnstructured(hr)
 		if err != nil {
-			l.StatusUpdate(kraanv1alpha1.FailedCondition, fmt.Sprintf("%s, %s", kraanv1alpha1.AddonsLayerFailedMsg, err.Error()))
+			l.setStatus(kraanv1alpha1.FailedCondition, fmt.Sprintf("%s, %s", kraanv1alpha1.AddonsLayerFailedMsg, err.Error()))
 			return false
 		}
-		if !l.isOtherDeployed(otherVersion, otherLayer) {
+		if result := readiness.Compute(&unstructured.Unstructured{Object: content}, "Ready"); !result.Ready() {
+			message := fmt.Sprintf("Waiting for layer: %s, version: %s, release: %s to be ready. Release: %s, %s, %s.",
+				otherLayer.Name, otherVersion, release, client.ObjectKeyFromObject(hr), result.Status, result.Message)
+			l.setStatus(kraanv1alpha1.ApplyPendingCondition, message)
 			return false
 		}
 	}
+	if !found {
+		message := fmt.Sprintf("Waiting for layer: %s, version: %s, release: %s to be applied. Release not found.",
+			otherLayer.Name, otherVersion, release)
+		l.setStatus(kraanv1alpha1.ApplyPendingCondition, message)
+		return false
+	}
 	return true
 }
 
@@ -453,6 +1304,20 @@ func (l *KraanLayer) getOtherAddonsLayer(name string) (*kraanv1alpha1.AddonsLaye
 	return obj, nil
 }
 
+// getLayerSource returns the GitRepository Source described by a layer's source spec.
+// Directory sources are described by a GitRepository built from the local directory or tarball.
+func (l *KraanLayer) getLayerSource(source kraanv1alpha1.SourceSpec) (*sourcev1.GitRepository, error) {
+	namespace := common.GetSourceNamespace(source.NameSpace)
+	if source.Kind == kraanv1alpha1.DirectorySourceKind {
+		srcRepo, err := repos.NewLocalSource(source.Name, namespace, source.URL)
+		if err != nil {
+			return nil, errors.WithMessagef(err, "%s - failed to retrieve directory source: %s", logging.CallerStr(logging.Me), source.URL)
+		}
+		return srcRepo, nil
+	}
+	return l.getSource(namespace, common.GetSourceName(source))
+}
+
 // getSource returns a GitRepository Source.
 func (l *KraanLayer) getSource(namespace, name string) (*sourcev1.GitRepository, error) {
 	logging.TraceCall(l.GetLogger())
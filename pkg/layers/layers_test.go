@@ -5,11 +5,14 @@ import (
 	"encoding/json"
 	"fmt"
 	"os"
+	"reflect"
 	"testing"
 
+	helmctlv2 "github.com/fluxcd/helm-controller/api/v2beta1"
 	sourcev1 "github.com/fluxcd/source-controller/api/v1beta2"
 	"github.com/go-logr/logr"
 	corev1 "k8s.io/api/core/v1"
+	apierrors "k8s.io/apimachinery/pkg/api/errors"
 	"k8s.io/apimachinery/pkg/runtime"
 	"k8s.io/apimachinery/pkg/version"
 	fakediscovery "k8s.io/client-go/discovery/fake"
@@ -18,8 +21,10 @@ import (
 	"k8s.io/client-go/tools/record"
 
 	//k8sscheme "k8s.io/client-go/kubernetes/scheme"
+	apiextensionsv1 "k8s.io/apiextensions-apiserver/pkg/apis/apiextensions/v1"
 	extv1b1 "k8s.io/apiextensions-apiserver/pkg/apis/apiextensions/v1beta1"
 	metav1 "k8s.io/apimachinery/pkg/apis/meta/v1"
+	"k8s.io/apimachinery/pkg/apis/meta/v1/unstructured"
 	"sigs.k8s.io/controller-runtime/pkg/client/fake"
 
 	kraanv1alpha1 "github.com/fidelity/kraan/api/v1alpha1"
@@ -69,6 +74,7 @@ func init() {
 	_ = corev1.AddToScheme(testScheme)        //nolint:errcheck // ok
 	_ = sourcev1.AddToScheme(testScheme)      //nolint:errcheck // ok
 	_ = kraanv1alpha1.AddToScheme(testScheme) //nolint:errcheck // ok
+	_ = helmctlv2.AddToScheme(testScheme)     //nolint:errcheck // ok
 }
 
 func TestCreateLayer(t *testing.T) {
@@ -659,3 +665,620 @@ func TestDependenciesDeployed(t *testing.T) { //nolint: funlen // ok
 		t.Logf("test: %s, successful", test.name)
 	}
 }
+
+func TestNamespaceAllowed(t *testing.T) { //nolint:funlen // ok
+	t.Setenv("RUNTIME_NAMESPACE", "gotk-system")
+	k8sClient := fakeK8s.NewSimpleClientset(
+		&corev1.Namespace{ObjectMeta: metav1.ObjectMeta{Name: "team-a", Labels: map[string]string{"team": "a"}}},
+		&corev1.Namespace{ObjectMeta: metav1.ObjectMeta{Name: "team-b", Labels: map[string]string{"team": "b"}}},
+		&corev1.Namespace{ObjectMeta: metav1.ObjectMeta{Name: "kube-system"}},
+	)
+	newLayer := func(allowed *kraanv1alpha1.AllowedNamespacesSpec, sourceNamespaces ...string) layers.Layer {
+		addonsLayer := &kraanv1alpha1.AddonsLayer{ObjectMeta: metav1.ObjectMeta{Name: "tenant"}}
+		addonsLayer.Spec.AllowedNamespaces = allowed
+		for index, namespace := range sourceNamespaces {
+			source := kraanv1alpha1.SourceSpec{Name: "addons-config", NameSpace: namespace, Path: "./addons"}
+			if index == 0 {
+				addonsLayer.Spec.Source = source
+				continue
+			}
+			addonsLayer.Spec.Sources = append(addonsLayer.Spec.Sources, source)
+		}
+		client := fake.NewClientBuilder().WithScheme(testScheme).Build()
+		return layers.CreateLayer(context.Background(), client, k8sClient, logr.Discard(), record.NewFakeRecorder(10), testScheme, addonsLayer)
+	}
+
+	selectors := &kraanv1alpha1.AllowedNamespacesSpec{
+		Names:     []string{"shared"},
+		Selectors: []metav1.LabelSelector{{MatchLabels: map[string]string{"team": "a"}}},
+	}
+	tests := []struct {
+		name      string
+		allowed   *kraanv1alpha1.AllowedNamespacesSpec
+		namespace string
+		expected  bool
+	}{
+		{"no restriction", nil, "kube-system", true},
+		{"allowed by name", selectors, "shared", true},
+		{"allowed by selector", selectors, "team-a", true},
+		{"not matching selector", selectors, "team-b", false},
+		{"not allowed", selectors, "kube-system", false},
+		{"namespace not found", selectors, "missing", false},
+		{"nothing allowed", &kraanv1alpha1.AllowedNamespacesSpec{}, "team-a", false},
+	}
+	for _, test := range tests {
+		allowed, err := newLayer(test.allowed).IsNamespaceAllowed(test.namespace)
+		if err != nil {
+			t.Fatalf("test: %s, failed, error: %s", test.name, err)
+		}
+		if allowed != test.expected {
+			t.Fatalf("test: %s, failed, wrong result, Actual: %t, Expected: %t", test.name, allowed, test.expected)
+		}
+	}
+
+	invalid := &kraanv1alpha1.AllowedNamespacesSpec{Selectors: []metav1.LabelSelector{{MatchExpressions: []metav1.LabelSelectorRequirement{{Key: "team", Operator: "Bad"}}}}}
+	if _, err := newLayer(invalid).IsNamespaceAllowed("team-a"); err == nil {
+		t.Fatalf("invalid selector did not return an error")
+	}
+
+	if err := newLayer(nil, "", "team-b").CheckSourceNamespaces(); err != nil {
+		t.Fatalf("cross namespace source rejected when not restricted: %s", err)
+	}
+	layers.NoCrossNamespaceSources = true
+	defer func() { layers.NoCrossNamespaceSources = false }()
+	if err := newLayer(nil, "", "gotk-system").CheckSourceNamespaces(); err != nil {
+		t.Fatalf("source in controller namespace rejected: %s", err)
+	}
+	if err := newLayer(selectors, "", "team-a").CheckSourceNamespaces(); err != nil {
+		t.Fatalf("source in allowed namespace rejected: %s", err)
+	}
+	if err := newLayer(selectors, "", "team-b").CheckSourceNamespaces(); !apierrors.IsForbidden(err) {
+		t.Fatalf("source in namespace not allowed did not return a forbidden error: %v", err)
+	}
+
+	gets := 0
+	k8sClient.PrependReactor("get", "namespaces", func(action fakeTest.Action) (bool, runtime.Object, error) {
+		gets++
+		return false, nil, nil
+	})
+	l := newLayer(selectors)
+	for i := 0; i < 3; i++ {
+		for _, namespace := range []string{"team-a", "team-b", "missing"} {
+			if _, err := l.IsNamespaceAllowed(namespace); err != nil {
+				t.Fatalf("IsNamespaceAllowed returned an error: %s", err)
+			}
+		}
+	}
+	if gets != 3 {
+		t.Fatalf("namespaces not cached, expected 3 gets, got: %d", gets)
+	}
+}
+
+func TestCheckServiceAccountNamespace(t *testing.T) {
+	tests := []struct {
+		name      string
+		account   string
+		namespace string
+		allowed   *kraanv1alpha1.AllowedNamespacesSpec
+		forbidden bool
+	}{
+		{name: "no service account", allowed: &kraanv1alpha1.AllowedNamespacesSpec{Names: []string{"team-a"}}},
+		{name: "no allowed namespaces", account: "deployer", namespace: "kube-system"},
+		{name: "allowed namespace", account: "deployer", namespace: "team-a", allowed: &kraanv1alpha1.AllowedNamespacesSpec{Names: []string{"team-a"}}},
+		{name: "other namespace", account: "deployer", namespace: "kube-system", allowed: &kraanv1alpha1.AllowedNamespacesSpec{Names: []string{"team-a"}}, forbidden: true},
+		{name: "controller namespace", account: "deployer", allowed: &kraanv1alpha1.AllowedNamespacesSpec{Names: []string{"team-a"}}, forbidden: true},
+	}
+	for _, test := range tests {
+		addonsLayer := &kraanv1alpha1.AddonsLayer{ObjectMeta: metav1.ObjectMeta{Name: "apps"}}
+		addonsLayer.Spec.ServiceAccountName = test.account
+		addonsLayer.Spec.ServiceAccountNamespace = test.namespace
+		addonsLayer.Spec.AllowedNamespaces = test.allowed
+		client := fake.NewClientBuilder().WithScheme(testScheme).Build()
+		l := layers.CreateLayer(context.Background(), client, fakeK8s.NewSimpleClientset(), logr.Discard(), record.NewFakeRecorder(10), testScheme, addonsLayer)
+		err := l.CheckServiceAccountNamespace()
+		if test.forbidden && !apierrors.IsForbidden(err) {
+			t.Errorf("test: %s, CheckServiceAccountNamespace did not return a forbidden error: %v", test.name, err)
+		}
+		if !test.forbidden && err != nil {
+			t.Errorf("test: %s, CheckServiceAccountNamespace returned an error: %s", test.name, err)
+		}
+	}
+}
+
+func TestGetValues(t *testing.T) { //nolint:funlen // ok
+	t.Setenv("RUNTIME_NAMESPACE", "gotk-system")
+	k8sClient := fakeK8s.NewSimpleClientset(
+		&corev1.ConfigMap{
+			ObjectMeta: metav1.ObjectMeta{Name: "cluster-values", Namespace: "gotk-system"},
+			Data:       map[string]string{"values.yaml": "cluster:\n  name: dev\n  region: eu-west-1\nmirror: registry.example.com\n"},
+		},
+		&corev1.Secret{
+			ObjectMeta: metav1.ObjectMeta{Name: "region-values", Namespace: "team-a"},
+			Data:       map[string][]byte{"override.yaml": []byte("cluster:\n  region: us-east-1\n")},
+		},
+		&corev1.ConfigMap{
+			ObjectMeta: metav1.ObjectMeta{Name: "kraan-ingress-outputs", Namespace: "gotk-system"},
+			Data:       map[string]string{"ingressClass": "nginx", "caBundle": "LS0tLS1CRUdJTg=="},
+		},
+	)
+	newLayer := func(values string, valuesFrom ...kraanv1alpha1.ValuesReference) layers.Layer {
+		addonsLayer := &kraanv1alpha1.AddonsLayer{ObjectMeta: metav1.ObjectMeta{Name: "apps"}}
+		if values != "" {
+			addonsLayer.Spec.Values = &apiextensionsv1.JSON{Raw: []byte(values)}
+		}
+		addonsLayer.Spec.ValuesFrom = valuesFrom
+		client := fake.NewClientBuilder().WithScheme(testScheme).Build()
+		return layers.CreateLayer(context.Background(), client, k8sClient, logr.Discard(), record.NewFakeRecorder(10), testScheme, addonsLayer)
+	}
+	clusterValues := kraanv1alpha1.ValuesReference{Kind: "ConfigMap", Name: "cluster-values"}
+	regionValues := kraanv1alpha1.ValuesReference{Kind: "Secret", Name: "region-values", Namespace: "team-a", ValuesKey: "override.yaml"}
+	missing := kraanv1alpha1.ValuesReference{Kind: "ConfigMap", Name: "missing"}
+
+	tests := []struct {
+		name      string
+		layer     layers.Layer
+		expected  map[string]interface{}
+		expectErr bool
+	}{{
+		name:  "no values",
+		layer: newLayer(""),
+	}, {
+		name:  "values from in order then values, secrets are not read",
+		layer: newLayer(`{"cluster":{"name":"test"}}`, clusterValues, regionValues),
+		expected: map[string]interface{}{
+			"cluster": map[string]interface{}{"name": "test", "region": "eu-west-1"},
+			"mirror":  "registry.example.com",
+		},
+	}, {
+		name:      "missing reference",
+		layer:     newLayer("", missing),
+		expectErr: true,
+	}, {
+		name:     "optional missing reference",
+		layer:    newLayer(`{"mirror":"local"}`, kraanv1alpha1.ValuesReference{Kind: "ConfigMap", Name: "missing", Optional: true}),
+		expected: map[string]interface{}{"mirror": "local"},
+	}, {
+		name:      "missing key",
+		layer:     newLayer("", kraanv1alpha1.ValuesReference{Kind: "ConfigMap", Name: "cluster-values", ValuesKey: "missing.yaml"}),
+		expectErr: true,
+	}, {
+		name: "value at target path",
+		layer: newLayer("", kraanv1alpha1.ValuesReference{Kind: "ConfigMap", Name: "cluster-values",
+			ValuesKey: "values.yaml", TargetPath: "config.raw"}),
+		expected: map[string]interface{}{
+			"config": map[string]interface{}{"raw": "cluster:\n  name: dev\n  region: eu-west-1\nmirror: registry.example.com\n"},
+		},
+	}, {
+		name: "layer outputs",
+		layer: newLayer(`{"ingress":{"enabled":true}}`,
+			kraanv1alpha1.ValuesReference{Kind: "AddonsLayer", Name: "ingress", ValuesKey: "ingressClass", TargetPath: "ingress.className"},
+			kraanv1alpha1.ValuesReference{Kind: "AddonsLayer", Name: "ingress", ValuesKey: "caBundle"},
+			kraanv1alpha1.ValuesReference{Kind: "AddonsLayer", Name: "ingress", TargetPath: "platform"}),
+		expected: map[string]interface{}{
+			"ingress":  map[string]interface{}{"enabled": true, "className": "nginx"},
+			"caBundle": "LS0tLS1CRUdJTg==",
+			"platform": map[string]interface{}{"ingressClass": "nginx", "caBundle": "LS0tLS1CRUdJTg=="},
+		},
+	}, {
+		name:      "missing layer outputs",
+		layer:     newLayer("", kraanv1alpha1.ValuesReference{Kind: "AddonsLayer", Name: "mesh"}),
+		expectErr: true,
+	}, {
+		name:     "optional missing layer output",
+		layer:    newLayer("", kraanv1alpha1.ValuesReference{Kind: "AddonsLayer", Name: "ingress", ValuesKey: "missing", Optional: true}),
+		expected: map[string]interface{}{},
+	}}
+	for _, test := range tests {
+		values, err := test.layer.GetValues()
+		if test.expectErr {
+			if err == nil {
+				t.Fatalf("test: %s, failed, no error returned", test.name)
+			}
+			continue
+		}
+		if err != nil {
+			t.Fatalf("test: %s, failed, error: %s", test.name, err)
+		}
+		if !reflect.DeepEqual(values, test.expected) {
+			t.Fatalf("test: %s, failed, wrong result, Actual: %v, Expected: %v", test.name, values, test.expected)
+		}
+	}
+
+	layers.NoCrossNamespaceSources = true
+	defer func() { layers.NoCrossNamespaceSources = false }()
+	restricted := newLayer("", kraanv1alpha1.ValuesReference{Kind: "ConfigMap", Name: "region-values", Namespace: "team-a"})
+	restricted.GetSpec().AllowedNamespaces = &kraanv1alpha1.AllowedNamespacesSpec{Names: []string{"apps"}}
+	if _, err := restricted.GetValues(); !apierrors.IsForbidden(err) {
+		t.Fatalf("values reference in namespace not allowed did not return a forbidden error: %v", err)
+	}
+}
+
+func TestGetVariables(t *testing.T) {
+	t.Setenv("RUNTIME_NAMESPACE", "gotk-system")
+	k8sClient := fakeK8s.NewSimpleClientset(
+		&corev1.ConfigMap{
+			ObjectMeta: metav1.ObjectMeta{Name: "cluster-vars", Namespace: "gotk-system"},
+			Data:       map[string]string{"env": "dev", "KRAAN_LAYER_NAME": "ignored"},
+		},
+		&corev1.Secret{
+			ObjectMeta: metav1.ObjectMeta{Name: "env-override", Namespace: "gotk-system"},
+			Data:       map[string][]byte{"env": []byte("test")},
+		},
+	)
+	fakeD, ok := k8sClient.Discovery().(*fakediscovery.FakeDiscovery)
+	if !ok {
+		t.Fatalf("couldn't convert Discovery() to *FakeDiscovery")
+	}
+	fakeD.FakedServerVersion = &version.Info{GitVersion: "v1.26.3"}
+	addonsLayer := &kraanv1alpha1.AddonsLayer{ObjectMeta: metav1.ObjectMeta{Name: "apps"}}
+	addonsLayer.Spec.Version = versionOne
+	addonsLayer.Spec.SubstituteFrom = []kraanv1alpha1.SubstituteReference{
+		{Kind: "ConfigMap", Name: "cluster-vars"},
+		{Kind: "Secret", Name: "env-override"},
+		{Kind: "ConfigMap", Name: "missing", Optional: true},
+	}
+	client := fake.NewClientBuilder().WithScheme(testScheme).Build()
+	l := layers.CreateLayer(context.Background(), client, k8sClient, logr.Discard(), record.NewFakeRecorder(10), testScheme, addonsLayer)
+	l.SetSourceRevision("main@sha1:1234")
+
+	variables, err := l.GetVariables()
+	if err != nil {
+		t.Fatalf("GetVariables returned an error: %s", err)
+	}
+	expected := map[string]string{
+		"env":                         "test",
+		layers.LayerNameVariable:      "apps",
+		layers.LayerVersionVariable:   versionOne,
+		layers.SourceRevisionVariable: "main@sha1:1234",
+		layers.K8sVersionVariable:     "v1.26.3",
+	}
+	if !reflect.DeepEqual(variables, expected) {
+		t.Fatalf("wrong result, Actual: %v, Expected: %v", variables, expected)
+	}
+
+	l.SetUnresolvedVariables([]string{"REGION"})
+	if !l.IsUpdated() || !reflect.DeepEqual(l.GetFullStatus().UnresolvedVariables, []string{"REGION"}) {
+		t.Fatalf("unresolved variables not recorded in status: %v", l.GetFullStatus().UnresolvedVariables)
+	}
+}
+
+func TestGetClusterInfo(t *testing.T) {
+	k8sClient := fakeK8s.NewSimpleClientset(
+		&corev1.Node{ObjectMeta: metav1.ObjectMeta{Name: "node-1"}},
+		&corev1.Node{ObjectMeta: metav1.ObjectMeta{Name: "node-2"}},
+		&corev1.ConfigMap{
+			ObjectMeta: metav1.ObjectMeta{Name: "kraan-cluster-info", Namespace: "kube-system", Labels: map[string]string{"env": "dev", "cloud": "gcp"}},
+			Data:       map[string]string{"cloud": "aws", "region": "eu-west-1"},
+		},
+	)
+	fakeD, ok := k8sClient.Discovery().(*fakediscovery.FakeDiscovery)
+	if !ok {
+		t.Fatalf("couldn't convert Discovery() to *FakeDiscovery")
+	}
+	fakeD.FakedServerVersion = &version.Info{GitVersion: "v1.26.3"}
+	addonsLayer := &kraanv1alpha1.AddonsLayer{ObjectMeta: metav1.ObjectMeta{Name: "apps"}}
+	addonsLayer.Spec.PreReqs.DependsOn = []string{"bootstrap@0.1.01", "base@0.1.02"}
+	client := fake.NewClientBuilder().WithScheme(testScheme).Build()
+	l := layers.CreateLayer(context.Background(), client, k8sClient, logr.Discard(), record.NewFakeRecorder(10), testScheme, addonsLayer)
+
+	info, err := l.GetClusterInfo()
+	if err != nil {
+		t.Fatalf("GetClusterInfo returned an error: %s", err)
+	}
+	expected := &layers.ClusterInfo{
+		Version:   "v1.26.3",
+		NodeCount: 2,
+		Labels:    map[string]string{"env": "dev", "cloud": "aws", "region": "eu-west-1"},
+	}
+	if !reflect.DeepEqual(info, expected) {
+		t.Fatalf("wrong result, Actual: %v, Expected: %v", info, expected)
+	}
+	if versions := l.GetDependencyVersions(); !reflect.DeepEqual(versions, map[string]string{"bootstrap": "0.1.01", "base": "0.1.02"}) {
+		t.Fatalf("wrong dependency versions: %v", versions)
+	}
+}
+
+func TestSourceResources(t *testing.T) {
+	addonsLayer := &kraanv1alpha1.AddonsLayer{ObjectMeta: metav1.ObjectMeta{Name: "apps"}}
+	client := fake.NewClientBuilder().WithScheme(testScheme).Build()
+	l := layers.CreateLayer(context.Background(), client, fakeK8s.NewSimpleClientset(), logr.Discard(), record.NewFakeRecorder(10), testScheme, addonsLayer)
+	objs := []runtime.Object{&corev1.Secret{ObjectMeta: metav1.ObjectMeta{Namespace: "apps", Name: "registry"}}}
+
+	l.SetSourceResources(objs)
+	if !reflect.DeepEqual(l.GetSourceResources(), objs) {
+		t.Fatalf("GetSourceResources did not return the resources set")
+	}
+	l.SetSourceRevision("main@sha1:1234")
+	if l.GetSourceResources() != nil {
+		t.Fatalf("SetSourceRevision did not clear the source resources")
+	}
+	l.SetSourceResources(objs)
+	l.SetCluster(nil)
+	if l.GetSourceResources() != nil {
+		t.Fatalf("SetCluster did not clear the source resources")
+	}
+}
+
+func TestIsVerificationRequired(t *testing.T) {
+	required := true
+	notRequired := false
+	addonsLayer := &kraanv1alpha1.AddonsLayer{ObjectMeta: metav1.ObjectMeta{Name: "apps"}}
+	addonsLayer.Spec.Source = kraanv1alpha1.SourceSpec{Name: "base", RequireVerified: &notRequired}
+	addonsLayer.Spec.Sources = []kraanv1alpha1.SourceSpec{{Name: "overlay", RequireVerified: &required}, {Name: "other"}}
+	client := fake.NewClientBuilder().WithScheme(testScheme).Build()
+	l := layers.CreateLayer(context.Background(), client, fakeK8s.NewSimpleClientset(), logr.Discard(), record.NewFakeRecorder(10), testScheme, addonsLayer)
+
+	defer func(value bool) { layers.DefaultRequireVerified = value }(layers.DefaultRequireVerified)
+	for _, defaultValue := range []bool{false, true} {
+		layers.DefaultRequireVerified = defaultValue
+		expected := map[string]bool{"base": false, "overlay": true, "other": defaultValue}
+		for _, source := range l.GetSources() {
+			if result := l.IsVerificationRequired(source); result != expected[source.Name] {
+				t.Errorf("default: %t, source: %s, IsVerificationRequired returned %t", defaultValue, source.Name, result)
+			}
+		}
+	}
+}
+
+func TestIsApplicable(t *testing.T) {
+	k8sClient := fakeK8s.NewSimpleClientset(&corev1.ConfigMap{
+		ObjectMeta: metav1.ObjectMeta{Name: "kraan-cluster-info", Namespace: "kube-system"},
+		Data:       map[string]string{"cloud": "aws", "gpu": "true"},
+	})
+	fakeD, ok := k8sClient.Discovery().(*fakediscovery.FakeDiscovery)
+	if !ok {
+		t.Fatalf("couldn't convert Discovery() to *FakeDiscovery")
+	}
+	fakeD.FakedServerVersion = &version.Info{GitVersion: "v1.26.3"}
+	tests := []struct {
+		name      string
+		selector  *metav1.LabelSelector
+		expected  bool
+		expectErr bool
+	}{{
+		name:     "no selector",
+		expected: true,
+	}, {
+		name:     "matching labels",
+		selector: &metav1.LabelSelector{MatchLabels: map[string]string{"gpu": "true"}},
+		expected: true,
+	}, {
+		name: "matching expression",
+		selector: &metav1.LabelSelector{MatchExpressions: []metav1.LabelSelectorRequirement{
+			{Key: "cloud", Operator: metav1.LabelSelectorOpIn, Values: []string{"aws", "gcp"}},
+		}},
+		expected: true,
+	}, {
+		name:     "not matching",
+		selector: &metav1.LabelSelector{MatchLabels: map[string]string{"cloud": "azure"}},
+		expected: false,
+	}, {
+		name: "invalid selector",
+		selector: &metav1.LabelSelector{MatchExpressions: []metav1.LabelSelectorRequirement{
+			{Key: "cloud", Operator: "Like"},
+		}},
+		expectErr: true,
+	}}
+	for _, test := range tests {
+		addonsLayer := &kraanv1alpha1.AddonsLayer{ObjectMeta: metav1.ObjectMeta{Name: "gpu"}}
+		addonsLayer.Spec.ClusterSelector = test.selector
+		client := fake.NewClientBuilder().WithScheme(testScheme).Build()
+		l := layers.CreateLayer(context.Background(), client, k8sClient, logr.Discard(), record.NewFakeRecorder(10), testScheme, addonsLayer)
+		applicable, err := l.IsApplicable()
+		if test.expectErr {
+			if err == nil {
+				t.Fatalf("test: %s, failed, no error returned", test.name)
+			}
+			continue
+		}
+		if err != nil {
+			t.Fatalf("test: %s, failed, error: %s", test.name, err)
+		}
+		if applicable != test.expected {
+			t.Fatalf("test: %s, failed, wrong result, Actual: %t, Expected: %t", test.name, applicable, test.expected)
+		}
+	}
+}
+
+func TestOptionalDependenciesDeployed(t *testing.T) {
+	gpu := &kraanv1alpha1.AddonsLayer{ObjectMeta: metav1.ObjectMeta{Name: "gpu", Generation: 2}}
+	gpu.Spec.Version = versionOne
+	gpu.Status = kraanv1alpha1.AddonsLayerStatus{State: kraanv1alpha1.NotApplicableCondition, Version: versionOne, ObservedGeneration: 2}
+	client := fake.NewClientBuilder().WithScheme(testScheme).WithObjects(gpu).Build()
+	newLayer := func(dependsOn, optionalDependsOn []string) layers.Layer {
+		addonsLayer := &kraanv1alpha1.AddonsLayer{ObjectMeta: metav1.ObjectMeta{Name: "ml"}}
+		addonsLayer.Spec.PreReqs = kraanv1alpha1.PreReqs{DependsOn: dependsOn, OptionalDependsOn: optionalDependsOn}
+		return layers.CreateLayer(context.Background(), client, fakeK8s.NewSimpleClientset(), logr.Discard(), record.NewFakeRecorder(10), testScheme, addonsLayer)
+	}
+	if !newLayer(nil, []string{"gpu@" + versionOne}).DependenciesDeployed() {
+		t.Fatalf("optional dependency that is not applicable was not treated as deployed")
+	}
+	if newLayer([]string{"gpu@" + versionOne}, nil).DependenciesDeployed() {
+		t.Fatalf("dependency that is not applicable was treated as deployed")
+	}
+	gpu.Generation = 3
+	if err := client.Update(context.Background(), gpu); err != nil {
+		t.Fatalf("failed to update layer: %s", err)
+	}
+	if newLayer(nil, []string{"gpu@" + versionOne}).DependenciesDeployed() {
+		t.Fatalf("optional dependency with an unobserved generation was treated as deployed")
+	}
+}
+
+func TestCheckPrerequisites(t *testing.T) {
+	k8sClient := fakeK8s.NewSimpleClientset()
+	fakeD, ok := k8sClient.Discovery().(*fakediscovery.FakeDiscovery)
+	if !ok {
+		t.Fatalf("couldn't convert Discovery() to *FakeDiscovery")
+	}
+	fakeD.Resources = []*metav1.APIResourceList{{
+		GroupVersion: "monitoring.coreos.com/v1",
+		APIResources: []metav1.APIResource{{Name: "servicemonitors", Kind: "ServiceMonitor"}},
+	}, {
+		GroupVersion: "v1",
+		APIResources: []metav1.APIResource{{Name: "configmaps", Kind: "ConfigMap"}},
+	}}
+	newCRD := func(name, established string) *unstructured.Unstructured {
+		return &unstructured.Unstructured{Object: map[string]interface{}{
+			"apiVersion": "apiextensions.k8s.io/v1",
+			"kind":       "CustomResourceDefinition",
+			"metadata":   map[string]interface{}{"name": name},
+			"status": map[string]interface{}{"conditions": []interface{}{
+				map[string]interface{}{"type": "Established", "status": established},
+			}},
+		}}
+	}
+	client := fake.NewClientBuilder().WithScheme(testScheme).WithObjects(
+		newCRD("servicemonitors.monitoring.coreos.com", "True"),
+		newCRD("podmonitors.monitoring.coreos.com", "False"),
+	).Build()
+	addonsLayer := &kraanv1alpha1.AddonsLayer{ObjectMeta: metav1.ObjectMeta{Name: "monitoring"}}
+	l := layers.CreateLayer(context.Background(), client, k8sClient, logr.Discard(), record.NewFakeRecorder(10), testScheme, addonsLayer)
+
+	missing, err := l.CheckPrerequisites()
+	if err != nil || len(missing) != 0 {
+		t.Fatalf("layer without prerequisites returned: %v, %v", missing, err)
+	}
+
+	addonsLayer.Spec.PreReqs.APIResources = []kraanv1alpha1.APIResourceReference{
+		{Group: "monitoring.coreos.com", Version: "v1", Kind: "ServiceMonitor"},
+		{Group: "monitoring.coreos.com", Version: "v1", Kind: "PodMonitor"},
+		{Version: "v1", Kind: "ConfigMap"},
+		{Group: "cert-manager.io", Version: "v1", Kind: "Certificate"},
+	}
+	addonsLayer.Spec.PreReqs.CRDs = []string{
+		"servicemonitors.monitoring.coreos.com",
+		"podmonitors.monitoring.coreos.com",
+		"certificates.cert-manager.io",
+	}
+	missing, err = l.CheckPrerequisites()
+	if err != nil {
+		t.Fatalf("CheckPrerequisites returned an error: %s", err)
+	}
+	expected := []string{
+		"api resource: monitoring.coreos.com/v1, kind: PodMonitor, not served",
+		"api resource: cert-manager.io/v1, kind: Certificate, not served",
+		"crd: podmonitors.monitoring.coreos.com, not established",
+		"crd: certificates.cert-manager.io, not found",
+	}
+	if !reflect.DeepEqual(missing, expected) {
+		t.Fatalf("wrong result, Actual: %v, Expected: %v", missing, expected)
+	}
+
+	l.SetStatusPrerequisitesNotMet(missing)
+	if l.GetStatus() != kraanv1alpha1.PrerequisitesNotMetCondition {
+		t.Fatalf("wrong status: %s", l.GetStatus())
+	}
+}
+
+func TestCheckReadinessGates(t *testing.T) {
+	newObject := func(apiVersion, kind, namespace, name string, status map[string]interface{}) *unstructured.Unstructured {
+		return &unstructured.Unstructured{Object: map[string]interface{}{
+			"apiVersion": apiVersion,
+			"kind":       kind,
+			"metadata":   map[string]interface{}{"name": name, "namespace": namespace},
+			"status":     status,
+		}}
+	}
+	ready := map[string]interface{}{"conditions": []interface{}{
+		map[string]interface{}{"type": "Ready", "status": "True"},
+	}}
+	notReady := map[string]interface{}{"conditions": []interface{}{
+		map[string]interface{}{"type": "Ready", "status": "False", "message": "install retries exhausted"},
+		map[string]interface{}{"type": "Released", "status": "True"},
+	}}
+	client := fake.NewClientBuilder().WithScheme(testScheme).WithObjects(
+		newObject("kustomize.toolkit.fluxcd.io/v1", "Kustomization", "flux-system", "cni", ready),
+		newObject("helm.toolkit.fluxcd.io/v2beta1", "HelmRelease", "ingress", "ingress-nginx", notReady),
+	).Build()
+	addonsLayer := &kraanv1alpha1.AddonsLayer{ObjectMeta: metav1.ObjectMeta{Name: "apps"}}
+	l := layers.CreateLayer(context.Background(), client, fakeK8s.NewSimpleClientset(), logr.Discard(), record.NewFakeRecorder(10), testScheme, addonsLayer)
+
+	notReadyGates, err := l.CheckReadinessGates()
+	if err != nil || len(notReadyGates) != 0 {
+		t.Fatalf("layer without readiness gates returned: %v, %v", notReadyGates, err)
+	}
+
+	addonsLayer.Spec.PreReqs.ReadinessGates = []kraanv1alpha1.ReadinessGate{
+		{APIVersion: "kustomize.toolkit.fluxcd.io/v1", Kind: "Kustomization", Namespace: "flux-system", Name: "cni"},
+		{APIVersion: "helm.toolkit.fluxcd.io/v2beta1", Kind: "HelmRelease", Namespace: "ingress", Name: "ingress-nginx"},
+		{APIVersion: "helm.toolkit.fluxcd.io/v2beta1", Kind: "HelmRelease", Namespace: "ingress", Name: "ingress-nginx", ConditionType: "Released"},
+		{APIVersion: "apps/v1", Kind: "Deployment", Namespace: "kube-system", Name: "coredns"},
+	}
+	notReadyGates, err = l.CheckReadinessGates()
+	if err != nil {
+		t.Fatalf("CheckReadinessGates returned an error: %s", err)
+	}
+	expected := []string{
+		"HelmRelease: ingress/ingress-nginx, InProgress, condition: Ready, is False, install retries exhausted",
+		"Deployment: kube-system/coredns, not found",
+	}
+	if !reflect.DeepEqual(notReadyGates, expected) {
+		t.Fatalf("wrong result, Actual: %v, Expected: %v", notReadyGates, expected)
+	}
+}
+
+func TestReleaseDependenciesDeployed(t *testing.T) {
+	commonLayer := &kraanv1alpha1.AddonsLayer{ObjectMeta: metav1.ObjectMeta{Name: "common"}}
+	newRelease := func(name, version, ready string) *helmctlv2.HelmRelease {
+		return &helmctlv2.HelmRelease{
+			ObjectMeta: metav1.ObjectMeta{
+				Name:        name,
+				Namespace:   name,
+				Generation:  1,
+				Labels:      map[string]string{"kraan/layer": "common"},
+				Annotations: map[string]string{"kraan.layerVersion": version},
+			},
+			Status: helmctlv2.HelmReleaseStatus{
+				ObservedGeneration: 1,
+				Conditions:         []metav1.Condition{{Type: "Ready", Status: metav1.ConditionStatus(ready), Reason: "Test"}},
+			},
+		}
+	}
+	client := fake.NewClientBuilder().WithScheme(testScheme).WithObjects(
+		commonLayer,
+		newRelease("cert-manager", "1.2", "True"),
+		newRelease("ingress-nginx", "1.2", "False"),
+		newRelease("external-dns", "1.1", "True"),
+		newRelease("vault", "1.2", "True"),
+	).Build()
+	duplicate := newRelease("vault", "1.2", "False")
+	duplicate.Namespace = "secrets"
+	if err := client.Create(context.Background(), duplicate); err != nil {
+		t.Fatalf("failed to create HelmRelease: %s", err)
+	}
+	tests := []struct {
+		dependency string
+		expected   bool
+		state      string
+	}{
+		{dependency: "common/cert-manager@1.2", expected: true},
+		{dependency: "common/ingress-nginx@1.2", state: kraanv1alpha1.ApplyPendingCondition},
+		{dependency: "common/external-dns@1.2", state: kraanv1alpha1.ApplyPendingCondition},
+		{dependency: "common/nginx@1.2", state: kraanv1alpha1.ApplyPendingCondition},
+		{dependency: "common/vault@1.2", state: kraanv1alpha1.FailedCondition},
+		{dependency: "common/vault/vault@1.2", expected: true},
+		{dependency: "common/secrets/vault@1.2", state: kraanv1alpha1.ApplyPendingCondition},
+		{dependency: "common/other/vault@1.2", state: kraanv1alpha1.ApplyPendingCondition},
+	}
+	for _, test := range tests {
+		addonsLayer := &kraanv1alpha1.AddonsLayer{ObjectMeta: metav1.ObjectMeta{Name: "apps"}}
+		addonsLayer.Spec.PreReqs.DependsOn = []string{test.dependency}
+		l := layers.CreateLayer(context.Background(), client, fakeK8s.NewSimpleClientset(), logr.Discard(), record.NewFakeRecorder(10), testScheme, addonsLayer)
+		if deployed := l.DependenciesDeployed(); deployed != test.expected {
+			t.Fatalf("dependency: %s, wrong result, Actual: %t, Expected: %t", test.dependency, deployed, test.expected)
+		}
+		if !test.expected && l.GetStatus() != test.state {
+			t.Fatalf("dependency: %s, wrong status: %s", test.dependency, l.GetStatus())
+		}
+	}
+
+	name, release, version := layers.ParseDependency("common/cert-manager@1.2")
+	if name != "common" || release != "cert-manager" || version != "1.2" {
+		t.Fatalf("ParseDependency returned: %s, %s, %s", name, release, version)
+	}
+	name, release, version = layers.ParseDependency("common/cert-manager/cert-manager@1.2")
+	if name != "common" || release != "cert-manager/cert-manager" || version != "1.2" {
+		t.Fatalf("ParseDependency returned: %s, %s, %s", name, release, version)
+	}
+}
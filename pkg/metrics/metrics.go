@@ -18,12 +18,16 @@ type Metrics interface {
 	Init()
 	RecordCondition(obj runtime.Object, condition metav1.Condition, deleted bool)
 	RecordDuration(obj runtime.Object, start time.Time)
+	RecordSyncQueueDepth(depth int)
+	RecordSyncDuration(obj runtime.Object, start time.Time, success bool)
 }
 
 type metricsData struct {
 	Metrics
-	durationHistogram *prometheus.HistogramVec
-	conditionGauge    *prometheus.GaugeVec
+	durationHistogram     *prometheus.HistogramVec
+	conditionGauge        *prometheus.GaugeVec
+	syncQueueGauge        prometheus.Gauge
+	syncDurationHistogram *prometheus.HistogramVec
 }
 
 const (
@@ -57,6 +61,24 @@ func (m *metricsData) Init() {
 		[]string{"kind", "name", "namespace"},
 	)
 	ctlmetrics.Registry.MustRegister(m.durationHistogram)
+
+	m.syncQueueGauge = prometheus.NewGauge(
+		prometheus.GaugeOpts{
+			Name: "source_sync_queue_depth",
+			Help: "The number of sources waiting to be synced.",
+		},
+	)
+	ctlmetrics.Registry.MustRegister(m.syncQueueGauge)
+
+	m.syncDurationHistogram = prometheus.NewHistogramVec(
+		prometheus.HistogramOpts{
+			Name:    "source_sync_duration_seconds",
+			Help:    "The duration in seconds of downloading and extracting a source artifact.",
+			Buckets: prometheus.ExponentialBuckets(10e-3, 2, 12),
+		},
+		[]string{"kind", "name", "namespace", "success"},
+	)
+	ctlmetrics.Registry.MustRegister(m.syncDurationHistogram)
 }
 
 // RecordCondition records condition metrics
@@ -82,6 +104,17 @@ func (m *metricsData) RecordDuration(obj runtime.Object, start time.Time) {
 	m.durationHistogram.WithLabelValues(getObjKindNamespaceName(obj)...).Observe(time.Since(start).Seconds())
 }
 
+// RecordSyncQueueDepth records the number of sources waiting to be synced
+func (m *metricsData) RecordSyncQueueDepth(depth int) {
+	m.syncQueueGauge.Set(float64(depth))
+}
+
+// RecordSyncDuration records source sync duration metrics
+func (m *metricsData) RecordSyncDuration(obj runtime.Object, start time.Time, success bool) {
+	args := append(getObjKindNamespaceName(obj), fmt.Sprintf("%t", success))
+	m.syncDurationHistogram.WithLabelValues(args...).Observe(time.Since(start).Seconds())
+}
+
 func getObjKindNamespaceName(obj runtime.Object) []string {
 	mobj, ok := (obj).(metav1.Object)
 	if !ok {
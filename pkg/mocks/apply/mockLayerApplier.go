@@ -66,6 +66,20 @@ func (mr *MockLayerApplierMockRecorder) Prune(ctx, layer, pruneHrs interface{})
 	return mr.mock.ctrl.RecordCallWithMethodType(mr.mock, "Prune", reflect.TypeOf((*MockLayerApplier)(nil).Prune), ctx, layer, pruneHrs)
 }
 
+// DeleteAll mocks base method
+func (m *MockLayerApplier) DeleteAll(ctx context.Context, layer layers.Layer) error {
+	m.ctrl.T.Helper()
+	ret := m.ctrl.Call(m, "DeleteAll", ctx, layer)
+	ret0, _ := ret[0].(error)
+	return ret0
+}
+
+// DeleteAll indicates an expected call of DeleteAll
+func (mr *MockLayerApplierMockRecorder) DeleteAll(ctx, layer interface{}) *gomock.Call {
+	mr.mock.ctrl.T.Helper()
+	return mr.mock.ctrl.RecordCallWithMethodType(mr.mock, "DeleteAll", reflect.TypeOf((*MockLayerApplier)(nil).DeleteAll), ctx, layer)
+}
+
 // PruneIsRequired mocks base method
 func (m *MockLayerApplier) PruneIsRequired(ctx context.Context, layer layers.Layer) (bool, []*v2beta1.HelmRelease, error) {
 	m.ctrl.T.Helper()
@@ -188,6 +202,20 @@ func (mr *MockLayerApplierMockRecorder) Adopt(ctx, layer, hr interface{}) *gomoc
 	return mr.mock.ctrl.RecordCallWithMethodType(mr.mock, "Adopt", reflect.TypeOf((*MockLayerApplier)(nil).Adopt), ctx, layer, hr)
 }
 
+// PublishOutputs mocks base method
+func (m *MockLayerApplier) PublishOutputs(ctx context.Context, layer layers.Layer) error {
+	m.ctrl.T.Helper()
+	ret := m.ctrl.Call(m, "PublishOutputs", ctx, layer)
+	ret0, _ := ret[0].(error)
+	return ret0
+}
+
+// PublishOutputs indicates an expected call of PublishOutputs
+func (mr *MockLayerApplierMockRecorder) PublishOutputs(ctx, layer interface{}) *gomock.Call {
+	mr.mock.ctrl.T.Helper()
+	return mr.mock.ctrl.RecordCallWithMethodType(mr.mock, "PublishOutputs", reflect.TypeOf((*MockLayerApplier)(nil).PublishOutputs), ctx, layer)
+}
+
 // addOwnerRefs mocks base method
 func (m *MockLayerApplier) addOwnerRefs(layer layers.Layer, objs []runtime.Object) error {
 	m.ctrl.T.Helper()
@@ -203,18 +231,18 @@ func (mr *MockLayerApplierMockRecorder) addOwnerRefs(layer, objs interface{}) *g
 }
 
 // orphanLabel mocks base method
-func (m *MockLayerApplier) orphanLabel(ctx context.Context, hr *v2beta1.HelmRelease) (*v1.Time, error) {
+func (m *MockLayerApplier) orphanLabel(ctx context.Context, layer layers.Layer, hr *v2beta1.HelmRelease) (*v1.Time, error) {
 	m.ctrl.T.Helper()
-	ret := m.ctrl.Call(m, "orphanLabel", ctx, hr)
+	ret := m.ctrl.Call(m, "orphanLabel", ctx, layer, hr)
 	ret0, _ := ret[0].(*v1.Time)
 	ret1, _ := ret[1].(error)
 	return ret0, ret1
 }
 
 // orphanLabel indicates an expected call of orphanLabel
-func (mr *MockLayerApplierMockRecorder) orphanLabel(ctx, hr interface{}) *gomock.Call {
+func (mr *MockLayerApplierMockRecorder) orphanLabel(ctx, layer, hr interface{}) *gomock.Call {
 	mr.mock.ctrl.T.Helper()
-	return mr.mock.ctrl.RecordCallWithMethodType(mr.mock, "orphanLabel", reflect.TypeOf((*MockLayerApplier)(nil).orphanLabel), ctx, hr)
+	return mr.mock.ctrl.RecordCallWithMethodType(mr.mock, "orphanLabel", reflect.TypeOf((*MockLayerApplier)(nil).orphanLabel), ctx, layer, hr)
 }
 
 // GetHelmReleases mocks base method
@@ -0,0 +1,92 @@
+// Code generated by MockGen. DO NOT EDIT.
+// Source: clusters.go
+
+// Package mocks is a generated GoMock package.
+package mocks
+
+import (
+	context "context"
+	reflect "reflect"
+
+	v1alpha1 "github.com/fidelity/kraan/api/v1alpha1"
+	clusters "github.com/fidelity/kraan/pkg/clusters"
+	gomock "github.com/golang/mock/gomock"
+	types "k8s.io/apimachinery/pkg/types"
+)
+
+// MockClusters is a mock of Clusters interface.
+type MockClusters struct {
+	ctrl     *gomock.Controller
+	recorder *MockClustersMockRecorder
+}
+
+// MockClustersMockRecorder is the mock recorder for MockClusters.
+type MockClustersMockRecorder struct {
+	mock *MockClusters
+}
+
+// NewMockClusters creates a new mock instance.
+func NewMockClusters(ctrl *gomock.Controller) *MockClusters {
+	mock := &MockClusters{ctrl: ctrl}
+	mock.recorder = &MockClustersMockRecorder{mock}
+	return mock
+}
+
+// EXPECT returns an object that allows the caller to indicate expected use.
+func (m *MockClusters) EXPECT() *MockClustersMockRecorder {
+	return m.recorder
+}
+
+// Close mocks base method.
+func (m *MockClusters) Close() {
+	m.ctrl.T.Helper()
+	m.ctrl.Call(m, "Close")
+}
+
+// Close indicates an expected call of Close.
+func (mr *MockClustersMockRecorder) Close() *gomock.Call {
+	mr.mock.ctrl.T.Helper()
+	return mr.mock.ctrl.RecordCallWithMethodType(mr.mock, "Close", reflect.TypeOf((*MockClusters)(nil).Close))
+}
+
+// Delete mocks base method.
+func (m *MockClusters) Delete(key string) {
+	m.ctrl.T.Helper()
+	m.ctrl.Call(m, "Delete", key)
+}
+
+// Delete indicates an expected call of Delete.
+func (mr *MockClustersMockRecorder) Delete(key interface{}) *gomock.Call {
+	mr.mock.ctrl.T.Helper()
+	return mr.mock.ctrl.RecordCallWithMethodType(mr.mock, "Delete", reflect.TypeOf((*MockClusters)(nil).Delete), key)
+}
+
+// Get mocks base method.
+func (m *MockClusters) Get(ctx context.Context, kubeConfig *v1alpha1.KubeConfigSpec) (*clusters.Cluster, error) {
+	m.ctrl.T.Helper()
+	ret := m.ctrl.Call(m, "Get", ctx, kubeConfig)
+	ret0, _ := ret[0].(*clusters.Cluster)
+	ret1, _ := ret[1].(error)
+	return ret0, ret1
+}
+
+// Get indicates an expected call of Get.
+func (mr *MockClustersMockRecorder) Get(ctx, kubeConfig interface{}) *gomock.Call {
+	mr.mock.ctrl.T.Helper()
+	return mr.mock.ctrl.RecordCallWithMethodType(mr.mock, "Get", reflect.TypeOf((*MockClusters)(nil).Get), ctx, kubeConfig)
+}
+
+// Impersonate mocks base method.
+func (m *MockClusters) Impersonate(cluster *clusters.Cluster, serviceAccount types.NamespacedName) (*clusters.Cluster, error) {
+	m.ctrl.T.Helper()
+	ret := m.ctrl.Call(m, "Impersonate", cluster, serviceAccount)
+	ret0, _ := ret[0].(*clusters.Cluster)
+	ret1, _ := ret[1].(error)
+	return ret0, ret1
+}
+
+// Impersonate indicates an expected call of Impersonate.
+func (mr *MockClustersMockRecorder) Impersonate(cluster, serviceAccount interface{}) *gomock.Call {
+	mr.mock.ctrl.T.Helper()
+	return mr.mock.ctrl.RecordCallWithMethodType(mr.mock, "Impersonate", reflect.TypeOf((*MockClusters)(nil).Impersonate), cluster, serviceAccount)
+}
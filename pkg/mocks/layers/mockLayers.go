@@ -6,178 +6,271 @@ package mocks
 
 import (
 	context "context"
+	reflect "reflect"
+	time "time"
+
 	v1alpha1 "github.com/fidelity/kraan/api/v1alpha1"
+	clusters "github.com/fidelity/kraan/pkg/clusters"
+	layers "github.com/fidelity/kraan/pkg/layers"
+	v1beta2 "github.com/fluxcd/source-controller/api/v1beta2"
 	logr "github.com/go-logr/logr"
 	gomock "github.com/golang/mock/gomock"
-	v1 "k8s.io/apimachinery/pkg/apis/meta/v1"
-	reflect "reflect"
-	time "time"
+	runtime "k8s.io/apimachinery/pkg/runtime"
 )
 
-// MockLayer is a mock of Layer interface
+// MockLayer is a mock of Layer interface.
 type MockLayer struct {
 	ctrl     *gomock.Controller
 	recorder *MockLayerMockRecorder
 }
 
-// MockLayerMockRecorder is the mock recorder for MockLayer
+// MockLayerMockRecorder is the mock recorder for MockLayer.
 type MockLayerMockRecorder struct {
 	mock *MockLayer
 }
 
-// NewMockLayer creates a new mock instance
+// NewMockLayer creates a new mock instance.
 func NewMockLayer(ctrl *gomock.Controller) *MockLayer {
 	mock := &MockLayer{ctrl: ctrl}
 	mock.recorder = &MockLayerMockRecorder{mock}
 	return mock
 }
 
-// EXPECT returns an object that allows the caller to indicate expected use
+// EXPECT returns an object that allows the caller to indicate expected use.
 func (m *MockLayer) EXPECT() *MockLayerMockRecorder {
 	return m.recorder
 }
 
-// SetStatusK8sVersion mocks base method
-func (m *MockLayer) SetStatusK8sVersion() {
+// CheckK8sVersion mocks base method.
+func (m *MockLayer) CheckK8sVersion() bool {
 	m.ctrl.T.Helper()
-	m.ctrl.Call(m, "SetStatusK8sVersion")
+	ret := m.ctrl.Call(m, "CheckK8sVersion")
+	ret0, _ := ret[0].(bool)
+	return ret0
 }
 
-// SetStatusK8sVersion indicates an expected call of SetStatusK8sVersion
-func (mr *MockLayerMockRecorder) SetStatusK8sVersion() *gomock.Call {
+// CheckK8sVersion indicates an expected call of CheckK8sVersion.
+func (mr *MockLayerMockRecorder) CheckK8sVersion() *gomock.Call {
 	mr.mock.ctrl.T.Helper()
-	return mr.mock.ctrl.RecordCallWithMethodType(mr.mock, "SetStatusK8sVersion", reflect.TypeOf((*MockLayer)(nil).SetStatusK8sVersion))
+	return mr.mock.ctrl.RecordCallWithMethodType(mr.mock, "CheckK8sVersion", reflect.TypeOf((*MockLayer)(nil).CheckK8sVersion))
 }
 
-// SetStatusApplying mocks base method
-func (m *MockLayer) SetStatusApplying() {
+// CheckPrerequisites mocks base method.
+func (m *MockLayer) CheckPrerequisites() ([]string, error) {
 	m.ctrl.T.Helper()
-	m.ctrl.Call(m, "SetStatusApplying")
+	ret := m.ctrl.Call(m, "CheckPrerequisites")
+	ret0, _ := ret[0].([]string)
+	ret1, _ := ret[1].(error)
+	return ret0, ret1
 }
 
-// SetStatusApplying indicates an expected call of SetStatusApplying
-func (mr *MockLayerMockRecorder) SetStatusApplying() *gomock.Call {
+// CheckPrerequisites indicates an expected call of CheckPrerequisites.
+func (mr *MockLayerMockRecorder) CheckPrerequisites() *gomock.Call {
 	mr.mock.ctrl.T.Helper()
-	return mr.mock.ctrl.RecordCallWithMethodType(mr.mock, "SetStatusApplying", reflect.TypeOf((*MockLayer)(nil).SetStatusApplying))
+	return mr.mock.ctrl.RecordCallWithMethodType(mr.mock, "CheckPrerequisites", reflect.TypeOf((*MockLayer)(nil).CheckPrerequisites))
 }
 
-// SetStatusPruning mocks base method
-func (m *MockLayer) SetStatusPruning() {
+// CheckReadinessGates mocks base method.
+func (m *MockLayer) CheckReadinessGates() ([]string, error) {
 	m.ctrl.T.Helper()
-	m.ctrl.Call(m, "SetStatusPruning")
+	ret := m.ctrl.Call(m, "CheckReadinessGates")
+	ret0, _ := ret[0].([]string)
+	ret1, _ := ret[1].(error)
+	return ret0, ret1
 }
 
-// SetStatusPruning indicates an expected call of SetStatusPruning
-func (mr *MockLayerMockRecorder) SetStatusPruning() *gomock.Call {
+// CheckReadinessGates indicates an expected call of CheckReadinessGates.
+func (mr *MockLayerMockRecorder) CheckReadinessGates() *gomock.Call {
 	mr.mock.ctrl.T.Helper()
-	return mr.mock.ctrl.RecordCallWithMethodType(mr.mock, "SetStatusPruning", reflect.TypeOf((*MockLayer)(nil).SetStatusPruning))
+	return mr.mock.ctrl.RecordCallWithMethodType(mr.mock, "CheckReadinessGates", reflect.TypeOf((*MockLayer)(nil).CheckReadinessGates))
 }
 
-// SetStatusPending mocks base method
-func (m *MockLayer) SetStatusPending() {
+// CheckServiceAccountNamespace mocks base method.
+func (m *MockLayer) CheckServiceAccountNamespace() error {
 	m.ctrl.T.Helper()
-	m.ctrl.Call(m, "SetStatusPending")
+	ret := m.ctrl.Call(m, "CheckServiceAccountNamespace")
+	ret0, _ := ret[0].(error)
+	return ret0
 }
 
-// SetStatusPending indicates an expected call of SetStatusPending
-func (mr *MockLayerMockRecorder) SetStatusPending() *gomock.Call {
+// CheckServiceAccountNamespace indicates an expected call of CheckServiceAccountNamespace.
+func (mr *MockLayerMockRecorder) CheckServiceAccountNamespace() *gomock.Call {
 	mr.mock.ctrl.T.Helper()
-	return mr.mock.ctrl.RecordCallWithMethodType(mr.mock, "SetStatusPending", reflect.TypeOf((*MockLayer)(nil).SetStatusPending))
+	return mr.mock.ctrl.RecordCallWithMethodType(mr.mock, "CheckServiceAccountNamespace", reflect.TypeOf((*MockLayer)(nil).CheckServiceAccountNamespace))
 }
 
-// SetStatusDeployed mocks base method
-func (m *MockLayer) SetStatusDeployed() {
+// CheckSourceNamespaces mocks base method.
+func (m *MockLayer) CheckSourceNamespaces() error {
 	m.ctrl.T.Helper()
-	m.ctrl.Call(m, "SetStatusDeployed")
+	ret := m.ctrl.Call(m, "CheckSourceNamespaces")
+	ret0, _ := ret[0].(error)
+	return ret0
 }
 
-// SetStatusDeployed indicates an expected call of SetStatusDeployed
-func (mr *MockLayerMockRecorder) SetStatusDeployed() *gomock.Call {
+// CheckSourceNamespaces indicates an expected call of CheckSourceNamespaces.
+func (mr *MockLayerMockRecorder) CheckSourceNamespaces() *gomock.Call {
 	mr.mock.ctrl.T.Helper()
-	return mr.mock.ctrl.RecordCallWithMethodType(mr.mock, "SetStatusDeployed", reflect.TypeOf((*MockLayer)(nil).SetStatusDeployed))
+	return mr.mock.ctrl.RecordCallWithMethodType(mr.mock, "CheckSourceNamespaces", reflect.TypeOf((*MockLayer)(nil).CheckSourceNamespaces))
 }
 
-// StatusUpdate mocks base method
-func (m *MockLayer) StatusUpdate(status, message string) {
+// DependenciesDeployed mocks base method.
+func (m *MockLayer) DependenciesDeployed() bool {
 	m.ctrl.T.Helper()
-	m.ctrl.Call(m, "StatusUpdate", status, message)
+	ret := m.ctrl.Call(m, "DependenciesDeployed")
+	ret0, _ := ret[0].(bool)
+	return ret0
 }
 
-// StatusUpdate indicates an expected call of StatusUpdate
-func (mr *MockLayerMockRecorder) StatusUpdate(status, message interface{}) *gomock.Call {
+// DependenciesDeployed indicates an expected call of DependenciesDeployed.
+func (mr *MockLayerMockRecorder) DependenciesDeployed() *gomock.Call {
 	mr.mock.ctrl.T.Helper()
-	return mr.mock.ctrl.RecordCallWithMethodType(mr.mock, "StatusUpdate", reflect.TypeOf((*MockLayer)(nil).StatusUpdate), status, message)
+	return mr.mock.ctrl.RecordCallWithMethodType(mr.mock, "DependenciesDeployed", reflect.TypeOf((*MockLayer)(nil).DependenciesDeployed))
 }
 
-// IsHold mocks base method
-func (m *MockLayer) IsHold() bool {
+// GetAddonsLayer mocks base method.
+func (m *MockLayer) GetAddonsLayer() *v1alpha1.AddonsLayer {
 	m.ctrl.T.Helper()
-	ret := m.ctrl.Call(m, "IsHold")
-	ret0, _ := ret[0].(bool)
+	ret := m.ctrl.Call(m, "GetAddonsLayer")
+	ret0, _ := ret[0].(*v1alpha1.AddonsLayer)
 	return ret0
 }
 
-// IsHold indicates an expected call of IsHold
-func (mr *MockLayerMockRecorder) IsHold() *gomock.Call {
+// GetAddonsLayer indicates an expected call of GetAddonsLayer.
+func (mr *MockLayerMockRecorder) GetAddonsLayer() *gomock.Call {
 	mr.mock.ctrl.T.Helper()
-	return mr.mock.ctrl.RecordCallWithMethodType(mr.mock, "IsHold", reflect.TypeOf((*MockLayer)(nil).IsHold))
+	return mr.mock.ctrl.RecordCallWithMethodType(mr.mock, "GetAddonsLayer", reflect.TypeOf((*MockLayer)(nil).GetAddonsLayer))
 }
 
-// SetHold mocks base method
-func (m *MockLayer) SetHold() {
+// GetCluster mocks base method.
+func (m *MockLayer) GetCluster() *clusters.Cluster {
 	m.ctrl.T.Helper()
-	m.ctrl.Call(m, "SetHold")
+	ret := m.ctrl.Call(m, "GetCluster")
+	ret0, _ := ret[0].(*clusters.Cluster)
+	return ret0
 }
 
-// SetHold indicates an expected call of SetHold
-func (mr *MockLayerMockRecorder) SetHold() *gomock.Call {
+// GetCluster indicates an expected call of GetCluster.
+func (mr *MockLayerMockRecorder) GetCluster() *gomock.Call {
 	mr.mock.ctrl.T.Helper()
-	return mr.mock.ctrl.RecordCallWithMethodType(mr.mock, "SetHold", reflect.TypeOf((*MockLayer)(nil).SetHold))
+	return mr.mock.ctrl.RecordCallWithMethodType(mr.mock, "GetCluster", reflect.TypeOf((*MockLayer)(nil).GetCluster))
 }
 
-// DependenciesDeployed mocks base method
-func (m *MockLayer) DependenciesDeployed() bool {
+// GetClusterInfo mocks base method.
+func (m *MockLayer) GetClusterInfo() (*layers.ClusterInfo, error) {
 	m.ctrl.T.Helper()
-	ret := m.ctrl.Call(m, "DependenciesDeployed")
-	ret0, _ := ret[0].(bool)
+	ret := m.ctrl.Call(m, "GetClusterInfo")
+	ret0, _ := ret[0].(*layers.ClusterInfo)
+	ret1, _ := ret[1].(error)
+	return ret0, ret1
+}
+
+// GetClusterInfo indicates an expected call of GetClusterInfo.
+func (mr *MockLayerMockRecorder) GetClusterInfo() *gomock.Call {
+	mr.mock.ctrl.T.Helper()
+	return mr.mock.ctrl.RecordCallWithMethodType(mr.mock, "GetClusterInfo", reflect.TypeOf((*MockLayer)(nil).GetClusterInfo))
+}
+
+// GetContext mocks base method.
+func (m *MockLayer) GetContext() context.Context {
+	m.ctrl.T.Helper()
+	ret := m.ctrl.Call(m, "GetContext")
+	ret0, _ := ret[0].(context.Context)
 	return ret0
 }
 
-// DependenciesDeployed indicates an expected call of DependenciesDeployed
-func (mr *MockLayerMockRecorder) DependenciesDeployed() *gomock.Call {
+// GetContext indicates an expected call of GetContext.
+func (mr *MockLayerMockRecorder) GetContext() *gomock.Call {
 	mr.mock.ctrl.T.Helper()
-	return mr.mock.ctrl.RecordCallWithMethodType(mr.mock, "DependenciesDeployed", reflect.TypeOf((*MockLayer)(nil).DependenciesDeployed))
+	return mr.mock.ctrl.RecordCallWithMethodType(mr.mock, "GetContext", reflect.TypeOf((*MockLayer)(nil).GetContext))
 }
 
-// GetSourceKey mocks base method
-func (m *MockLayer) GetSourceKey() string {
+// GetDecryptionKeys mocks base method.
+func (m *MockLayer) GetDecryptionKeys() ([]map[string][]byte, error) {
 	m.ctrl.T.Helper()
-	ret := m.ctrl.Call(m, "GetSourceKey")
-	ret0, _ := ret[0].(string)
+	ret := m.ctrl.Call(m, "GetDecryptionKeys")
+	ret0, _ := ret[0].([]map[string][]byte)
+	ret1, _ := ret[1].(error)
+	return ret0, ret1
+}
+
+// GetDecryptionKeys indicates an expected call of GetDecryptionKeys.
+func (mr *MockLayerMockRecorder) GetDecryptionKeys() *gomock.Call {
+	mr.mock.ctrl.T.Helper()
+	return mr.mock.ctrl.RecordCallWithMethodType(mr.mock, "GetDecryptionKeys", reflect.TypeOf((*MockLayer)(nil).GetDecryptionKeys))
+}
+
+// GetDelay mocks base method.
+func (m *MockLayer) GetDelay() time.Duration {
+	m.ctrl.T.Helper()
+	ret := m.ctrl.Call(m, "GetDelay")
+	ret0, _ := ret[0].(time.Duration)
 	return ret0
 }
 
-// GetSourceKey indicates an expected call of GetSourceKey
-func (mr *MockLayerMockRecorder) GetSourceKey() *gomock.Call {
+// GetDelay indicates an expected call of GetDelay.
+func (mr *MockLayerMockRecorder) GetDelay() *gomock.Call {
 	mr.mock.ctrl.T.Helper()
-	return mr.mock.ctrl.RecordCallWithMethodType(mr.mock, "GetSourceKey", reflect.TypeOf((*MockLayer)(nil).GetSourceKey))
+	return mr.mock.ctrl.RecordCallWithMethodType(mr.mock, "GetDelay", reflect.TypeOf((*MockLayer)(nil).GetDelay))
 }
 
-// GetStatus mocks base method
-func (m *MockLayer) GetStatus() string {
+// GetDependencyVersions mocks base method.
+func (m *MockLayer) GetDependencyVersions() map[string]string {
 	m.ctrl.T.Helper()
-	ret := m.ctrl.Call(m, "GetStatus")
+	ret := m.ctrl.Call(m, "GetDependencyVersions")
+	ret0, _ := ret[0].(map[string]string)
+	return ret0
+}
+
+// GetDependencyVersions indicates an expected call of GetDependencyVersions.
+func (mr *MockLayerMockRecorder) GetDependencyVersions() *gomock.Call {
+	mr.mock.ctrl.T.Helper()
+	return mr.mock.ctrl.RecordCallWithMethodType(mr.mock, "GetDependencyVersions", reflect.TypeOf((*MockLayer)(nil).GetDependencyVersions))
+}
+
+// GetFullStatus mocks base method.
+func (m *MockLayer) GetFullStatus() *v1alpha1.AddonsLayerStatus {
+	m.ctrl.T.Helper()
+	ret := m.ctrl.Call(m, "GetFullStatus")
+	ret0, _ := ret[0].(*v1alpha1.AddonsLayerStatus)
+	return ret0
+}
+
+// GetFullStatus indicates an expected call of GetFullStatus.
+func (mr *MockLayerMockRecorder) GetFullStatus() *gomock.Call {
+	mr.mock.ctrl.T.Helper()
+	return mr.mock.ctrl.RecordCallWithMethodType(mr.mock, "GetFullStatus", reflect.TypeOf((*MockLayer)(nil).GetFullStatus))
+}
+
+// GetK8sVersion mocks base method.
+func (m *MockLayer) GetK8sVersion() (string, error) {
+	m.ctrl.T.Helper()
+	ret := m.ctrl.Call(m, "GetK8sVersion")
 	ret0, _ := ret[0].(string)
+	ret1, _ := ret[1].(error)
+	return ret0, ret1
+}
+
+// GetK8sVersion indicates an expected call of GetK8sVersion.
+func (mr *MockLayerMockRecorder) GetK8sVersion() *gomock.Call {
+	mr.mock.ctrl.T.Helper()
+	return mr.mock.ctrl.RecordCallWithMethodType(mr.mock, "GetK8sVersion", reflect.TypeOf((*MockLayer)(nil).GetK8sVersion))
+}
+
+// GetLogger mocks base method.
+func (m *MockLayer) GetLogger() logr.Logger {
+	m.ctrl.T.Helper()
+	ret := m.ctrl.Call(m, "GetLogger")
+	ret0, _ := ret[0].(logr.Logger)
 	return ret0
 }
 
-// GetStatus indicates an expected call of GetStatus
-func (mr *MockLayerMockRecorder) GetStatus() *gomock.Call {
+// GetLogger indicates an expected call of GetLogger.
+func (mr *MockLayerMockRecorder) GetLogger() *gomock.Call {
 	mr.mock.ctrl.T.Helper()
-	return mr.mock.ctrl.RecordCallWithMethodType(mr.mock, "GetStatus", reflect.TypeOf((*MockLayer)(nil).GetStatus))
+	return mr.mock.ctrl.RecordCallWithMethodType(mr.mock, "GetLogger", reflect.TypeOf((*MockLayer)(nil).GetLogger))
 }
 
-// GetName mocks base method
+// GetName mocks base method.
 func (m *MockLayer) GetName() string {
 	m.ctrl.T.Helper()
 	ret := m.ctrl.Call(m, "GetName")
@@ -185,41 +278,41 @@ func (m *MockLayer) GetName() string {
 	return ret0
 }
 
-// GetName indicates an expected call of GetName
+// GetName indicates an expected call of GetName.
 func (mr *MockLayerMockRecorder) GetName() *gomock.Call {
 	mr.mock.ctrl.T.Helper()
 	return mr.mock.ctrl.RecordCallWithMethodType(mr.mock, "GetName", reflect.TypeOf((*MockLayer)(nil).GetName))
 }
 
-// GetLogger mocks base method
-func (m *MockLayer) GetLogger() logr.Logger {
+// GetRequiredK8sVersion mocks base method.
+func (m *MockLayer) GetRequiredK8sVersion() string {
 	m.ctrl.T.Helper()
-	ret := m.ctrl.Call(m, "GetLogger")
-	ret0, _ := ret[0].(logr.Logger)
+	ret := m.ctrl.Call(m, "GetRequiredK8sVersion")
+	ret0, _ := ret[0].(string)
 	return ret0
 }
 
-// GetLogger indicates an expected call of GetLogger
-func (mr *MockLayerMockRecorder) GetLogger() *gomock.Call {
+// GetRequiredK8sVersion indicates an expected call of GetRequiredK8sVersion.
+func (mr *MockLayerMockRecorder) GetRequiredK8sVersion() *gomock.Call {
 	mr.mock.ctrl.T.Helper()
-	return mr.mock.ctrl.RecordCallWithMethodType(mr.mock, "GetLogger", reflect.TypeOf((*MockLayer)(nil).GetLogger))
+	return mr.mock.ctrl.RecordCallWithMethodType(mr.mock, "GetRequiredK8sVersion", reflect.TypeOf((*MockLayer)(nil).GetRequiredK8sVersion))
 }
 
-// GetContext mocks base method
-func (m *MockLayer) GetContext() context.Context {
+// GetSourceKey mocks base method.
+func (m *MockLayer) GetSourceKey() string {
 	m.ctrl.T.Helper()
-	ret := m.ctrl.Call(m, "GetContext")
-	ret0, _ := ret[0].(context.Context)
+	ret := m.ctrl.Call(m, "GetSourceKey")
+	ret0, _ := ret[0].(string)
 	return ret0
 }
 
-// GetContext indicates an expected call of GetContext
-func (mr *MockLayerMockRecorder) GetContext() *gomock.Call {
+// GetSourceKey indicates an expected call of GetSourceKey.
+func (mr *MockLayerMockRecorder) GetSourceKey() *gomock.Call {
 	mr.mock.ctrl.T.Helper()
-	return mr.mock.ctrl.RecordCallWithMethodType(mr.mock, "GetContext", reflect.TypeOf((*MockLayer)(nil).GetContext))
+	return mr.mock.ctrl.RecordCallWithMethodType(mr.mock, "GetSourceKey", reflect.TypeOf((*MockLayer)(nil).GetSourceKey))
 }
 
-// GetSourcePath mocks base method
+// GetSourcePath mocks base method.
 func (m *MockLayer) GetSourcePath() string {
 	m.ctrl.T.Helper()
 	ret := m.ctrl.Call(m, "GetSourcePath")
@@ -227,13 +320,97 @@ func (m *MockLayer) GetSourcePath() string {
 	return ret0
 }
 
-// GetSourcePath indicates an expected call of GetSourcePath
+// GetSourcePath indicates an expected call of GetSourcePath.
 func (mr *MockLayerMockRecorder) GetSourcePath() *gomock.Call {
 	mr.mock.ctrl.T.Helper()
 	return mr.mock.ctrl.RecordCallWithMethodType(mr.mock, "GetSourcePath", reflect.TypeOf((*MockLayer)(nil).GetSourcePath))
 }
 
-// GetTimeout mocks base method
+// GetSourcePaths mocks base method.
+func (m *MockLayer) GetSourcePaths() []string {
+	m.ctrl.T.Helper()
+	ret := m.ctrl.Call(m, "GetSourcePaths")
+	ret0, _ := ret[0].([]string)
+	return ret0
+}
+
+// GetSourcePaths indicates an expected call of GetSourcePaths.
+func (mr *MockLayerMockRecorder) GetSourcePaths() *gomock.Call {
+	mr.mock.ctrl.T.Helper()
+	return mr.mock.ctrl.RecordCallWithMethodType(mr.mock, "GetSourcePaths", reflect.TypeOf((*MockLayer)(nil).GetSourcePaths))
+}
+
+// GetSourceResources mocks base method.
+func (m *MockLayer) GetSourceResources() []runtime.Object {
+	m.ctrl.T.Helper()
+	ret := m.ctrl.Call(m, "GetSourceResources")
+	ret0, _ := ret[0].([]runtime.Object)
+	return ret0
+}
+
+// GetSourceResources indicates an expected call of GetSourceResources.
+func (mr *MockLayerMockRecorder) GetSourceResources() *gomock.Call {
+	mr.mock.ctrl.T.Helper()
+	return mr.mock.ctrl.RecordCallWithMethodType(mr.mock, "GetSourceResources", reflect.TypeOf((*MockLayer)(nil).GetSourceResources))
+}
+
+// GetSourceRevision mocks base method.
+func (m *MockLayer) GetSourceRevision() string {
+	m.ctrl.T.Helper()
+	ret := m.ctrl.Call(m, "GetSourceRevision")
+	ret0, _ := ret[0].(string)
+	return ret0
+}
+
+// GetSourceRevision indicates an expected call of GetSourceRevision.
+func (mr *MockLayerMockRecorder) GetSourceRevision() *gomock.Call {
+	mr.mock.ctrl.T.Helper()
+	return mr.mock.ctrl.RecordCallWithMethodType(mr.mock, "GetSourceRevision", reflect.TypeOf((*MockLayer)(nil).GetSourceRevision))
+}
+
+// GetSources mocks base method.
+func (m *MockLayer) GetSources() []v1alpha1.SourceSpec {
+	m.ctrl.T.Helper()
+	ret := m.ctrl.Call(m, "GetSources")
+	ret0, _ := ret[0].([]v1alpha1.SourceSpec)
+	return ret0
+}
+
+// GetSources indicates an expected call of GetSources.
+func (mr *MockLayerMockRecorder) GetSources() *gomock.Call {
+	mr.mock.ctrl.T.Helper()
+	return mr.mock.ctrl.RecordCallWithMethodType(mr.mock, "GetSources", reflect.TypeOf((*MockLayer)(nil).GetSources))
+}
+
+// GetSpec mocks base method.
+func (m *MockLayer) GetSpec() *v1alpha1.AddonsLayerSpec {
+	m.ctrl.T.Helper()
+	ret := m.ctrl.Call(m, "GetSpec")
+	ret0, _ := ret[0].(*v1alpha1.AddonsLayerSpec)
+	return ret0
+}
+
+// GetSpec indicates an expected call of GetSpec.
+func (mr *MockLayerMockRecorder) GetSpec() *gomock.Call {
+	mr.mock.ctrl.T.Helper()
+	return mr.mock.ctrl.RecordCallWithMethodType(mr.mock, "GetSpec", reflect.TypeOf((*MockLayer)(nil).GetSpec))
+}
+
+// GetStatus mocks base method.
+func (m *MockLayer) GetStatus() string {
+	m.ctrl.T.Helper()
+	ret := m.ctrl.Call(m, "GetStatus")
+	ret0, _ := ret[0].(string)
+	return ret0
+}
+
+// GetStatus indicates an expected call of GetStatus.
+func (mr *MockLayerMockRecorder) GetStatus() *gomock.Call {
+	mr.mock.ctrl.T.Helper()
+	return mr.mock.ctrl.RecordCallWithMethodType(mr.mock, "GetStatus", reflect.TypeOf((*MockLayer)(nil).GetStatus))
+}
+
+// GetTimeout mocks base method.
 func (m *MockLayer) GetTimeout() time.Duration {
 	m.ctrl.T.Helper()
 	ret := m.ctrl.Call(m, "GetTimeout")
@@ -241,13 +418,101 @@ func (m *MockLayer) GetTimeout() time.Duration {
 	return ret0
 }
 
-// GetTimeout indicates an expected call of GetTimeout
+// GetTimeout indicates an expected call of GetTimeout.
 func (mr *MockLayerMockRecorder) GetTimeout() *gomock.Call {
 	mr.mock.ctrl.T.Helper()
 	return mr.mock.ctrl.RecordCallWithMethodType(mr.mock, "GetTimeout", reflect.TypeOf((*MockLayer)(nil).GetTimeout))
 }
 
-// IsUpdated mocks base method
+// GetValues mocks base method.
+func (m *MockLayer) GetValues() (map[string]interface{}, error) {
+	m.ctrl.T.Helper()
+	ret := m.ctrl.Call(m, "GetValues")
+	ret0, _ := ret[0].(map[string]interface{})
+	ret1, _ := ret[1].(error)
+	return ret0, ret1
+}
+
+// GetValues indicates an expected call of GetValues.
+func (mr *MockLayerMockRecorder) GetValues() *gomock.Call {
+	mr.mock.ctrl.T.Helper()
+	return mr.mock.ctrl.RecordCallWithMethodType(mr.mock, "GetValues", reflect.TypeOf((*MockLayer)(nil).GetValues))
+}
+
+// GetVariables mocks base method.
+func (m *MockLayer) GetVariables() (map[string]string, error) {
+	m.ctrl.T.Helper()
+	ret := m.ctrl.Call(m, "GetVariables")
+	ret0, _ := ret[0].(map[string]string)
+	ret1, _ := ret[1].(error)
+	return ret0, ret1
+}
+
+// GetVariables indicates an expected call of GetVariables.
+func (mr *MockLayerMockRecorder) GetVariables() *gomock.Call {
+	mr.mock.ctrl.T.Helper()
+	return mr.mock.ctrl.RecordCallWithMethodType(mr.mock, "GetVariables", reflect.TypeOf((*MockLayer)(nil).GetVariables))
+}
+
+// IsApplicable mocks base method.
+func (m *MockLayer) IsApplicable() (bool, error) {
+	m.ctrl.T.Helper()
+	ret := m.ctrl.Call(m, "IsApplicable")
+	ret0, _ := ret[0].(bool)
+	ret1, _ := ret[1].(error)
+	return ret0, ret1
+}
+
+// IsApplicable indicates an expected call of IsApplicable.
+func (mr *MockLayerMockRecorder) IsApplicable() *gomock.Call {
+	mr.mock.ctrl.T.Helper()
+	return mr.mock.ctrl.RecordCallWithMethodType(mr.mock, "IsApplicable", reflect.TypeOf((*MockLayer)(nil).IsApplicable))
+}
+
+// IsDelayed mocks base method.
+func (m *MockLayer) IsDelayed() bool {
+	m.ctrl.T.Helper()
+	ret := m.ctrl.Call(m, "IsDelayed")
+	ret0, _ := ret[0].(bool)
+	return ret0
+}
+
+// IsDelayed indicates an expected call of IsDelayed.
+func (mr *MockLayerMockRecorder) IsDelayed() *gomock.Call {
+	mr.mock.ctrl.T.Helper()
+	return mr.mock.ctrl.RecordCallWithMethodType(mr.mock, "IsDelayed", reflect.TypeOf((*MockLayer)(nil).IsDelayed))
+}
+
+// IsHold mocks base method.
+func (m *MockLayer) IsHold() bool {
+	m.ctrl.T.Helper()
+	ret := m.ctrl.Call(m, "IsHold")
+	ret0, _ := ret[0].(bool)
+	return ret0
+}
+
+// IsHold indicates an expected call of IsHold.
+func (mr *MockLayerMockRecorder) IsHold() *gomock.Call {
+	mr.mock.ctrl.T.Helper()
+	return mr.mock.ctrl.RecordCallWithMethodType(mr.mock, "IsHold", reflect.TypeOf((*MockLayer)(nil).IsHold))
+}
+
+// IsNamespaceAllowed mocks base method.
+func (m *MockLayer) IsNamespaceAllowed(namespace string) (bool, error) {
+	m.ctrl.T.Helper()
+	ret := m.ctrl.Call(m, "IsNamespaceAllowed", namespace)
+	ret0, _ := ret[0].(bool)
+	ret1, _ := ret[1].(error)
+	return ret0, ret1
+}
+
+// IsNamespaceAllowed indicates an expected call of IsNamespaceAllowed.
+func (mr *MockLayerMockRecorder) IsNamespaceAllowed(namespace interface{}) *gomock.Call {
+	mr.mock.ctrl.T.Helper()
+	return mr.mock.ctrl.RecordCallWithMethodType(mr.mock, "IsNamespaceAllowed", reflect.TypeOf((*MockLayer)(nil).IsNamespaceAllowed), namespace)
+}
+
+// IsUpdated mocks base method.
 func (m *MockLayer) IsUpdated() bool {
 	m.ctrl.T.Helper()
 	ret := m.ctrl.Call(m, "IsUpdated")
@@ -255,13 +520,27 @@ func (m *MockLayer) IsUpdated() bool {
 	return ret0
 }
 
-// IsUpdated indicates an expected call of IsUpdated
+// IsUpdated indicates an expected call of IsUpdated.
 func (mr *MockLayerMockRecorder) IsUpdated() *gomock.Call {
 	mr.mock.ctrl.T.Helper()
 	return mr.mock.ctrl.RecordCallWithMethodType(mr.mock, "IsUpdated", reflect.TypeOf((*MockLayer)(nil).IsUpdated))
 }
 
-// NeedsRequeue mocks base method
+// IsVerificationRequired mocks base method.
+func (m *MockLayer) IsVerificationRequired(source v1alpha1.SourceSpec) bool {
+	m.ctrl.T.Helper()
+	ret := m.ctrl.Call(m, "IsVerificationRequired", source)
+	ret0, _ := ret[0].(bool)
+	return ret0
+}
+
+// IsVerificationRequired indicates an expected call of IsVerificationRequired.
+func (mr *MockLayerMockRecorder) IsVerificationRequired(source interface{}) *gomock.Call {
+	mr.mock.ctrl.T.Helper()
+	return mr.mock.ctrl.RecordCallWithMethodType(mr.mock, "IsVerificationRequired", reflect.TypeOf((*MockLayer)(nil).IsVerificationRequired), source)
+}
+
+// NeedsRequeue mocks base method.
 func (m *MockLayer) NeedsRequeue() bool {
 	m.ctrl.T.Helper()
 	ret := m.ctrl.Call(m, "NeedsRequeue")
@@ -269,169 +548,277 @@ func (m *MockLayer) NeedsRequeue() bool {
 	return ret0
 }
 
-// NeedsRequeue indicates an expected call of NeedsRequeue
+// NeedsRequeue indicates an expected call of NeedsRequeue.
 func (mr *MockLayerMockRecorder) NeedsRequeue() *gomock.Call {
 	mr.mock.ctrl.T.Helper()
 	return mr.mock.ctrl.RecordCallWithMethodType(mr.mock, "NeedsRequeue", reflect.TypeOf((*MockLayer)(nil).NeedsRequeue))
 }
 
-// IsDelayed mocks base method
-func (m *MockLayer) IsDelayed() bool {
+// ReferencesOutputs mocks base method.
+func (m *MockLayer) ReferencesOutputs(name string) bool {
 	m.ctrl.T.Helper()
-	ret := m.ctrl.Call(m, "IsDelayed")
+	ret := m.ctrl.Call(m, "ReferencesOutputs", name)
 	ret0, _ := ret[0].(bool)
 	return ret0
 }
 
-// IsDelayed indicates an expected call of IsDelayed
-func (mr *MockLayerMockRecorder) IsDelayed() *gomock.Call {
+// ReferencesOutputs indicates an expected call of ReferencesOutputs.
+func (mr *MockLayerMockRecorder) ReferencesOutputs(name interface{}) *gomock.Call {
 	mr.mock.ctrl.T.Helper()
-	return mr.mock.ctrl.RecordCallWithMethodType(mr.mock, "IsDelayed", reflect.TypeOf((*MockLayer)(nil).IsDelayed))
+	return mr.mock.ctrl.RecordCallWithMethodType(mr.mock, "ReferencesOutputs", reflect.TypeOf((*MockLayer)(nil).ReferencesOutputs), name)
 }
 
-// GetDelay mocks base method
-func (m *MockLayer) GetDelay() time.Duration {
+// SetCluster mocks base method.
+func (m *MockLayer) SetCluster(cluster *clusters.Cluster) {
 	m.ctrl.T.Helper()
-	ret := m.ctrl.Call(m, "GetDelay")
-	ret0, _ := ret[0].(time.Duration)
-	return ret0
+	m.ctrl.Call(m, "SetCluster", cluster)
 }
 
-// GetDelay indicates an expected call of GetDelay
-func (mr *MockLayerMockRecorder) GetDelay() *gomock.Call {
+// SetCluster indicates an expected call of SetCluster.
+func (mr *MockLayerMockRecorder) SetCluster(cluster interface{}) *gomock.Call {
 	mr.mock.ctrl.T.Helper()
-	return mr.mock.ctrl.RecordCallWithMethodType(mr.mock, "GetDelay", reflect.TypeOf((*MockLayer)(nil).GetDelay))
+	return mr.mock.ctrl.RecordCallWithMethodType(mr.mock, "SetCluster", reflect.TypeOf((*MockLayer)(nil).SetCluster), cluster)
+}
+
+// SetDelayedRequeue mocks base method.
+func (m *MockLayer) SetDelayedRequeue() {
+	m.ctrl.T.Helper()
+	m.ctrl.Call(m, "SetDelayedRequeue")
+}
+
+// SetDelayedRequeue indicates an expected call of SetDelayedRequeue.
+func (mr *MockLayerMockRecorder) SetDelayedRequeue() *gomock.Call {
+	mr.mock.ctrl.T.Helper()
+	return mr.mock.ctrl.RecordCallWithMethodType(mr.mock, "SetDelayedRequeue", reflect.TypeOf((*MockLayer)(nil).SetDelayedRequeue))
+}
+
+// SetDeleted mocks base method.
+func (m *MockLayer) SetDeleted() {
+	m.ctrl.T.Helper()
+	m.ctrl.Call(m, "SetDeleted")
+}
+
+// SetDeleted indicates an expected call of SetDeleted.
+func (mr *MockLayerMockRecorder) SetDeleted() *gomock.Call {
+	mr.mock.ctrl.T.Helper()
+	return mr.mock.ctrl.RecordCallWithMethodType(mr.mock, "SetDeleted", reflect.TypeOf((*MockLayer)(nil).SetDeleted))
+}
+
+// SetHold mocks base method.
+func (m *MockLayer) SetHold() {
+	m.ctrl.T.Helper()
+	m.ctrl.Call(m, "SetHold")
+}
+
+// SetHold indicates an expected call of SetHold.
+func (mr *MockLayerMockRecorder) SetHold() *gomock.Call {
+	mr.mock.ctrl.T.Helper()
+	return mr.mock.ctrl.RecordCallWithMethodType(mr.mock, "SetHold", reflect.TypeOf((*MockLayer)(nil).SetHold))
+}
+
+// SetOutputs mocks base method.
+func (m *MockLayer) SetOutputs(outputs map[string]string) {
+	m.ctrl.T.Helper()
+	m.ctrl.Call(m, "SetOutputs", outputs)
+}
+
+// SetOutputs indicates an expected call of SetOutputs.
+func (mr *MockLayerMockRecorder) SetOutputs(outputs interface{}) *gomock.Call {
+	mr.mock.ctrl.T.Helper()
+	return mr.mock.ctrl.RecordCallWithMethodType(mr.mock, "SetOutputs", reflect.TypeOf((*MockLayer)(nil).SetOutputs), outputs)
+}
+
+// SetPolicyViolations mocks base method.
+func (m *MockLayer) SetPolicyViolations(violations []v1alpha1.PolicyViolation) {
+	m.ctrl.T.Helper()
+	m.ctrl.Call(m, "SetPolicyViolations", violations)
+}
+
+// SetPolicyViolations indicates an expected call of SetPolicyViolations.
+func (mr *MockLayerMockRecorder) SetPolicyViolations(violations interface{}) *gomock.Call {
+	mr.mock.ctrl.T.Helper()
+	return mr.mock.ctrl.RecordCallWithMethodType(mr.mock, "SetPolicyViolations", reflect.TypeOf((*MockLayer)(nil).SetPolicyViolations), violations)
 }
 
-// SetRequeue mocks base method
+// SetRequeue mocks base method.
 func (m *MockLayer) SetRequeue() {
 	m.ctrl.T.Helper()
 	m.ctrl.Call(m, "SetRequeue")
 }
 
-// SetRequeue indicates an expected call of SetRequeue
+// SetRequeue indicates an expected call of SetRequeue.
 func (mr *MockLayerMockRecorder) SetRequeue() *gomock.Call {
 	mr.mock.ctrl.T.Helper()
 	return mr.mock.ctrl.RecordCallWithMethodType(mr.mock, "SetRequeue", reflect.TypeOf((*MockLayer)(nil).SetRequeue))
 }
 
-// SetDelayedRequeue mocks base method
-func (m *MockLayer) SetDelayedRequeue() {
+// SetSourceResources mocks base method.
+func (m *MockLayer) SetSourceResources(objs []runtime.Object) {
 	m.ctrl.T.Helper()
-	m.ctrl.Call(m, "SetDelayedRequeue")
+	m.ctrl.Call(m, "SetSourceResources", objs)
 }
 
-// SetDelayedRequeue indicates an expected call of SetDelayedRequeue
-func (mr *MockLayerMockRecorder) SetDelayedRequeue() *gomock.Call {
+// SetSourceResources indicates an expected call of SetSourceResources.
+func (mr *MockLayerMockRecorder) SetSourceResources(objs interface{}) *gomock.Call {
 	mr.mock.ctrl.T.Helper()
-	return mr.mock.ctrl.RecordCallWithMethodType(mr.mock, "SetDelayedRequeue", reflect.TypeOf((*MockLayer)(nil).SetDelayedRequeue))
+	return mr.mock.ctrl.RecordCallWithMethodType(mr.mock, "SetSourceResources", reflect.TypeOf((*MockLayer)(nil).SetSourceResources), objs)
 }
 
-// SetUpdated mocks base method
-func (m *MockLayer) SetUpdated() {
+// SetSourceRevision mocks base method.
+func (m *MockLayer) SetSourceRevision(revision string) {
 	m.ctrl.T.Helper()
-	m.ctrl.Call(m, "SetUpdated")
+	m.ctrl.Call(m, "SetSourceRevision", revision)
 }
 
-// SetUpdated indicates an expected call of SetUpdated
-func (mr *MockLayerMockRecorder) SetUpdated() *gomock.Call {
+// SetSourceRevision indicates an expected call of SetSourceRevision.
+func (mr *MockLayerMockRecorder) SetSourceRevision(revision interface{}) *gomock.Call {
 	mr.mock.ctrl.T.Helper()
-	return mr.mock.ctrl.RecordCallWithMethodType(mr.mock, "SetUpdated", reflect.TypeOf((*MockLayer)(nil).SetUpdated))
+	return mr.mock.ctrl.RecordCallWithMethodType(mr.mock, "SetSourceRevision", reflect.TypeOf((*MockLayer)(nil).SetSourceRevision), revision)
 }
 
-// SetDeleted mocks base method
-func (m *MockLayer) SetDeleted() {
+// SetStatusApplying mocks base method.
+func (m *MockLayer) SetStatusApplying() {
 	m.ctrl.T.Helper()
-	m.ctrl.Call(m, "SetDeleted")
+	m.ctrl.Call(m, "SetStatusApplying")
 }
 
-// SetDeleted indicates an expected call of SetDeleted
-func (mr *MockLayerMockRecorder) SetDeleted() *gomock.Call {
+// SetStatusApplying indicates an expected call of SetStatusApplying.
+func (mr *MockLayerMockRecorder) SetStatusApplying() *gomock.Call {
 	mr.mock.ctrl.T.Helper()
-	return mr.mock.ctrl.RecordCallWithMethodType(mr.mock, "SetDeleted", reflect.TypeOf((*MockLayer)(nil).SetDeleted))
+	return mr.mock.ctrl.RecordCallWithMethodType(mr.mock, "SetStatusApplying", reflect.TypeOf((*MockLayer)(nil).SetStatusApplying))
 }
 
-// GetRequiredK8sVersion mocks base method
-func (m *MockLayer) GetRequiredK8sVersion() string {
+// SetStatusDeployed mocks base method.
+func (m *MockLayer) SetStatusDeployed() {
 	m.ctrl.T.Helper()
-	ret := m.ctrl.Call(m, "GetRequiredK8sVersion")
-	ret0, _ := ret[0].(string)
-	return ret0
+	m.ctrl.Call(m, "SetStatusDeployed")
 }
 
-// GetRequiredK8sVersion indicates an expected call of GetRequiredK8sVersion
-func (mr *MockLayerMockRecorder) GetRequiredK8sVersion() *gomock.Call {
+// SetStatusDeployed indicates an expected call of SetStatusDeployed.
+func (mr *MockLayerMockRecorder) SetStatusDeployed() *gomock.Call {
 	mr.mock.ctrl.T.Helper()
-	return mr.mock.ctrl.RecordCallWithMethodType(mr.mock, "GetRequiredK8sVersion", reflect.TypeOf((*MockLayer)(nil).GetRequiredK8sVersion))
+	return mr.mock.ctrl.RecordCallWithMethodType(mr.mock, "SetStatusDeployed", reflect.TypeOf((*MockLayer)(nil).SetStatusDeployed))
 }
 
-// CheckK8sVersion mocks base method
-func (m *MockLayer) CheckK8sVersion() bool {
+// SetStatusK8sVersion mocks base method.
+func (m *MockLayer) SetStatusK8sVersion() {
 	m.ctrl.T.Helper()
-	ret := m.ctrl.Call(m, "CheckK8sVersion")
-	ret0, _ := ret[0].(bool)
-	return ret0
+	m.ctrl.Call(m, "SetStatusK8sVersion")
 }
 
-// CheckK8sVersion indicates an expected call of CheckK8sVersion
-func (mr *MockLayerMockRecorder) CheckK8sVersion() *gomock.Call {
+// SetStatusK8sVersion indicates an expected call of SetStatusK8sVersion.
+func (mr *MockLayerMockRecorder) SetStatusK8sVersion() *gomock.Call {
 	mr.mock.ctrl.T.Helper()
-	return mr.mock.ctrl.RecordCallWithMethodType(mr.mock, "CheckK8sVersion", reflect.TypeOf((*MockLayer)(nil).CheckK8sVersion))
+	return mr.mock.ctrl.RecordCallWithMethodType(mr.mock, "SetStatusK8sVersion", reflect.TypeOf((*MockLayer)(nil).SetStatusK8sVersion))
 }
 
-// GetFullStatus mocks base method
-func (m *MockLayer) GetFullStatus() *v1alpha1.AddonsLayerStatus {
+// SetStatusNotApplicable mocks base method.
+func (m *MockLayer) SetStatusNotApplicable() {
 	m.ctrl.T.Helper()
-	ret := m.ctrl.Call(m, "GetFullStatus")
-	ret0, _ := ret[0].(*v1alpha1.AddonsLayerStatus)
-	return ret0
+	m.ctrl.Call(m, "SetStatusNotApplicable")
 }
 
-// GetFullStatus indicates an expected call of GetFullStatus
-func (mr *MockLayerMockRecorder) GetFullStatus() *gomock.Call {
+// SetStatusNotApplicable indicates an expected call of SetStatusNotApplicable.
+func (mr *MockLayerMockRecorder) SetStatusNotApplicable() *gomock.Call {
 	mr.mock.ctrl.T.Helper()
-	return mr.mock.ctrl.RecordCallWithMethodType(mr.mock, "GetFullStatus", reflect.TypeOf((*MockLayer)(nil).GetFullStatus))
+	return mr.mock.ctrl.RecordCallWithMethodType(mr.mock, "SetStatusNotApplicable", reflect.TypeOf((*MockLayer)(nil).SetStatusNotApplicable))
 }
 
-// GetSpec mocks base method
-func (m *MockLayer) GetSpec() *v1alpha1.AddonsLayerSpec {
+// SetStatusPending mocks base method.
+func (m *MockLayer) SetStatusPending() {
 	m.ctrl.T.Helper()
-	ret := m.ctrl.Call(m, "GetSpec")
-	ret0, _ := ret[0].(*v1alpha1.AddonsLayerSpec)
-	return ret0
+	m.ctrl.Call(m, "SetStatusPending")
 }
 
-// GetSpec indicates an expected call of GetSpec
-func (mr *MockLayerMockRecorder) GetSpec() *gomock.Call {
+// SetStatusPending indicates an expected call of SetStatusPending.
+func (mr *MockLayerMockRecorder) SetStatusPending() *gomock.Call {
 	mr.mock.ctrl.T.Helper()
-	return mr.mock.ctrl.RecordCallWithMethodType(mr.mock, "GetSpec", reflect.TypeOf((*MockLayer)(nil).GetSpec))
+	return mr.mock.ctrl.RecordCallWithMethodType(mr.mock, "SetStatusPending", reflect.TypeOf((*MockLayer)(nil).SetStatusPending))
 }
 
-// GetAddonsLayer mocks base method
-func (m *MockLayer) GetAddonsLayer() *v1alpha1.AddonsLayer {
+// SetStatusPrerequisitesNotMet mocks base method.
+func (m *MockLayer) SetStatusPrerequisitesNotMet(missing []string) {
 	m.ctrl.T.Helper()
-	ret := m.ctrl.Call(m, "GetAddonsLayer")
-	ret0, _ := ret[0].(*v1alpha1.AddonsLayer)
-	return ret0
+	m.ctrl.Call(m, "SetStatusPrerequisitesNotMet", missing)
 }
 
-// GetAddonsLayer indicates an expected call of GetAddonsLayer
-func (mr *MockLayerMockRecorder) GetAddonsLayer() *gomock.Call {
+// SetStatusPrerequisitesNotMet indicates an expected call of SetStatusPrerequisitesNotMet.
+func (mr *MockLayerMockRecorder) SetStatusPrerequisitesNotMet(missing interface{}) *gomock.Call {
 	mr.mock.ctrl.T.Helper()
-	return mr.mock.ctrl.RecordCallWithMethodType(mr.mock, "GetAddonsLayer", reflect.TypeOf((*MockLayer)(nil).GetAddonsLayer))
+	return mr.mock.ctrl.RecordCallWithMethodType(mr.mock, "SetStatusPrerequisitesNotMet", reflect.TypeOf((*MockLayer)(nil).SetStatusPrerequisitesNotMet), missing)
+}
+
+// SetStatusPruning mocks base method.
+func (m *MockLayer) SetStatusPruning() {
+	m.ctrl.T.Helper()
+	m.ctrl.Call(m, "SetStatusPruning")
+}
+
+// SetStatusPruning indicates an expected call of SetStatusPruning.
+func (mr *MockLayerMockRecorder) SetStatusPruning() *gomock.Call {
+	mr.mock.ctrl.T.Helper()
+	return mr.mock.ctrl.RecordCallWithMethodType(mr.mock, "SetStatusPruning", reflect.TypeOf((*MockLayer)(nil).SetStatusPruning))
+}
+
+// SetStatusSourceUnverified mocks base method.
+func (m *MockLayer) SetStatusSourceUnverified(sourceKey, reason string) {
+	m.ctrl.T.Helper()
+	m.ctrl.Call(m, "SetStatusSourceUnverified", sourceKey, reason)
+}
+
+// SetStatusSourceUnverified indicates an expected call of SetStatusSourceUnverified.
+func (mr *MockLayerMockRecorder) SetStatusSourceUnverified(sourceKey, reason interface{}) *gomock.Call {
+	mr.mock.ctrl.T.Helper()
+	return mr.mock.ctrl.RecordCallWithMethodType(mr.mock, "SetStatusSourceUnverified", reflect.TypeOf((*MockLayer)(nil).SetStatusSourceUnverified), sourceKey, reason)
 }
 
-// RevisionReady mocks base method
-func (m *MockLayer) RevisionReady(conditions []v1.Condition, revision string) (bool, string) {
+// SetUnresolvedVariables mocks base method.
+func (m *MockLayer) SetUnresolvedVariables(names []string) {
 	m.ctrl.T.Helper()
-	ret := m.ctrl.Call(m, "RevisionReady", conditions, revision)
+	m.ctrl.Call(m, "SetUnresolvedVariables", names)
+}
+
+// SetUnresolvedVariables indicates an expected call of SetUnresolvedVariables.
+func (mr *MockLayerMockRecorder) SetUnresolvedVariables(names interface{}) *gomock.Call {
+	mr.mock.ctrl.T.Helper()
+	return mr.mock.ctrl.RecordCallWithMethodType(mr.mock, "SetUnresolvedVariables", reflect.TypeOf((*MockLayer)(nil).SetUnresolvedVariables), names)
+}
+
+// SetUpdated mocks base method.
+func (m *MockLayer) SetUpdated() {
+	m.ctrl.T.Helper()
+	m.ctrl.Call(m, "SetUpdated")
+}
+
+// SetUpdated indicates an expected call of SetUpdated.
+func (mr *MockLayerMockRecorder) SetUpdated() *gomock.Call {
+	mr.mock.ctrl.T.Helper()
+	return mr.mock.ctrl.RecordCallWithMethodType(mr.mock, "SetUpdated", reflect.TypeOf((*MockLayer)(nil).SetUpdated))
+}
+
+// SourceReady mocks base method.
+func (m *MockLayer) SourceReady(srcRepo *v1beta2.GitRepository) (bool, string) {
+	m.ctrl.T.Helper()
+	ret := m.ctrl.Call(m, "SourceReady", srcRepo)
 	ret0, _ := ret[0].(bool)
 	ret1, _ := ret[1].(string)
 	return ret0, ret1
 }
 
-// RevisionReady indicates an expected call of RevisionReady
-func (mr *MockLayerMockRecorder) RevisionReady(conditions, revision interface{}) *gomock.Call {
+// SourceReady indicates an expected call of SourceReady.
+func (mr *MockLayerMockRecorder) SourceReady(srcRepo interface{}) *gomock.Call {
 	mr.mock.ctrl.T.Helper()
-	return mr.mock.ctrl.RecordCallWithMethodType(mr.mock, "RevisionReady", reflect.TypeOf((*MockLayer)(nil).RevisionReady), conditions, revision)
+	return mr.mock.ctrl.RecordCallWithMethodType(mr.mock, "SourceReady", reflect.TypeOf((*MockLayer)(nil).SourceReady), srcRepo)
+}
+
+// StatusUpdate mocks base method.
+func (m *MockLayer) StatusUpdate(status, message string) {
+	m.ctrl.T.Helper()
+	m.ctrl.Call(m, "StatusUpdate", status, message)
+}
+
+// StatusUpdate indicates an expected call of StatusUpdate.
+func (mr *MockLayerMockRecorder) StatusUpdate(status, message interface{}) *gomock.Call {
+	mr.mock.ctrl.T.Helper()
+	return mr.mock.ctrl.RecordCallWithMethodType(mr.mock, "StatusUpdate", reflect.TypeOf((*MockLayer)(nil).StatusUpdate), status, message)
 }
@@ -6,53 +6,67 @@ package mocks
 
 import (
 	context "context"
-	tarconsumer "github.com/fidelity/kraan/pkg/internal/tarconsumer"
-	repos "github.com/fidelity/kraan/pkg/repos"
-	v1beta1 "github.com/fluxcd/source-controller/api/v1beta2"
-	gomock "github.com/golang/mock/gomock"
 	http "net/http"
 	reflect "reflect"
 	time "time"
+
+	tarconsumer "github.com/fidelity/kraan/pkg/internal/tarconsumer"
+	repos "github.com/fidelity/kraan/pkg/repos"
+	storage "github.com/fidelity/kraan/pkg/storage"
+	v1beta2 "github.com/fluxcd/source-controller/api/v1beta2"
+	gomock "github.com/golang/mock/gomock"
 )
 
-// MockRepos is a mock of Repos interface
+// MockRepos is a mock of Repos interface.
 type MockRepos struct {
 	ctrl     *gomock.Controller
 	recorder *MockReposMockRecorder
 }
 
-// MockReposMockRecorder is the mock recorder for MockRepos
+// MockReposMockRecorder is the mock recorder for MockRepos.
 type MockReposMockRecorder struct {
 	mock *MockRepos
 }
 
-// NewMockRepos creates a new mock instance
+// NewMockRepos creates a new mock instance.
 func NewMockRepos(ctrl *gomock.Controller) *MockRepos {
 	mock := &MockRepos{ctrl: ctrl}
 	mock.recorder = &MockReposMockRecorder{mock}
 	return mock
 }
 
-// EXPECT returns an object that allows the caller to indicate expected use
+// EXPECT returns an object that allows the caller to indicate expected use.
 func (m *MockRepos) EXPECT() *MockReposMockRecorder {
 	return m.recorder
 }
 
-// Add mocks base method
-func (m *MockRepos) Add(srcRepo *v1beta1.GitRepository) repos.Repo {
+// Add mocks base method.
+func (m *MockRepos) Add(srcRepo *v1beta2.GitRepository) repos.Repo {
 	m.ctrl.T.Helper()
 	ret := m.ctrl.Call(m, "Add", srcRepo)
 	ret0, _ := ret[0].(repos.Repo)
 	return ret0
 }
 
-// Add indicates an expected call of Add
+// Add indicates an expected call of Add.
 func (mr *MockReposMockRecorder) Add(srcRepo interface{}) *gomock.Call {
 	mr.mock.ctrl.T.Helper()
 	return mr.mock.ctrl.RecordCallWithMethodType(mr.mock, "Add", reflect.TypeOf((*MockRepos)(nil).Add), srcRepo)
 }
 
-// Get mocks base method
+// Delete mocks base method.
+func (m *MockRepos) Delete(name string) {
+	m.ctrl.T.Helper()
+	m.ctrl.Call(m, "Delete", name)
+}
+
+// Delete indicates an expected call of Delete.
+func (mr *MockReposMockRecorder) Delete(name interface{}) *gomock.Call {
+	mr.mock.ctrl.T.Helper()
+	return mr.mock.ctrl.RecordCallWithMethodType(mr.mock, "Delete", reflect.TypeOf((*MockRepos)(nil).Delete), name)
+}
+
+// Get mocks base method.
 func (m *MockRepos) Get(name string) repos.Repo {
 	m.ctrl.T.Helper()
 	ret := m.ctrl.Call(m, "Get", name)
@@ -60,234 +74,276 @@ func (m *MockRepos) Get(name string) repos.Repo {
 	return ret0
 }
 
-// Get indicates an expected call of Get
+// Get indicates an expected call of Get.
 func (mr *MockReposMockRecorder) Get(name interface{}) *gomock.Call {
 	mr.mock.ctrl.T.Helper()
 	return mr.mock.ctrl.RecordCallWithMethodType(mr.mock, "Get", reflect.TypeOf((*MockRepos)(nil).Get), name)
 }
 
-// Delete mocks base method
-func (m *MockRepos) Delete(name string) {
+// GetRootPath mocks base method.
+func (m *MockRepos) GetRootPath() string {
 	m.ctrl.T.Helper()
-	m.ctrl.Call(m, "Delete", name)
+	ret := m.ctrl.Call(m, "GetRootPath")
+	ret0, _ := ret[0].(string)
+	return ret0
 }
 
-// Delete indicates an expected call of Delete
-func (mr *MockReposMockRecorder) Delete(name interface{}) *gomock.Call {
+// GetRootPath indicates an expected call of GetRootPath.
+func (mr *MockReposMockRecorder) GetRootPath() *gomock.Call {
 	mr.mock.ctrl.T.Helper()
-	return mr.mock.ctrl.RecordCallWithMethodType(mr.mock, "Delete", reflect.TypeOf((*MockRepos)(nil).Delete), name)
+	return mr.mock.ctrl.RecordCallWithMethodType(mr.mock, "GetRootPath", reflect.TypeOf((*MockRepos)(nil).GetRootPath))
 }
 
-// List mocks base method
-func (m *MockRepos) List() map[string]repos.Repo {
+// GetStorage mocks base method.
+func (m *MockRepos) GetStorage() storage.Storage {
 	m.ctrl.T.Helper()
-	ret := m.ctrl.Call(m, "List")
-	ret0, _ := ret[0].(map[string]repos.Repo)
+	ret := m.ctrl.Call(m, "GetStorage")
+	ret0, _ := ret[0].(storage.Storage)
 	return ret0
 }
 
-// List indicates an expected call of List
-func (mr *MockReposMockRecorder) List() *gomock.Call {
+// GetStorage indicates an expected call of GetStorage.
+func (mr *MockReposMockRecorder) GetStorage() *gomock.Call {
 	mr.mock.ctrl.T.Helper()
-	return mr.mock.ctrl.RecordCallWithMethodType(mr.mock, "List", reflect.TypeOf((*MockRepos)(nil).List))
+	return mr.mock.ctrl.RecordCallWithMethodType(mr.mock, "GetStorage", reflect.TypeOf((*MockRepos)(nil).GetStorage))
 }
 
-// SetRootPath mocks base method
-func (m *MockRepos) SetRootPath(path string) {
+// GetSyncStatus mocks base method.
+func (m *MockRepos) GetSyncStatus(name string) repos.SyncStatus {
 	m.ctrl.T.Helper()
-	m.ctrl.Call(m, "SetRootPath", path)
+	ret := m.ctrl.Call(m, "GetSyncStatus", name)
+	ret0, _ := ret[0].(repos.SyncStatus)
+	return ret0
 }
 
-// SetRootPath indicates an expected call of SetRootPath
-func (mr *MockReposMockRecorder) SetRootPath(path interface{}) *gomock.Call {
+// GetSyncStatus indicates an expected call of GetSyncStatus.
+func (mr *MockReposMockRecorder) GetSyncStatus(name interface{}) *gomock.Call {
 	mr.mock.ctrl.T.Helper()
-	return mr.mock.ctrl.RecordCallWithMethodType(mr.mock, "SetRootPath", reflect.TypeOf((*MockRepos)(nil).SetRootPath), path)
+	return mr.mock.ctrl.RecordCallWithMethodType(mr.mock, "GetSyncStatus", reflect.TypeOf((*MockRepos)(nil).GetSyncStatus), name)
 }
 
-// GetRootPath mocks base method
-func (m *MockRepos) GetRootPath() string {
+// List mocks base method.
+func (m *MockRepos) List() map[string]repos.Repo {
 	m.ctrl.T.Helper()
-	ret := m.ctrl.Call(m, "GetRootPath")
-	ret0, _ := ret[0].(string)
+	ret := m.ctrl.Call(m, "List")
+	ret0, _ := ret[0].(map[string]repos.Repo)
 	return ret0
 }
 
-// GetRootPath indicates an expected call of GetRootPath
-func (mr *MockReposMockRecorder) GetRootPath() *gomock.Call {
+// List indicates an expected call of List.
+func (mr *MockReposMockRecorder) List() *gomock.Call {
 	mr.mock.ctrl.T.Helper()
-	return mr.mock.ctrl.RecordCallWithMethodType(mr.mock, "GetRootPath", reflect.TypeOf((*MockRepos)(nil).GetRootPath))
+	return mr.mock.ctrl.RecordCallWithMethodType(mr.mock, "List", reflect.TypeOf((*MockRepos)(nil).List))
+}
+
+// SetHTTPClient mocks base method.
+func (m *MockRepos) SetHTTPClient(client *http.Client) {
+	m.ctrl.T.Helper()
+	m.ctrl.Call(m, "SetHTTPClient", client)
+}
+
+// SetHTTPClient indicates an expected call of SetHTTPClient.
+func (mr *MockReposMockRecorder) SetHTTPClient(client interface{}) *gomock.Call {
+	mr.mock.ctrl.T.Helper()
+	return mr.mock.ctrl.RecordCallWithMethodType(mr.mock, "SetHTTPClient", reflect.TypeOf((*MockRepos)(nil).SetHTTPClient), client)
 }
 
-// SetHostName mocks base method
+// SetHostName mocks base method.
 func (m *MockRepos) SetHostName(hostName string) {
 	m.ctrl.T.Helper()
 	m.ctrl.Call(m, "SetHostName", hostName)
 }
 
-// SetHostName indicates an expected call of SetHostName
+// SetHostName indicates an expected call of SetHostName.
 func (mr *MockReposMockRecorder) SetHostName(hostName interface{}) *gomock.Call {
 	mr.mock.ctrl.T.Helper()
 	return mr.mock.ctrl.RecordCallWithMethodType(mr.mock, "SetHostName", reflect.TypeOf((*MockRepos)(nil).SetHostName), hostName)
 }
 
-// SetTimeOut mocks base method
+// SetRootPath mocks base method.
+func (m *MockRepos) SetRootPath(path string) {
+	m.ctrl.T.Helper()
+	m.ctrl.Call(m, "SetRootPath", path)
+}
+
+// SetRootPath indicates an expected call of SetRootPath.
+func (mr *MockReposMockRecorder) SetRootPath(path interface{}) *gomock.Call {
+	mr.mock.ctrl.T.Helper()
+	return mr.mock.ctrl.RecordCallWithMethodType(mr.mock, "SetRootPath", reflect.TypeOf((*MockRepos)(nil).SetRootPath), path)
+}
+
+// SetStorage mocks base method.
+func (m *MockRepos) SetStorage(store storage.Storage) {
+	m.ctrl.T.Helper()
+	m.ctrl.Call(m, "SetStorage", store)
+}
+
+// SetStorage indicates an expected call of SetStorage.
+func (mr *MockReposMockRecorder) SetStorage(store interface{}) *gomock.Call {
+	mr.mock.ctrl.T.Helper()
+	return mr.mock.ctrl.RecordCallWithMethodType(mr.mock, "SetStorage", reflect.TypeOf((*MockRepos)(nil).SetStorage), store)
+}
+
+// SetTimeOut mocks base method.
 func (m *MockRepos) SetTimeOut(timeOut time.Duration) {
 	m.ctrl.T.Helper()
 	m.ctrl.Call(m, "SetTimeOut", timeOut)
 }
 
-// SetTimeOut indicates an expected call of SetTimeOut
+// SetTimeOut indicates an expected call of SetTimeOut.
 func (mr *MockReposMockRecorder) SetTimeOut(timeOut interface{}) *gomock.Call {
 	mr.mock.ctrl.T.Helper()
 	return mr.mock.ctrl.RecordCallWithMethodType(mr.mock, "SetTimeOut", reflect.TypeOf((*MockRepos)(nil).SetTimeOut), timeOut)
 }
 
-// SetHTTPClient mocks base method
-func (m *MockRepos) SetHTTPClient(client *http.Client) {
+// Subscribe mocks base method.
+func (m *MockRepos) Subscribe() <-chan repos.SyncStatus {
 	m.ctrl.T.Helper()
-	m.ctrl.Call(m, "SetHTTPClient", client)
+	ret := m.ctrl.Call(m, "Subscribe")
+	ret0, _ := ret[0].(<-chan repos.SyncStatus)
+	return ret0
 }
 
-// SetHTTPClient indicates an expected call of SetHTTPClient
-func (mr *MockReposMockRecorder) SetHTTPClient(client interface{}) *gomock.Call {
+// Subscribe indicates an expected call of Subscribe.
+func (mr *MockReposMockRecorder) Subscribe() *gomock.Call {
 	mr.mock.ctrl.T.Helper()
-	return mr.mock.ctrl.RecordCallWithMethodType(mr.mock, "SetHTTPClient", reflect.TypeOf((*MockRepos)(nil).SetHTTPClient), client)
+	return mr.mock.ctrl.RecordCallWithMethodType(mr.mock, "Subscribe", reflect.TypeOf((*MockRepos)(nil).Subscribe))
 }
 
-// MockRepo is a mock of Repo interface
+// MockRepo is a mock of Repo interface.
 type MockRepo struct {
 	ctrl     *gomock.Controller
 	recorder *MockRepoMockRecorder
 }
 
-// MockRepoMockRecorder is the mock recorder for MockRepo
+// MockRepoMockRecorder is the mock recorder for MockRepo.
 type MockRepoMockRecorder struct {
 	mock *MockRepo
 }
 
-// NewMockRepo creates a new mock instance
+// NewMockRepo creates a new mock instance.
 func NewMockRepo(ctrl *gomock.Controller) *MockRepo {
 	mock := &MockRepo{ctrl: ctrl}
 	mock.recorder = &MockRepoMockRecorder{mock}
 	return mock
 }
 
-// EXPECT returns an object that allows the caller to indicate expected use
+// EXPECT returns an object that allows the caller to indicate expected use.
 func (m *MockRepo) EXPECT() *MockRepoMockRecorder {
 	return m.recorder
 }
 
-// GetSourceName mocks base method
-func (m *MockRepo) GetSourceName() string {
+// AddUser mocks base method.
+func (m *MockRepo) AddUser(name string) {
 	m.ctrl.T.Helper()
-	ret := m.ctrl.Call(m, "GetSourceName")
-	ret0, _ := ret[0].(string)
-	return ret0
+	m.ctrl.Call(m, "AddUser", name)
 }
 
-// GetSourceName indicates an expected call of GetSourceName
-func (mr *MockRepoMockRecorder) GetSourceName() *gomock.Call {
+// AddUser indicates an expected call of AddUser.
+func (mr *MockRepoMockRecorder) AddUser(name interface{}) *gomock.Call {
 	mr.mock.ctrl.T.Helper()
-	return mr.mock.ctrl.RecordCallWithMethodType(mr.mock, "GetSourceName", reflect.TypeOf((*MockRepo)(nil).GetSourceName))
+	return mr.mock.ctrl.RecordCallWithMethodType(mr.mock, "AddUser", reflect.TypeOf((*MockRepo)(nil).AddUser), name)
 }
 
-// GetSourceNameSpace mocks base method
-func (m *MockRepo) GetSourceNameSpace() string {
+// GetDataPath mocks base method.
+func (m *MockRepo) GetDataPath() string {
 	m.ctrl.T.Helper()
-	ret := m.ctrl.Call(m, "GetSourceNameSpace")
+	ret := m.ctrl.Call(m, "GetDataPath")
 	ret0, _ := ret[0].(string)
 	return ret0
 }
 
-// GetSourceNameSpace indicates an expected call of GetSourceNameSpace
-func (mr *MockRepoMockRecorder) GetSourceNameSpace() *gomock.Call {
+// GetDataPath indicates an expected call of GetDataPath.
+func (mr *MockRepoMockRecorder) GetDataPath() *gomock.Call {
 	mr.mock.ctrl.T.Helper()
-	return mr.mock.ctrl.RecordCallWithMethodType(mr.mock, "GetSourceNameSpace", reflect.TypeOf((*MockRepo)(nil).GetSourceNameSpace))
+	return mr.mock.ctrl.RecordCallWithMethodType(mr.mock, "GetDataPath", reflect.TypeOf((*MockRepo)(nil).GetDataPath))
 }
 
-// SyncRepo mocks base method
-func (m *MockRepo) SyncRepo() error {
+// GetGitRepo mocks base method.
+func (m *MockRepo) GetGitRepo() *v1beta2.GitRepository {
 	m.ctrl.T.Helper()
-	ret := m.ctrl.Call(m, "SyncRepo")
-	ret0, _ := ret[0].(error)
+	ret := m.ctrl.Call(m, "GetGitRepo")
+	ret0, _ := ret[0].(*v1beta2.GitRepository)
 	return ret0
 }
 
-// SyncRepo indicates an expected call of SyncRepo
-func (mr *MockRepoMockRecorder) SyncRepo() *gomock.Call {
+// GetGitRepo indicates an expected call of GetGitRepo.
+func (mr *MockRepoMockRecorder) GetGitRepo() *gomock.Call {
 	mr.mock.ctrl.T.Helper()
-	return mr.mock.ctrl.RecordCallWithMethodType(mr.mock, "SyncRepo", reflect.TypeOf((*MockRepo)(nil).SyncRepo))
+	return mr.mock.ctrl.RecordCallWithMethodType(mr.mock, "GetGitRepo", reflect.TypeOf((*MockRepo)(nil).GetGitRepo))
 }
 
-// IsSynced mocks base method
-func (m *MockRepo) IsSynced() bool {
+// GetLoadPath mocks base method.
+func (m *MockRepo) GetLoadPath() string {
 	m.ctrl.T.Helper()
-	ret := m.ctrl.Call(m, "IsSynced")
-	ret0, _ := ret[0].(bool)
+	ret := m.ctrl.Call(m, "GetLoadPath")
+	ret0, _ := ret[0].(string)
 	return ret0
 }
 
-// IsSynced indicates an expected call of IsSynced
-func (mr *MockRepoMockRecorder) IsSynced() *gomock.Call {
+// GetLoadPath indicates an expected call of GetLoadPath.
+func (mr *MockRepoMockRecorder) GetLoadPath() *gomock.Call {
 	mr.mock.ctrl.T.Helper()
-	return mr.mock.ctrl.RecordCallWithMethodType(mr.mock, "IsSynced", reflect.TypeOf((*MockRepo)(nil).IsSynced))
+	return mr.mock.ctrl.RecordCallWithMethodType(mr.mock, "GetLoadPath", reflect.TypeOf((*MockRepo)(nil).GetLoadPath))
 }
 
-// TidyRepo mocks base method
-func (m *MockRepo) TidyRepo() error {
+// GetPath mocks base method.
+func (m *MockRepo) GetPath() string {
 	m.ctrl.T.Helper()
-	ret := m.ctrl.Call(m, "TidyRepo")
-	ret0, _ := ret[0].(error)
+	ret := m.ctrl.Call(m, "GetPath")
+	ret0, _ := ret[0].(string)
 	return ret0
 }
 
-// TidyRepo indicates an expected call of TidyRepo
-func (mr *MockRepoMockRecorder) TidyRepo() *gomock.Call {
+// GetPath indicates an expected call of GetPath.
+func (mr *MockRepoMockRecorder) GetPath() *gomock.Call {
 	mr.mock.ctrl.T.Helper()
-	return mr.mock.ctrl.RecordCallWithMethodType(mr.mock, "TidyRepo", reflect.TypeOf((*MockRepo)(nil).TidyRepo))
+	return mr.mock.ctrl.RecordCallWithMethodType(mr.mock, "GetPath", reflect.TypeOf((*MockRepo)(nil).GetPath))
 }
 
-// TidyAll mocks base method
-func (m *MockRepo) TidyAll() error {
+// GetSourceName mocks base method.
+func (m *MockRepo) GetSourceName() string {
 	m.ctrl.T.Helper()
-	ret := m.ctrl.Call(m, "TidyAll")
-	ret0, _ := ret[0].(error)
+	ret := m.ctrl.Call(m, "GetSourceName")
+	ret0, _ := ret[0].(string)
 	return ret0
 }
 
-// TidyAll indicates an expected call of TidyAll
-func (mr *MockRepoMockRecorder) TidyAll() *gomock.Call {
+// GetSourceName indicates an expected call of GetSourceName.
+func (mr *MockRepoMockRecorder) GetSourceName() *gomock.Call {
 	mr.mock.ctrl.T.Helper()
-	return mr.mock.ctrl.RecordCallWithMethodType(mr.mock, "TidyAll", reflect.TypeOf((*MockRepo)(nil).TidyAll))
+	return mr.mock.ctrl.RecordCallWithMethodType(mr.mock, "GetSourceName", reflect.TypeOf((*MockRepo)(nil).GetSourceName))
 }
 
-// AddUser mocks base method
-func (m *MockRepo) AddUser(name string) {
+// GetSourceNameSpace mocks base method.
+func (m *MockRepo) GetSourceNameSpace() string {
 	m.ctrl.T.Helper()
-	m.ctrl.Call(m, "AddUser", name)
+	ret := m.ctrl.Call(m, "GetSourceNameSpace")
+	ret0, _ := ret[0].(string)
+	return ret0
 }
 
-// AddUser indicates an expected call of AddUser
-func (mr *MockRepoMockRecorder) AddUser(name interface{}) *gomock.Call {
+// GetSourceNameSpace indicates an expected call of GetSourceNameSpace.
+func (mr *MockRepoMockRecorder) GetSourceNameSpace() *gomock.Call {
 	mr.mock.ctrl.T.Helper()
-	return mr.mock.ctrl.RecordCallWithMethodType(mr.mock, "AddUser", reflect.TypeOf((*MockRepo)(nil).AddUser), name)
+	return mr.mock.ctrl.RecordCallWithMethodType(mr.mock, "GetSourceNameSpace", reflect.TypeOf((*MockRepo)(nil).GetSourceNameSpace))
 }
 
-// RemoveUser mocks base method
-func (m *MockRepo) RemoveUser(namer string) bool {
+// IsSynced mocks base method.
+func (m *MockRepo) IsSynced() bool {
 	m.ctrl.T.Helper()
-	ret := m.ctrl.Call(m, "RemoveUser", namer)
+	ret := m.ctrl.Call(m, "IsSynced")
 	ret0, _ := ret[0].(bool)
 	return ret0
 }
 
-// RemoveUser indicates an expected call of RemoveUser
-func (mr *MockRepoMockRecorder) RemoveUser(namer interface{}) *gomock.Call {
+// IsSynced indicates an expected call of IsSynced.
+func (mr *MockRepoMockRecorder) IsSynced() *gomock.Call {
 	mr.mock.ctrl.T.Helper()
-	return mr.mock.ctrl.RecordCallWithMethodType(mr.mock, "RemoveUser", reflect.TypeOf((*MockRepo)(nil).RemoveUser), namer)
+	return mr.mock.ctrl.RecordCallWithMethodType(mr.mock, "IsSynced", reflect.TypeOf((*MockRepo)(nil).IsSynced))
 }
 
-// LinkData mocks base method
+// LinkData mocks base method.
 func (m *MockRepo) LinkData(layerPath, sourcePath string) error {
 	m.ctrl.T.Helper()
 	ret := m.ctrl.Call(m, "LinkData", layerPath, sourcePath)
@@ -295,117 +351,117 @@ func (m *MockRepo) LinkData(layerPath, sourcePath string) error {
 	return ret0
 }
 
-// LinkData indicates an expected call of LinkData
+// LinkData indicates an expected call of LinkData.
 func (mr *MockRepoMockRecorder) LinkData(layerPath, sourcePath interface{}) *gomock.Call {
 	mr.mock.ctrl.T.Helper()
 	return mr.mock.ctrl.RecordCallWithMethodType(mr.mock, "LinkData", reflect.TypeOf((*MockRepo)(nil).LinkData), layerPath, sourcePath)
 }
 
-// GetGitRepo mocks base method
-func (m *MockRepo) GetGitRepo() *v1beta1.GitRepository {
+// RemoveUser mocks base method.
+func (m *MockRepo) RemoveUser(namer string) bool {
 	m.ctrl.T.Helper()
-	ret := m.ctrl.Call(m, "GetGitRepo")
-	ret0, _ := ret[0].(*v1beta1.GitRepository)
+	ret := m.ctrl.Call(m, "RemoveUser", namer)
+	ret0, _ := ret[0].(bool)
 	return ret0
 }
 
-// GetGitRepo indicates an expected call of GetGitRepo
-func (mr *MockRepoMockRecorder) GetGitRepo() *gomock.Call {
+// RemoveUser indicates an expected call of RemoveUser.
+func (mr *MockRepoMockRecorder) RemoveUser(namer interface{}) *gomock.Call {
 	mr.mock.ctrl.T.Helper()
-	return mr.mock.ctrl.RecordCallWithMethodType(mr.mock, "GetGitRepo", reflect.TypeOf((*MockRepo)(nil).GetGitRepo))
+	return mr.mock.ctrl.RecordCallWithMethodType(mr.mock, "RemoveUser", reflect.TypeOf((*MockRepo)(nil).RemoveUser), namer)
 }
 
-// GetPath mocks base method
-func (m *MockRepo) GetPath() string {
+// SetGitRepo mocks base method.
+func (m *MockRepo) SetGitRepo(src *v1beta2.GitRepository, rootPath string) {
 	m.ctrl.T.Helper()
-	ret := m.ctrl.Call(m, "GetPath")
-	ret0, _ := ret[0].(string)
-	return ret0
+	m.ctrl.Call(m, "SetGitRepo", src, rootPath)
 }
 
-// GetPath indicates an expected call of GetPath
-func (mr *MockRepoMockRecorder) GetPath() *gomock.Call {
+// SetGitRepo indicates an expected call of SetGitRepo.
+func (mr *MockRepoMockRecorder) SetGitRepo(src, rootPath interface{}) *gomock.Call {
 	mr.mock.ctrl.T.Helper()
-	return mr.mock.ctrl.RecordCallWithMethodType(mr.mock, "GetPath", reflect.TypeOf((*MockRepo)(nil).GetPath))
+	return mr.mock.ctrl.RecordCallWithMethodType(mr.mock, "SetGitRepo", reflect.TypeOf((*MockRepo)(nil).SetGitRepo), src, rootPath)
 }
 
-// GetDataPath mocks base method
-func (m *MockRepo) GetDataPath() string {
+// SetHTTPClient mocks base method.
+func (m *MockRepo) SetHTTPClient(client *http.Client) {
 	m.ctrl.T.Helper()
-	ret := m.ctrl.Call(m, "GetDataPath")
-	ret0, _ := ret[0].(string)
-	return ret0
+	m.ctrl.Call(m, "SetHTTPClient", client)
 }
 
-// GetDataPath indicates an expected call of GetDataPath
-func (mr *MockRepoMockRecorder) GetDataPath() *gomock.Call {
+// SetHTTPClient indicates an expected call of SetHTTPClient.
+func (mr *MockRepoMockRecorder) SetHTTPClient(client interface{}) *gomock.Call {
 	mr.mock.ctrl.T.Helper()
-	return mr.mock.ctrl.RecordCallWithMethodType(mr.mock, "GetDataPath", reflect.TypeOf((*MockRepo)(nil).GetDataPath))
+	return mr.mock.ctrl.RecordCallWithMethodType(mr.mock, "SetHTTPClient", reflect.TypeOf((*MockRepo)(nil).SetHTTPClient), client)
 }
 
-// GetLoadPath mocks base method
-func (m *MockRepo) GetLoadPath() string {
+// SetHostName mocks base method.
+func (m *MockRepo) SetHostName(hostName string) {
 	m.ctrl.T.Helper()
-	ret := m.ctrl.Call(m, "GetLoadPath")
-	ret0, _ := ret[0].(string)
-	return ret0
+	m.ctrl.Call(m, "SetHostName", hostName)
 }
 
-// GetLoadPath indicates an expected call of GetLoadPath
-func (mr *MockRepoMockRecorder) GetLoadPath() *gomock.Call {
+// SetHostName indicates an expected call of SetHostName.
+func (mr *MockRepoMockRecorder) SetHostName(hostName interface{}) *gomock.Call {
 	mr.mock.ctrl.T.Helper()
-	return mr.mock.ctrl.RecordCallWithMethodType(mr.mock, "GetLoadPath", reflect.TypeOf((*MockRepo)(nil).GetLoadPath))
+	return mr.mock.ctrl.RecordCallWithMethodType(mr.mock, "SetHostName", reflect.TypeOf((*MockRepo)(nil).SetHostName), hostName)
 }
 
-// SetHostName mocks base method
-func (m *MockRepo) SetHostName(hostName string) {
+// SetTarConsumer mocks base method.
+func (m *MockRepo) SetTarConsumer(tarConsumer tarconsumer.TarConsumer) {
 	m.ctrl.T.Helper()
-	m.ctrl.Call(m, "SetHostName", hostName)
+	m.ctrl.Call(m, "SetTarConsumer", tarConsumer)
 }
 
-// SetHostName indicates an expected call of SetHostName
-func (mr *MockRepoMockRecorder) SetHostName(hostName interface{}) *gomock.Call {
+// SetTarConsumer indicates an expected call of SetTarConsumer.
+func (mr *MockRepoMockRecorder) SetTarConsumer(tarConsumer interface{}) *gomock.Call {
 	mr.mock.ctrl.T.Helper()
-	return mr.mock.ctrl.RecordCallWithMethodType(mr.mock, "SetHostName", reflect.TypeOf((*MockRepo)(nil).SetHostName), hostName)
+	return mr.mock.ctrl.RecordCallWithMethodType(mr.mock, "SetTarConsumer", reflect.TypeOf((*MockRepo)(nil).SetTarConsumer), tarConsumer)
 }
 
-// SetGitRepo mocks base method
-func (m *MockRepo) SetGitRepo(src *v1beta1.GitRepository, rootPath string) {
+// SyncRepo mocks base method.
+func (m *MockRepo) SyncRepo() error {
 	m.ctrl.T.Helper()
-	m.ctrl.Call(m, "SetGitRepo", src, rootPath)
+	ret := m.ctrl.Call(m, "SyncRepo")
+	ret0, _ := ret[0].(error)
+	return ret0
 }
 
-// SetGitRepo indicates an expected call of SetGitRepo
-func (mr *MockRepoMockRecorder) SetGitRepo(src, rootPath interface{}) *gomock.Call {
+// SyncRepo indicates an expected call of SyncRepo.
+func (mr *MockRepoMockRecorder) SyncRepo() *gomock.Call {
 	mr.mock.ctrl.T.Helper()
-	return mr.mock.ctrl.RecordCallWithMethodType(mr.mock, "SetGitRepo", reflect.TypeOf((*MockRepo)(nil).SetGitRepo), src, rootPath)
+	return mr.mock.ctrl.RecordCallWithMethodType(mr.mock, "SyncRepo", reflect.TypeOf((*MockRepo)(nil).SyncRepo))
 }
 
-// SetHTTPClient mocks base method
-func (m *MockRepo) SetHTTPClient(client *http.Client) {
+// TidyAll mocks base method.
+func (m *MockRepo) TidyAll() error {
 	m.ctrl.T.Helper()
-	m.ctrl.Call(m, "SetHTTPClient", client)
+	ret := m.ctrl.Call(m, "TidyAll")
+	ret0, _ := ret[0].(error)
+	return ret0
 }
 
-// SetHTTPClient indicates an expected call of SetHTTPClient
-func (mr *MockRepoMockRecorder) SetHTTPClient(client interface{}) *gomock.Call {
+// TidyAll indicates an expected call of TidyAll.
+func (mr *MockRepoMockRecorder) TidyAll() *gomock.Call {
 	mr.mock.ctrl.T.Helper()
-	return mr.mock.ctrl.RecordCallWithMethodType(mr.mock, "SetHTTPClient", reflect.TypeOf((*MockRepo)(nil).SetHTTPClient), client)
+	return mr.mock.ctrl.RecordCallWithMethodType(mr.mock, "TidyAll", reflect.TypeOf((*MockRepo)(nil).TidyAll))
 }
 
-// SetTarConsumer mocks base method
-func (m *MockRepo) SetTarConsumer(tarConsumer tarconsumer.TarConsumer) {
+// TidyRepo mocks base method.
+func (m *MockRepo) TidyRepo() error {
 	m.ctrl.T.Helper()
-	m.ctrl.Call(m, "SetTarConsumer", tarConsumer)
+	ret := m.ctrl.Call(m, "TidyRepo")
+	ret0, _ := ret[0].(error)
+	return ret0
 }
 
-// SetTarConsumer indicates an expected call of SetTarConsumer
-func (mr *MockRepoMockRecorder) SetTarConsumer(tarConsumer interface{}) *gomock.Call {
+// TidyRepo indicates an expected call of TidyRepo.
+func (mr *MockRepoMockRecorder) TidyRepo() *gomock.Call {
 	mr.mock.ctrl.T.Helper()
-	return mr.mock.ctrl.RecordCallWithMethodType(mr.mock, "SetTarConsumer", reflect.TypeOf((*MockRepo)(nil).SetTarConsumer), tarConsumer)
+	return mr.mock.ctrl.RecordCallWithMethodType(mr.mock, "TidyRepo", reflect.TypeOf((*MockRepo)(nil).TidyRepo))
 }
 
-// fetchArtifact mocks base method
+// fetchArtifact mocks base method.
 func (m *MockRepo) fetchArtifact(ctx context.Context) error {
 	m.ctrl.T.Helper()
 	ret := m.ctrl.Call(m, "fetchArtifact", ctx)
@@ -413,7 +469,7 @@ func (m *MockRepo) fetchArtifact(ctx context.Context) error {
 	return ret0
 }
 
-// fetchArtifact indicates an expected call of fetchArtifact
+// fetchArtifact indicates an expected call of fetchArtifact.
 func (mr *MockRepoMockRecorder) fetchArtifact(ctx interface{}) *gomock.Call {
 	mr.mock.ctrl.T.Helper()
 	return mr.mock.ctrl.RecordCallWithMethodType(mr.mock, "fetchArtifact", reflect.TypeOf((*MockRepo)(nil).fetchArtifact), ctx)
@@ -0,0 +1,214 @@
+// Package policy evaluates the CEL rules of LayerPolicies against the resources in an AddonsLayer.
+package policy
+
+import (
+	"fmt"
+	"sort"
+	"strings"
+	"sync"
+
+	"github.com/google/cel-go/cel"
+	"github.com/pkg/errors"
+	metav1 "k8s.io/apimachinery/pkg/apis/meta/v1"
+	"k8s.io/apimachinery/pkg/labels"
+	"k8s.io/apimachinery/pkg/runtime"
+
+	kraanv1alpha1 "github.com/fidelity/kraan/api/v1alpha1"
+	"github.com/fidelity/kraan/pkg/logging"
+)
+
+// ViolationError is returned when the resources in an AddonsLayer do not satisfy the rules of an enforced LayerPolicy.
+type ViolationError struct {
+	Violations []kraanv1alpha1.PolicyViolation
+}
+
+// Error returns the violations as a message.
+func (e *ViolationError) Error() string {
+	messages := make([]string, 0, len(e.Violations))
+	for _, violation := range e.Violations {
+		messages = append(messages, FormatViolation(violation))
+	}
+	return strings.Join(messages, "; ")
+}
+
+// FormatViolation returns a message describing a violation.
+func FormatViolation(violation kraanv1alpha1.PolicyViolation) string {
+	return fmt.Sprintf("%s %s/%s violates rule: %s/%s, %s",
+		violation.Kind, violation.Namespace, violation.Name, violation.Policy, violation.Rule, violation.Message)
+}
+
+var (
+	programsMutex sync.Mutex
+	programs      = map[string]cel.Program{}
+	env           *cel.Env
+	envErr        error
+	envOnce       sync.Once
+)
+
+func getEnv() (*cel.Env, error) {
+	envOnce.Do(func() {
+		env, envErr = cel.NewEnv(
+			cel.Variable("object", cel.DynType),
+			cel.Variable("layer", cel.DynType),
+		)
+	})
+	return env, envErr
+}
+
+// Compile compiles a rule's expression, returning an error if it is not valid. Programs are cached by expression.
+func Compile(expression string) (cel.Program, error) {
+	programsMutex.Lock()
+	defer programsMutex.Unlock()
+	if program, ok := programs[expression]; ok {
+		return program, nil
+	}
+	celEnv, err := getEnv()
+	if err != nil {
+		return nil, errors.Wrapf(err, "%s - failed to create CEL environment", logging.CallerStr(logging.Me))
+	}
+	ast, issues := celEnv.Compile(expression)
+	if issues != nil && issues.Err() != nil {
+		return nil, errors.Wrapf(issues.Err(), "%s - failed to compile expression: %s", logging.CallerStr(logging.Me), expression)
+	}
+	if ast.OutputType() != cel.BoolType && ast.OutputType() != cel.DynType {
+		return nil, fmt.Errorf("expression: %s, returns %s, not bool", expression, ast.OutputType())
+	}
+	program, err := celEnv.Program(ast)
+	if err != nil {
+		return nil, errors.Wrapf(err, "%s - failed to create program for expression: %s", logging.CallerStr(logging.Me), expression)
+	}
+	programs[expression] = program
+	return program, nil
+}
+
+// Applies returns true if a policy applies to an AddonsLayer.
+func Applies(policy *kraanv1alpha1.LayerPolicy, layer *kraanv1alpha1.AddonsLayer) (bool, error) {
+	if policy.Spec.LayerSelector == nil {
+		return true, nil
+	}
+	selector, err := metav1.LabelSelectorAsSelector(policy.Spec.LayerSelector)
+	if err != nil {
+		return false, errors.Wrapf(err, "%s - invalid layer selector in LayerPolicy: %s", logging.CallerStr(logging.Me), policy.Name)
+	}
+	return selector.Matches(labels.Set(layer.Labels)), nil
+}
+
+// Evaluate evaluates the rules of the policies that apply to an AddonsLayer against its resources, returning the
+// violations sorted by policy, rule, kind, namespace and name. An error is returned if a policy is invalid.
+func Evaluate(policies []kraanv1alpha1.LayerPolicy, layer *kraanv1alpha1.AddonsLayer, objs []runtime.Object) ([]kraanv1alpha1.PolicyViolation, error) {
+	layerVar := map[string]interface{}{
+		"name":    layer.Name,
+		"version": layer.Spec.Version,
+		"labels":  stringMap(layer.Labels),
+	}
+	resources := make([]map[string]interface{}, 0, len(objs))
+	for _, obj := range objs {
+		resource, err := runtime.DefaultUnstructuredConverter.ToUnstructured(obj)
+		if err != nil {
+			return nil, errors.Wrapf(err, "%s - failed to convert resource to unstructured", logging.CallerStr(logging.Me))
+		}
+		resources = append(resources, resource)
+	}
+
+	violations := []kraanv1alpha1.PolicyViolation{}
+	for index := range policies {
+		policy := &policies[index]
+		applies, err := Applies(policy, layer)
+		if err != nil {
+			return nil, err
+		}
+		if !applies {
+			continue
+		}
+		mode := kraanv1alpha1.PolicyModeEnforce
+		if policy.Spec.IsAudit() {
+			mode = kraanv1alpha1.PolicyModeAudit
+		}
+		for _, rule := range policy.Spec.Rules {
+			program, err := Compile(rule.Expression)
+			if err != nil {
+				return nil, errors.WithMessagef(err, "%s - invalid rule: %s, in LayerPolicy: %s", logging.CallerStr(logging.Me), rule.Name, policy.Name)
+			}
+			for i, obj := range objs {
+				kind := obj.GetObjectKind().GroupVersionKind().Kind
+				if len(rule.Kinds) > 0 && !containsString(rule.Kinds, kind) {
+					continue
+				}
+				message, ok := evaluateRule(program, rule, resources[i], layerVar)
+				if ok {
+					continue
+				}
+				violation := kraanv1alpha1.PolicyViolation{Policy: policy.Name, Rule: rule.Name, Mode: mode, Kind: kind, Message: message}
+				if meta, isMeta := obj.(metav1.Object); isMeta {
+					violation.Namespace = meta.GetNamespace()
+					violation.Name = meta.GetName()
+				}
+				violations = append(violations, violation)
+			}
+		}
+	}
+	sort.SliceStable(violations, func(i, j int) bool {
+		a, b := violations[i], violations[j]
+		if a.Policy != b.Policy {
+			return a.Policy < b.Policy
+		}
+		if a.Rule != b.Rule {
+			return a.Rule < b.Rule
+		}
+		if a.Kind != b.Kind {
+			return a.Kind < b.Kind
+		}
+		if a.Namespace != b.Namespace {
+			return a.Namespace < b.Namespace
+		}
+		return a.Name < b.Name
+	})
+	return violations, nil
+}
+
+// Enforced returns the violations of enforced policies.
+func Enforced(violations []kraanv1alpha1.PolicyViolation) []kraanv1alpha1.PolicyViolation {
+	enforced := []kraanv1alpha1.PolicyViolation{}
+	for _, violation := range violations {
+		if violation.Mode != kraanv1alpha1.PolicyModeAudit {
+			enforced = append(enforced, violation)
+		}
+	}
+	return enforced
+}
+
+// evaluateRule returns true if a resource satisfies a rule, otherwise the message describing the violation. A rule that
+// cannot be evaluated for a resource, for example because it references a field the resource does not have, is
+// violated.
+func evaluateRule(program cel.Program, rule kraanv1alpha1.PolicyRule, resource, layer map[string]interface{}) (string, bool) {
+	message := rule.Message
+	if message == "" {
+		message = fmt.Sprintf("failed expression: %s", rule.Expression)
+	}
+	out, _, err := program.Eval(map[string]interface{}{"object": resource, "layer": layer})
+	if err != nil {
+		return fmt.Sprintf("%s, %s", message, err.Error()), false
+	}
+	result, ok := out.Value().(bool)
+	if !ok {
+		return fmt.Sprintf("%s, expression returned %v, not bool", message, out.Value()), false
+	}
+	return message, result
+}
+
+func stringMap(in map[string]string) map[string]interface{} {
+	out := map[string]interface{}{}
+	for key, value := range in {
+		out[key] = value
+	}
+	return out
+}
+
+func containsString(slice []string, s string) bool {
+	for _, item := range slice {
+		if item == s {
+			return true
+		}
+	}
+	return false
+}
@@ -0,0 +1,133 @@
+package policy_test
+
+import (
+	"errors"
+	"testing"
+
+	helmctlv2 "github.com/fluxcd/helm-controller/api/v2beta1"
+	"github.com/google/go-cmp/cmp"
+	pkgerrors "github.com/pkg/errors"
+	corev1 "k8s.io/api/core/v1"
+	metav1 "k8s.io/apimachinery/pkg/apis/meta/v1"
+	"k8s.io/apimachinery/pkg/runtime"
+
+	kraanv1alpha1 "github.com/fidelity/kraan/api/v1alpha1"
+	"github.com/fidelity/kraan/pkg/policy"
+)
+
+func newHelmRelease(namespace, name, chartVersion string) *helmctlv2.HelmRelease {
+	return &helmctlv2.HelmRelease{
+		TypeMeta:   metav1.TypeMeta{APIVersion: helmctlv2.GroupVersion.String(), Kind: helmctlv2.HelmReleaseKind},
+		ObjectMeta: metav1.ObjectMeta{Namespace: namespace, Name: name},
+		Spec: helmctlv2.HelmReleaseSpec{
+			Chart: helmctlv2.HelmChartTemplate{Spec: helmctlv2.HelmChartTemplateSpec{Chart: name, Version: chartVersion}},
+		},
+	}
+}
+
+func newConfigMap(namespace, name string) *corev1.ConfigMap {
+	return &corev1.ConfigMap{
+		TypeMeta:   metav1.TypeMeta{APIVersion: "v1", Kind: "ConfigMap"},
+		ObjectMeta: metav1.ObjectMeta{Namespace: namespace, Name: name},
+	}
+}
+
+func newPolicy(name, mode string, selector *metav1.LabelSelector, rules ...kraanv1alpha1.PolicyRule) kraanv1alpha1.LayerPolicy {
+	return kraanv1alpha1.LayerPolicy{
+		ObjectMeta: metav1.ObjectMeta{Name: name},
+		Spec:       kraanv1alpha1.LayerPolicySpec{Rules: rules, Mode: mode, LayerSelector: selector},
+	}
+}
+
+func TestEvaluate(t *testing.T) {
+	layer := &kraanv1alpha1.AddonsLayer{
+		ObjectMeta: metav1.ObjectMeta{Name: "apps", Labels: map[string]string{"tier": "apps"}},
+		Spec:       kraanv1alpha1.AddonsLayerSpec{Version: "0.1.01"},
+	}
+	objs := []runtime.Object{
+		newHelmRelease("apps", "podinfo", "6.3.5"),
+		newHelmRelease("kube-system", "microservice", "*"),
+		newConfigMap("apps", "settings"),
+	}
+	pinned := kraanv1alpha1.PolicyRule{
+		Name:       "pinned-charts",
+		Kinds:      []string{helmctlv2.HelmReleaseKind},
+		Expression: "object.spec.chart.spec.version != '*'",
+		Message:    "chart version must be pinned",
+	}
+	namespaced := kraanv1alpha1.PolicyRule{
+		Name:       "layer-namespace",
+		Expression: "object.metadata.namespace == layer.labels.tier",
+	}
+
+	tests := []struct {
+		name     string
+		policies []kraanv1alpha1.LayerPolicy
+		expected []kraanv1alpha1.PolicyViolation
+		enforced int
+	}{{
+		name:     "no policies",
+		expected: []kraanv1alpha1.PolicyViolation{},
+	}, {
+		name:     "kinds filter and default message",
+		policies: []kraanv1alpha1.LayerPolicy{newPolicy("charts", "", nil, pinned, namespaced)},
+		expected: []kraanv1alpha1.PolicyViolation{{
+			Policy: "charts", Rule: "layer-namespace", Mode: kraanv1alpha1.PolicyModeEnforce, Kind: helmctlv2.HelmReleaseKind,
+			Namespace: "kube-system", Name: "microservice", Message: "failed expression: " + namespaced.Expression,
+		}, {
+			Policy: "charts", Rule: "pinned-charts", Mode: kraanv1alpha1.PolicyModeEnforce, Kind: helmctlv2.HelmReleaseKind,
+			Namespace: "kube-system", Name: "microservice", Message: "chart version must be pinned",
+		}},
+		enforced: 2,
+	}, {
+		name:     "audit only",
+		policies: []kraanv1alpha1.LayerPolicy{newPolicy("charts", kraanv1alpha1.PolicyModeAudit, nil, pinned)},
+		expected: []kraanv1alpha1.PolicyViolation{{
+			Policy: "charts", Rule: "pinned-charts", Mode: kraanv1alpha1.PolicyModeAudit, Kind: helmctlv2.HelmReleaseKind,
+			Namespace: "kube-system", Name: "microservice", Message: "chart version must be pinned",
+		}},
+	}, {
+		name: "layer selector does not match",
+		policies: []kraanv1alpha1.LayerPolicy{newPolicy("charts", "",
+			&metav1.LabelSelector{MatchLabels: map[string]string{"tier": "base"}}, pinned)},
+		expected: []kraanv1alpha1.PolicyViolation{},
+	}}
+
+	for _, test := range tests {
+		violations, err := policy.Evaluate(test.policies, layer, objs)
+		if err != nil {
+			t.Fatalf("%s: Evaluate returned an error: %s", test.name, err)
+		}
+		if diff := cmp.Diff(test.expected, violations); diff != "" {
+			t.Errorf("%s: unexpected violations (-want +got):\n%s", test.name, diff)
+		}
+		if enforced := policy.Enforced(violations); len(enforced) != test.enforced {
+			t.Errorf("%s: expected %d enforced violations, got %d", test.name, test.enforced, len(enforced))
+		}
+	}
+}
+
+func TestEvaluateInvalidRule(t *testing.T) {
+	layer := &kraanv1alpha1.AddonsLayer{ObjectMeta: metav1.ObjectMeta{Name: "apps"}}
+	for _, expression := range []string{"object.metadata.name ==", "object.metadata.name.size()"} {
+		policies := []kraanv1alpha1.LayerPolicy{newPolicy("invalid", "", nil, kraanv1alpha1.PolicyRule{Name: "rule", Expression: expression})}
+		if _, err := policy.Evaluate(policies, layer, []runtime.Object{newConfigMap("apps", "settings")}); err == nil {
+			t.Errorf("Evaluate did not return an error for expression: %s", expression)
+		}
+	}
+}
+
+func TestViolationError(t *testing.T) {
+	violation := kraanv1alpha1.PolicyViolation{
+		Policy: "charts", Rule: "pinned-charts", Kind: helmctlv2.HelmReleaseKind, Namespace: "apps", Name: "podinfo", Message: "chart version must be pinned",
+	}
+	err := pkgerrors.WithMessagef(&policy.ViolationError{Violations: []kraanv1alpha1.PolicyViolation{violation}}, "failed to apply")
+	violationErr := &policy.ViolationError{}
+	if !errors.As(err, &violationErr) {
+		t.Fatalf("errors.As did not find the ViolationError")
+	}
+	expected := "HelmRelease apps/podinfo violates rule: charts/pinned-charts, chart version must be pinned"
+	if violationErr.Error() != expected {
+		t.Fatalf("unexpected error message: %s", violationErr.Error())
+	}
+}
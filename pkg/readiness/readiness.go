@@ -0,0 +1,266 @@
+// Package readiness computes whether Kubernetes objects are ready.
+//
+// The rules follow the kstatus conventions: an object whose latest generation has not been observed by its controller
+// is in progress, the standard Stalled and Reconciling conditions report failure and progress, well known built in kinds
+// are checked using their status fields, objects with a Ready condition are ready when it is True and any other object
+// is ready once it exists.
+package readiness
+
+import (
+	"fmt"
+	"strings"
+
+	metav1 "k8s.io/apimachinery/pkg/apis/meta/v1"
+	"k8s.io/apimachinery/pkg/apis/meta/v1/unstructured"
+)
+
+// Status is the readiness status of an object.
+type Status string
+
+const (
+	// InProgress means the object is being reconciled towards its desired state.
+	InProgress Status = "InProgress"
+	// Failed means the object's controller reported that it cannot reach its desired state.
+	Failed Status = "Failed"
+	// Current means the object has reached its desired state.
+	Current Status = "Current"
+	// Terminating means the object is being deleted.
+	Terminating Status = "Terminating"
+)
+
+// Result is the readiness status of an object and a message explaining it.
+type Result struct {
+	Status  Status
+	Message string
+}
+
+// Ready returns true if the object is ready.
+func (r Result) Ready() bool {
+	return r.Status == Current
+}
+
+// Compute returns the readiness of an object. If conditionType is set the object is ready when its condition of that
+// type has status True, instead of using the default rules for the object's kind.
+func Compute(obj *unstructured.Unstructured, conditionType string) Result {
+	if obj.GetDeletionTimestamp() != nil {
+		return Result{Status: Terminating, Message: "object is being deleted"}
+	}
+	observed, found, err := unstructured.NestedInt64(obj.Object, "status", "observedGeneration")
+	if err == nil && found && observed != obj.GetGeneration() {
+		return Result{Status: InProgress, Message: fmt.Sprintf("generation: %d, not yet observed", obj.GetGeneration())}
+	}
+	if len(conditionType) > 0 {
+		return conditionResult(obj, conditionType)
+	}
+	if condition := getCondition(obj, "Stalled"); isTrue(condition) {
+		return Result{Status: Failed, Message: conditionMessage(condition)}
+	}
+	if condition := getCondition(obj, "Reconciling"); isTrue(condition) {
+		return Result{Status: InProgress, Message: conditionMessage(condition)}
+	}
+	if compute, ok := kinds[obj.GroupVersionKind().GroupKind().String()]; ok {
+		return compute(obj)
+	}
+	if condition := getCondition(obj, "Ready"); condition != nil {
+		return conditionResult(obj, "Ready")
+	}
+	return current()
+}
+
+var kinds = map[string]func(obj *unstructured.Unstructured) Result{
+	"Deployment.apps":       deploymentResult,
+	"StatefulSet.apps":      statefulSetResult,
+	"DaemonSet.apps":        daemonSetResult,
+	"ReplicaSet.apps":       replicaSetResult,
+	"Job.batch":             jobResult,
+	"Pod":                   podResult,
+	"PersistentVolumeClaim": pvcResult,
+	"Service":               serviceResult,
+	"CustomResourceDefinition.apiextensions.k8s.io": crdResult,
+}
+
+func current() Result {
+	return Result{Status: Current, Message: "object is ready"}
+}
+
+func conditionResult(obj *unstructured.Unstructured, conditionType string) Result {
+	condition := getCondition(obj, conditionType)
+	if condition == nil {
+		return Result{Status: InProgress, Message: fmt.Sprintf("condition: %s, not found", conditionType)}
+	}
+	if !isTrue(condition) {
+		return Result{Status: InProgress, Message: fmt.Sprintf("condition: %s, is %s, %s", conditionType, condition["status"], conditionMessage(condition))}
+	}
+	return current()
+}
+
+func getCondition(obj *unstructured.Unstructured, conditionType string) map[string]interface{} {
+	conditions, _, _ := unstructured.NestedSlice(obj.Object, "status", "conditions")
+	for _, item := range conditions {
+		condition, ok := item.(map[string]interface{})
+		if ok && condition["type"] == conditionType {
+			return condition
+		}
+	}
+	return nil
+}
+
+func isTrue(condition map[string]interface{}) bool {
+	return condition != nil && condition["status"] == string(metav1.ConditionTrue)
+}
+
+func isFalse(condition map[string]interface{}) bool {
+	return condition != nil && condition["status"] == string(metav1.ConditionFalse)
+}
+
+func conditionMessage(condition map[string]interface{}) string {
+	message, _ := condition["message"].(string)
+	if len(message) > 0 {
+		return message
+	}
+	reason, _ := condition["reason"].(string)
+	return reason
+}
+
+func getInt(obj *unstructured.Unstructured, fields ...string) int64 {
+	value, _, _ := unstructured.NestedInt64(obj.Object, fields...)
+	return value
+}
+
+func getString(obj *unstructured.Unstructured, fields ...string) string {
+	value, _, _ := unstructured.NestedString(obj.Object, fields...)
+	return value
+}
+
+// replicas returns the desired number of replicas, which defaults to one.
+func replicas(obj *unstructured.Unstructured) int64 {
+	value, found, _ := unstructured.NestedInt64(obj.Object, "spec", "replicas")
+	if !found {
+		return 1
+	}
+	return value
+}
+
+func replicasResult(desired int64, counts map[string]int64) Result {
+	for _, name := range []string{"updated", "ready", "available"} {
+		count, ok := counts[name]
+		if ok && count < desired {
+			return Result{Status: InProgress, Message: fmt.Sprintf("%s replicas: %d/%d", name, count, desired)}
+		}
+	}
+	return current()
+}
+
+func deploymentResult(obj *unstructured.Unstructured) Result {
+	progressing := getCondition(obj, "Progressing")
+	if isFalse(progressing) && progressing["reason"] == "ProgressDeadlineExceeded" {
+		return Result{Status: Failed, Message: conditionMessage(progressing)}
+	}
+	desired := replicas(obj)
+	result := replicasResult(desired, map[string]int64{
+		"updated":   getInt(obj, "status", "updatedReplicas"),
+		"ready":     getInt(obj, "status", "readyReplicas"),
+		"available": getInt(obj, "status", "availableReplicas"),
+	})
+	if !result.Ready() {
+		return result
+	}
+	if total := getInt(obj, "status", "replicas"); total > desired {
+		return Result{Status: InProgress, Message: fmt.Sprintf("pending termination: %d", total-desired)}
+	}
+	return result
+}
+
+func statefulSetResult(obj *unstructured.Unstructured) Result {
+	desired := replicas(obj)
+	result := replicasResult(desired, map[string]int64{
+		"ready": getInt(obj, "status", "readyReplicas"),
+	})
+	if !result.Ready() {
+		return result
+	}
+	if getString(obj, "spec", "updateStrategy", "type") == "OnDelete" {
+		return result
+	}
+	if partition := getInt(obj, "spec", "updateStrategy", "rollingUpdate", "partition"); partition > 0 {
+		if updated := getInt(obj, "status", "updatedReplicas"); updated < desired-partition {
+			return Result{Status: InProgress, Message: fmt.Sprintf("updated replicas: %d/%d", updated, desired-partition)}
+		}
+		return result
+	}
+	if currentRevision, updateRevision := getString(obj, "status", "currentRevision"), getString(obj, "status", "updateRevision"); currentRevision != updateRevision {
+		return Result{Status: InProgress, Message: fmt.Sprintf("revision: %s, not yet rolled out", updateRevision)}
+	}
+	return result
+}
+
+func daemonSetResult(obj *unstructured.Unstructured) Result {
+	desired := getInt(obj, "status", "desiredNumberScheduled")
+	return replicasResult(desired, map[string]int64{
+		"updated":   getInt(obj, "status", "updatedNumberScheduled"),
+		"ready":     getInt(obj, "status", "numberReady"),
+		"available": getInt(obj, "status", "numberAvailable"),
+	})
+}
+
+func replicaSetResult(obj *unstructured.Unstructured) Result {
+	return replicasResult(replicas(obj), map[string]int64{
+		"ready":     getInt(obj, "status", "readyReplicas"),
+		"available": getInt(obj, "status", "availableReplicas"),
+	})
+}
+
+// jobResult returns the readiness of a job, which is ready when it has completed.
+func jobResult(obj *unstructured.Unstructured) Result {
+	if condition := getCondition(obj, "Failed"); isTrue(condition) {
+		return Result{Status: Failed, Message: conditionMessage(condition)}
+	}
+	if condition := getCondition(obj, "Complete"); isTrue(condition) {
+		return current()
+	}
+	return Result{Status: InProgress, Message: "job not complete"}
+}
+
+func podResult(obj *unstructured.Unstructured) Result {
+	switch phase := getString(obj, "status", "phase"); phase {
+	case "Succeeded":
+		return current()
+	case "Failed":
+		return Result{Status: Failed, Message: "pod failed"}
+	case "Running":
+		if isTrue(getCondition(obj, "Ready")) {
+			return current()
+		}
+		return Result{Status: InProgress, Message: "pod not ready"}
+	default:
+		return Result{Status: InProgress, Message: fmt.Sprintf("pod phase: %s", strings.ToLower(phase))}
+	}
+}
+
+func pvcResult(obj *unstructured.Unstructured) Result {
+	if phase := getString(obj, "status", "phase"); phase != "Bound" {
+		return Result{Status: InProgress, Message: "persistent volume claim not bound"}
+	}
+	return current()
+}
+
+func serviceResult(obj *unstructured.Unstructured) Result {
+	if getString(obj, "spec", "type") != "LoadBalancer" {
+		return current()
+	}
+	ingress, _, _ := unstructured.NestedSlice(obj.Object, "status", "loadBalancer", "ingress")
+	if len(ingress) == 0 {
+		return Result{Status: InProgress, Message: "load balancer not provisioned"}
+	}
+	return current()
+}
+
+func crdResult(obj *unstructured.Unstructured) Result {
+	if condition := getCondition(obj, "NamesAccepted"); isFalse(condition) {
+		return Result{Status: Failed, Message: conditionMessage(condition)}
+	}
+	if !isTrue(getCondition(obj, "Established")) {
+		return Result{Status: InProgress, Message: "crd not established"}
+	}
+	return current()
+}
@@ -0,0 +1,155 @@
+package readiness_test
+
+import (
+	"testing"
+
+	"k8s.io/apimachinery/pkg/apis/meta/v1/unstructured"
+	"sigs.k8s.io/yaml"
+
+	"github.com/fidelity/kraan/pkg/readiness"
+)
+
+func toObject(t *testing.T, manifest string) *unstructured.Unstructured {
+	data, err := yaml.YAMLToJSON([]byte(manifest))
+	if err != nil {
+		t.Fatalf("failed to convert manifest: %s", err)
+	}
+	obj := &unstructured.Unstructured{}
+	if err := obj.UnmarshalJSON(data); err != nil {
+		t.Fatalf("failed to parse manifest: %s", err)
+	}
+	return obj
+}
+
+func TestCompute(t *testing.T) {
+	tests := []struct {
+		name          string
+		manifest      string
+		conditionType string
+		expected      readiness.Status
+	}{
+		{
+			name: "deployment available",
+			manifest: `
+apiVersion: apps/v1
+kind: Deployment
+metadata: {name: coredns, namespace: kube-system, generation: 2}
+spec: {replicas: 2}
+status: {observedGeneration: 2, replicas: 2, updatedReplicas: 2, readyReplicas: 2, availableReplicas: 2}`,
+			expected: readiness.Current,
+		}, {
+			name: "deployment rolling out",
+			manifest: `
+apiVersion: apps/v1
+kind: Deployment
+metadata: {name: coredns, namespace: kube-system, generation: 2}
+spec: {replicas: 2}
+status: {observedGeneration: 2, replicas: 3, updatedReplicas: 1, readyReplicas: 2, availableReplicas: 2}`,
+			expected: readiness.InProgress,
+		}, {
+			name: "deployment generation not observed",
+			manifest: `
+apiVersion: apps/v1
+kind: Deployment
+metadata: {name: coredns, namespace: kube-system, generation: 3}
+spec: {replicas: 2}
+status: {observedGeneration: 2, replicas: 2, updatedReplicas: 2, readyReplicas: 2, availableReplicas: 2}`,
+			expected: readiness.InProgress,
+		}, {
+			name: "deployment progress deadline exceeded",
+			manifest: `
+apiVersion: apps/v1
+kind: Deployment
+metadata: {name: coredns, namespace: kube-system}
+status:
+  conditions:
+  - {type: Progressing, status: "False", reason: ProgressDeadlineExceeded}`,
+			expected: readiness.Failed,
+		}, {
+			name: "daemonset not ready",
+			manifest: `
+apiVersion: apps/v1
+kind: DaemonSet
+metadata: {name: cilium, namespace: kube-system}
+status: {desiredNumberScheduled: 3, updatedNumberScheduled: 3, numberReady: 2, numberAvailable: 2}`,
+			expected: readiness.InProgress,
+		}, {
+			name: "job complete",
+			manifest: `
+apiVersion: batch/v1
+kind: Job
+metadata: {name: migrate, namespace: apps}
+status:
+  conditions:
+  - {type: Complete, status: "True"}`,
+			expected: readiness.Current,
+		}, {
+			name: "kustomization ready",
+			manifest: `
+apiVersion: kustomize.toolkit.fluxcd.io/v1
+kind: Kustomization
+metadata: {name: cni, namespace: flux-system, generation: 1}
+status:
+  observedGeneration: 1
+  conditions:
+  - {type: Ready, status: "True", reason: ReconciliationSucceeded}`,
+			expected: readiness.Current,
+		}, {
+			name: "helmrelease not ready",
+			manifest: `
+apiVersion: helm.toolkit.fluxcd.io/v2beta1
+kind: HelmRelease
+metadata: {name: ingress, namespace: ingress, generation: 1}
+status:
+  observedGeneration: 1
+  conditions:
+  - {type: Ready, status: "False", reason: InstallFailed}`,
+			expected: readiness.InProgress,
+		}, {
+			name: "stalled",
+			manifest: `
+apiVersion: example.io/v1
+kind: Database
+metadata: {name: db, namespace: apps}
+status:
+  conditions:
+  - {type: Stalled, status: "True", message: quota exceeded}`,
+			expected: readiness.Failed,
+		}, {
+			name: "explicit condition",
+			manifest: `
+apiVersion: example.io/v1
+kind: Database
+metadata: {name: db, namespace: apps}
+status:
+  conditions:
+  - {type: Ready, status: "True"}
+  - {type: Provisioned, status: "False"}`,
+			conditionType: "Provisioned",
+			expected:      readiness.InProgress,
+		}, {
+			name: "no status",
+			manifest: `
+apiVersion: v1
+kind: ConfigMap
+metadata: {name: settings, namespace: apps}`,
+			expected: readiness.Current,
+		}, {
+			name: "terminating",
+			manifest: `
+apiVersion: v1
+kind: Namespace
+metadata: {name: apps, deletionTimestamp: "2024-01-01T00:00:00Z"}`,
+			expected: readiness.Terminating,
+		},
+	}
+	for _, test := range tests {
+		result := readiness.Compute(toObject(t, test.manifest), test.conditionType)
+		if result.Status != test.expected {
+			t.Errorf("%s: Compute returned %s (%s), expected %s", test.name, result.Status, result.Message, test.expected)
+		}
+		if result.Ready() != (test.expected == readiness.Current) {
+			t.Errorf("%s: Ready returned %t", test.name, result.Ready())
+		}
+	}
+}
@@ -2,4 +2,5 @@ package repos
 
 var (
 	FetchRepoArtifact = Repo.fetchArtifact
+	Notify            = notify
 )
@@ -0,0 +1,258 @@
+package repos
+
+import (
+	"crypto/sha256"
+	"encoding/hex"
+	"fmt"
+	"io"
+	"io/fs"
+	"net/url"
+	"os"
+	"path/filepath"
+	"strings"
+	"sync"
+
+	sourcev1 "github.com/fluxcd/source-controller/api/v1beta2"
+	"github.com/pkg/errors"
+	apimeta "k8s.io/apimachinery/pkg/api/meta"
+	metav1 "k8s.io/apimachinery/pkg/apis/meta/v1"
+
+	"github.com/fidelity/kraan/pkg/internal/tarconsumer"
+	"github.com/fidelity/kraan/pkg/logging"
+)
+
+// FileURLScheme is the scheme of artifact URLs referring to the controller's local filesystem.
+const FileURLScheme = "file"
+
+// LocalSourceRoot is the directory containing all local sources, local sources are disabled if it is not set.
+var LocalSourceRoot = ""
+
+// localRevisions caches the revision of each local source, keyed by path, until the size or modification time of one
+// of its files changes.
+var localRevisions = struct {
+	sync.Mutex
+	entries map[string]localRevision
+}{entries: map[string]localRevision{}}
+
+type localRevision struct {
+	stamp    string
+	revision string
+}
+
+// IsFileURL returns true if the URL refers to the controller's local filesystem.
+func IsFileURL(artifactURL string) bool {
+	return strings.HasPrefix(artifactURL, FileURLScheme+"://")
+}
+
+// LocalPath returns the local filesystem path referred to by a file:// URL. The path must be in LocalSourceRoot.
+func LocalPath(fileURL string) (string, error) {
+	parsed, err := url.Parse(fileURL)
+	if err != nil {
+		return "", errors.Wrapf(err, "%s - failed to parse url: %s", logging.CallerStr(logging.Me), fileURL)
+	}
+	if parsed.Scheme != FileURLScheme {
+		return "", fmt.Errorf("url: %s, is not a %s:// url", fileURL, FileURLScheme)
+	}
+	if parsed.Host != "" && parsed.Host != "localhost" {
+		return "", fmt.Errorf("url: %s, does not refer to the local host", fileURL)
+	}
+	if !filepath.IsAbs(parsed.Path) {
+		return "", fmt.Errorf("url: %s, does not contain an absolute path", fileURL)
+	}
+	if len(LocalSourceRoot) == 0 {
+		return "", fmt.Errorf("url: %s, local sources are not enabled", fileURL)
+	}
+	path, err := filepath.EvalSymlinks(filepath.Clean(parsed.Path))
+	if err != nil {
+		return "", errors.Wrapf(err, "%s - failed to resolve path: %s", logging.CallerStr(logging.Me), parsed.Path)
+	}
+	root, err := filepath.EvalSymlinks(LocalSourceRoot)
+	if err != nil {
+		return "", errors.Wrapf(err, "%s - failed to resolve local source root: %s", logging.CallerStr(logging.Me), LocalSourceRoot)
+	}
+	rel, err := filepath.Rel(root, path)
+	if err != nil || rel == ".." || strings.HasPrefix(rel, ".."+string(filepath.Separator)) {
+		return "", fmt.Errorf("url: %s, is not in the local source root: %s", fileURL, LocalSourceRoot)
+	}
+	return path, nil
+}
+
+// LocalRevision computes a revision for a local directory or gzip compressed tarball from a hash of its content.
+// The content is only hashed again if the size or modification time of one of its files has changed.
+func LocalRevision(path string) (string, error) {
+	stamp, err := localStamp(path)
+	if err != nil {
+		return "", err
+	}
+	localRevisions.Lock()
+	cached, ok := localRevisions.entries[path]
+	localRevisions.Unlock()
+	if ok && cached.stamp == stamp {
+		return cached.revision, nil
+	}
+	revision, err := hashLocal(path)
+	if err != nil {
+		return "", err
+	}
+	localRevisions.Lock()
+	localRevisions.entries[path] = localRevision{stamp: stamp, revision: revision}
+	localRevisions.Unlock()
+	return revision, nil
+}
+
+// localStamp returns a summary of the names, sizes and modification times of the files in a local source.
+func localStamp(path string) (string, error) {
+	hash := sha256.New()
+	err := filepath.WalkDir(path, func(file string, entry fs.DirEntry, err error) error {
+		if err != nil {
+			return err
+		}
+		info, err := entry.Info()
+		if err != nil {
+			return err
+		}
+		fmt.Fprintf(hash, "%s:%d:%d\n", filepath.ToSlash(file), info.Size(), info.ModTime().UnixNano())
+		return nil
+	})
+	if err != nil {
+		return "", errors.Wrapf(err, "%s - failed to stat: %s", logging.CallerStr(logging.Me), path)
+	}
+	return hex.EncodeToString(hash.Sum(nil)), nil
+}
+
+func hashLocal(path string) (string, error) {
+	info, err := os.Stat(path)
+	if err != nil {
+		return "", errors.Wrapf(err, "%s - failed to stat: %s", logging.CallerStr(logging.Me), path)
+	}
+	hash := sha256.New()
+	if !info.IsDir() {
+		if err := hashFile(hash, path); err != nil {
+			return "", err
+		}
+		return fmt.Sprintf("sha256:%s", hex.EncodeToString(hash.Sum(nil))), nil
+	}
+	err = filepath.WalkDir(path, func(file string, entry fs.DirEntry, err error) error {
+		if err != nil {
+			return err
+		}
+		rel, err := filepath.Rel(path, file)
+		if err != nil {
+			return err
+		}
+		if entry.IsDir() {
+			fmt.Fprintf(hash, "dir:%s\n", filepath.ToSlash(rel))
+			return nil
+		}
+		if !entry.Type().IsRegular() {
+			return nil
+		}
+		fmt.Fprintf(hash, "file:%s\n", filepath.ToSlash(rel))
+		return hashFile(hash, file)
+	})
+	if err != nil {
+		return "", errors.Wrapf(err, "%s - failed to hash directory: %s", logging.CallerStr(logging.Me), path)
+	}
+	return fmt.Sprintf("sha256:%s", hex.EncodeToString(hash.Sum(nil))), nil
+}
+
+func hashFile(w io.Writer, path string) error {
+	f, err := os.Open(path)
+	if err != nil {
+		return errors.Wrapf(err, "%s - failed to open: %s", logging.CallerStr(logging.Me), path)
+	}
+	defer f.Close()
+	if _, err := io.Copy(w, f); err != nil {
+		return errors.Wrapf(err, "%s - failed to read: %s", logging.CallerStr(logging.Me), path)
+	}
+	return nil
+}
+
+// NewLocalSource returns a GitRepository describing a directory or gzip compressed tarball on the controller's
+// local filesystem. It is never created in the cluster, the artifact URL refers to the local path and the artifact
+// revision is a hash of the content, so that local sources can be processed in the same way as git repositories.
+func NewLocalSource(name, namespace, fileURL string) (*sourcev1.GitRepository, error) {
+	path, err := LocalPath(fileURL)
+	if err != nil {
+		return nil, errors.WithMessagef(err, "%s - invalid local source url", logging.CallerStr(logging.Me))
+	}
+	revision, err := LocalRevision(path)
+	if err != nil {
+		return nil, errors.WithMessagef(err, "%s - failed to compute local source revision", logging.CallerStr(logging.Me))
+	}
+	srcRepo := &sourcev1.GitRepository{
+		TypeMeta: metav1.TypeMeta{
+			Kind:       sourcev1.GitRepositoryKind,
+			APIVersion: sourcev1.GroupVersion.String(),
+		},
+		ObjectMeta: metav1.ObjectMeta{
+			Name:       name,
+			Namespace:  namespace,
+			Generation: 1,
+		},
+	}
+	srcRepo.Spec.URL = fileURL
+	srcRepo.Status.ObservedGeneration = 1
+	srcRepo.Status.URL = fileURL
+	srcRepo.Status.Artifact = &sourcev1.Artifact{
+		Path:           path,
+		URL:            fileURL,
+		Revision:       revision,
+		LastUpdateTime: metav1.Now(),
+	}
+	apimeta.SetStatusCondition(&srcRepo.Status.Conditions, metav1.Condition{
+		Type:               "Ready",
+		Status:             metav1.ConditionTrue,
+		Reason:             "Succeeded",
+		Message:            fmt.Sprintf("stored artifact for revision '%s'", revision),
+		ObservedGeneration: 1,
+	})
+	return srcRepo, nil
+}
+
+// fetchLocal copies a local directory, or unpacks a local gzip compressed tarball, to the load path.
+func (r *repoData) fetchLocal(fileURL string) error {
+	path, err := LocalPath(fileURL)
+	if err != nil {
+		return errors.WithMessagef(err, "%s - invalid artifact url", logging.CallerStr(logging.Me))
+	}
+	info, err := os.Stat(path)
+	if err != nil {
+		return errors.Wrapf(err, "%s - failed to stat: %s", logging.CallerStr(logging.Me), path)
+	}
+	if !info.IsDir() {
+		data, err := os.ReadFile(path)
+		if err != nil {
+			return errors.Wrapf(err, "%s - failed to read: %s", logging.CallerStr(logging.Me), path)
+		}
+		if err := tarconsumer.UnpackTar(r.store, data, r.GetLoadPath()); err != nil {
+			return errors.WithMessagef(err, "%s - failed to untar local artifact", logging.CallerStr(logging.Me))
+		}
+		return nil
+	}
+	err = filepath.WalkDir(path, func(file string, entry fs.DirEntry, err error) error {
+		if err != nil {
+			return err
+		}
+		rel, err := filepath.Rel(path, file)
+		if err != nil {
+			return err
+		}
+		target := fmt.Sprintf("%s/%s", r.GetLoadPath(), filepath.ToSlash(rel))
+		if entry.IsDir() {
+			return r.store.MkdirAll(target)
+		}
+		if !entry.Type().IsRegular() {
+			return nil
+		}
+		data, err := os.ReadFile(file)
+		if err != nil {
+			return err
+		}
+		return r.store.WriteFile(target, data, 0o644)
+	})
+	if err != nil {
+		return errors.Wrapf(err, "%s - failed to copy local directory: %s", logging.CallerStr(logging.Me), path)
+	}
+	return nil
+}
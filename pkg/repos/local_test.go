@@ -0,0 +1,149 @@
+package repos_test
+
+import (
+	"context"
+	"fmt"
+	"os"
+	"path/filepath"
+	"testing"
+	"time"
+
+	testlogr "github.com/go-logr/logr/testing"
+
+	"github.com/fidelity/kraan/pkg/internal/testutils"
+	"github.com/fidelity/kraan/pkg/repos"
+)
+
+func TestLocalRevision(t *testing.T) {
+	dir := t.TempDir()
+	if err := os.WriteFile(filepath.Join(dir, "hr.yaml"), []byte("kind: HelmRelease"), 0o600); err != nil {
+		t.Fatalf("failed to write test file: %s", err)
+	}
+
+	first, err := repos.LocalRevision(dir)
+	if err != nil {
+		t.Fatalf("repos.LocalRevision returned an error: %s", err)
+	}
+	second, err := repos.LocalRevision(dir)
+	if err != nil {
+		t.Fatalf("repos.LocalRevision returned an error: %s", err)
+	}
+	if first != second {
+		t.Fatalf("revision of unchanged directory changed from %s to %s", first, second)
+	}
+
+	if err := os.WriteFile(filepath.Join(dir, "hr.yaml"), []byte("kind: HelmRelease\nspec: {}"), 0o600); err != nil {
+		t.Fatalf("failed to write test file: %s", err)
+	}
+	third, err := repos.LocalRevision(dir)
+	if err != nil {
+		t.Fatalf("repos.LocalRevision returned an error: %s", err)
+	}
+	if third == first {
+		t.Fatalf("revision of changed directory did not change: %s", third)
+	}
+
+	modTime := time.Now().Add(-time.Hour)
+	if err := os.Chtimes(filepath.Join(dir, "hr.yaml"), modTime, modTime); err != nil {
+		t.Fatalf("failed to set modification time: %s", err)
+	}
+	fourth, err := repos.LocalRevision(dir)
+	if err != nil {
+		t.Fatalf("repos.LocalRevision returned an error: %s", err)
+	}
+	if err := os.WriteFile(filepath.Join(dir, "hr.yaml"), []byte("kind: HelmRelease\nspec: []"), 0o600); err != nil {
+		t.Fatalf("failed to write test file: %s", err)
+	}
+	if err := os.Chtimes(filepath.Join(dir, "hr.yaml"), modTime, modTime); err != nil {
+		t.Fatalf("failed to set modification time: %s", err)
+	}
+	fifth, err := repos.LocalRevision(dir)
+	if err != nil {
+		t.Fatalf("repos.LocalRevision returned an error: %s", err)
+	}
+	if fifth != fourth {
+		t.Fatalf("revision of directory with unchanged file sizes and times was recomputed: %s", fifth)
+	}
+}
+
+func TestLocalSource(t *testing.T) {
+	absDataDir, err := filepath.Abs(testdataDir)
+	if err != nil {
+		t.Fatalf("failed to get absolute path of test data: %s", err)
+	}
+	tarFile := filepath.Join(t.TempDir(), "addons.tar.gz")
+	if err := os.WriteFile(tarFile, testutils.Compress(t, testdataDir), 0o600); err != nil {
+		t.Fatalf("failed to write test tarball: %s", err)
+	}
+
+	tests := []struct {
+		name     string
+		root     string
+		url      string
+		expected string
+	}{
+		{name: "directory", root: filepath.Dir(absDataDir), url: "file://" + absDataDir, expected: "base/microservice1.yaml"},
+		{name: "tarball", root: filepath.Dir(tarFile), url: "file://" + tarFile, expected: testdataDir + "/base/microservice1.yaml"},
+	}
+
+	defer func(root string) { repos.LocalSourceRoot = root }(repos.LocalSourceRoot)
+	for _, test := range tests {
+		repos.LocalSourceRoot = test.root
+		testRepos := repos.NewRepos(context.Background(), testlogr.NewTestLogger(t))
+		store := useMemoryStorage(testRepos)
+
+		srcRepo, err := repos.NewLocalSource("local", "default", test.url)
+		if err != nil {
+			t.Fatalf("test: %s, repos.NewLocalSource returned an error: %s", test.name, err)
+		}
+		r := testRepos.Add(srcRepo)
+		if err := r.SyncRepo(); err != nil {
+			t.Fatalf("test: %s, error returned from %T.SyncRepo: %s", test.name, r, err)
+		}
+		file := fmt.Sprintf("%s/%s", r.GetDataPath(), test.expected)
+		if _, err := store.ReadFile(file); err != nil {
+			t.Fatalf("test: %s, expected file %s was not synced: %s", test.name, file, err)
+		}
+		t.Logf("test: %s, successful", test.name)
+	}
+
+	if _, err := repos.NewLocalSource("local", "default", "file://relative/path"); err == nil {
+		t.Fatalf("repos.NewLocalSource did not return an error for a relative path")
+	}
+}
+
+func TestLocalPathRoot(t *testing.T) {
+	root := t.TempDir()
+	outside := t.TempDir()
+	if err := os.Mkdir(filepath.Join(root, "addons"), 0o700); err != nil {
+		t.Fatalf("failed to create test directory: %s", err)
+	}
+	if err := os.Symlink(outside, filepath.Join(root, "link")); err != nil {
+		t.Fatalf("failed to create test link: %s", err)
+	}
+
+	tests := []struct {
+		name  string
+		root  string
+		url   string
+		valid bool
+	}{
+		{name: "in root", root: root, url: "file://" + filepath.Join(root, "addons"), valid: true},
+		{name: "root not set", root: "", url: "file://" + filepath.Join(root, "addons")},
+		{name: "outside root", root: root, url: "file://" + outside},
+		{name: "parent reference", root: root, url: "file://" + root + "/addons/../../" + filepath.Base(outside)},
+		{name: "link outside root", root: root, url: "file://" + filepath.Join(root, "link")},
+	}
+
+	defer func(root string) { repos.LocalSourceRoot = root }(repos.LocalSourceRoot)
+	for _, test := range tests {
+		repos.LocalSourceRoot = test.root
+		_, err := repos.LocalPath(test.url)
+		if test.valid && err != nil {
+			t.Errorf("test: %s, repos.LocalPath returned an error: %s", test.name, err)
+		}
+		if !test.valid && err == nil {
+			t.Errorf("test: %s, repos.LocalPath did not return an error", test.name)
+		}
+	}
+}
@@ -19,14 +19,43 @@ import (
 	"github.com/fidelity/kraan/pkg/common"
 	"github.com/fidelity/kraan/pkg/internal/tarconsumer"
 	"github.com/fidelity/kraan/pkg/logging"
+	"github.com/fidelity/kraan/pkg/storage"
 )
 
 var (
 	DefaultRootPath = "/data"
 	DefaultHostName = ""
 	DefaultTimeOut  = 15 * time.Second
+	// SubscriberBufferSize is the size of the channel buffer used for each sync status subscriber.
+	SubscriberBufferSize = 100
 )
 
+// SyncState is the state of the local copy of a repository's artifact.
+type SyncState string
+
+const (
+	// SyncStatePending means the repository revision has not yet been synced.
+	SyncStatePending SyncState = "Pending"
+	// SyncStateSyncing means the repository revision is being downloaded and extracted.
+	SyncStateSyncing SyncState = "Syncing"
+	// SyncStateSynced means the repository revision is available in the local filesystem.
+	SyncStateSynced SyncState = "Synced"
+	// SyncStateFailed means the last attempt to sync the repository revision failed.
+	SyncStateFailed SyncState = "Failed"
+)
+
+// SyncStatus describes the sync state of a repository revision.
+type SyncStatus struct {
+	// Key is the namespace/name of the repository.
+	Key string
+	// Revision is the artifact revision the state relates to.
+	Revision string
+	// State is the sync state.
+	State SyncState
+	// Err is the error that caused the sync to fail, if the state is SyncStateFailed.
+	Err error
+}
+
 // Repos defines the interface for managing multiple instances of repository and revision data.
 type Repos interface {
 	Add(srcRepo *sourcev1.GitRepository) Repo
@@ -38,6 +67,10 @@ type Repos interface {
 	SetHostName(hostName string)
 	SetTimeOut(timeOut time.Duration)
 	SetHTTPClient(client *http.Client)
+	SetStorage(store storage.Storage)
+	GetStorage() storage.Storage
+	GetSyncStatus(name string) SyncStatus
+	Subscribe() <-chan SyncStatus
 }
 
 // reposData hold data about all repositories.
@@ -49,8 +82,12 @@ type reposData struct {
 	hostName     string
 	timeOut      time.Duration
 	client       *http.Client
+	store        storage.Storage
 	Repos        `json:"-"`
 	sync.RWMutex `json:"-"`
+	statusLock   sync.RWMutex
+	status       map[string]SyncStatus
+	subscribers  []chan SyncStatus
 }
 
 // NewRepos creates a repos object.
@@ -65,6 +102,8 @@ func NewRepos(ctx context.Context, log logr.Logger) Repos {
 		hostName: DefaultHostName,
 		timeOut:  DefaultTimeOut,
 		client:   &http.Client{},
+		store:    storage.NewDisk(),
+		status:   map[string]SyncStatus{},
 	}
 }
 
@@ -92,6 +131,77 @@ func (r *reposData) SetHTTPClient(client *http.Client) {
 	r.client = client
 }
 
+// SetStorage sets the storage backend used for repositories added after this call.
+func (r *reposData) SetStorage(store storage.Storage) {
+	r.store = store
+}
+
+func (r *reposData) GetStorage() storage.Storage {
+	return r.store
+}
+
+// GetSyncStatus returns the sync status of a repository, the state is pending if the repository is not known.
+func (r *reposData) GetSyncStatus(name string) SyncStatus {
+	r.statusLock.RLock()
+	defer r.statusLock.RUnlock()
+	if status, found := r.status[name]; found {
+		return status
+	}
+	return SyncStatus{Key: name, State: SyncStatePending}
+}
+
+// Subscribe returns a channel on which every change to the sync status of a repository is published.
+func (r *reposData) Subscribe() <-chan SyncStatus {
+	r.statusLock.Lock()
+	defer r.statusLock.Unlock()
+	ch := make(chan SyncStatus, SubscriberBufferSize)
+	r.subscribers = append(r.subscribers, ch)
+	return ch
+}
+
+// setSyncStatus records the sync status of a repository and notifies subscribers.
+func (r *reposData) setSyncStatus(status SyncStatus) {
+	r.statusLock.Lock()
+	if current, found := r.status[status.Key]; found && current.State == status.State && current.Revision == status.Revision && status.Err == nil {
+		r.statusLock.Unlock()
+		return
+	}
+	r.status[status.Key] = status
+	subscribers := make([]chan SyncStatus, len(r.subscribers))
+	copy(subscribers, r.subscribers)
+	r.statusLock.Unlock()
+
+	r.log.V(1).Info("repository sync status", append(logging.GetFunctionAndSource(logging.MyCaller),
+		"repo", status.Key, "revision", status.Revision, "state", status.State)...)
+	for _, ch := range subscribers {
+		notify(ch, status)
+	}
+}
+
+// notify sends a sync status to a subscriber without blocking. Subscribers that are not consuming, such as the
+// controller on a replica that is not the leader, must not stall syncing, so if the subscriber's buffer is full the
+// oldest status is discarded to make room for the latest one.
+func notify(ch chan SyncStatus, status SyncStatus) {
+	for {
+		select {
+		case ch <- status:
+			return
+		default:
+		}
+		select {
+		case <-ch:
+		default:
+		}
+	}
+}
+
+// deleteSyncStatus removes the sync status of a repository.
+func (r *reposData) deleteSyncStatus(name string) {
+	r.statusLock.Lock()
+	defer r.statusLock.Unlock()
+	delete(r.status, name)
+}
+
 // List returns a map of repos keyed by repo label
 func (r *reposData) List() map[string]Repo {
 	logging.TraceCall(r.log)
@@ -117,15 +227,18 @@ func (r *reposData) Get(name string) Repo {
 func (r *reposData) Add(repo *sourcev1.GitRepository) Repo {
 	logging.TraceCall(r.log)
 	defer logging.TraceExit(r.log)
-	r.Lock()
-	defer r.Unlock()
 	key := PathKey(repo)
+	r.Lock()
 	rp, found := r.repos[key]
 	if !found {
-		r.repos[key] = r.newRepo(key, repo)
-		return r.repos[key]
+		rp = r.newRepo(key, repo)
+		r.repos[key] = rp
+	}
+	r.Unlock()
+	if found {
+		// Updated outside the lock on the map of repos, it waits for any sync of the repo in progress.
+		rp.SetGitRepo(repo, r.GetRootPath())
 	}
-	rp.SetGitRepo(repo, r.GetRootPath())
 	return rp
 }
 
@@ -139,6 +252,7 @@ func (r *reposData) Delete(name string) {
 		_ = repo.TidyAll() //nolint:errcheck // ok
 		delete(r.repos, name)
 	}
+	r.deleteSyncStatus(name)
 }
 
 // Repo defines the interface for managing repository and revision data.
@@ -174,10 +288,12 @@ type repoData struct {
 	path         string
 	repo         *sourcev1.GitRepository
 	tarConsumer  tarconsumer.TarConsumer
+	store        storage.Storage
 	Repo         `json:"-"`
 	sync.RWMutex `json:"-"`
 	syncLock     sync.RWMutex
 	users        []string
+	setStatus    func(status SyncStatus)
 }
 
 // newRepo creates a repo.
@@ -200,7 +316,9 @@ func (r *reposData) newRepo(path string, sourceRepo *sourcev1.GitRepository) Rep
 		path:        path,
 		repo:        sourceRepo,
 		tarConsumer: tarconsumer.NewTarConsumer(r.ctx, r.client, url),
+		store:       r.store,
 		users:       []string{},
+		setStatus:   r.setSyncStatus,
 	}
 	return repo
 }
@@ -286,7 +404,7 @@ func (r *repoData) tidyAll() error {
 	dataPathParts := strings.Split(r.GetDataPath(), "/")
 	dirName := strings.Join(dataPathParts[:len(dataPathParts)-1], "/")
 
-	if err := os.RemoveAll(dirName); err != nil {
+	if err := r.store.RemoveAll(dirName); err != nil {
 		return errors.Wrapf(err, "%s - failed to remove directory: %s", logging.CallerStr(logging.Me), dirName)
 	}
 	return nil
@@ -310,7 +428,7 @@ func (r *repoData) AddUser(name string) {
 func (r *repoData) removeDirs(path, exclude string) error {
 	r.log.V(2).Info("processing directory",
 		append(logging.GetGitRepoInfo(r.repo), append(logging.GetFunctionAndSource(logging.MyCaller), "path", path, "exclude", exclude)...)...)
-	files, err := os.ReadDir(path)
+	files, err := r.store.ReadDir(path)
 	if err != nil {
 		return errors.Wrapf(err, "%s -failed to read directory", logging.CallerStr(logging.Me))
 	}
@@ -321,7 +439,7 @@ func (r *repoData) removeDirs(path, exclude string) error {
 				dirName := fmt.Sprintf("%s/%s", path, f.Name())
 				r.log.V(1).Info("removing directory", append(logging.GetGitRepoInfo(r.repo),
 					append(logging.GetFunctionAndSource(logging.MyCaller), "path", dirName)...)...)
-				if e := os.RemoveAll(dirName); e != nil {
+				if e := r.store.RemoveAll(dirName); e != nil {
 					return errors.Wrapf(e, "%s - failed to remove directory: %s", logging.CallerStr(logging.Me), dirName)
 				}
 			}
@@ -336,24 +454,24 @@ func (r *repoData) LinkData(layerPath, sourcePath string) error {
 	r.Lock()
 	defer r.Unlock()
 	addonsPath := fmt.Sprintf("%s/%s", r.GetDataPath(), sourcePath)
-	if err := isExistingDir(addonsPath); err != nil {
+	if err := isExistingDir(r.store, addonsPath); err != nil {
 		return errors.Wrapf(err, "%s - failed, target directory does not exist", logging.CallerStr(logging.Me))
 	}
 	layerPathParts := strings.Split(layerPath, "/")
 	layerPathDir := strings.Join(layerPathParts[:len(layerPathParts)-1], "/")
 
-	if err := os.MkdirAll(layerPathDir, os.ModePerm); err != nil {
+	if err := r.store.MkdirAll(layerPathDir); err != nil {
 		return errors.Wrapf(err, "%s - failed to make directory: %s", logging.CallerStr(logging.Me), layerPathDir)
 	}
-	if _, err := os.Lstat(layerPath); err == nil {
-		if e := os.RemoveAll(layerPath); e != nil {
+	if _, err := r.store.Lstat(layerPath); err == nil {
+		if e := r.store.RemoveAll(layerPath); e != nil {
 			return errors.Wrapf(err, "%s - failed to remove link: %s", logging.CallerStr(logging.Me), layerPath)
 		}
 	} else if !os.IsNotExist(err) {
 		return err
 	}
 
-	if err := os.Symlink(addonsPath, layerPath); err != nil {
+	if err := r.store.Symlink(addonsPath, layerPath); err != nil {
 		return errors.Wrapf(err, "%s - failed to create link: %s", logging.CallerStr(logging.Me), layerPath)
 	}
 	return nil
@@ -376,31 +494,31 @@ limitations under the License.
 */
 
 // removeIfExists removes a directory if it exists
-func removeIfExists(path string) error {
-	if _, err := os.Stat(path); os.IsNotExist(err) {
+func removeIfExists(store storage.Storage, path string) error {
+	if _, err := store.Stat(path); os.IsNotExist(err) {
 		return nil
 	} else if err != nil {
 		return err
 	}
-	if e := os.RemoveAll(path); e != nil {
+	if e := store.RemoveAll(path); e != nil {
 		return errors.Wrapf(e, "%s - failed to remove directory", logging.CallerStr(logging.Me))
 	}
 	return nil
 }
 
 // removeRecreateDir removes a directory if it exists and then recreates it
-func removeRecreateDir(path string) error {
-	if e := removeIfExists(path); e != nil {
+func removeRecreateDir(store storage.Storage, path string) error {
+	if e := removeIfExists(store, path); e != nil {
 		return errors.WithMessagef(e, "%s - failed to remove directory before recreate", logging.CallerStr(logging.Me))
 	}
-	if err := os.MkdirAll(path, os.ModePerm); err != nil {
+	if err := store.MkdirAll(path); err != nil {
 		return errors.Wrapf(err, "%s - failed to make directory: %s", logging.CallerStr(logging.Me), path)
 	}
 	return nil
 }
 
-func isExistingDir(dataPath string) error {
-	info, err := os.Stat(dataPath)
+func isExistingDir(store storage.Storage, dataPath string) error {
+	info, err := store.Stat(dataPath)
 	if !os.IsNotExist(err) {
 		return errors.Wrapf(err, "%s - failed to stat: %s", logging.CallerStr(logging.Me), dataPath)
 	}
@@ -415,7 +533,7 @@ func isExistingDir(dataPath string) error {
 }
 
 func (r *repoData) IsSynced() bool {
-	if err := isExistingDir(r.dataPath); err == nil {
+	if err := isExistingDir(r.store, r.dataPath); err == nil {
 		r.log.V(1).Info("Revision is synced", append(logging.GetGitRepoInfo(r.repo), logging.GetFunctionAndSource(logging.MyCaller)...)...)
 		return false
 	}
@@ -423,6 +541,7 @@ func (r *repoData) IsSynced() bool {
 	return true
 }
 
+// SyncRepo downloads and extracts the repository's current artifact revision, publishing the sync status as it progresses.
 func (r *repoData) SyncRepo() error {
 	logging.TraceCall(r.log)
 	defer logging.TraceExit(r.log)
@@ -432,13 +551,31 @@ func (r *repoData) SyncRepo() error {
 	if r.repo.Status.Artifact == nil {
 		return fmt.Errorf("repository %s does not contain an artifact", r.path)
 	}
-	if err := isExistingDir(r.dataPath); err == nil {
+	status := SyncStatus{Key: r.path, Revision: r.repo.Status.Artifact.Revision, State: SyncStateSynced}
+	if err := isExistingDir(r.store, r.dataPath); err == nil {
 		r.log.V(1).Info("Revision already synced", append(logging.GetGitRepoInfo(r.repo), logging.GetFunctionAndSource(logging.MyCaller)...)...)
+		r.publish(status)
 		return nil
 	}
 	r.log.V(1).Info("New revision detected", append(logging.GetGitRepoInfo(r.repo), logging.GetFunctionAndSource(logging.MyCaller)...)...)
+	r.publish(SyncStatus{Key: r.path, Revision: status.Revision, State: SyncStateSyncing})
 
-	if err := removeRecreateDir(r.loadPath); err != nil {
+	if err := r.syncArtifact(); err != nil {
+		r.publish(SyncStatus{Key: r.path, Revision: status.Revision, State: SyncStateFailed, Err: err})
+		return err
+	}
+	r.publish(status)
+	return nil
+}
+
+func (r *repoData) publish(status SyncStatus) {
+	if r.setStatus != nil {
+		r.setStatus(status)
+	}
+}
+
+func (r *repoData) syncArtifact() error {
+	if err := removeRecreateDir(r.store, r.loadPath); err != nil {
 		return errors.WithMessagef(err, "%s - failed to remove and recreate load directory", logging.CallerStr(logging.Me))
 	}
 
@@ -450,16 +587,16 @@ func (r *repoData) SyncRepo() error {
 		return errors.Wrapf(err, "%s - failed to fetch repository tar file from source controller", logging.CallerStr(logging.Me))
 	}
 
-	if err := os.MkdirAll(r.dataPath, os.ModePerm); err != nil {
+	if err := r.store.MkdirAll(r.dataPath); err != nil {
 		return errors.Wrapf(err, "%s - failed to make directory: %s", logging.CallerStr(logging.Me), r.dataPath)
 	}
 
 	r.Lock()
 	defer r.Unlock()
-	if e := os.RemoveAll(r.dataPath); e != nil {
+	if e := r.store.RemoveAll(r.dataPath); e != nil {
 		return errors.Wrapf(e, "%s - failed to remove data path: %s", logging.CallerStr(logging.Me), r.dataPath)
 	}
-	if err := os.Rename(r.loadPath, r.dataPath); err != nil {
+	if err := r.store.Rename(r.loadPath, r.dataPath); err != nil {
 		return errors.Wrapf(err, "%s - failed to rename load path: %s", logging.CallerStr(logging.Me), r.loadPath)
 	}
 	r.log.V(1).Info("synced repo", append(logging.GetGitRepoInfo(r.repo), logging.GetFunctionAndSource(logging.MyCaller)...)...)
@@ -476,6 +613,10 @@ func (r *repoData) fetchArtifact(ctx context.Context) error {
 
 	url := repo.Status.Artifact.URL
 
+	if IsFileURL(url) {
+		return r.fetchLocal(url)
+	}
+
 	if r.hostName != "" {
 		url = fmt.Sprintf("http://%s/gitrepository/%s/%s/latest.tar.gz", r.hostName, repo.Namespace, repo.Name)
 	}
@@ -489,7 +630,7 @@ func (r *repoData) fetchArtifact(ctx context.Context) error {
 	// Debugging for unzip error
 	r.log.V(2).Info("tar data", append(logging.GetGitRepoInfo(r.repo), append(logging.GetFunctionAndSource(logging.MyCaller), "length", len(tar))...)...)
 
-	if err := tarconsumer.UnpackTar(tar, r.GetLoadPath()); err != nil {
+	if err := tarconsumer.UnpackTar(r.store, tar, r.GetLoadPath()); err != nil {
 		return errors.WithMessagef(err, "%s - failed to untar artifact", logging.CallerStr(logging.Me))
 	}
 
@@ -11,14 +11,16 @@ import (
 
 	"github.com/fidelity/kraan/pkg/internal/testutils"
 	"github.com/fidelity/kraan/pkg/repos"
+	"github.com/fidelity/kraan/pkg/storage"
 
 	sourcev1 "github.com/fluxcd/source-controller/api/v1beta2"
 	testlogr "github.com/go-logr/logr/testing"
 )
 
 const (
-	testSrcRepo = "testdata/source-repo.yaml"
-	testdataDir = "testdata/addons"
+	testSrcRepo  = "testdata/source-repo.yaml"
+	testdataDir  = "testdata/addons"
+	testRootPath = "/data"
 )
 
 type repoTest struct {
@@ -53,20 +55,16 @@ func getTestSourceRepo(t *testing.T, repoYAML string) *sourcev1.GitRepository {
 	return srcRepo
 }
 
-func makeTempRootDir(t *testing.T) string {
-	rootPath, err := os.MkdirTemp("", "test-*")
-	if err != nil {
-		t.Fatalf(err.Error())
-	}
-	return rootPath
+// useMemoryStorage configures repos to use a new in-memory storage backend.
+func useMemoryStorage(repoStore repos.Repos) storage.Storage {
+	store := storage.NewMemory()
+	repoStore.SetStorage(store)
+	repoStore.SetRootPath(testRootPath)
+	return store
 }
 
-func (r *repoTest) createTempRootDir(t *testing.T) {
-	rootPath, err := os.MkdirTemp("", "test-*")
-	if err != nil {
-		t.Fatalf(err.Error())
-	}
-	r.repoStore.SetRootPath(rootPath)
+func (r *repoTest) createRootDir() {
+	useMemoryStorage(r.repoStore)
 }
 
 func (r *repoTest) getRepo() repos.Repo {
@@ -93,7 +91,7 @@ func TestFetchArtifact(t *testing.T) {
 	}
 
 	doTest := func(t *testing.T, test fetchArtifactTest) {
-		test.createTempRootDir(t)
+		test.createRootDir()
 
 		httpClient, host, teardown := testutils.StartHTTPServer(t, testdataDir)
 		defer teardown()
@@ -135,7 +133,7 @@ func TestSyncRepo(t *testing.T) {
 	}
 
 	doTest := func(t *testing.T, test syncRepoTest) {
-		test.createTempRootDir(t)
+		test.createRootDir()
 
 		httpClient, host, teardown := testutils.StartHTTPServer(t, testdataDir)
 		defer teardown()
@@ -211,8 +209,7 @@ func TestLinkData(t *testing.T) {
 	}
 
 	doTest := func(t *testing.T, test linkDataTest) {
-		rootPath := makeTempRootDir(t)
-		testRepos.SetRootPath(rootPath)
+		store := useMemoryStorage(testRepos)
 
 		httpClient, host, teardown := testutils.StartHTTPServer(t, testdataDir)
 		defer teardown()
@@ -227,23 +224,25 @@ func TestLinkData(t *testing.T) {
 		sourceDirPath := fmt.Sprintf("%s/%s", r.GetDataPath(), test.sourcePath)
 		if test.createSource {
 			t.Logf("creating source dir '%s'", sourceDirPath)
-			if err := os.MkdirAll(sourceDirPath, os.ModePerm); err != nil {
+			if err := store.MkdirAll(sourceDirPath); err != nil {
 				t.Fatalf("error creating test source path directory '%s': %#v", test.sourcePath, err)
 				return
 			}
 		}
-		layerDirPath := fmt.Sprintf("%s/%s", rootPath, test.layerPath)
+		layerDirPath := fmt.Sprintf("%s/%s", testRootPath, test.layerPath)
 		if test.createTarget {
 			t.Logf("creating layer dir '%s'", layerDirPath)
-			if err := os.MkdirAll(layerDirPath, os.ModePerm); err != nil {
+			if err := store.MkdirAll(layerDirPath); err != nil {
 				t.Fatalf("error creating target layer path directory '%s': %#v", layerDirPath, err)
 			}
 		}
 		err := r.LinkData(layerDirPath, test.sourcePath)
 		test.checkExpected(t, r, err)
 
-		if e := os.RemoveAll(rootPath); e != nil {
-			t.Fatalf("error removing test '%s' temp directory '%s': %#v", test.name, rootPath, e.Error())
+		if err == nil {
+			if _, e := store.ReadDir(layerDirPath); e != nil {
+				t.Fatalf("linked layer directory '%s' could not be read: %s", layerDirPath, e)
+			}
 		}
 		repoStore.Delete(r.GetPath())
 		t.Logf("test: %s, successful", test.name)
@@ -253,3 +252,65 @@ func TestLinkData(t *testing.T) {
 		doTest(t, test)
 	}
 }
+
+func TestSyncStatus(t *testing.T) {
+	testRepos := repos.NewRepos(context.Background(), testlogr.NewTestLogger(t))
+	useMemoryStorage(testRepos)
+
+	httpClient, host, teardown := testutils.StartHTTPServer(t, testdataDir)
+	defer teardown()
+
+	testRepos.SetHTTPClient(httpClient)
+	testRepos.SetHostName(host)
+
+	srcRepo := getTestSourceRepo(t, testSrcRepo)
+	key := repos.PathKey(srcRepo)
+	revision := srcRepo.Status.Artifact.Revision
+
+	if status := testRepos.GetSyncStatus(key); status.State != repos.SyncStatePending {
+		t.Fatalf("expected sync state of unknown repo to be %s, got: %s", repos.SyncStatePending, status.State)
+	}
+
+	statuses := testRepos.Subscribe()
+	r := testRepos.Add(srcRepo)
+	if err := r.SyncRepo(); err != nil {
+		t.Fatalf("error returned from %T.SyncRepo: %s", r, err)
+	}
+
+	for _, expected := range []repos.SyncState{repos.SyncStateSyncing, repos.SyncStateSynced} {
+		status := <-statuses
+		if status.Key != key || status.Revision != revision || status.State != expected {
+			t.Fatalf("unexpected sync status notification:\nWanted: %s %s %s\nGot: %#v", key, revision, expected, status)
+		}
+	}
+
+	if status := testRepos.GetSyncStatus(key); status.State != repos.SyncStateSynced || status.Revision != revision {
+		t.Fatalf("expected sync state %s for revision %s, got: %#v", repos.SyncStateSynced, revision, status)
+	}
+
+	if err := r.SyncRepo(); err != nil {
+		t.Fatalf("error returned from %T.SyncRepo: %s", r, err)
+	}
+	select {
+	case status := <-statuses:
+		t.Fatalf("unexpected sync status notification for already synced revision: %#v", status)
+	default:
+	}
+
+	testRepos.Delete(key)
+	if status := testRepos.GetSyncStatus(key); status.State != repos.SyncStatePending {
+		t.Fatalf("expected sync state of deleted repo to be %s, got: %s", repos.SyncStatePending, status.State)
+	}
+}
+
+func TestNotifyDoesNotBlock(t *testing.T) {
+	ch := make(chan repos.SyncStatus, 2)
+	for index := 0; index < 5; index++ {
+		repos.Notify(ch, repos.SyncStatus{Key: fmt.Sprintf("flux-system/repo%d", index), State: repos.SyncStateSynced})
+	}
+	for _, expected := range []string{"flux-system/repo3", "flux-system/repo4"} {
+		if status := <-ch; status.Key != expected {
+			t.Fatalf("unexpected sync status notification, wanted: %s, got: %#v", expected, status)
+		}
+	}
+}
@@ -0,0 +1,128 @@
+package repos
+
+import (
+	"fmt"
+	"regexp"
+	"strings"
+
+	sourcev1 "github.com/fluxcd/source-controller/api/v1beta2"
+	apimeta "k8s.io/apimachinery/pkg/api/meta"
+	metav1 "k8s.io/apimachinery/pkg/apis/meta/v1"
+)
+
+// legacyDigestRegex matches the hexadecimal commit hash used by the legacy <ref>/<hash> revision format.
+var legacyDigestRegex = regexp.MustCompile(`^[0-9a-fA-F]{7,64}$`)
+
+// verifiedRevisionRegex matches the revisions of the commits and tags listed in the source controller's verification
+// message, e.g. verified signature of commit 'main@sha1:<hash>'.
+var verifiedRevisionRegex = regexp.MustCompile(`(?:commit|tag) '([^']+)'`)
+
+// Revision is an artifact revision parsed from one of the formats used by the Flux source controller.
+//   - <ref>@<algorithm>:<digest>, e.g. main@sha1:<hash> or v1.0.0@sha256:<digest>.
+//   - <algorithm>:<digest>, e.g. sha256:<digest>.
+//   - <ref>/<hash>, the legacy format, e.g. main/<hash>.
+//   - <hash>, a legacy commit hash.
+type Revision struct {
+	// Ref is the branch, tag or other reference, if present.
+	Ref string
+	// Algorithm is the digest algorithm, empty for legacy revisions.
+	Algorithm string
+	// Digest is the commit hash or content digest, empty if the revision could not be parsed.
+	Digest string
+	// Raw is the revision as reported by the source controller.
+	Raw string
+}
+
+// ParseRevision parses an artifact revision.
+func ParseRevision(revision string) Revision {
+	rev := Revision{Raw: revision}
+	ref, digest := "", revision
+	if index := strings.LastIndex(revision, "@"); index >= 0 {
+		ref, digest = revision[:index], revision[index+1:]
+	}
+	if algorithm, value, found := strings.Cut(digest, ":"); found {
+		rev.Ref = ref
+		rev.Algorithm = strings.ToLower(algorithm)
+		rev.Digest = strings.ToLower(value)
+		return rev
+	}
+	if ref != "" {
+		return rev
+	}
+	if index := strings.LastIndex(revision, "/"); index >= 0 {
+		ref, digest = revision[:index], revision[index+1:]
+	}
+	if legacyDigestRegex.MatchString(digest) {
+		rev.Ref = ref
+		rev.Digest = strings.ToLower(digest)
+	}
+	return rev
+}
+
+// String returns the revision as reported by the source controller.
+func (r Revision) String() string {
+	return r.Raw
+}
+
+// Equal returns true if the revisions refer to the same content. Revisions are equal if their digests match,
+// irrespective of the format used. Legacy revisions are assumed to use the same algorithm as the other revision.
+func (r Revision) Equal(other Revision) bool {
+	if r.Digest == "" || other.Digest == "" {
+		return r.Raw == other.Raw
+	}
+	if r.Algorithm != "" && other.Algorithm != "" && r.Algorithm != other.Algorithm {
+		return false
+	}
+	return r.Digest == other.Digest
+}
+
+// RevisionsEqual returns true if two artifact revisions, in any of the Flux revision formats, refer to the same content.
+func RevisionsEqual(a, b string) bool {
+	return ParseRevision(a).Equal(ParseRevision(b))
+}
+
+// SourceVerified returns true if the source controller reports a successful verification of the signature of the
+// GitRepository's current artifact revision, along with a message describing the verification state.
+func SourceVerified(srcRepo *sourcev1.GitRepository) (bool, string) {
+	if srcRepo.Spec.Verification == nil {
+		return false, "GitRepository is not configured to verify commit signatures"
+	}
+	if srcRepo.Status.Artifact == nil {
+		return false, "GitRepository has no artifact to verify"
+	}
+	cond := apimeta.FindStatusCondition(srcRepo.Status.Conditions, sourcev1.SourceVerifiedCondition)
+	if cond == nil {
+		return false, "GitRepository has not reported a verification result"
+	}
+	if cond.Status != metav1.ConditionTrue {
+		return false, cond.Message
+	}
+	if cond.ObservedGeneration < srcRepo.Generation {
+		return false, fmt.Sprintf("GitRepository verification result relates to generation %d, current generation: %d",
+			cond.ObservedGeneration, srcRepo.Generation)
+	}
+	revision := ParseRevision(srcRepo.Status.Artifact.Revision)
+	for _, match := range verifiedRevisionRegex.FindAllStringSubmatch(cond.Message, -1) {
+		if ParseRevision(match[1]).Equal(revision) {
+			return true, cond.Message
+		}
+	}
+	return false, fmt.Sprintf("GitRepository verification result does not relate to revision %s", revision)
+}
+
+// SourceReady returns true if the source controller has reconciled the latest generation of the GitRepository and
+// produced an artifact, along with a message describing the state of the source.
+func SourceReady(srcRepo *sourcev1.GitRepository) (bool, string) {
+	if srcRepo.Status.ObservedGeneration < srcRepo.Generation {
+		return false, fmt.Sprintf("GitRepository generation %d not yet reconciled, observed generation: %d",
+			srcRepo.Generation, srcRepo.Status.ObservedGeneration)
+	}
+	if srcRepo.Status.Artifact == nil {
+		return false, "GitRepository not yet reconciled, no artifact available"
+	}
+	cond := apimeta.FindStatusCondition(srcRepo.Status.Conditions, "Ready")
+	if cond != nil && cond.Status == metav1.ConditionFalse {
+		return false, cond.Message
+	}
+	return true, fmt.Sprintf("artifact revision %s available", srcRepo.Status.Artifact.Revision)
+}
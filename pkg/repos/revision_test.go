@@ -0,0 +1,129 @@
+package repos_test
+
+import (
+	"testing"
+
+	sourcev1 "github.com/fluxcd/source-controller/api/v1beta2"
+	metav1 "k8s.io/apimachinery/pkg/apis/meta/v1"
+
+	"github.com/fidelity/kraan/pkg/repos"
+)
+
+const (
+	testCommit = "6a226b05a5aa0a775c2147d5b8b3b14d1adfa094"
+	testDigest = "e3b0c44298fc1c149afbf4c8996fb92427ae41e4649b934ca495991b7852b855"
+)
+
+func TestParseRevision(t *testing.T) {
+	tests := []struct {
+		revision string
+		expected repos.Revision
+	}{
+		{"main@sha1:" + testCommit, repos.Revision{Ref: "main", Algorithm: "sha1", Digest: testCommit}},
+		{"main/" + testCommit, repos.Revision{Ref: "main", Digest: testCommit}},
+		{"feature/test/" + testCommit, repos.Revision{Ref: "feature/test", Digest: testCommit}},
+		{"v1.0.0@sha256:" + testDigest, repos.Revision{Ref: "v1.0.0", Algorithm: "sha256", Digest: testDigest}},
+		{"sha256:" + testDigest, repos.Revision{Algorithm: "sha256", Digest: testDigest}},
+		{testCommit, repos.Revision{Digest: testCommit}},
+		{"not a revision", repos.Revision{}},
+	}
+
+	for _, test := range tests {
+		test.expected.Raw = test.revision
+		if got := repos.ParseRevision(test.revision); got != test.expected {
+			t.Fatalf("repos.ParseRevision(%s) did not return expected revision:\nWanted: %#v\nGot: %#v", test.revision, test.expected, got)
+		}
+	}
+}
+
+func TestRevisionsEqual(t *testing.T) {
+	tests := []struct {
+		a, b     string
+		expected bool
+	}{
+		{"main@sha1:" + testCommit, "main/" + testCommit, true},
+		{"main@sha1:" + testCommit, "main@sha1:" + testCommit, true},
+		{"main@sha1:" + testCommit, testCommit, true},
+		{"main@sha1:" + testCommit, "main@sha1:0000000000000000000000000000000000000000", false},
+		{"v1.0.0@sha256:" + testDigest, "sha256:" + testDigest, true},
+		{"v1.0.0@sha256:" + testDigest, "v1.0.0@sha1:" + testDigest, false},
+		{"", "main/" + testCommit, false},
+		{"", "", true},
+	}
+
+	for _, test := range tests {
+		if got := repos.RevisionsEqual(test.a, test.b); got != test.expected {
+			t.Fatalf("repos.RevisionsEqual(%s, %s) returned %t, expected %t", test.a, test.b, got, test.expected)
+		}
+	}
+}
+
+func TestSourceReady(t *testing.T) {
+	newSource := func(generation, observed int64, artifact bool, ready metav1.ConditionStatus) *sourcev1.GitRepository {
+		src := &sourcev1.GitRepository{ObjectMeta: metav1.ObjectMeta{Generation: generation}}
+		src.Status.ObservedGeneration = observed
+		if artifact {
+			src.Status.Artifact = &sourcev1.Artifact{Revision: "main@sha1:" + testCommit}
+		}
+		if ready != "" {
+			src.Status.Conditions = []metav1.Condition{{Type: "Ready", Status: ready, Message: "reconciling"}}
+		}
+		return src
+	}
+
+	tests := []struct {
+		name     string
+		src      *sourcev1.GitRepository
+		expected bool
+	}{
+		{"ready", newSource(2, 2, true, metav1.ConditionTrue), true},
+		{"ready message does not contain revision", newSource(1, 1, true, metav1.ConditionUnknown), true},
+		{"generation not observed", newSource(2, 1, true, metav1.ConditionTrue), false},
+		{"no artifact", newSource(1, 1, false, metav1.ConditionTrue), false},
+		{"not ready", newSource(1, 1, true, metav1.ConditionFalse), false},
+	}
+
+	for _, test := range tests {
+		if got, msg := repos.SourceReady(test.src); got != test.expected {
+			t.Fatalf("test: %s, repos.SourceReady returned %t (%s), expected %t", test.name, got, msg, test.expected)
+		}
+	}
+}
+
+func TestSourceVerified(t *testing.T) {
+	newSource := func(verify bool, status metav1.ConditionStatus, observed int64, message string) *sourcev1.GitRepository {
+		src := &sourcev1.GitRepository{ObjectMeta: metav1.ObjectMeta{Generation: 2}}
+		if verify {
+			src.Spec.Verification = &sourcev1.GitRepositoryVerification{Mode: "head"}
+		}
+		src.Status.Artifact = &sourcev1.Artifact{Revision: "main@sha1:" + testCommit}
+		if status != "" {
+			src.Status.Conditions = []metav1.Condition{{
+				Type: sourcev1.SourceVerifiedCondition, Status: status, ObservedGeneration: observed, Message: message,
+			}}
+		}
+		return src
+	}
+
+	tests := []struct {
+		name     string
+		src      *sourcev1.GitRepository
+		expected bool
+	}{
+		{"verified", newSource(true, metav1.ConditionTrue, 2, "verified signature of commit '"+testCommit+"'"), true},
+		{"verification not configured", newSource(false, metav1.ConditionTrue, 2, "verified signature of commit '"+testCommit+"'"), false},
+		{"no verification result", newSource(true, "", 0, ""), false},
+		{"verification failed", newSource(true, metav1.ConditionFalse, 2, "signature verification failed"), false},
+		{"previous generation", newSource(true, metav1.ConditionTrue, 1, "verified signature of commit '"+testCommit+"'"), false},
+		{"other revision", newSource(true, metav1.ConditionTrue, 2, "verified signature of commit '0000000'"), false},
+		{"revision format", newSource(true, metav1.ConditionTrue, 2, "verified signature of\n\t- commit 'main@sha1:"+testCommit+"'"), true},
+		{"digest outside revision", newSource(true, metav1.ConditionTrue, 2, "verified signature of commit '0000000', expected "+testCommit), false},
+		{"abbreviated revision", newSource(true, metav1.ConditionTrue, 2, "verified signature of commit '"+testCommit[:7]+"'"), false},
+	}
+
+	for _, test := range tests {
+		if got, msg := repos.SourceVerified(test.src); got != test.expected {
+			t.Fatalf("test: %s, repos.SourceVerified returned %t (%s), expected %t", test.name, got, msg, test.expected)
+		}
+	}
+}
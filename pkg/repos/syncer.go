@@ -0,0 +1,154 @@
+//go:generate mockgen -destination=../mocks/repos/mockSyncer.go -package=mocks -source=syncer.go . Syncer
+
+package repos
+
+import (
+	"context"
+	"sync"
+	"time"
+
+	sourcev1 "github.com/fluxcd/source-controller/api/v1beta2"
+	"github.com/go-logr/logr"
+	"github.com/pkg/errors"
+	"k8s.io/client-go/util/workqueue"
+
+	"github.com/fidelity/kraan/pkg/logging"
+	"github.com/fidelity/kraan/pkg/metrics"
+)
+
+var (
+	// DefaultSyncWorkers is the default number of workers syncing repositories.
+	DefaultSyncWorkers = 2
+	// MaxSyncRetries is the number of times a failed sync is retried before waiting for the next repository event.
+	MaxSyncRetries = 5
+)
+
+// Syncer defines the interface for syncing repositories asynchronously using a bounded pool of workers.
+type Syncer interface {
+	Enqueue(srcRepo *sourcev1.GitRepository)
+	Start(ctx context.Context) error
+	NeedLeaderElection() bool
+}
+
+// syncerData holds the queue of repositories waiting to be synced and the latest state of each queued repository.
+type syncerData struct {
+	log         logr.Logger
+	repos       Repos
+	metrics     metrics.Metrics
+	workers     int
+	queue       workqueue.RateLimitingInterface
+	pendingLock sync.Mutex
+	pending     map[string]*sourcev1.GitRepository
+	Syncer      `json:"-"`
+}
+
+// NewSyncer creates a syncer, repositories are synced once the syncer is started.
+// Requests to sync the same repository are merged while the repository is waiting to be synced.
+func NewSyncer(log logr.Logger, repos Repos, metrics metrics.Metrics, workers int) Syncer {
+	logging.TraceCall(log)
+	defer logging.TraceExit(log)
+	if workers < 1 {
+		workers = DefaultSyncWorkers
+	}
+	return &syncerData{
+		log:     log,
+		repos:   repos,
+		metrics: metrics,
+		workers: workers,
+		queue:   workqueue.NewNamedRateLimitingQueue(workqueue.DefaultControllerRateLimiter(), "repos"),
+		pending: map[string]*sourcev1.GitRepository{},
+	}
+}
+
+// Enqueue records the latest state of a repository and queues it to be synced. It does not wait for a sync of the
+// repository in progress, the repository is updated to the latest state by the worker that syncs it.
+func (s *syncerData) Enqueue(srcRepo *sourcev1.GitRepository) {
+	logging.TraceCall(s.log)
+	defer logging.TraceExit(s.log)
+	if srcRepo.GetArtifact() == nil {
+		s.log.V(1).Info("repository has no artifact, not queuing", logging.GetGitRepoInfo(srcRepo)...)
+		return
+	}
+	key := PathKey(srcRepo)
+	s.pendingLock.Lock()
+	s.pending[key] = srcRepo
+	s.pendingLock.Unlock()
+	s.queue.Add(key)
+	s.metrics.RecordSyncQueueDepth(s.queue.Len())
+}
+
+// Start runs the sync workers until the context is cancelled.
+func (s *syncerData) Start(ctx context.Context) error {
+	logging.TraceCall(s.log)
+	defer logging.TraceExit(s.log)
+	var wg sync.WaitGroup
+	for i := 0; i < s.workers; i++ {
+		wg.Add(1)
+		go func() {
+			defer wg.Done()
+			for s.processNext() {
+			}
+		}()
+	}
+	<-ctx.Done()
+	s.queue.ShutDown()
+	wg.Wait()
+	return nil
+}
+
+// NeedLeaderElection returns false, every replica needs a local copy of the repository data.
+func (s *syncerData) NeedLeaderElection() bool {
+	return false
+}
+
+func (s *syncerData) processNext() bool {
+	item, shutdown := s.queue.Get()
+	if shutdown {
+		return false
+	}
+	defer s.queue.Done(item)
+	s.metrics.RecordSyncQueueDepth(s.queue.Len())
+
+	key, ok := item.(string)
+	if !ok {
+		s.queue.Forget(item)
+		return true
+	}
+	if err := s.sync(key); err != nil {
+		if s.queue.NumRequeues(item) < MaxSyncRetries {
+			s.log.Error(err, "failed to sync repository, retrying", append(logging.GetFunctionAndSource(logging.MyCaller), "repo", key)...)
+			s.queue.AddRateLimited(item)
+			return true
+		}
+		s.log.Error(err, "failed to sync repository, giving up", append(logging.GetFunctionAndSource(logging.MyCaller), "repo", key)...)
+	}
+	s.queue.Forget(item)
+	return true
+}
+
+func (s *syncerData) sync(key string) error {
+	s.pendingLock.Lock()
+	srcRepo, found := s.pending[key]
+	delete(s.pending, key)
+	s.pendingLock.Unlock()
+	var repo Repo
+	if found {
+		repo = s.repos.Add(srcRepo)
+	} else {
+		repo = s.repos.Get(key)
+	}
+	if repo == nil {
+		s.log.V(1).Info("repository no longer present, not syncing", append(logging.GetFunctionAndSource(logging.MyCaller), "repo", key)...)
+		return nil
+	}
+	start := time.Now()
+	err := repo.SyncRepo()
+	s.metrics.RecordSyncDuration(repo.GetGitRepo(), start, err == nil)
+	if err != nil {
+		return errors.WithMessagef(err, "%s - failed to sync repository: %s", logging.CallerStr(logging.Me), key)
+	}
+	if err := repo.TidyRepo(); err != nil {
+		s.log.Error(err, "unable to garbage collect repo revisions", append(logging.GetGitRepoInfo(repo.GetGitRepo()), logging.GetFunctionAndSource(logging.MyCaller)...)...)
+	}
+	return nil
+}
@@ -0,0 +1,155 @@
+package repos_test
+
+import (
+	"context"
+	"net"
+	"net/http"
+	"net/http/httptest"
+	"testing"
+	"time"
+
+	testlogr "github.com/go-logr/logr/testing"
+	"github.com/golang/mock/gomock"
+
+	"github.com/fidelity/kraan/pkg/internal/testutils"
+	mocks "github.com/fidelity/kraan/pkg/mocks/metrics"
+	"github.com/fidelity/kraan/pkg/repos"
+)
+
+func TestSyncer(t *testing.T) {
+	mockCtl := gomock.NewController(t)
+	defer mockCtl.Finish()
+
+	testRepos := repos.NewRepos(context.Background(), testlogr.NewTestLogger(t))
+	useMemoryStorage(testRepos)
+
+	httpClient, host, teardown := testutils.StartHTTPServer(t, testdataDir)
+	defer teardown()
+
+	testRepos.SetHTTPClient(httpClient)
+	testRepos.SetHostName(host)
+
+	mockMetrics := mocks.NewMockMetrics(mockCtl)
+	mockMetrics.EXPECT().RecordSyncQueueDepth(gomock.Any()).AnyTimes()
+	mockMetrics.EXPECT().RecordSyncDuration(gomock.Any(), gomock.Any(), true).Times(1)
+
+	srcRepo := getTestSourceRepo(t, testSrcRepo)
+	key := repos.PathKey(srcRepo)
+
+	statuses := testRepos.Subscribe()
+	syncer := repos.NewSyncer(testlogr.NewTestLogger(t), testRepos, mockMetrics, 1)
+
+	// Requests queued before the workers start are merged into a single sync.
+	syncer.Enqueue(srcRepo)
+	syncer.Enqueue(srcRepo)
+
+	ctx, cancel := context.WithCancel(context.Background())
+	done := make(chan struct{})
+	go func() {
+		defer close(done)
+		if err := syncer.Start(ctx); err != nil {
+			t.Errorf("error returned from %T.Start: %s", syncer, err)
+		}
+	}()
+
+	for _, expected := range []repos.SyncState{repos.SyncStateSyncing, repos.SyncStateSynced} {
+		select {
+		case status := <-statuses:
+			if status.Key != key || status.State != expected {
+				t.Fatalf("unexpected sync status notification:\nWanted: %s %s\nGot: %#v", key, expected, status)
+			}
+		case <-time.After(10 * time.Second):
+			t.Fatalf("timed out waiting for sync status %s", expected)
+		}
+	}
+
+	cancel()
+	<-done
+
+	select {
+	case status := <-statuses:
+		t.Fatalf("unexpected sync status notification after sync completed: %#v", status)
+	default:
+	}
+}
+
+func TestSyncerEnqueueDuringSync(t *testing.T) { //nolint:funlen // ok
+	mockCtl := gomock.NewController(t)
+	defer mockCtl.Finish()
+
+	testRepos := repos.NewRepos(context.Background(), testlogr.NewTestLogger(t))
+	useMemoryStorage(testRepos)
+
+	// The artifact download blocks until released, so the first sync is in progress while the repository is queued again.
+	started := make(chan struct{}, 2)
+	release := make(chan struct{})
+	data := testutils.Compress(t, testdataDir)
+	server := httptest.NewServer(http.HandlerFunc(func(w http.ResponseWriter, r *http.Request) {
+		started <- struct{}{}
+		<-release
+		w.Write(data) //nolint:errcheck // ok
+	}))
+	defer server.Close()
+	testRepos.SetHTTPClient(&http.Client{Transport: &http.Transport{
+		DialContext: func(_ context.Context, network, _ string) (net.Conn, error) {
+			return net.Dial(network, server.Listener.Addr().String())
+		},
+	}})
+	testRepos.SetHostName(server.Listener.Addr().String())
+
+	mockMetrics := mocks.NewMockMetrics(mockCtl)
+	mockMetrics.EXPECT().RecordSyncQueueDepth(gomock.Any()).AnyTimes()
+	mockMetrics.EXPECT().RecordSyncDuration(gomock.Any(), gomock.Any(), true).Times(2)
+
+	srcRepo := getTestSourceRepo(t, testSrcRepo)
+	key := repos.PathKey(srcRepo)
+	syncer := repos.NewSyncer(testlogr.NewTestLogger(t), testRepos, mockMetrics, 1)
+
+	ctx, cancel := context.WithCancel(context.Background())
+	done := make(chan struct{})
+	go func() {
+		defer close(done)
+		if err := syncer.Start(ctx); err != nil {
+			t.Errorf("error returned from %T.Start: %s", syncer, err)
+		}
+	}()
+	defer func() {
+		cancel()
+		<-done
+	}()
+
+	syncer.Enqueue(srcRepo)
+	select {
+	case <-started:
+	case <-time.After(10 * time.Second):
+		t.Fatalf("timed out waiting for sync to start")
+	}
+
+	updated := srcRepo.DeepCopy()
+	updated.Status.Artifact.Revision = "main@sha1:0123456789abcdef0123456789abcdef01234567"
+	returned := make(chan struct{})
+	go func() {
+		defer close(returned)
+		syncer.Enqueue(updated)
+		testRepos.Get(key)
+	}()
+	select {
+	case <-returned:
+	case <-time.After(time.Second):
+		close(release)
+		t.Fatalf("Enqueue or Get waited for the sync in progress")
+	}
+
+	statuses := testRepos.Subscribe()
+	close(release)
+	for {
+		select {
+		case status := <-statuses:
+			if status.State == repos.SyncStateSynced && status.Revision == updated.Status.Artifact.Revision {
+				return
+			}
+		case <-time.After(10 * time.Second):
+			t.Fatalf("timed out waiting for the updated revision to be synced")
+		}
+	}
+}
@@ -0,0 +1,591 @@
+// Package sops decrypts SOPS encrypted manifests in memory using age and PGP private keys.
+package sops
+
+import (
+	"bytes"
+	"crypto/aes"
+	"crypto/cipher"
+	"crypto/sha512"
+	"encoding/base64"
+	"fmt"
+	"hash"
+	"io"
+	"regexp"
+	"strconv"
+	"strings"
+	"time"
+
+	"filippo.io/age"
+	"filippo.io/age/armor"
+	"github.com/ProtonMail/go-crypto/openpgp"
+	pgparmor "github.com/ProtonMail/go-crypto/openpgp/armor"
+	"github.com/pkg/errors"
+	"gopkg.in/yaml.v3"
+
+	"github.com/fidelity/kraan/pkg/logging"
+)
+
+const (
+	// AgeKeySuffix is the suffix of the keys in a decryption secret that contain age identities.
+	AgeKeySuffix = ".agekey"
+
+	// PGPKeySuffix is the suffix of the keys in a decryption secret that contain armored PGP private keys.
+	PGPKeySuffix = ".asc"
+
+	metadataKey = "sops"
+)
+
+var encryptedValue = regexp.MustCompile(`^ENC\[AES256_GCM,data:(.*),iv:(.+),tag:(.+),type:(.+)\]$`)
+
+// Keys holds the private keys used to decrypt SOPS encrypted manifests.
+type Keys struct {
+	age []age.Identity
+	pgp openpgp.EntityList
+}
+
+// ParseKeys parses the age identities in the values of keys ending in .agekey and the armored PGP private keys in the
+// values of keys ending in .asc, other keys are ignored.
+func ParseKeys(secrets ...map[string][]byte) (*Keys, error) {
+	keys := &Keys{}
+	for _, data := range secrets {
+		for name, value := range data {
+			switch {
+			case strings.HasSuffix(name, AgeKeySuffix):
+				identities, err := age.ParseIdentities(bytes.NewReader(value))
+				if err != nil {
+					return nil, errors.Wrapf(err, "%s - failed to parse age identities in: %s", logging.CallerStr(logging.Me), name)
+				}
+				keys.age = append(keys.age, identities...)
+			case strings.HasSuffix(name, PGPKeySuffix):
+				entities, err := openpgp.ReadArmoredKeyRing(bytes.NewReader(value))
+				if err != nil {
+					return nil, errors.Wrapf(err, "%s - failed to parse PGP keys in: %s", logging.CallerStr(logging.Me), name)
+				}
+				keys.pgp = append(keys.pgp, entities...)
+			}
+		}
+	}
+	return keys, nil
+}
+
+// IsEmpty returns true if there are no keys.
+func (k *Keys) IsEmpty() bool {
+	return k == nil || (len(k.age) == 0 && len(k.pgp) == 0)
+}
+
+type ageKey struct {
+	Recipient string `yaml:"recipient"`
+	Enc       string `yaml:"enc"`
+}
+
+type pgpKey struct {
+	Fingerprint string `yaml:"fp"`
+	Enc         string `yaml:"enc"`
+}
+
+// metadata is the metadata SOPS adds to an encrypted file.
+type metadata struct {
+	Age               []ageKey    `yaml:"age"`
+	PGP               []pgpKey    `yaml:"pgp"`
+	KeyGroups         []yaml.Node `yaml:"key_groups"`
+	LastModified      string      `yaml:"lastmodified"`
+	MAC               string      `yaml:"mac"`
+	MACOnlyEncrypted  bool        `yaml:"mac_only_encrypted"`
+	UnencryptedSuffix string      `yaml:"unencrypted_suffix"`
+	EncryptedSuffix   string      `yaml:"encrypted_suffix"`
+	UnencryptedRegex  string      `yaml:"unencrypted_regex"`
+	EncryptedRegex    string      `yaml:"encrypted_regex"`
+	Version           string      `yaml:"version"`
+}
+
+// IsEncrypted returns true if a YAML or JSON manifest has been encrypted by SOPS.
+func IsEncrypted(data []byte) bool {
+	holder := struct {
+		Sops *metadata `yaml:"sops"`
+	}{}
+	if !bytes.Contains(data, []byte(metadataKey)) {
+		return false
+	}
+	if err := yaml.Unmarshal(data, &holder); err != nil {
+		return false
+	}
+	return holder.Sops != nil && holder.Sops.MAC != ""
+}
+
+// Decrypt decrypts a SOPS encrypted YAML or JSON manifest, returning the plaintext YAML documents without the SOPS
+// metadata. The message authentication code of the file is verified.
+func Decrypt(data []byte, keys *Keys) ([]byte, error) {
+	docs, err := parseDocuments(data)
+	if err != nil {
+		return nil, err
+	}
+	meta, err := getMetadata(docs[0])
+	if err != nil {
+		return nil, err
+	}
+	if len(meta.KeyGroups) > 0 {
+		return nil, fmt.Errorf("key groups are not supported")
+	}
+	dataKey, err := getDataKey(meta, keys)
+	if err != nil {
+		return nil, err
+	}
+	d, err := newDecryptor(meta, dataKey)
+	if err != nil {
+		return nil, err
+	}
+	for _, doc := range docs {
+		if err := d.walkMap(doc, nil, false); err != nil {
+			return nil, err
+		}
+	}
+	if err := d.verifyMAC(); err != nil {
+		return nil, err
+	}
+
+	var out bytes.Buffer
+	encoder := yaml.NewEncoder(&out)
+	encoder.SetIndent(2)
+	for _, doc := range docs {
+		removeMetadata(doc)
+		if err := encoder.Encode(doc); err != nil {
+			return nil, errors.Wrapf(err, "%s - failed to encode decrypted document", logging.CallerStr(logging.Me))
+		}
+	}
+	if err := encoder.Close(); err != nil {
+		return nil, errors.Wrapf(err, "%s - failed to encode decrypted documents", logging.CallerStr(logging.Me))
+	}
+	return out.Bytes(), nil
+}
+
+func parseDocuments(data []byte) ([]*yaml.Node, error) {
+	docs := []*yaml.Node{}
+	decoder := yaml.NewDecoder(bytes.NewReader(data))
+	for {
+		doc := &yaml.Node{}
+		err := decoder.Decode(doc)
+		if err == io.EOF {
+			break
+		}
+		if err != nil {
+			return nil, errors.Wrapf(err, "%s - failed to parse encrypted file", logging.CallerStr(logging.Me))
+		}
+		if len(doc.Content) != 1 || doc.Content[0].Kind != yaml.MappingNode {
+			return nil, fmt.Errorf("encrypted documents must be mappings")
+		}
+		docs = append(docs, doc)
+	}
+	if len(docs) == 0 {
+		return nil, fmt.Errorf("encrypted file is empty")
+	}
+	return docs, nil
+}
+
+func getMetadata(doc *yaml.Node) (*metadata, error) {
+	mapping := doc.Content[0]
+	for i := 0; i < len(mapping.Content); i += 2 {
+		if mapping.Content[i].Value == metadataKey {
+			meta := &metadata{}
+			if err := mapping.Content[i+1].Decode(meta); err != nil {
+				return nil, errors.Wrapf(err, "%s - failed to decode SOPS metadata", logging.CallerStr(logging.Me))
+			}
+			return meta, nil
+		}
+	}
+	return nil, fmt.Errorf("SOPS metadata not found")
+}
+
+func removeMetadata(doc *yaml.Node) {
+	mapping := doc.Content[0]
+	for i := 0; i < len(mapping.Content); i += 2 {
+		if mapping.Content[i].Value == metadataKey {
+			mapping.Content = append(mapping.Content[:i], mapping.Content[i+2:]...)
+			return
+		}
+	}
+}
+
+// getDataKey decrypts the data key of an encrypted file using the first key that can.
+func getDataKey(meta *metadata, keys *Keys) ([]byte, error) {
+	if keys.IsEmpty() {
+		return nil, fmt.Errorf("no decryption keys available")
+	}
+	for _, key := range meta.Age {
+		if len(keys.age) == 0 {
+			break
+		}
+		reader, err := age.Decrypt(armor.NewReader(strings.NewReader(key.Enc)), keys.age...)
+		if err != nil {
+			continue
+		}
+		dataKey, err := io.ReadAll(reader)
+		if err == nil {
+			return dataKey, nil
+		}
+	}
+	for _, key := range meta.PGP {
+		if len(keys.pgp) == 0 {
+			break
+		}
+		block, err := pgparmor.Decode(strings.NewReader(key.Enc))
+		if err != nil {
+			continue
+		}
+		message, err := openpgp.ReadMessage(block.Body, keys.pgp, nil, nil)
+		if err != nil {
+			continue
+		}
+		dataKey, err := io.ReadAll(message.UnverifiedBody)
+		if err == nil {
+			return dataKey, nil
+		}
+	}
+	return nil, fmt.Errorf("none of the decryption keys can decrypt the data key")
+}
+
+// decryptor decrypts the values of a SOPS encrypted tree in place, in the order SOPS walks the tree, calculating its
+// message authentication code.
+type decryptor struct {
+	meta             *metadata
+	block            cipher.Block
+	unencryptedRegex *regexp.Regexp
+	encryptedRegex   *regexp.Regexp
+	hash             hash.Hash
+}
+
+func newDecryptor(meta *metadata, dataKey []byte) (*decryptor, error) {
+	block, err := aes.NewCipher(dataKey)
+	if err != nil {
+		return nil, errors.Wrapf(err, "%s - invalid data key", logging.CallerStr(logging.Me))
+	}
+	d := &decryptor{
+		meta:  meta,
+		block: block,
+		hash:  sha512.New(),
+	}
+	if meta.UnencryptedRegex != "" {
+		if d.unencryptedRegex, err = regexp.Compile(meta.UnencryptedRegex); err != nil {
+			return nil, errors.Wrapf(err, "%s - invalid unencrypted_regex", logging.CallerStr(logging.Me))
+		}
+	}
+	if meta.EncryptedRegex != "" {
+		if d.encryptedRegex, err = regexp.Compile(meta.EncryptedRegex); err != nil {
+			return nil, errors.Wrapf(err, "%s - invalid encrypted_regex", logging.CallerStr(logging.Me))
+		}
+	}
+	return d, nil
+}
+
+// isEncrypted returns true if the values at a path are encrypted, according to the file's metadata.
+func (d *decryptor) isEncrypted(path []string) bool {
+	encrypted := true
+	if d.meta.UnencryptedSuffix != "" {
+		for _, key := range path {
+			if strings.HasSuffix(key, d.meta.UnencryptedSuffix) {
+				encrypted = false
+				break
+			}
+		}
+	}
+	if d.meta.EncryptedSuffix != "" {
+		encrypted = false
+		for _, key := range path {
+			if strings.HasSuffix(key, d.meta.EncryptedSuffix) {
+				encrypted = true
+				break
+			}
+		}
+	}
+	if d.unencryptedRegex != nil {
+		for _, key := range path {
+			if d.unencryptedRegex.MatchString(key) {
+				encrypted = false
+				break
+			}
+		}
+	}
+	if d.encryptedRegex != nil {
+		encrypted = false
+		for _, key := range path {
+			if d.encryptedRegex.MatchString(key) {
+				encrypted = true
+				break
+			}
+		}
+	}
+	return encrypted
+}
+
+// walkMap walks a document or mapping node. Comments are visited in the positions SOPS stores them in its tree.
+func (d *decryptor) walkMap(node *yaml.Node, path []string, commentsHandled bool) error {
+	if !commentsHandled {
+		if err := d.comments(&node.HeadComment, path); err != nil {
+			return err
+		}
+		if err := d.comments(&node.LineComment, path); err != nil {
+			return err
+		}
+	}
+	switch node.Kind {
+	case yaml.DocumentNode:
+		for _, item := range node.Content {
+			if err := d.walkMap(item, path, false); err != nil {
+				return err
+			}
+		}
+	case yaml.MappingNode:
+		for i := 0; i+1 < len(node.Content); i += 2 {
+			if err := d.walkPair(node.Content[i], node.Content[i+1], path, len(path) == 0); err != nil {
+				return err
+			}
+		}
+	default:
+		return fmt.Errorf("unexpected %s node at: %s", kindName(node.Kind), strings.Join(path, "."))
+	}
+	if !commentsHandled {
+		return d.comments(&node.FootComment, path)
+	}
+	return nil
+}
+
+func (d *decryptor) walkPair(key, value *yaml.Node, path []string, topLevel bool) error {
+	if err := d.comments(&key.HeadComment, path); err != nil {
+		return err
+	}
+	if err := d.comments(&key.LineComment, path); err != nil {
+		return err
+	}
+	scalar := value.Kind == yaml.ScalarNode || value.Kind == yaml.AliasNode
+	if scalar {
+		if err := d.comments(&value.HeadComment, path); err != nil {
+			return err
+		}
+		if err := d.comments(&value.LineComment, path); err != nil {
+			return err
+		}
+	}
+	if key.Kind != yaml.ScalarNode || (key.Tag != "" && key.Tag != "!!str") {
+		return fmt.Errorf("only string keys are supported, found: %s", key.Value)
+	}
+	if !topLevel || key.Value != metadataKey {
+		if err := d.walkValue(value, append(append([]string{}, path...), key.Value)); err != nil {
+			return err
+		}
+	}
+	if scalar {
+		if err := d.comments(&value.FootComment, path); err != nil {
+			return err
+		}
+	}
+	return d.comments(&key.FootComment, path)
+}
+
+func (d *decryptor) walkValue(node *yaml.Node, path []string) error {
+	switch node.Kind {
+	case yaml.MappingNode:
+		return d.walkMap(node, path, false)
+	case yaml.SequenceNode:
+		if err := d.comments(&node.HeadComment, path); err != nil {
+			return err
+		}
+		if err := d.comments(&node.LineComment, path); err != nil {
+			return err
+		}
+		for _, item := range node.Content {
+			if err := d.comments(&item.HeadComment, path); err != nil {
+				return err
+			}
+			if err := d.comments(&item.LineComment, path); err != nil {
+				return err
+			}
+			if err := d.walkValue(item, path); err != nil {
+				return err
+			}
+			if err := d.comments(&item.FootComment, path); err != nil {
+				return err
+			}
+		}
+		return nil
+	case yaml.ScalarNode:
+		return d.scalar(node, path)
+	default:
+		return fmt.Errorf("unexpected %s node at: %s", kindName(node.Kind), strings.Join(path, "."))
+	}
+}
+
+// scalar decrypts a scalar value if it is encrypted, adding its plaintext to the message authentication code.
+func (d *decryptor) scalar(node *yaml.Node, path []string) error {
+	var value interface{}
+	if err := node.Decode(&value); err != nil {
+		return errors.Wrapf(err, "%s - failed to decode value at: %s", logging.CallerStr(logging.Me), strings.Join(path, "."))
+	}
+	if value == nil {
+		return nil
+	}
+	if d.isEncrypted(path) {
+		ciphertext, ok := value.(string)
+		if !ok {
+			return fmt.Errorf("value at: %s, is not encrypted", strings.Join(path, "."))
+		}
+		plaintext, valueType, err := d.decrypt(ciphertext, aad(path))
+		if err != nil {
+			return errors.WithMessagef(err, "%s - failed to decrypt value at: %s", logging.CallerStr(logging.Me), strings.Join(path, "."))
+		}
+		value, err = typedValue(plaintext, valueType)
+		if err != nil {
+			return errors.WithMessagef(err, "%s - invalid value at: %s", logging.CallerStr(logging.Me), strings.Join(path, "."))
+		}
+		setScalar(node, value)
+	} else if d.meta.MACOnlyEncrypted {
+		return nil
+	}
+	text, err := toBytes(value)
+	if err != nil {
+		return errors.WithMessagef(err, "%s - unsupported value at: %s", logging.CallerStr(logging.Me), strings.Join(path, "."))
+	}
+	d.hash.Write(text) //nolint:errcheck // hash writes do not fail
+	return nil
+}
+
+// comments decrypts the lines of a comment if the values at its path are encrypted. Comments that are not encrypted
+// are left as they are, as SOPS does for files written by versions that did not encrypt comments. As in SOPS, comments
+// are not part of the message authentication code.
+func (d *decryptor) comments(comment *string, path []string) error {
+	if *comment == "" || !d.isEncrypted(path) {
+		return nil
+	}
+	lines := strings.Split(*comment, "\n")
+	for i, line := range lines {
+		if line == "" {
+			continue
+		}
+		if plaintext, _, err := d.decrypt(line[1:], aad(path)); err == nil {
+			lines[i] = "#" + plaintext
+		}
+	}
+	*comment = strings.Join(lines, "\n")
+	return nil
+}
+
+// decrypt decrypts a value encrypted using AES-GCM, returning the plaintext and type of the value.
+func (d *decryptor) decrypt(value, additionalData string) (string, string, error) {
+	if value == "" {
+		return "", "str", nil
+	}
+	matches := encryptedValue.FindStringSubmatch(value)
+	if matches == nil {
+		return "", "", fmt.Errorf("value is not in the SOPS encrypted format")
+	}
+	data, err := base64.StdEncoding.DecodeString(matches[1])
+	if err != nil {
+		return "", "", errors.Wrapf(err, "%s - invalid data", logging.CallerStr(logging.Me))
+	}
+	iv, err := base64.StdEncoding.DecodeString(matches[2])
+	if err != nil {
+		return "", "", errors.Wrapf(err, "%s - invalid iv", logging.CallerStr(logging.Me))
+	}
+	tag, err := base64.StdEncoding.DecodeString(matches[3])
+	if err != nil {
+		return "", "", errors.Wrapf(err, "%s - invalid tag", logging.CallerStr(logging.Me))
+	}
+	if len(iv) == 0 {
+		return "", "", fmt.Errorf("invalid iv")
+	}
+	gcm, err := cipher.NewGCMWithNonceSize(d.block, len(iv))
+	if err != nil {
+		return "", "", errors.Wrapf(err, "%s - failed to create cipher", logging.CallerStr(logging.Me))
+	}
+	plaintext, err := gcm.Open(nil, iv, append(data, tag...), []byte(additionalData))
+	if err != nil {
+		return "", "", errors.Wrapf(err, "%s - failed to authenticate value", logging.CallerStr(logging.Me))
+	}
+	return string(plaintext), matches[4], nil
+}
+
+// verifyMAC checks the message authentication code of the file matches its plaintext values.
+func (d *decryptor) verifyMAC() error {
+	lastModified, err := time.Parse(time.RFC3339, d.meta.LastModified)
+	if err != nil {
+		return errors.Wrapf(err, "%s - invalid lastmodified time", logging.CallerStr(logging.Me))
+	}
+	mac, _, err := d.decrypt(d.meta.MAC, lastModified.Format(time.RFC3339))
+	if err != nil {
+		return errors.WithMessagef(err, "%s - failed to decrypt message authentication code", logging.CallerStr(logging.Me))
+	}
+	if mac != fmt.Sprintf("%X", d.hash.Sum(nil)) {
+		return fmt.Errorf("message authentication code does not match, the file has been modified")
+	}
+	return nil
+}
+
+func aad(path []string) string {
+	return strings.Join(path, ":") + ":"
+}
+
+func typedValue(plaintext, valueType string) (interface{}, error) {
+	switch valueType {
+	case "str", "bytes":
+		return plaintext, nil
+	case "int":
+		return strconv.Atoi(plaintext)
+	case "float":
+		return strconv.ParseFloat(plaintext, 64)
+	case "bool":
+		return strconv.ParseBool(plaintext)
+	default:
+		return nil, fmt.Errorf("unknown type: %s", valueType)
+	}
+}
+
+func setScalar(node *yaml.Node, value interface{}) {
+	node.Style = 0
+	switch v := value.(type) {
+	case int:
+		node.Tag, node.Value = "!!int", strconv.Itoa(v)
+	case float64:
+		node.Tag, node.Value = "!!float", strconv.FormatFloat(v, 'f', -1, 64)
+	case bool:
+		node.Tag, node.Value = "!!bool", strconv.FormatBool(v)
+	case string:
+		node.Tag, node.Value = "!!str", v
+		if strings.Contains(v, "\n") {
+			node.Style = yaml.LiteralStyle
+		}
+	}
+}
+
+// toBytes returns the representation of a value SOPS uses to calculate the message authentication code.
+func toBytes(value interface{}) ([]byte, error) {
+	switch v := value.(type) {
+	case string:
+		return []byte(v), nil
+	case int:
+		return []byte(strconv.Itoa(v)), nil
+	case float64:
+		return []byte(strconv.FormatFloat(v, 'f', -1, 64)), nil
+	case bool:
+		if v {
+			return []byte("True"), nil
+		}
+		return []byte("False"), nil
+	default:
+		return nil, fmt.Errorf("unsupported type: %T", value)
+	}
+}
+
+func kindName(kind yaml.Kind) string {
+	switch kind {
+	case yaml.DocumentNode:
+		return "document"
+	case yaml.SequenceNode:
+		return "sequence"
+	case yaml.MappingNode:
+		return "mapping"
+	case yaml.ScalarNode:
+		return "scalar"
+	case yaml.AliasNode:
+		return "alias"
+	default:
+		return "unknown"
+	}
+}
@@ -0,0 +1,372 @@
+package sops_test
+
+import (
+	"bytes"
+	"crypto/aes"
+	"crypto/cipher"
+	"crypto/rand"
+	"crypto/sha512"
+	"encoding/base64"
+	"fmt"
+	"os"
+	"path/filepath"
+	"strings"
+	"testing"
+
+	"filippo.io/age"
+	"filippo.io/age/armor"
+	"github.com/ProtonMail/go-crypto/openpgp"
+	pgparmor "github.com/ProtonMail/go-crypto/openpgp/armor"
+
+	"github.com/fidelity/kraan/pkg/sops"
+)
+
+const (
+	lastModified      = "2023-05-01T10:00:00Z"
+	encryptedTemplate = `apiVersion: v1
+kind: Secret
+metadata:
+  name: registry
+  namespace: apps
+type: %[1]s
+stringData:
+  username: %[2]s
+  password: %[3]s
+  port: %[4]s
+sops:
+  age:
+  - recipient: %[5]s
+    enc: |
+%[6]s
+  pgp:
+  - fp: %[7]s
+    enc: |
+%[8]s
+  lastmodified: "%[9]s"
+  mac: %[10]s
+  encrypted_regex: ^(data|stringData)$
+  version: 3.7.3
+`
+)
+
+func encryptValue(t *testing.T, dataKey []byte, plaintext, additionalData, valueType string) string {
+	block, err := aes.NewCipher(dataKey)
+	if err != nil {
+		t.Fatalf("failed to create cipher: %s", err)
+	}
+	iv := make([]byte, 32)
+	if _, err := rand.Read(iv); err != nil {
+		t.Fatalf("failed to generate iv: %s", err)
+	}
+	gcm, err := cipher.NewGCMWithNonceSize(block, len(iv))
+	if err != nil {
+		t.Fatalf("failed to create gcm: %s", err)
+	}
+	out := gcm.Seal(nil, iv, []byte(plaintext), []byte(additionalData))
+	data, tag := out[:len(out)-gcm.Overhead()], out[len(out)-gcm.Overhead():]
+	return fmt.Sprintf("ENC[AES256_GCM,data:%s,iv:%s,tag:%s,type:%s]", base64.StdEncoding.EncodeToString(data),
+		base64.StdEncoding.EncodeToString(iv), base64.StdEncoding.EncodeToString(tag), valueType)
+}
+
+func indent(text string) string {
+	lines := strings.Split(strings.TrimSuffix(text, "\n"), "\n")
+	for i, line := range lines {
+		lines[i] = "      " + line
+	}
+	return strings.Join(lines, "\n")
+}
+
+type testKeys struct {
+	ageIdentity *age.X25519Identity
+	pgpEntity   *openpgp.Entity
+	pgpKey      []byte
+}
+
+func newTestKeys(t *testing.T) testKeys {
+	identity, err := age.GenerateX25519Identity()
+	if err != nil {
+		t.Fatalf("failed to generate age identity: %s", err)
+	}
+	entity, err := openpgp.NewEntity("kraan", "test", "kraan@example.com", nil)
+	if err != nil {
+		t.Fatalf("failed to generate PGP key: %s", err)
+	}
+	var key bytes.Buffer
+	w, err := pgparmor.Encode(&key, openpgp.PrivateKeyType, nil)
+	if err != nil {
+		t.Fatalf("failed to armor PGP key: %s", err)
+	}
+	if err := entity.SerializePrivate(w, nil); err != nil {
+		t.Fatalf("failed to serialize PGP key: %s", err)
+	}
+	w.Close() //nolint:errcheck // ok
+	return testKeys{ageIdentity: identity, pgpEntity: entity, pgpKey: key.Bytes()}
+}
+
+// encryptDataKey returns the data key encrypted for the age and PGP keys, in the armored form SOPS stores it in.
+func encryptDataKey(t *testing.T, keys testKeys, dataKey []byte) (string, string) {
+	var ageEnc bytes.Buffer
+	aw := armor.NewWriter(&ageEnc)
+	w, err := age.Encrypt(aw, keys.ageIdentity.Recipient())
+	if err != nil {
+		t.Fatalf("failed to encrypt data key with age: %s", err)
+	}
+	w.Write(dataKey) //nolint:errcheck // ok
+	w.Close()        //nolint:errcheck // ok
+	aw.Close()       //nolint:errcheck // ok
+
+	var pgpEnc bytes.Buffer
+	pw, err := pgparmor.Encode(&pgpEnc, "PGP MESSAGE", nil)
+	if err != nil {
+		t.Fatalf("failed to armor PGP message: %s", err)
+	}
+	mw, err := openpgp.Encrypt(pw, []*openpgp.Entity{keys.pgpEntity}, nil, nil, nil)
+	if err != nil {
+		t.Fatalf("failed to encrypt data key with PGP: %s", err)
+	}
+	mw.Write(dataKey) //nolint:errcheck // ok
+	mw.Close()        //nolint:errcheck // ok
+	pw.Close()        //nolint:errcheck // ok
+	return ageEnc.String(), pgpEnc.String()
+}
+
+func newDataKey(t *testing.T) []byte {
+	dataKey := make([]byte, 32)
+	if _, err := rand.Read(dataKey); err != nil {
+		t.Fatalf("failed to generate data key: %s", err)
+	}
+	return dataKey
+}
+
+// mac returns the encrypted message authentication code of the plaintext values, SOPS does not include comments.
+func mac(t *testing.T, dataKey []byte, values ...string) string {
+	hash := sha512.New()
+	for _, value := range values {
+		hash.Write([]byte(value)) //nolint:errcheck // ok
+	}
+	return encryptValue(t, dataKey, fmt.Sprintf("%X", hash.Sum(nil)), lastModified, "str")
+}
+
+// encrypt returns a SOPS encrypted secret, with its stringData encrypted for the keys.
+func encrypt(t *testing.T, keys testKeys) []byte {
+	dataKey := newDataKey(t)
+	ageEnc, pgpEnc := encryptDataKey(t, keys, dataKey)
+
+	return []byte(fmt.Sprintf(encryptedTemplate, "Opaque",
+		encryptValue(t, dataKey, "admin", "stringData:username:", "str"),
+		encryptValue(t, dataKey, "s3cr3t", "stringData:password:", "str"),
+		encryptValue(t, dataKey, "5000", "stringData:port:", "int"),
+		keys.ageIdentity.Recipient().String(), indent(ageEnc),
+		fmt.Sprintf("%X", keys.pgpEntity.PrimaryKey.Fingerprint), indent(pgpEnc),
+		lastModified, mac(t, dataKey, "v1", "Secret", "registry", "apps", "Opaque", "admin", "s3cr3t", "5000")))
+}
+
+func TestDecrypt(t *testing.T) {
+	keys := newTestKeys(t)
+	other := newTestKeys(t)
+	encrypted := encrypt(t, keys)
+	expected := `apiVersion: v1
+kind: Secret
+metadata:
+  name: registry
+  namespace: apps
+type: Opaque
+stringData:
+  username: admin
+  password: s3cr3t
+  port: 5000
+`
+
+	if !sops.IsEncrypted(encrypted) {
+		t.Fatalf("IsEncrypted returned false for an encrypted file")
+	}
+	if sops.IsEncrypted([]byte(expected)) {
+		t.Fatalf("IsEncrypted returned true for a plaintext file")
+	}
+
+	tests := []struct {
+		name      string
+		data      []byte
+		keys      map[string][]byte
+		expectErr bool
+	}{{
+		name: "age key",
+		data: encrypted,
+		keys: map[string][]byte{"identity" + sops.AgeKeySuffix: []byte(keys.ageIdentity.String())},
+	}, {
+		name: "pgp key",
+		data: encrypted,
+		keys: map[string][]byte{"private" + sops.PGPKeySuffix: keys.pgpKey},
+	}, {
+		name:      "other keys",
+		data:      encrypted,
+		keys:      map[string][]byte{"identity" + sops.AgeKeySuffix: []byte(other.ageIdentity.String()), "private" + sops.PGPKeySuffix: other.pgpKey},
+		expectErr: true,
+	}, {
+		name:      "no keys",
+		data:      encrypted,
+		keys:      map[string][]byte{"ignored": []byte(keys.ageIdentity.String())},
+		expectErr: true,
+	}, {
+		name:      "modified plaintext value",
+		data:      bytes.Replace(encrypted, []byte("type: Opaque"), []byte("type: Other"), 1),
+		keys:      map[string][]byte{"identity" + sops.AgeKeySuffix: []byte(keys.ageIdentity.String())},
+		expectErr: true,
+	}, {
+		name:      "moved encrypted value",
+		data:      bytes.Replace(encrypted, []byte("  username:"), []byte("  user:"), 1),
+		keys:      map[string][]byte{"identity" + sops.AgeKeySuffix: []byte(keys.ageIdentity.String())},
+		expectErr: true,
+	}}
+
+	for _, test := range tests {
+		parsed, err := sops.ParseKeys(test.keys)
+		if err != nil {
+			t.Fatalf("%s: ParseKeys returned an error: %s", test.name, err)
+		}
+		plaintext, err := sops.Decrypt(test.data, parsed)
+		if test.expectErr {
+			if err == nil {
+				t.Errorf("%s: Decrypt did not return an error", test.name)
+			}
+			continue
+		}
+		if err != nil {
+			t.Errorf("%s: Decrypt returned an error: %s", test.name, err)
+			continue
+		}
+		if string(plaintext) != expected {
+			t.Errorf("%s: Decrypt returned:\n%s\nexpected:\n%s", test.name, plaintext, expected)
+		}
+	}
+}
+
+func TestDecryptDocuments(t *testing.T) {
+	keys := newTestKeys(t)
+	dataKey := newDataKey(t)
+	ageEnc, _ := encryptDataKey(t, keys, dataKey)
+	metadata := fmt.Sprintf(`sops:
+  age:
+  - recipient: %s
+    enc: |
+%s
+  lastmodified: "%s"
+  mac: %s
+  encrypted_regex: ^(data|stringData)$
+  version: 3.8.1
+`, keys.ageIdentity.Recipient().String(), indent(ageEnc), lastModified,
+		mac(t, dataKey, "v1", "Secret", "registry", "apps", "admin", "5000", "0.5", "True", "v1", "ConfigMap", "settings", "debug"))
+
+	encrypted := fmt.Sprintf(`# registry credentials
+apiVersion: v1
+kind: Secret
+metadata:
+  name: registry
+  namespace: apps
+stringData:
+  #%s
+  username: %s
+  port: %s
+  ratio: %s
+  enabled: %s
+%s---
+apiVersion: v1
+kind: ConfigMap
+metadata:
+  name: settings
+data:
+  level: %s #%s
+%s`,
+		encryptValue(t, dataKey, " rotated monthly", "stringData:", "comment"),
+		encryptValue(t, dataKey, "admin", "stringData:username:", "str"),
+		encryptValue(t, dataKey, "5000", "stringData:port:", "int"),
+		encryptValue(t, dataKey, "0.5", "stringData:ratio:", "float"),
+		encryptValue(t, dataKey, "true", "stringData:enabled:", "bool"),
+		metadata,
+		encryptValue(t, dataKey, "debug", "data:level:", "str"),
+		encryptValue(t, dataKey, " or info", "data:", "comment"),
+		metadata)
+	expected := `# registry credentials
+apiVersion: v1
+kind: Secret
+metadata:
+  name: registry
+  namespace: apps
+stringData:
+  # rotated monthly
+  username: admin
+  port: 5000
+  ratio: 0.5
+  enabled: true
+---
+apiVersion: v1
+kind: ConfigMap
+metadata:
+  name: settings
+data:
+  level: debug # or info
+`
+
+	parsed, err := sops.ParseKeys(map[string][]byte{"identity" + sops.AgeKeySuffix: []byte(keys.ageIdentity.String())})
+	if err != nil {
+		t.Fatalf("ParseKeys returned an error: %s", err)
+	}
+	plaintext, err := sops.Decrypt([]byte(encrypted), parsed)
+	if err != nil {
+		t.Fatalf("Decrypt returned an error: %s", err)
+	}
+	if string(plaintext) != expected {
+		t.Fatalf("Decrypt returned:\n%s\nexpected:\n%s", plaintext, expected)
+	}
+
+	modified := strings.Replace(encrypted, "name: settings", "name: other", 1)
+	if _, err := sops.Decrypt([]byte(modified), parsed); err == nil {
+		t.Fatalf("Decrypt did not return an error for a modified document")
+	}
+}
+
+// TestDecryptFixtures decrypts the files in testdata encrypted by the sops command line tool, see
+// testdata/generate.sh, and compares them with the plaintext they were encrypted from.
+func TestDecryptFixtures(t *testing.T) {
+	fixtures, err := filepath.Glob("testdata/*/*.sops.yaml")
+	if err != nil {
+		t.Fatalf("failed to list fixtures: %s", err)
+	}
+	if len(fixtures) == 0 {
+		t.Skip("no sops encrypted fixtures, run testdata/generate.sh to create them")
+	}
+	keyData := map[string][]byte{}
+	for _, name := range []string{"age.agekey", "pgp.asc"} {
+		data, err := os.ReadFile(filepath.Join("testdata", name))
+		if err != nil {
+			t.Fatalf("failed to read key: %s", err)
+		}
+		keyData[name] = data
+	}
+	for _, fixture := range fixtures {
+		keyType := strings.TrimSuffix(filepath.Base(fixture), ".sops.yaml")
+		keyType = keyType[strings.LastIndex(keyType, "-")+1:]
+		name := strings.TrimSuffix(strings.TrimSuffix(filepath.Base(fixture), ".sops.yaml"), "-"+keyType)
+		keyName := map[string]string{"age": "age.agekey", "pgp": "pgp.asc"}[keyType]
+		keys, err := sops.ParseKeys(map[string][]byte{keyName: keyData[keyName]})
+		if err != nil {
+			t.Fatalf("%s: ParseKeys returned an error: %s", fixture, err)
+		}
+		encrypted, err := os.ReadFile(fixture)
+		if err != nil {
+			t.Fatalf("%s: failed to read fixture: %s", fixture, err)
+		}
+		expected, err := os.ReadFile(filepath.Join("testdata", name+".yaml"))
+		if err != nil {
+			t.Fatalf("%s: failed to read plaintext: %s", fixture, err)
+		}
+		plaintext, err := sops.Decrypt(encrypted, keys)
+		if err != nil {
+			t.Errorf("%s: Decrypt returned an error: %s", fixture, err)
+			continue
+		}
+		if string(plaintext) != string(expected) {
+			t.Errorf("%s: Decrypt returned:\n%s\nexpected:\n%s", fixture, plaintext, expected)
+		}
+	}
+}
@@ -0,0 +1,30 @@
+#!/usr/bin/env bash
+# Encrypts the plaintext files in this directory with each sops version using the test keys, the encrypted files are
+# decrypted by TestDecryptFixtures and compared with the plaintext they were encrypted from.
+# Usage: generate.sh [sops versions], defaults to 3.7.3 and 3.8.1, requires gpg and curl.
+set -euo pipefail
+
+cd "$(dirname "${BASH_SOURCE[0]}")"
+versions=("${@:-3.7.3 3.8.1}")
+os="$(uname -s | tr '[:upper:]' '[:lower:]')"
+arch="$(uname -m | sed -e 's/x86_64/amd64/' -e 's/aarch64/arm64/')"
+age_recipient="$(grep -o 'age1[a-z0-9]*' age.agekey)"
+
+export GNUPGHOME="$(mktemp -d)"
+trap 'rm -rf "${GNUPGHOME}"' EXIT
+gpg --batch --import pgp.asc
+pgp_fingerprint="$(gpg --list-secret-keys --with-colons | awk -F: '/^fpr/ {print $10; exit}')"
+
+for version in ${versions[@]}; do
+  sops="${GNUPGHOME}/sops-${version}"
+  curl -sSfL -o "${sops}" "https://github.com/getsops/sops/releases/download/v${version}/sops-v${version}.${os}.${arch}"
+  chmod +x "${sops}"
+  mkdir -p "v${version}"
+  for plaintext in *.yaml; do
+    name="${plaintext%.yaml}"
+    "${sops}" --encrypt --encrypted-regex '^(data|stringData)$' --age "${age_recipient}" \
+      "${plaintext}" > "v${version}/${name}-age.sops.yaml"
+    "${sops}" --encrypt --encrypted-regex '^(data|stringData)$' --pgp "${pgp_fingerprint}" \
+      "${plaintext}" > "v${version}/${name}-pgp.sops.yaml"
+  done
+done
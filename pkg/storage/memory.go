@@ -0,0 +1,285 @@
+package storage
+
+import (
+	"io/fs"
+	"os"
+	"path"
+	"sort"
+	"strings"
+	"sync"
+	"time"
+
+	"github.com/pkg/errors"
+)
+
+// maxSymlinkDepth is the maximum number of symbolic links followed when resolving a path.
+const maxSymlinkDepth = 40
+
+// memNode is a directory, file or symbolic link held in memory.
+type memNode struct {
+	name     string
+	mode     fs.FileMode
+	modTime  time.Time
+	data     []byte
+	target   string
+	children map[string]*memNode
+}
+
+func newDirNode(name string) *memNode {
+	return &memNode{name: name, mode: fs.ModeDir | os.ModePerm, modTime: time.Now(), children: map[string]*memNode{}}
+}
+
+func (n *memNode) isDir() bool {
+	return n.mode.IsDir()
+}
+
+func (n *memNode) isSymlink() bool {
+	return n.mode&fs.ModeSymlink != 0
+}
+
+// memFileInfo implements fs.FileInfo for a memNode.
+type memFileInfo struct {
+	name    string
+	size    int64
+	mode    fs.FileMode
+	modTime time.Time
+}
+
+func (i memFileInfo) Name() string       { return i.name }
+func (i memFileInfo) Size() int64        { return i.size }
+func (i memFileInfo) Mode() fs.FileMode  { return i.mode }
+func (i memFileInfo) ModTime() time.Time { return i.modTime }
+func (i memFileInfo) IsDir() bool        { return i.mode.IsDir() }
+func (i memFileInfo) Sys() interface{}   { return nil }
+
+func (n *memNode) info(name string) fs.FileInfo {
+	return memFileInfo{name: name, size: int64(len(n.data)), mode: n.mode, modTime: n.modTime}
+}
+
+// memoryStorage is a Storage implementation holding all data in memory.
+type memoryStorage struct {
+	root         *memNode
+	sync.RWMutex `json:"-"`
+	Storage      `json:"-"`
+}
+
+// NewMemory returns a storage backend holding all data in memory.
+func NewMemory() Storage {
+	return &memoryStorage{root: newDirNode("/")}
+}
+
+func pathError(op, name string, err error) error {
+	return &fs.PathError{Op: op, Path: name, Err: err}
+}
+
+// splitPath returns the components of a cleaned absolute path.
+func splitPath(name string) []string {
+	cleaned := path.Clean("/" + name)
+	if cleaned == "/" {
+		return []string{}
+	}
+	return strings.Split(strings.TrimPrefix(cleaned, "/"), "/")
+}
+
+// lookup returns the node at a path, following symbolic links, including the last element if follow is true.
+func (m *memoryStorage) lookup(name string, follow bool) (*memNode, error) {
+	return m.walk(name, follow, 0)
+}
+
+func (m *memoryStorage) walk(name string, follow bool, depth int) (*memNode, error) {
+	if depth > maxSymlinkDepth {
+		return nil, pathError("lookup", name, errors.New("too many levels of symbolic links"))
+	}
+	parts := splitPath(name)
+	node := m.root
+	for index, part := range parts {
+		if !node.isDir() {
+			return nil, pathError("lookup", name, fs.ErrNotExist)
+		}
+		child, found := node.children[part]
+		if !found {
+			return nil, pathError("lookup", name, fs.ErrNotExist)
+		}
+		last := index == len(parts)-1
+		if child.isSymlink() && (!last || follow) {
+			target := child.target
+			if !path.IsAbs(target) {
+				target = path.Join("/", path.Join(parts[:index]...), target)
+			}
+			resolved, err := m.walk(target, true, depth+1)
+			if err != nil {
+				return nil, pathError("lookup", name, fs.ErrNotExist)
+			}
+			child = resolved
+		}
+		node = child
+	}
+	return node, nil
+}
+
+// parent returns the directory containing a path and the base name of the path.
+func (m *memoryStorage) parent(op, name string) (*memNode, string, error) {
+	parts := splitPath(name)
+	if len(parts) == 0 {
+		return nil, "", pathError(op, name, fs.ErrInvalid)
+	}
+	dir, err := m.lookup(path.Join("/", path.Join(parts[:len(parts)-1]...)), true)
+	if err != nil {
+		return nil, "", pathError(op, name, fs.ErrNotExist)
+	}
+	if !dir.isDir() {
+		return nil, "", pathError(op, name, fs.ErrNotExist)
+	}
+	return dir, parts[len(parts)-1], nil
+}
+
+func (m *memoryStorage) MkdirAll(name string) error {
+	m.Lock()
+	defer m.Unlock()
+	node := m.root
+	for index, part := range splitPath(name) {
+		child, found := node.children[part]
+		if !found {
+			child = newDirNode(part)
+			node.children[part] = child
+		} else if child.isSymlink() {
+			resolved, err := m.lookup(path.Join("/", path.Join(splitPath(name)[:index+1]...)), true)
+			if err != nil {
+				return pathError("mkdir", name, fs.ErrNotExist)
+			}
+			child = resolved
+		}
+		if !child.isDir() {
+			return pathError("mkdir", name, fs.ErrExist)
+		}
+		node = child
+	}
+	return nil
+}
+
+func (m *memoryStorage) RemoveAll(name string) error {
+	m.Lock()
+	defer m.Unlock()
+	dir, base, err := m.parent("removeall", name)
+	if err != nil {
+		if errors.Is(err, fs.ErrNotExist) {
+			return nil
+		}
+		return err
+	}
+	delete(dir.children, base)
+	return nil
+}
+
+func (m *memoryStorage) Rename(oldPath, newPath string) error {
+	m.Lock()
+	defer m.Unlock()
+	oldDir, oldBase, err := m.parent("rename", oldPath)
+	if err != nil {
+		return err
+	}
+	node, found := oldDir.children[oldBase]
+	if !found {
+		return pathError("rename", oldPath, fs.ErrNotExist)
+	}
+	newDir, newBase, err := m.parent("rename", newPath)
+	if err != nil {
+		return err
+	}
+	if existing, found := newDir.children[newBase]; found && existing.isDir() && len(existing.children) > 0 {
+		return pathError("rename", newPath, fs.ErrExist)
+	}
+	delete(oldDir.children, oldBase)
+	node.name = newBase
+	newDir.children[newBase] = node
+	return nil
+}
+
+func (m *memoryStorage) Symlink(target, link string) error {
+	m.Lock()
+	defer m.Unlock()
+	dir, base, err := m.parent("symlink", link)
+	if err != nil {
+		return err
+	}
+	if _, found := dir.children[base]; found {
+		return pathError("symlink", link, fs.ErrExist)
+	}
+	dir.children[base] = &memNode{name: base, mode: fs.ModeSymlink | os.ModePerm, modTime: time.Now(), target: target}
+	return nil
+}
+
+func (m *memoryStorage) Stat(name string) (fs.FileInfo, error) {
+	m.RLock()
+	defer m.RUnlock()
+	node, err := m.lookup(name, true)
+	if err != nil {
+		return nil, pathError("stat", name, fs.ErrNotExist)
+	}
+	return node.info(path.Base(name)), nil
+}
+
+func (m *memoryStorage) Lstat(name string) (fs.FileInfo, error) {
+	m.RLock()
+	defer m.RUnlock()
+	node, err := m.lookup(name, false)
+	if err != nil {
+		return nil, pathError("lstat", name, fs.ErrNotExist)
+	}
+	return node.info(path.Base(name)), nil
+}
+
+func (m *memoryStorage) ReadDir(name string) ([]fs.DirEntry, error) {
+	m.RLock()
+	defer m.RUnlock()
+	node, err := m.lookup(name, true)
+	if err != nil {
+		return nil, pathError("readdir", name, fs.ErrNotExist)
+	}
+	if !node.isDir() {
+		return nil, pathError("readdir", name, errors.New("not a directory"))
+	}
+	entries := make([]fs.DirEntry, 0, len(node.children))
+	for childName, child := range node.children {
+		entries = append(entries, fs.FileInfoToDirEntry(child.info(childName)))
+	}
+	sort.Slice(entries, func(i, j int) bool { return entries[i].Name() < entries[j].Name() })
+	return entries, nil
+}
+
+func (m *memoryStorage) ReadFile(name string) ([]byte, error) {
+	m.RLock()
+	defer m.RUnlock()
+	node, err := m.lookup(name, true)
+	if err != nil {
+		return nil, pathError("read", name, fs.ErrNotExist)
+	}
+	if node.isDir() {
+		return nil, pathError("read", name, errors.New("is a directory"))
+	}
+	data := make([]byte, len(node.data))
+	copy(data, node.data)
+	return data, nil
+}
+
+func (m *memoryStorage) WriteFile(name string, data []byte, perm fs.FileMode) error {
+	m.Lock()
+	defer m.Unlock()
+	dir, base, err := m.parent("write", name)
+	if err != nil {
+		return err
+	}
+	if existing, found := dir.children[base]; found && existing.isDir() {
+		return pathError("write", name, errors.New("is a directory"))
+	}
+	contents := make([]byte, len(data))
+	copy(contents, data)
+	dir.children[base] = &memNode{name: base, mode: perm.Perm(), modTime: time.Now(), data: contents}
+	return nil
+}
+
+// LocalPath copies a directory to a temporary directory on the local filesystem, for use by external commands.
+// The cleanup function removes the temporary directory.
+func (m *memoryStorage) LocalPath(name string) (string, func(), error) {
+	return LocalCopy(m, name, nil)
+}
@@ -0,0 +1,161 @@
+// Package storage provides a filesystem abstraction for the repository and layer data managed by Kraan.
+//
+//go:generate mockgen -destination=../mocks/storage/mockStorage.go -package=mocks -source=storage.go . Storage
+package storage
+
+import (
+	"fmt"
+	"io/fs"
+	"os"
+
+	"github.com/pkg/errors"
+
+	"github.com/fidelity/kraan/pkg/logging"
+)
+
+const (
+	// DiskBackend stores data on the local filesystem.
+	DiskBackend = "disk"
+	// MemoryBackend stores data in memory.
+	MemoryBackend = "memory"
+)
+
+// DefaultBackend is the storage backend used if none is configured.
+var DefaultBackend = DiskBackend
+
+// Storage defines the filesystem operations used to manage repository and layer data.
+// Paths are slash separated and absolute.
+type Storage interface {
+	MkdirAll(path string) error
+	RemoveAll(path string) error
+	Rename(oldPath, newPath string) error
+	Symlink(target, link string) error
+	Stat(path string) (fs.FileInfo, error)
+	Lstat(path string) (fs.FileInfo, error)
+	ReadDir(path string) ([]fs.DirEntry, error)
+	ReadFile(path string) ([]byte, error)
+	WriteFile(path string, data []byte, perm fs.FileMode) error
+	LocalPath(path string) (localPath string, cleanup func(), err error)
+}
+
+// New returns a storage backend of the type specified.
+func New(backend string) (Storage, error) {
+	switch backend {
+	case DiskBackend, "":
+		return NewDisk(), nil
+	case MemoryBackend:
+		return NewMemory(), nil
+	default:
+		return nil, fmt.Errorf("unsupported storage backend: %s, must be %s or %s", backend, DiskBackend, MemoryBackend)
+	}
+}
+
+// diskStorage is a Storage implementation using the local filesystem.
+type diskStorage struct {
+	Storage `json:"-"`
+}
+
+// NewDisk returns a storage backend using the local filesystem.
+func NewDisk() Storage {
+	return &diskStorage{}
+}
+
+func (d *diskStorage) MkdirAll(path string) error {
+	return os.MkdirAll(path, os.ModePerm)
+}
+
+func (d *diskStorage) RemoveAll(path string) error {
+	return os.RemoveAll(path)
+}
+
+func (d *diskStorage) Rename(oldPath, newPath string) error {
+	return os.Rename(oldPath, newPath)
+}
+
+func (d *diskStorage) Symlink(target, link string) error {
+	return os.Symlink(target, link)
+}
+
+func (d *diskStorage) Stat(path string) (fs.FileInfo, error) {
+	return os.Stat(path)
+}
+
+func (d *diskStorage) Lstat(path string) (fs.FileInfo, error) {
+	return os.Lstat(path)
+}
+
+func (d *diskStorage) ReadDir(path string) ([]fs.DirEntry, error) {
+	return os.ReadDir(path)
+}
+
+func (d *diskStorage) ReadFile(path string) ([]byte, error) {
+	return os.ReadFile(path)
+}
+
+func (d *diskStorage) WriteFile(path string, data []byte, perm fs.FileMode) error {
+	return os.WriteFile(path, data, perm)
+}
+
+// LocalPath returns the path unchanged, the data is already on the local filesystem.
+func (d *diskStorage) LocalPath(path string) (string, func(), error) {
+	return path, func() {}, nil
+}
+
+// LocalCopy copies the files in a directory that are selected by the keep function to a temporary directory on the
+// local filesystem, for use by external commands. The keep function is passed the slash separated path relative to the
+// directory, files in a directory that is not kept are not copied. The cleanup function removes the temporary directory.
+func LocalCopy(s Storage, path string, keep func(relPath string, isDir bool) bool) (string, func(), error) {
+	tempDir, err := os.MkdirTemp("", "kraan-*")
+	if err != nil {
+		return "", func() {}, errors.Wrapf(err, "%s - failed to create temporary directory", logging.CallerStr(logging.Me))
+	}
+	cleanup := func() {
+		os.RemoveAll(tempDir) //nolint:errcheck // ok
+	}
+	if err := copyToDisk(s, path, tempDir, "", keep); err != nil {
+		cleanup()
+		return "", func() {}, errors.WithMessagef(err, "%s - failed to copy %s to local filesystem", logging.CallerStr(logging.Me), path)
+	}
+	return tempDir, cleanup, nil
+}
+
+// copyToDisk copies a directory tree from a storage backend to a directory on the local filesystem, skipping files and
+// directories not selected by the keep function, if any.
+func copyToDisk(s Storage, srcDir, destDir, relDir string, keep func(relPath string, isDir bool) bool) error {
+	entries, err := s.ReadDir(srcDir)
+	if err != nil {
+		return errors.WithMessagef(err, "%s - failed to read directory: %s", logging.CallerStr(logging.Me), srcDir)
+	}
+	for _, entry := range entries {
+		src := fmt.Sprintf("%s/%s", srcDir, entry.Name())
+		dest := fmt.Sprintf("%s/%s", destDir, entry.Name())
+		rel := entry.Name()
+		if relDir != "" {
+			rel = fmt.Sprintf("%s/%s", relDir, entry.Name())
+		}
+		info, err := s.Stat(src)
+		if err != nil {
+			return errors.WithMessagef(err, "%s - failed to stat: %s", logging.CallerStr(logging.Me), src)
+		}
+		if keep != nil && !keep(rel, info.IsDir()) {
+			continue
+		}
+		if info.IsDir() {
+			if err := os.MkdirAll(dest, os.ModePerm); err != nil {
+				return errors.Wrapf(err, "%s - failed to make directory: %s", logging.CallerStr(logging.Me), dest)
+			}
+			if err := copyToDisk(s, src, dest, rel, keep); err != nil {
+				return err
+			}
+			continue
+		}
+		data, err := s.ReadFile(src)
+		if err != nil {
+			return errors.WithMessagef(err, "%s - failed to read file: %s", logging.CallerStr(logging.Me), src)
+		}
+		if err := os.WriteFile(dest, data, info.Mode().Perm()); err != nil {
+			return errors.Wrapf(err, "%s - failed to write file: %s", logging.CallerStr(logging.Me), dest)
+		}
+	}
+	return nil
+}
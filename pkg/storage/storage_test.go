@@ -0,0 +1,152 @@
+package storage_test
+
+import (
+	"os"
+	"path"
+	"path/filepath"
+	"testing"
+
+	"github.com/fidelity/kraan/pkg/storage"
+)
+
+func TestNew(t *testing.T) {
+	for _, backend := range []string{storage.DiskBackend, storage.MemoryBackend, ""} {
+		if _, err := storage.New(backend); err != nil {
+			t.Fatalf("storage.New returned an error for backend '%s': %s", backend, err)
+		}
+	}
+	if _, err := storage.New("s3"); err == nil {
+		t.Fatalf("storage.New did not return an error for an unsupported backend")
+	}
+}
+
+func TestMemoryStorage(t *testing.T) { //nolint:funlen // ok
+	store := storage.NewMemory()
+
+	if err := store.MkdirAll("/data/load/ns/repo/rev1/addons"); err != nil {
+		t.Fatalf("%T.MkdirAll returned an error: %s", store, err)
+	}
+	if err := store.WriteFile("/data/load/ns/repo/rev1/addons/hr.yaml", []byte("kind: HelmRelease"), 0o644); err != nil {
+		t.Fatalf("%T.WriteFile returned an error: %s", store, err)
+	}
+	if err := store.Rename("/data/load/ns/repo/rev1", "/data/ns/repo/rev1"); err == nil {
+		t.Fatalf("%T.Rename did not return an error when the target parent directory is missing", store)
+	}
+	if err := store.MkdirAll("/data/ns/repo"); err != nil {
+		t.Fatalf("%T.MkdirAll returned an error: %s", store, err)
+	}
+	if err := store.Rename("/data/load/ns/repo/rev1", "/data/ns/repo/rev1"); err != nil {
+		t.Fatalf("%T.Rename returned an error: %s", store, err)
+	}
+	if _, err := store.Stat("/data/load/ns/repo/rev1"); !os.IsNotExist(err) {
+		t.Fatalf("expected renamed directory to no longer exist, got: %v", err)
+	}
+
+	if err := store.MkdirAll("/data/layers/layer"); err != nil {
+		t.Fatalf("%T.MkdirAll returned an error: %s", store, err)
+	}
+	if err := store.Symlink("/data/ns/repo/rev1/addons", "/data/layers/layer/0.1.0"); err != nil {
+		t.Fatalf("%T.Symlink returned an error: %s", store, err)
+	}
+	if err := store.Symlink("/data/ns/repo/rev1/addons", "/data/layers/layer/0.1.0"); !os.IsExist(err) {
+		t.Fatalf("expected %T.Symlink to fail for an existing link, got: %v", store, err)
+	}
+
+	info, err := store.Lstat("/data/layers/layer/0.1.0")
+	if err != nil {
+		t.Fatalf("%T.Lstat returned an error: %s", store, err)
+	}
+	if info.Mode()&os.ModeSymlink == 0 {
+		t.Fatalf("expected %T.Lstat to return a symbolic link, got mode: %s", store, info.Mode())
+	}
+	info, err = store.Stat("/data/layers/layer/0.1.0")
+	if err != nil {
+		t.Fatalf("%T.Stat returned an error: %s", store, err)
+	}
+	if !info.IsDir() {
+		t.Fatalf("expected %T.Stat to follow the link to a directory, got mode: %s", store, info.Mode())
+	}
+
+	data, err := store.ReadFile("/data/layers/layer/0.1.0/hr.yaml")
+	if err != nil {
+		t.Fatalf("%T.ReadFile returned an error: %s", store, err)
+	}
+	if string(data) != "kind: HelmRelease" {
+		t.Fatalf("unexpected file content: %s", data)
+	}
+
+	entries, err := store.ReadDir("/data/ns/repo")
+	if err != nil {
+		t.Fatalf("%T.ReadDir returned an error: %s", store, err)
+	}
+	if len(entries) != 1 || entries[0].Name() != "rev1" || !entries[0].IsDir() {
+		t.Fatalf("unexpected directory entries: %v", entries)
+	}
+
+	localDir, cleanup, err := store.LocalPath("/data/layers/layer/0.1.0")
+	if err != nil {
+		t.Fatalf("%T.LocalPath returned an error: %s", store, err)
+	}
+	data, err = os.ReadFile(localDir + "/hr.yaml")
+	if err != nil {
+		t.Fatalf("failed to read file copied to local filesystem: %s", err)
+	}
+	if string(data) != "kind: HelmRelease" {
+		t.Fatalf("unexpected content in file copied to local filesystem: %s", data)
+	}
+	cleanup()
+	if _, err := os.Stat(localDir); !os.IsNotExist(err) {
+		t.Fatalf("expected local copy to be removed by cleanup, got: %v", err)
+	}
+
+	if err := store.RemoveAll("/data/ns/repo"); err != nil {
+		t.Fatalf("%T.RemoveAll returned an error: %s", store, err)
+	}
+	if err := store.RemoveAll("/data/ns/repo"); err != nil {
+		t.Fatalf("%T.RemoveAll returned an error for a missing path: %s", store, err)
+	}
+	if _, err := store.Stat("/data/layers/layer/0.1.0"); !os.IsNotExist(err) {
+		t.Fatalf("expected link to removed directory to be dangling, got: %v", err)
+	}
+	if _, err := store.Lstat("/data/layers/layer/0.1.0"); err != nil {
+		t.Fatalf("expected dangling link to remain, got: %v", err)
+	}
+}
+
+func TestLocalCopy(t *testing.T) {
+	store := storage.NewMemory()
+	for _, file := range []string{"/layer/hr.yaml", "/layer/README.md", "/layer/examples/hr.yaml"} {
+		if err := store.MkdirAll(path.Dir(file)); err != nil {
+			t.Fatalf("%T.MkdirAll returned an error: %s", store, err)
+		}
+		if err := store.WriteFile(file, []byte("kind: HelmRelease"), 0o644); err != nil {
+			t.Fatalf("%T.WriteFile returned an error: %s", store, err)
+		}
+	}
+
+	keep := func(relPath string, isDir bool) bool {
+		if isDir {
+			return relPath != "examples"
+		}
+		return path.Ext(relPath) == ".yaml"
+	}
+	if _, err := store.ReadFile("/layer/.kraanignore"); !os.IsNotExist(err) {
+		t.Fatalf("expected missing file to not exist, got: %v", err)
+	}
+	localDir, cleanup, err := storage.LocalCopy(store, "/layer", keep)
+	if err != nil {
+		t.Fatalf("storage.LocalCopy returned an error: %s", err)
+	}
+	if _, err := os.Stat(filepath.Join(localDir, "hr.yaml")); err != nil {
+		t.Fatalf("selected file was not copied: %s", err)
+	}
+	for _, file := range []string{"README.md", "examples"} {
+		if _, err := os.Stat(filepath.Join(localDir, file)); !os.IsNotExist(err) {
+			t.Fatalf("%s was copied, expected it to be skipped", file)
+		}
+	}
+	cleanup()
+	if _, err := os.Stat(localDir); !os.IsNotExist(err) {
+		t.Fatalf("cleanup did not remove %s", localDir)
+	}
+}
@@ -0,0 +1,103 @@
+// Package substitute replaces variable references in the string values of Kubernetes resources.
+//
+// A reference has the form ${NAME} or ${NAME:=default}, where the default is used if the variable is not defined.
+// A reference preceded by an additional $, for example $${NAME}, is not substituted and the extra $ is removed.
+package substitute
+
+import (
+	"regexp"
+	"sort"
+)
+
+var reference = regexp.MustCompile(`\$?\$\{([A-Za-z_][A-Za-z0-9_]*)(:=([^}]*))?\}`)
+
+// String substitutes the variable references in a string. It returns the names of any variables that are referenced
+// without a default but not defined, these references are left unchanged.
+func String(value string, variables map[string]string) (string, []string) {
+	unresolved := []string{}
+	result := reference.ReplaceAllStringFunc(value, func(match string) string {
+		if match[1] == '$' {
+			return match[1:]
+		}
+		groups := reference.FindStringSubmatch(match)
+		if variable, ok := variables[groups[1]]; ok {
+			return variable
+		}
+		if groups[2] != "" {
+			return groups[3]
+		}
+		unresolved = append(unresolved, groups[1])
+		return match
+	})
+	return result, unresolved
+}
+
+// Object substitutes the variable references in all the string values of an unstructured object, in place. It returns
+// true if any value was changed and the sorted names of any variables that are referenced but not defined.
+func Object(obj map[string]interface{}, variables map[string]string) (bool, []string) {
+	names := map[string]bool{}
+	changed := walk(obj, variables, names)
+	unresolved := make([]string, 0, len(names))
+	for name := range names {
+		unresolved = append(unresolved, name)
+	}
+	sort.Strings(unresolved)
+	return changed, unresolved
+}
+
+// HasReferences returns true if any string value of an unstructured object contains a variable reference.
+func HasReferences(value interface{}) bool {
+	switch typed := value.(type) {
+	case string:
+		return reference.MatchString(typed)
+	case map[string]interface{}:
+		for _, item := range typed {
+			if HasReferences(item) {
+				return true
+			}
+		}
+	case []interface{}:
+		for _, item := range typed {
+			if HasReferences(item) {
+				return true
+			}
+		}
+	}
+	return false
+}
+
+func walk(value interface{}, variables map[string]string, unresolved map[string]bool) (changed bool) {
+	switch typed := value.(type) {
+	case map[string]interface{}:
+		for key, item := range typed {
+			if text, ok := item.(string); ok {
+				if replaced, ok := substitute(text, variables, unresolved); ok {
+					typed[key] = replaced
+					changed = true
+				}
+				continue
+			}
+			changed = walk(item, variables, unresolved) || changed
+		}
+	case []interface{}:
+		for index, item := range typed {
+			if text, ok := item.(string); ok {
+				if replaced, ok := substitute(text, variables, unresolved); ok {
+					typed[index] = replaced
+					changed = true
+				}
+				continue
+			}
+			changed = walk(item, variables, unresolved) || changed
+		}
+	}
+	return changed
+}
+
+func substitute(text string, variables map[string]string, unresolved map[string]bool) (string, bool) {
+	replaced, names := String(text, variables)
+	for _, name := range names {
+		unresolved[name] = true
+	}
+	return replaced, replaced != text
+}
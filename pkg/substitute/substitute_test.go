@@ -0,0 +1,81 @@
+package substitute_test
+
+import (
+	"testing"
+
+	"github.com/google/go-cmp/cmp"
+
+	"github.com/fidelity/kraan/pkg/substitute"
+)
+
+func TestString(t *testing.T) {
+	variables := map[string]string{"CLUSTER": "dev", "REGION": "eu-west-1", "EMPTY": ""}
+	tests := []struct {
+		value      string
+		expected   string
+		unresolved []string
+	}{
+		{"no references", "no references", []string{}},
+		{"${CLUSTER}", "dev", []string{}},
+		{"${CLUSTER}-${REGION}.example.com", "dev-eu-west-1.example.com", []string{}},
+		{"x${EMPTY}y", "xy", []string{}},
+		{"${TIER:=apps}", "apps", []string{}},
+		{"${CLUSTER:=prod}", "dev", []string{}},
+		{"$${CLUSTER}", "${CLUSTER}", []string{}},
+		{"${MISSING}-${CLUSTER}", "${MISSING}-dev", []string{"MISSING"}},
+		{"$CLUSTER ${1INVALID}", "$CLUSTER ${1INVALID}", []string{}},
+	}
+	for _, test := range tests {
+		result, unresolved := substitute.String(test.value, variables)
+		if result != test.expected {
+			t.Errorf("String(%q) returned %q, expected %q", test.value, result, test.expected)
+		}
+		if diff := cmp.Diff(test.unresolved, unresolved); diff != "" {
+			t.Errorf("String(%q) unexpected unresolved variables (-want +got):\n%s", test.value, diff)
+		}
+	}
+}
+
+func TestObject(t *testing.T) {
+	obj := map[string]interface{}{
+		"metadata": map[string]interface{}{"name": "podinfo-${CLUSTER}", "namespace": "apps"},
+		"spec": map[string]interface{}{
+			"values": map[string]interface{}{
+				"hosts":    []interface{}{"${CLUSTER}.example.com", "${ZONE}.example.com", int64(1)},
+				"replicas": int64(2),
+				"region":   "${REGION}",
+			},
+		},
+	}
+	expected := map[string]interface{}{
+		"metadata": map[string]interface{}{"name": "podinfo-dev", "namespace": "apps"},
+		"spec": map[string]interface{}{
+			"values": map[string]interface{}{
+				"hosts":    []interface{}{"dev.example.com", "${ZONE}.example.com", int64(1)},
+				"replicas": int64(2),
+				"region":   "${REGION}",
+			},
+		},
+	}
+	if !substitute.HasReferences(obj) {
+		t.Fatalf("HasReferences returned false for an object with references")
+	}
+	changed, unresolved := substitute.Object(obj, map[string]string{"CLUSTER": "dev"})
+	if !changed {
+		t.Fatalf("Object did not report a change")
+	}
+	if diff := cmp.Diff(expected, obj); diff != "" {
+		t.Fatalf("unexpected object (-want +got):\n%s", diff)
+	}
+	if diff := cmp.Diff([]string{"REGION", "ZONE"}, unresolved); diff != "" {
+		t.Fatalf("unexpected unresolved variables (-want +got):\n%s", diff)
+	}
+
+	if substitute.HasReferences(map[string]interface{}{"kind": "ConfigMap", "data": map[string]interface{}{"price": "$5"}}) {
+		t.Fatalf("HasReferences returned true for an object without references")
+	}
+	changed, _ = substitute.Object(map[string]interface{}{"kind": "ConfigMap"}, nil)
+	if changed {
+		t.Fatalf("Object reported a change for an object without references")
+	}
+}
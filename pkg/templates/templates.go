@@ -0,0 +1,179 @@
+// Package templates renders Go templates in the manifests of Kubernetes resources.
+//
+// Templates are rendered with the missingkey=error option so a reference to an undefined field fails rather than
+// rendering an empty value. In addition to the built in functions a small set of helper functions is provided, see
+// Funcs.
+package templates
+
+import (
+	"bytes"
+	"fmt"
+	"strings"
+	"text/template"
+
+	"golang.org/x/mod/semver"
+	"sigs.k8s.io/yaml"
+)
+
+// Funcs returns the functions available to templates.
+func Funcs() template.FuncMap {
+	return template.FuncMap{
+		"default":       defaultValue,
+		"required":      required,
+		"quote":         func(value interface{}) string { return fmt.Sprintf("%q", fmt.Sprint(value)) },
+		"lower":         strings.ToLower,
+		"upper":         strings.ToUpper,
+		"trim":          strings.TrimSpace,
+		"contains":      func(substr, s string) bool { return strings.Contains(s, substr) },
+		"hasPrefix":     func(prefix, s string) bool { return strings.HasPrefix(s, prefix) },
+		"replace":       func(old, replacement, s string) string { return strings.ReplaceAll(s, old, replacement) },
+		"indent":        indent,
+		"nindent":       func(spaces int, text string) string { return "\n" + indent(spaces, text) },
+		"toYaml":        toYaml,
+		"semverCompare": semverCompare,
+	}
+}
+
+// Render renders a template with the data provided.
+func Render(name, text string, data interface{}) (string, error) {
+	tmpl, err := template.New(name).Option("missingkey=error").Funcs(Funcs()).Parse(text)
+	if err != nil {
+		return "", err
+	}
+	var out bytes.Buffer
+	if err := tmpl.Execute(&out, data); err != nil {
+		return "", err
+	}
+	return out.String(), nil
+}
+
+// HasActions returns true if a string contains a template action.
+func HasActions(text string) bool {
+	return strings.Contains(text, "{{")
+}
+
+// Object renders the string values of an unstructured object that contain template actions, in place. It returns true
+// if any value was changed.
+func Object(obj map[string]interface{}, name string, data interface{}) (bool, error) {
+	return walk(obj, name, data)
+}
+
+func walk(value interface{}, name string, data interface{}) (changed bool, err error) {
+	switch typed := value.(type) {
+	case map[string]interface{}:
+		for key, item := range typed {
+			rendered, itemChanged, err := renderItem(item, name, data)
+			if err != nil {
+				return false, err
+			}
+			if itemChanged {
+				typed[key] = rendered
+				changed = true
+			}
+		}
+	case []interface{}:
+		for index, item := range typed {
+			rendered, itemChanged, err := renderItem(item, name, data)
+			if err != nil {
+				return false, err
+			}
+			if itemChanged {
+				typed[index] = rendered
+				changed = true
+			}
+		}
+	}
+	return changed, nil
+}
+
+func renderItem(item interface{}, name string, data interface{}) (interface{}, bool, error) {
+	text, ok := item.(string)
+	if !ok {
+		changed, err := walk(item, name, data)
+		return item, changed, err
+	}
+	if !HasActions(text) {
+		return item, false, nil
+	}
+	rendered, err := Render(name, text, data)
+	if err != nil {
+		return nil, false, err
+	}
+	return rendered, rendered != text, nil
+}
+
+func defaultValue(value, given interface{}) interface{} {
+	if given == nil {
+		return value
+	}
+	if text, ok := given.(string); ok && text == "" {
+		return value
+	}
+	return given
+}
+
+func required(message string, value interface{}) (interface{}, error) {
+	if value == nil {
+		return nil, fmt.Errorf("%s", message)
+	}
+	if text, ok := value.(string); ok && text == "" {
+		return nil, fmt.Errorf("%s", message)
+	}
+	return value, nil
+}
+
+func indent(spaces int, text string) string {
+	padding := strings.Repeat(" ", spaces)
+	return padding + strings.ReplaceAll(text, "\n", "\n"+padding)
+}
+
+func toYaml(value interface{}) (string, error) {
+	data, err := yaml.Marshal(value)
+	if err != nil {
+		return "", err
+	}
+	return strings.TrimSuffix(string(data), "\n"), nil
+}
+
+// semverCompare returns true if a version satisfies a constraint, a version optionally preceded by one of the
+// operators =, !=, <, <=, > or >=, for example ">=1.25".
+func semverCompare(constraint, version string) (bool, error) {
+	constraint = strings.TrimSpace(constraint)
+	operator := "="
+	for _, op := range []string{">=", "<=", "!=", ">", "<", "="} {
+		if strings.HasPrefix(constraint, op) {
+			operator = op
+			constraint = strings.TrimSpace(strings.TrimPrefix(constraint, op))
+			break
+		}
+	}
+	want, have := canonical(constraint), canonical(version)
+	if !semver.IsValid(want) {
+		return false, fmt.Errorf("invalid version in constraint: %s", constraint)
+	}
+	if !semver.IsValid(have) {
+		return false, fmt.Errorf("invalid version: %s", version)
+	}
+	result := semver.Compare(have, want)
+	switch operator {
+	case ">=":
+		return result >= 0, nil
+	case "<=":
+		return result <= 0, nil
+	case "!=":
+		return result != 0, nil
+	case ">":
+		return result > 0, nil
+	case "<":
+		return result < 0, nil
+	default:
+		return result == 0, nil
+	}
+}
+
+func canonical(version string) string {
+	if strings.HasPrefix(version, "v") {
+		return version
+	}
+	return "v" + version
+}
@@ -0,0 +1,100 @@
+package templates_test
+
+import (
+	"testing"
+
+	"github.com/google/go-cmp/cmp"
+
+	"github.com/fidelity/kraan/pkg/templates"
+)
+
+type testData struct {
+	Name    string
+	Version string
+	Labels  map[string]string
+	Values  map[string]interface{}
+}
+
+func TestRender(t *testing.T) {
+	data := testData{
+		Name:    "apps",
+		Version: "v1.26.3",
+		Labels:  map[string]string{"cloud": "aws"},
+		Values:  map[string]interface{}{"replicas": 2},
+	}
+	tests := []struct {
+		text      string
+		expected  string
+		expectErr bool
+	}{
+		{text: "name: {{ .Name }}", expected: "name: apps"},
+		{text: `{{ index .Labels "cloud" | upper }}`, expected: "AWS"},
+		{text: `{{ index .Labels "gpu" | default "none" }}`, expected: "none"},
+		{text: `{{ if semverCompare ">=1.25" .Version }}policy/v1{{ else }}policy/v1beta1{{ end }}`, expected: "policy/v1"},
+		{text: `{{ semverCompare "<1.25.0" .Version }}`, expected: "false"},
+		{text: "values:{{ toYaml .Values | nindent 2 }}", expected: "values:\n  replicas: 2"},
+		{text: "{{ .Name | quote }}", expected: `"apps"`},
+		{text: "{{ .Missing }}", expectErr: true},
+		{text: `{{ required "gpu label is required" (index .Labels "gpu") }}`, expectErr: true},
+		{text: `{{ semverCompare ">=latest" .Version }}`, expectErr: true},
+		{text: "{{ .Name", expectErr: true},
+	}
+	for _, test := range tests {
+		result, err := templates.Render("test", test.text, data)
+		if test.expectErr {
+			if err == nil {
+				t.Errorf("Render(%q) did not return an error", test.text)
+			}
+			continue
+		}
+		if err != nil {
+			t.Errorf("Render(%q) returned an error: %s", test.text, err)
+			continue
+		}
+		if result != test.expected {
+			t.Errorf("Render(%q) returned %q, expected %q", test.text, result, test.expected)
+		}
+	}
+}
+
+func TestObject(t *testing.T) {
+	data := testData{Name: "apps", Labels: map[string]string{"cloud": "aws"}}
+	obj := map[string]interface{}{
+		"metadata": map[string]interface{}{"name": "podinfo-{{ .Name }}", "namespace": "apps"},
+		"spec": map[string]interface{}{
+			"values": map[string]interface{}{
+				"storageClass": `{{ if eq (index .Labels "cloud") "aws" }}gp3{{ else }}standard{{ end }}`,
+				"hosts":        []interface{}{"{{ .Name }}.example.com", int64(1)},
+				"replicas":     int64(2),
+			},
+		},
+	}
+	expected := map[string]interface{}{
+		"metadata": map[string]interface{}{"name": "podinfo-apps", "namespace": "apps"},
+		"spec": map[string]interface{}{
+			"values": map[string]interface{}{
+				"storageClass": "gp3",
+				"hosts":        []interface{}{"apps.example.com", int64(1)},
+				"replicas":     int64(2),
+			},
+		},
+	}
+	changed, err := templates.Object(obj, "test", data)
+	if err != nil {
+		t.Fatalf("Object returned an error: %s", err)
+	}
+	if !changed {
+		t.Fatalf("Object did not report a change")
+	}
+	if diff := cmp.Diff(expected, obj); diff != "" {
+		t.Fatalf("unexpected object (-want +got):\n%s", diff)
+	}
+
+	changed, err = templates.Object(map[string]interface{}{"kind": "ConfigMap"}, "test", data)
+	if err != nil || changed {
+		t.Fatalf("Object returned %t, %v for an object without templates", changed, err)
+	}
+	if _, err := templates.Object(map[string]interface{}{"name": "{{ .Missing }}"}, "test", data); err == nil {
+		t.Fatalf("Object did not return an error for an invalid template")
+	}
+}